@@ -0,0 +1,203 @@
+// cmd/codecat/adapters.go
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ContentAdapter converts a file format into text suitable for the output.
+type ContentAdapter func(relPath string, content []byte, opts GenerateOptions) ([]byte, error)
+
+// contentAdapters is the registry of adapters keyed by lower-case extension.
+var contentAdapters = map[string]ContentAdapter{
+	".ipynb": notebookAdapter,
+	".pdf":   pdfAdapter,
+	".docx":  docxAdapter,
+	".csv":   csvAdapter,
+}
+
+// adaptContent runs the adapter registered for the file's extension, if any.
+func adaptContent(relPath string, content []byte, opts GenerateOptions) ([]byte, error) {
+	adapter, ok := contentAdapters[strings.ToLower(filepath.Ext(relPath))]
+	if !ok {
+		return content, nil
+	}
+	return adapter(relPath, content, opts)
+}
+
+// notebookCell is a cell of an nbformat 4 notebook; nbformat 3 keeps code in Input.
+type notebookCell struct {
+	CellType string          `json:"cell_type"`
+	Source   json.RawMessage `json:"source"`
+	Input    json.RawMessage `json:"input"`
+}
+
+// notebookAdapter keeps only the code and markdown cell sources of a Jupyter
+// notebook, in the "# %%" percent format, dropping outputs, execution counts
+// and metadata. With NotebookCellIndex each cell header carries its 1-based
+// position in the notebook.
+func notebookAdapter(relPath string, content []byte, opts GenerateOptions) ([]byte, error) {
+	var nb struct {
+		Cells      []notebookCell `json:"cells"`
+		Worksheets []struct {
+			Cells []notebookCell `json:"cells"`
+		} `json:"worksheets"`
+	}
+	if err := json.Unmarshal(content, &nb); err != nil {
+		return nil, fmt.Errorf("parsing notebook: %w", err)
+	}
+	cells := nb.Cells
+	for _, ws := range nb.Worksheets {
+		cells = append(cells, ws.Cells...)
+	}
+
+	var out strings.Builder
+	for i, cell := range cells {
+		if cell.CellType != "code" && cell.CellType != "markdown" {
+			continue
+		}
+		raw := cell.Source
+		if len(raw) == 0 {
+			raw = cell.Input
+		}
+		source, err := notebookSource(raw)
+		if err != nil {
+			return nil, err
+		}
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		header := tern(cell.CellType == "markdown", "# %% [markdown]", "# %%")
+		if opts.NotebookCellIndex {
+			header += fmt.Sprintf(" cell %d", i+1)
+		}
+		out.WriteString(header + "\n")
+		out.WriteString(source)
+		if !strings.HasSuffix(source, "\n") {
+			out.WriteString("\n")
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+// notebookSource decodes a cell source, stored either as a string or as a list of lines.
+func notebookSource(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err == nil {
+		return strings.Join(lines, ""), nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return "", fmt.Errorf("parsing notebook cell source: %w", err)
+	}
+	return text, nil
+}
+
+// pdfAdapter extracts text with poppler's pdftotext, which must be on PATH.
+func pdfAdapter(relPath string, content []byte, opts GenerateOptions) ([]byte, error) {
+	bin, err := exec.LookPath("pdftotext")
+	if err != nil {
+		return nil, errors.New("pdftotext not found on PATH (install poppler-utils to include PDFs)")
+	}
+	cmd := exec.Command(bin, "-layout", "-", "-")
+	cmd.Stdin = bytes.NewReader(content)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("pdftotext failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// docxAdapter extracts paragraph text from word/document.xml of a .docx archive.
+func docxAdapter(relPath string, content []byte, opts GenerateOptions) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("opening docx: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return docxText(rc)
+	}
+	return nil, errors.New("word/document.xml not found in docx")
+}
+
+// docxText collects <w:t> runs, ending a line at each paragraph and honouring tabs and breaks.
+func docxText(r io.Reader) ([]byte, error) {
+	var out strings.Builder
+	dec := xml.NewDecoder(r)
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing docx XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				out.WriteString("\t")
+			case "br", "cr":
+				out.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				out.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				out.Write(t)
+			}
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+// csvAdapter keeps the header and the first CSVRows data rows when CSVRows > 0.
+func csvAdapter(relPath string, content []byte, opts GenerateOptions) ([]byte, error) {
+	if opts.CSVRows <= 0 {
+		return content, nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	keep := opts.CSVRows + 1 // header row
+	if len(lines) <= keep {
+		return content, nil
+	}
+	out := strings.Join(lines[:keep], "")
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	out += fmt.Sprintf("... (%d more rows)\n", len(lines)-keep)
+	return []byte(out), nil
+}
@@ -0,0 +1,104 @@
+// cmd/codecat/adapters_test.go
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"log/slog"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNotebook = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Title\n", "Intro"]},
+  {"cell_type": "code", "execution_count": 3, "metadata": {}, "outputs": [{"output_type": "display_data", "data": {"image/png": "iVBORw0KGgo="}}], "source": "import pandas as pd\ndf = pd.DataFrame()"},
+  {"cell_type": "raw", "metadata": {}, "source": ["ignored"]}
+ ],
+ "metadata": {"kernelspec": {"language": "python"}},
+ "nbformat": 4
+}`
+
+func TestNotebookAdapter(t *testing.T) {
+	out, err := adaptContent("analysis.ipynb", []byte(testNotebook), GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "# %% [markdown]\n# Title\nIntro\n\n# %%\nimport pandas as pd\ndf = pd.DataFrame()\n", string(out))
+
+	_, err = adaptContent("broken.ipynb", []byte("{not json"), GenerateOptions{})
+	assert.Error(t, err)
+}
+
+func TestNotebookAdapter_CellIndexAndV3(t *testing.T) {
+	out, err := adaptContent("analysis.ipynb", []byte(testNotebook), GenerateOptions{NotebookCellIndex: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "# %% [markdown] cell 1\n")
+	assert.Contains(t, string(out), "# %% cell 2\n")
+
+	v3 := `{"nbformat": 3, "worksheets": [{"cells": [
+		{"cell_type": "code", "input": ["x = 1\n", "print(x)"], "outputs": [{"text": ["1"]}], "prompt_number": 1}
+	]}]}`
+	out, err = adaptContent("old.ipynb", []byte(v3), GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "# %%\nx = 1\nprint(x)\n", string(out))
+}
+
+func TestDocxAdapter(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		`<w:p><w:r><w:t>Hello</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">world</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>Second &amp; last</w:t></w:r></w:p></w:body></w:document>`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	out, err := adaptContent("spec.docx", buf.Bytes(), GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello\tworld\nSecond & last\n", string(out))
+}
+
+func TestCSVAdapter(t *testing.T) {
+	content := []byte("id,name\n1,a\n2,b\n3,c\n")
+	out, err := adaptContent("data.csv", content, GenerateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, content, out, "no truncation without CSVRows")
+
+	out, err = adaptContent("data.CSV", content, GenerateOptions{CSVRows: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "id,name\n1,a\n... (2 more rows)\n", string(out))
+}
+
+func TestPDFAdapter(t *testing.T) {
+	if _, err := exec.LookPath("pdftotext"); err == nil {
+		t.Skip("pdftotext installed; only the missing-tool path is tested")
+	}
+	_, err := adaptContent("paper.pdf", []byte("%PDF-1.4"), GenerateOptions{})
+	assert.ErrorContains(t, err, "pdftotext")
+}
+
+func TestGenerate_NotebookAdapted(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"nb.ipynb": testNotebook})
+	testLogger, _ := setupTestLogger(t)
+	slog.SetDefault(testLogger)
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"ipynb"}),
+		Marker:     "---",
+	}
+
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "--- nb.ipynb\n# %% [markdown]\n")
+	assert.NotContains(t, result.Output, "iVBORw0KGgo")
+	assert.Equal(t, int64(len("# %% [markdown]\n# Title\nIntro\n\n# %%\nimport pandas as pd\ndf = pd.DataFrame()\n")), result.TotalSize)
+
+	opts.NoAdapters = true
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "iVBORw0KGgo")
+}
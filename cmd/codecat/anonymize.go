@@ -0,0 +1,172 @@
+// cmd/codecat/anonymize.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// pathAnonymizer replaces paths with pseudonyms for --anonymize-paths:
+// every directory becomes dir_NN and every file file_NNN, keeping its
+// extension so the language stays recognisable. Both are numbered in walk
+// order, so the same tree always gets the same names.
+type pathAnonymizer struct {
+	dirs  map[string]string // real directory path -> its pseudonym component
+	files map[string]string // real file path -> its pseudonym component
+}
+
+// newPathAnonymizer numbers the given CWD-relative paths; a path ending in a
+// slash is a directory.
+func newPathAnonymizer(paths []string) *pathAnonymizer {
+	sorted := slices.Clone(paths)
+	slices.SortFunc(sorted, compareWalkOrder)
+	sorted = slices.Compact(sorted)
+	var dirs, files []string
+	seen := make(map[string]bool)
+	for _, p := range sorted {
+		dir, isDir := strings.CutSuffix(p, "/")
+		parts := strings.Split(dir, "/")
+		for i := 1; i <= len(parts); i++ {
+			if i == len(parts) && !isDir {
+				files = append(files, p)
+			} else if ancestor := strings.Join(parts[:i], "/"); ancestor != "." && !seen[ancestor] {
+				seen[ancestor] = true
+				dirs = append(dirs, ancestor)
+			}
+		}
+	}
+	a := &pathAnonymizer{dirs: make(map[string]string, len(dirs)), files: make(map[string]string, len(files))}
+	dirWidth, fileWidth := max(2, len(strconv.Itoa(len(dirs)))), max(3, len(strconv.Itoa(len(files))))
+	for i, dir := range dirs {
+		a.dirs[dir] = fmt.Sprintf("dir_%0*d", dirWidth, i+1)
+	}
+	for i, file := range files {
+		a.files[file] = fmt.Sprintf("file_%0*d%s", fileWidth, i+1, path.Ext(file))
+	}
+	return a
+}
+
+// anonymize returns the pseudonym of a CWD-relative file path, or of a
+// directory path ending in a slash.
+func (a *pathAnonymizer) anonymize(p string) string {
+	if dir, ok := strings.CutSuffix(p, "/"); ok {
+		return a.anonymizeDir(dir) + "/"
+	}
+	name, ok := a.files[p]
+	if !ok {
+		return p // only paths given to newPathAnonymizer are known
+	}
+	if dir := path.Dir(p); dir != "." {
+		return a.anonymizeDir(dir) + "/" + name
+	}
+	return name
+}
+
+// anonymizeDir replaces each component of a directory path.
+func (a *pathAnonymizer) anonymizeDir(dir string) string {
+	if dir == "" || dir == "." {
+		return dir
+	}
+	parts := strings.Split(dir, "/")
+	anonymized := make([]string, len(parts))
+	for i := range parts {
+		anonymized[i] = parts[i]
+		if name, ok := a.dirs[strings.Join(parts[:i+1], "/")]; ok {
+			anonymized[i] = name
+		}
+	}
+	return strings.Join(anonymized, "/")
+}
+
+// mapping returns pseudonym -> real path for every file and directory, the
+// local key to read answers that refer to the pseudonyms.
+func (a *pathAnonymizer) mapping() map[string]string {
+	m := make(map[string]string, len(a.dirs)+len(a.files))
+	for dir := range a.dirs {
+		m[a.anonymizeDir(dir)+"/"] = dir + "/"
+	}
+	for file := range a.files {
+		m[a.anonymize(file)] = file
+	}
+	return m
+}
+
+// anonymizeResult replaces every path in result with its pseudonym, also
+// inside generated documents such as the import map, and returns the
+// mapping. File content is left alone.
+func anonymizeResult(result *GenerateResult) map[string]string {
+	var paths []string
+	for _, doc := range result.Documents {
+		if doc.Path != importMapPath { // the preamble's name says what it is, not where
+			paths = append(paths, doc.Path)
+		}
+	}
+	for _, f := range result.IncludedFiles {
+		paths = append(paths, f.Path)
+	}
+	paths = append(paths, result.EmptyFiles...)
+	paths = append(paths, result.Dropped...)
+	for _, f := range result.Outliers {
+		paths = append(paths, f.Path)
+	}
+	paths = append(paths, mapsKeys(result.ErrorFiles)...)
+	paths = append(paths, mapsKeys(result.SpecialFiles)...)
+	for _, doc := range result.Documents {
+		for _, dir := range []string{doc.Root, doc.NestedRepo} {
+			if dir != "" {
+				paths = append(paths, dir+"/")
+			}
+		}
+	}
+	for _, repo := range result.NestedRepos {
+		paths = append(paths, repo+"/")
+	}
+	for _, skipped := range result.Skipped {
+		paths = append(paths, skipped...)
+	}
+	a := newPathAnonymizer(paths)
+
+	// Longest first, so a path is replaced before a shorter one it contains.
+	known := mapsKeys(a.files)
+	slices.SortFunc(known, func(x, y string) int { return len(y) - len(x) })
+	var pairs []string
+	for _, p := range known {
+		pairs = append(pairs, p, a.anonymize(p))
+	}
+	replacer := strings.NewReplacer(pairs...)
+	for i, doc := range result.Documents {
+		if doc.modTime.IsZero() && doc.spooled == nil { // generated from the files, not read from disk
+			result.Documents[i].Content = replacer.Replace(doc.Content)
+		}
+		if doc.Root != "" {
+			result.Documents[i].Root = a.anonymizeDir(doc.Root)
+		}
+		if doc.NestedRepo != "" {
+			result.Documents[i].NestedRepo = a.anonymizeDir(doc.NestedRepo)
+		}
+	}
+	for i, repo := range result.NestedRepos {
+		result.NestedRepos[i] = a.anonymizeDir(repo)
+	}
+	for i, p := range result.Dropped {
+		result.Dropped[i] = a.anonymize(p)
+	}
+	for i, f := range result.Outliers {
+		result.Outliers[i].Path = a.anonymize(f.Path)
+	}
+	rebaseResultPaths(result, a.anonymize)
+	return a.mapping()
+}
+
+// writePathMap saves the pseudonym mapping as JSON for --path-map.
+func writePathMap(path string, mapping map[string]string) error {
+	data, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
@@ -0,0 +1,84 @@
+// cmd/codecat/anonymize_test.go
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathAnonymizer(t *testing.T) {
+	a := newPathAnonymizer([]string{"src/util/strings.go", "main.go", "src/app.py", "README", "src/app.py", "vendor/"})
+	assert.Equal(t, "file_001", a.anonymize("README"), "files before subdirectories, in name order")
+	assert.Equal(t, "file_002.go", a.anonymize("main.go"))
+	assert.Equal(t, "dir_01/file_003.py", a.anonymize("src/app.py"))
+	assert.Equal(t, "dir_01/dir_02/file_004.go", a.anonymize("src/util/strings.go"))
+	assert.Equal(t, "dir_03/", a.anonymize("vendor/"))
+	assert.Equal(t, "other.go", a.anonymize("other.go"), "unknown paths are left alone")
+
+	assert.Equal(t, map[string]string{
+		"dir_01/": "src/", "dir_01/dir_02/": "src/util/", "dir_03/": "vendor/",
+		"file_001": "README", "file_002.go": "main.go", "dir_01/file_003.py": "src/app.py",
+		"dir_01/dir_02/file_004.go": "src/util/strings.go",
+	}, a.mapping())
+
+	again := newPathAnonymizer([]string{"vendor/", "src/app.py", "src/util/strings.go", "README", "main.go"})
+	assert.Equal(t, a.mapping(), again.mapping(), "the same tree gets the same names in any order")
+}
+
+func TestGenerate_AnonymizePaths(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":              "package main\n\nimport \"example.com/app/internal/store\"\n",
+		"internal/store/db.go": "package store\n",
+		"empty.go":             "",
+	})
+	opts := GenerateOptions{
+		CWD:            tempDir,
+		ScanDirs:       []string{tempDir},
+		Extensions:     processExtensions([]string{"go"}),
+		Marker:         "---",
+		AnonymizePaths: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "--- file_002.go\npackage main\n")
+	assert.Contains(t, result.Output, "--- dir_01/dir_02/file_003.go\npackage store\n")
+	assert.NotContains(t, result.Output, "db.go")
+	assert.Equal(t, []string{"file_001.go"}, result.EmptyFiles)
+	assert.Equal(t, "internal/store/db.go", result.PathMap["dir_01/dir_02/file_003.go"])
+	assert.Contains(t, result.Output, "example.com/app/internal/store", "file content is not changed")
+
+	mapPath := filepath.Join(tempDir, stateDir, "path-map.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(mapPath), 0755))
+	require.NoError(t, writePathMap(mapPath, result.PathMap))
+	data, err := os.ReadFile(mapPath)
+	require.NoError(t, err)
+	var saved map[string]string
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, result.PathMap, saved)
+}
+
+func TestGenerate_AnonymizePathsWithRoots(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"backend/main.go": "package main\n", "frontend/app.ts": "export {}\n"})
+	opts := GenerateOptions{
+		CWD:      tempDir,
+		ScanDirs: []string{filepath.Join(tempDir, "backend"), filepath.Join(tempDir, "frontend")},
+		Marker:   "---",
+		Roots: []ScanRoot{
+			{Dir: filepath.Join(tempDir, "backend"), Extensions: processExtensions([]string{"go"})},
+			{Dir: filepath.Join(tempDir, "frontend"), Extensions: processExtensions([]string{"ts"})},
+		},
+		AnonymizePaths: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"dir_01/": "backend/", "dir_02/": "frontend/",
+		"dir_01/file_001.go": "backend/main.go", "dir_02/file_002.ts": "frontend/app.ts",
+	}, result.PathMap, "anonymized once, on the merged result")
+	assert.Contains(t, result.Output, "--- === root: dir_01 ===\n--- dir_01/file_001.go\n")
+}
@@ -0,0 +1,660 @@
+// cmd/codecat/apply.go
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	pflag "github.com/spf13/pflag"
+)
+
+var (
+	applyDryRun bool
+	applyReview bool
+)
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "apply",
+		Summary: "Apply a unified diff, such as one in a model's answer, to the files under the CWD.",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&applyDryRun, "dry-run", false,
+				"[apply] Report what the patch would change without writing any file.")
+			fs.BoolVar(&applyReview, "review", false,
+				"[apply] Show each file's diff against the working tree and ask y/n/e(dit) before writing it.")
+		},
+		Run: runApply,
+	})
+}
+
+// applyMaxFuzz is how many leading and trailing context lines of a hunk may
+// be dropped when the hunk does not match otherwise, as with patch --fuzz.
+const applyMaxFuzz = 2
+
+// filePatch is the part of a unified diff for one file.
+type filePatch struct {
+	OldPath, NewPath string // "" for /dev/null: a created or deleted file
+	Hunks            []hunk
+}
+
+// Path is the file the patch changes, relative to the CWD.
+func (p filePatch) Path() string { return tern(p.NewPath != "", p.NewPath, p.OldPath) }
+
+// hunk is one @@ section. Its line numbers are only a hint where to look:
+// hunks are located by their content.
+type hunk struct {
+	OldStart int
+	Lines    []string // each with its ' ', '-' or '+' prefix
+	NoEOL    *bool    // set when a "\ No newline at end of file" marker says how the new file ends
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+func runApply(cwd string, appConfig Config, args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Error: usage: codecat apply [--dry-run|--review] [patch-file|-]")
+		return 1
+	}
+	if applyReview {
+		var err error
+		switch {
+		case applyDryRun:
+			err = errors.New("--review and --dry-run are mutually exclusive")
+		case len(args) == 0 || args[0] == "-":
+			err = errors.New("--review reads the answers from stdin, so give the patch as a file")
+		case !isTerminal(os.Stdin):
+			err = errors.New("--review needs an interactive terminal")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	in := io.Reader(os.Stdin)
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	in, err := decompressing(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: decompressing patch: %v\n", err)
+		return 1
+	}
+	patches, err := parsePatch(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	changes := planChanges(cwd, patches)
+	if applyReview {
+		if err := reviewChanges(bufio.NewReader(os.Stdin), os.Stdout, changes, editInEditor); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	if !applyDryRun {
+		writeChanges(cwd, changes)
+	}
+	if !printApplyReport(os.Stdout, changes, applyDryRun) {
+		return 1
+	}
+	return 0
+}
+
+// parsePatch reads the file patches of a unified diff. Text around them, such
+// as the prose and code fences of a model's answer, is skipped, and hunk line
+// counts are not trusted: a hunk ends at the first line that cannot belong
+// to it.
+func parsePatch(r io.Reader) ([]filePatch, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var patches []filePatch
+	var current *filePatch
+	var h *hunk
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			oldPath, newPath, err := patchPaths(line[4:], lines[i+1][4:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			patches = append(patches, filePatch{OldPath: oldPath, NewPath: newPath})
+			current, h = &patches[len(patches)-1], nil
+			i++
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.HasPrefix(line, "@@") {
+			start := 0 // models often write a bare @@
+			if m := hunkHeaderPattern.FindStringSubmatch(line); m != nil {
+				start, _ = strconv.Atoi(m[1])
+			}
+			current.Hunks = append(current.Hunks, hunk{OldStart: start})
+			h = &current.Hunks[len(current.Hunks)-1]
+			continue
+		}
+		if h == nil {
+			continue
+		}
+		switch {
+		case line == "":
+			h.Lines = append(h.Lines, " ") // an empty context line that lost its space
+		case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+			h.Lines = append(h.Lines, line)
+		case strings.HasPrefix(line, `\`):
+			// After a '-' line only the old file lacked the final newline;
+			// a marker after the '+' lines, if any, follows and wins.
+			if n := len(h.Lines); n > 0 {
+				noEOL := h.Lines[n-1][0] != '-'
+				h.NoEOL = &noEOL
+			}
+		default:
+			h = nil // prose or a closing fence
+		}
+	}
+	for i := range patches {
+		for j := range patches[i].Hunks {
+			hk := &patches[i].Hunks[j]
+			// Blank lines ending a hunk are more likely spacing before the
+			// next paragraph than context.
+			for n := len(hk.Lines); n > 0 && hk.Lines[n-1] == " "; n-- {
+				hk.Lines = hk.Lines[:n-1]
+			}
+		}
+	}
+	if len(patches) == 0 {
+		return nil, errors.New("no unified diff found (want --- and +++ file lines followed by @@ hunks)")
+	}
+	return patches, nil
+}
+
+// patchPaths reads the paths of a "--- old" / "+++ new" pair: a tab and
+// timestamp after the path are dropped, /dev/null becomes "", and git's a/
+// and b/ prefixes are removed when both sides carry them. Paths must stay
+// below the CWD.
+func patchPaths(oldField, newField string) (oldPath, newPath string, err error) {
+	paths := [2]string{oldField, newField}
+	for i, p := range paths {
+		p, _, _ = strings.Cut(p, "\t")
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, `"`) {
+			if p, err = strconv.Unquote(p); err != nil {
+				return "", "", fmt.Errorf("invalid quoted path %s", paths[i])
+			}
+		}
+		paths[i] = tern(p == "/dev/null", "", p)
+	}
+	if (paths[0] == "" || strings.HasPrefix(paths[0], "a/")) && (paths[1] == "" || strings.HasPrefix(paths[1], "b/")) {
+		paths[0], paths[1] = strings.TrimPrefix(paths[0], "a/"), strings.TrimPrefix(paths[1], "b/")
+	}
+	if paths[0] == "" && paths[1] == "" {
+		return "", "", errors.New("both sides of the patch are /dev/null")
+	}
+	for _, p := range paths {
+		if p != "" && !filepath.IsLocal(filepath.FromSlash(p)) {
+			return "", "", fmt.Errorf("path %q is outside the CWD", p)
+		}
+	}
+	return paths[0], paths[1], nil
+}
+
+// fileChange is the outcome of applying one file patch, computed before
+// anything is written.
+type fileChange struct {
+	Path      string // CWD-relative, slash-separated
+	From      string // with a rename, the file the content was read from
+	Old, New  string // content before and after
+	Mode      fs.FileMode
+	Create    bool
+	Delete    bool
+	Hunks     int      // hunks in the patch
+	Fuzzy     int      // hunks located only by ignoring whitespace or dropping context lines
+	Present   int      // hunks already in the file, left alone
+	Conflicts []string // why the file cannot be patched; it is then left untouched
+	Declined  bool     // turned down at --review
+	Err       error    // writing the file failed
+}
+
+// writes reports whether writing the change touches the file system.
+func (c fileChange) writes() bool {
+	return len(c.Conflicts) == 0 && !c.Declined && (c.New != c.Old || c.Delete || c.From != "")
+}
+
+// planChanges applies each file patch to the current content under cwd in
+// memory. Several patches of one file apply on top of each other.
+func planChanges(cwd string, patches []filePatch) []fileChange {
+	var changes []fileChange
+	byPath := make(map[string]int)
+	for _, p := range patches {
+		c := fileChange{Path: p.Path(), Hunks: len(p.Hunks), Mode: 0644, Create: p.OldPath == "", Delete: p.NewPath == ""}
+		exists := false
+		if p.OldPath != "" && p.NewPath != "" && p.OldPath != p.NewPath {
+			c.From = p.OldPath
+		}
+		source := tern(c.From != "", c.From, c.Path)
+		prev, seen := byPath[source]
+		switch {
+		case seen && len(changes[prev].Conflicts) > 0:
+			c.Conflicts = []string{"a later patch of this file was not tried"}
+		case seen:
+			c.Old = changes[prev].New
+		default:
+			info, err := os.Lstat(filepath.Join(cwd, filepath.FromSlash(source)))
+			switch {
+			case err == nil && info.Mode()&os.ModeSymlink != 0:
+				c.Conflicts = []string{"a symlink; patch the file it points to instead"}
+			case err == nil && !info.Mode().IsRegular():
+				c.Conflicts = []string{"not a regular file"}
+			case err == nil:
+				exists = true
+				data, errRead := os.ReadFile(filepath.Join(cwd, filepath.FromSlash(source)))
+				c.Old, c.Mode, err = string(data), info.Mode().Perm(), errRead
+				if err != nil {
+					c.Conflicts = []string{err.Error()}
+				}
+			case !errors.Is(err, fs.ErrNotExist):
+				c.Conflicts = []string{err.Error()}
+			case !c.Create:
+				c.Conflicts = []string{"file not found"}
+			}
+		}
+		if _, err := os.Lstat(filepath.Join(cwd, filepath.FromSlash(c.Path))); err == nil && c.From != "" && len(c.Conflicts) == 0 {
+			c.Conflicts = []string{"the rename target already exists"}
+		}
+		switch {
+		case len(c.Conflicts) > 0:
+		case c.Create && exists:
+			// Created already, by this patch or by hand.
+			c.Create, c.New = false, c.Old
+			if created, _, _, _ := applyHunks("", p.Hunks); created == c.Old {
+				c.Present = c.Hunks
+			} else {
+				c.Conflicts = []string{"the file to create already exists"}
+			}
+		default:
+			c.New, c.Fuzzy, c.Present, c.Conflicts = applyHunks(c.Old, p.Hunks)
+		}
+		if c.Delete && len(c.Conflicts) == 0 && strings.TrimSpace(c.New) != "" {
+			c.Conflicts = []string{"the file has content the deletion does not remove"}
+		}
+		if seen && c.From == "" {
+			// Fold a later patch of the same file into the first change.
+			first := &changes[prev]
+			first.New, first.Delete = c.New, c.Delete
+			first.Hunks, first.Fuzzy, first.Present = first.Hunks+c.Hunks, first.Fuzzy+c.Fuzzy, first.Present+c.Present
+			first.Conflicts = append(first.Conflicts, c.Conflicts...)
+			continue
+		}
+		byPath[c.Path] = len(changes)
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// applyHunks applies hunks to content in order. Each hunk is searched for
+// from the end of the previous one, nearest to its line number hint: first
+// exactly, then ignoring whitespace, then with up to applyMaxFuzz context
+// lines dropped at either end. Context lines keep the file's own text. A hunk
+// whose result is already present counts as present; any other hunk that
+// cannot be placed is a conflict.
+func applyHunks(content string, hunks []hunk) (result string, fuzzy, present int, conflicts []string) {
+	lines, crlf, eol := splitFileLines(content)
+	pos, offset := 0, 0
+	for i, h := range hunks {
+		hint := tern(h.OldStart > 0, h.OldStart-1+offset, pos)
+		oldSide, newSide := hunkSides(h.Lines)
+		applied := len(newSide) > 0 && findLines(lines, newSide, 0, 0, true) >= 0
+		if len(oldSide) == 0 && applied {
+			present++ // a pure insertion matches anywhere, so check first
+			continue
+		}
+		at, body, level := locateHunk(lines, h.Lines, pos, hint)
+		if at < 0 {
+			if applied {
+				present++
+				continue
+			}
+			conflicts = append(conflicts, fmt.Sprintf("hunk %d (near line %d) does not match the file", i+1, max(h.OldStart, 1)))
+			continue
+		}
+		if level > 0 {
+			fuzzy++
+		}
+		var replacement []string
+		k := at
+		for _, line := range body {
+			switch line[0] {
+			case ' ':
+				replacement = append(replacement, lines[k])
+				k++
+			case '-':
+				k++
+			case '+':
+				replacement = append(replacement, line[1:])
+			}
+		}
+		lines = append(append(append([]string{}, lines[:at]...), replacement...), lines[k:]...)
+		pos = at + len(replacement)
+		offset += at - hint + len(replacement) - (k - at)
+		if h.NoEOL != nil && pos == len(lines) {
+			eol = !*h.NoEOL
+		}
+	}
+	if len(conflicts) > 0 {
+		return content, fuzzy, present, conflicts
+	}
+	if len(lines) == 0 {
+		return "", fuzzy, present, nil
+	}
+	sep := tern(crlf, "\r\n", "\n")
+	return strings.Join(lines, sep) + tern(eol, sep, ""), fuzzy, present, nil
+}
+
+// locateHunk finds where the old side of a hunk starts in lines, returning
+// the hunk lines that matched (fewer with fuzz) and the fuzz level used, 0
+// for an exact match; at is -1 when there is no match.
+func locateHunk(lines, hunkLines []string, from, hint int) (at int, body []string, level int) {
+	for fuzz := 0; fuzz <= applyMaxFuzz; fuzz++ {
+		body := trimHunkContext(hunkLines, fuzz)
+		if fuzz > 0 && len(body) == len(trimHunkContext(hunkLines, fuzz-1)) {
+			break // nothing more to drop
+		}
+		oldSide, _ := hunkSides(body)
+		if len(oldSide) == 0 {
+			// A pure insertion goes where the hint says.
+			return min(max(hint, from), len(lines)), body, 0
+		}
+		for _, loose := range []bool{false, true} {
+			if at := findLines(lines, oldSide, from, hint, loose); at >= 0 {
+				return at, body, tern(loose, 1, 0) + fuzz
+			}
+		}
+	}
+	return -1, nil, 0
+}
+
+// trimHunkContext drops up to n context lines from each end of a hunk.
+func trimHunkContext(hunkLines []string, n int) []string {
+	start, end := 0, len(hunkLines)
+	for start < end && start < n && hunkLines[start][0] == ' ' {
+		start++
+	}
+	for end > start && len(hunkLines)-end < n && hunkLines[end-1][0] == ' ' {
+		end--
+	}
+	return hunkLines[start:end]
+}
+
+// hunkSides splits hunk lines into the old and new text.
+func hunkSides(hunkLines []string) (oldSide, newSide []string) {
+	for _, line := range hunkLines {
+		if line[0] != '+' {
+			oldSide = append(oldSide, line[1:])
+		}
+		if line[0] != '-' {
+			newSide = append(newSide, line[1:])
+		}
+	}
+	return oldSide, newSide
+}
+
+// findLines returns the start of the occurrence of want in lines at or after
+// from that is nearest to hint, or -1. loose compares lines ignoring
+// whitespace differences.
+func findLines(lines, want []string, from, hint int, loose bool) int {
+	best := -1
+	for at := from; at+len(want) <= len(lines); at++ {
+		if matchLines(lines[at:at+len(want)], want, loose) && (best < 0 || absInt(at-hint) < absInt(best-hint)) {
+			best = at
+		}
+	}
+	return best
+}
+
+func matchLines(a, b []string, loose bool) bool {
+	for i := range a {
+		if a[i] != b[i] && (!loose || strings.Join(strings.Fields(a[i]), " ") != strings.Join(strings.Fields(b[i]), " ")) {
+			return false
+		}
+	}
+	return true
+}
+
+func absInt(n int) int { return tern(n < 0, -n, n) }
+
+// splitFileLines splits content into lines without their line endings and
+// reports whether it uses CRLF and ends with a newline.
+func splitFileLines(content string) (lines []string, crlf, eol bool) {
+	if content == "" {
+		return nil, false, true
+	}
+	crlf = strings.Contains(content, "\r\n")
+	eol = strings.HasSuffix(content, "\n")
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		lines = append(lines, strings.TrimSuffix(line, "\r"))
+	}
+	return lines, crlf, eol
+}
+
+// writeChanges writes the planned changes without conflicts, recording write
+// failures on the change. A file that changed on disk since it was read, as
+// it may while --review waits for an answer, is not overwritten.
+func writeChanges(cwd string, changes []fileChange) {
+	for i := range changes {
+		c := &changes[i]
+		if !c.writes() {
+			continue
+		}
+		source := filepath.Join(cwd, filepath.FromSlash(tern(c.From != "", c.From, c.Path)))
+		if current, err := os.ReadFile(source); tern(c.Create, err == nil, err != nil || string(current) != c.Old) {
+			c.Err = errors.New("changed on disk since the patch was read; not overwritten")
+			continue
+		}
+		abs := filepath.Join(cwd, filepath.FromSlash(c.Path))
+		if _, err := os.Lstat(abs); err == nil && c.From != "" {
+			c.Err = errors.New("the rename target appeared since the patch was read; not overwritten")
+			continue
+		}
+		slog.Debug("Writing patched file.", "path", c.Path, "delete", c.Delete)
+		if c.Delete {
+			c.Err = os.Remove(abs)
+			continue
+		}
+		c.Err = os.MkdirAll(filepath.Dir(abs), 0755)
+		if c.Err == nil {
+			c.Err = writeFileAtomic(abs, []byte(c.New))
+		}
+		if c.Err == nil && c.Mode != 0644 {
+			c.Err = os.Chmod(abs, c.Mode)
+		}
+		if c.Err == nil && c.From != "" {
+			c.Err = os.Remove(filepath.Join(cwd, filepath.FromSlash(c.From)))
+		}
+	}
+}
+
+// printApplyReport lists each file with what was done to it and the reasons
+// for conflicts, and reports whether every file applied.
+func printApplyReport(w io.Writer, changes []fileChange, dryRun bool) bool {
+	applied, failed, declined := 0, 0, 0
+	for _, c := range changes {
+		status := "patched"
+		switch {
+		case len(c.Conflicts) > 0:
+			status = "conflict"
+		case c.Declined:
+			status = "declined"
+		case c.Err != nil:
+			status = "failed"
+		case c.Delete:
+			status = "deleted"
+		case c.Create:
+			status = "created"
+		case c.From != "":
+			status = "renamed"
+		case c.New == c.Old:
+			status = "unchanged"
+		}
+		var notes []string
+		if c.Fuzzy > 0 {
+			notes = append(notes, fmt.Sprintf("%d of %d hunks fuzzy", c.Fuzzy, c.Hunks))
+		}
+		if c.Present > 0 {
+			notes = append(notes, fmt.Sprintf("%d already applied", c.Present))
+		}
+		name := tern(c.From != "", c.From+" -> "+c.Path, c.Path)
+		fmt.Fprintf(w, "%-9s %s", status, name)
+		if len(notes) > 0 {
+			fmt.Fprintf(w, " (%s)", strings.Join(notes, ", "))
+		}
+		fmt.Fprintln(w)
+		for _, reason := range c.Conflicts {
+			fmt.Fprintf(w, "          %s\n", reason)
+		}
+		if c.Err != nil {
+			fmt.Fprintf(w, "          %v\n", c.Err)
+		}
+		switch {
+		case len(c.Conflicts) > 0 || c.Err != nil:
+			failed++
+		case c.Declined:
+			declined++
+		default:
+			applied++
+		}
+	}
+	prefix := tern(dryRun, "Dry run, nothing written: ", "")
+	switch {
+	case failed > 0:
+		fmt.Fprintf(w, "%s%d of %d file(s) apply; %d left unchanged because of conflicts or errors.\n", prefix, applied, len(changes), failed)
+	case declined > 0:
+		fmt.Fprintf(w, "%d of %d file(s) written; %d declined at review.\n", applied, len(changes), declined)
+	default:
+		fmt.Fprintf(w, "%sall %d file(s) apply.\n", prefix, len(changes))
+	}
+	return failed == 0
+}
+
+// reviewChanges shows the diff of each change that would be written and asks
+// whether to write it: yes, no, edit the new content in $EDITOR first (then
+// the diff is shown again), or quit, which declines the rest. The end of the
+// input counts as quit.
+func reviewChanges(in *bufio.Reader, w io.Writer, changes []fileChange, edit func(path, content string) (string, error)) error {
+	for i := range changes {
+		c := &changes[i]
+		if !c.writes() {
+			continue
+		}
+		if err := printChangeDiff(w, *c); err != nil {
+			return err
+		}
+		for answered := false; !answered; {
+			fmt.Fprintf(w, "Write %s? %s: ", c.Path, tern(c.Delete, "[y]es, [n]o, [q]uit", "[y]es, [n]o, [e]dit, [q]uit"))
+			answer, err := in.ReadString('\n')
+			if answer == "" && err != nil {
+				answer = "q"
+				fmt.Fprintln(w)
+			}
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "y", "yes":
+				answered = true
+			case "n", "no":
+				c.Declined, answered = true, true
+			case "e", "edit":
+				if c.Delete {
+					continue
+				}
+				edited, err := edit(c.Path, c.New)
+				if err != nil {
+					fmt.Fprintf(w, "Editing failed: %v\n", err)
+					continue
+				}
+				c.New = edited
+				if err := printChangeDiff(w, *c); err != nil {
+					return err
+				}
+			case "q", "quit":
+				for j := i; j < len(changes); j++ {
+					if changes[j].writes() {
+						changes[j].Declined = true
+					}
+				}
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// printChangeDiff writes a unified diff of the file on disk against what
+// apply would write.
+func printChangeDiff(w io.Writer, c fileChange) error {
+	from, to := "a/"+tern(c.From != "", c.From, c.Path), "b/"+c.Path
+	newContent := c.New
+	if c.Create {
+		from = "/dev/null"
+	}
+	if c.Delete {
+		to, newContent = "/dev/null", ""
+	}
+	if c.Old == newContent {
+		_, err := fmt.Fprintf(w, "%s -> %s: content unchanged\n", from, to)
+		return err
+	}
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A: contentLines(c.Old), B: contentLines(newContent),
+		FromFile: from, ToFile: to, Context: 3,
+	})
+	if err != nil {
+		return fmt.Errorf("diffing %s: %w", c.Path, err)
+	}
+	_, err = fmt.Fprint(w, text)
+	return err
+}
+
+// editInEditor lets the user change content in $EDITOR through a temporary
+// file named like path, so the editor picks the right syntax.
+func editInEditor(path, content string) (string, error) {
+	f, err := os.CreateTemp("", "codecat-apply-*-"+filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(content)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := runEditor(f.Name()); err != nil {
+		return "", fmt.Errorf("editor: %w", err)
+	}
+	data, err := os.ReadFile(f.Name())
+	return string(data), err
+}
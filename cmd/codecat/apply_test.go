@@ -0,0 +1,234 @@
+// cmd/codecat/apply_test.go
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePatch(t *testing.T) {
+	answer := "Here is the fix:\n\n```diff\n" +
+		"diff --git a/src/app.go b/src/app.go\n" +
+		"--- a/src/app.go\n" +
+		"+++ b/src/app.go\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" package app\n" +
+		"\n" +
+		"-var x = 1\n" +
+		"+var x = 2\n" +
+		"```\n\n" +
+		"And a new file:\n\n" +
+		"--- /dev/null\n" +
+		"+++ b/notes.txt\t2025-06-01 10:00:00\n" +
+		"@@\n" +
+		"+hello\n" +
+		"\\ No newline at end of file\n"
+	patches, err := parsePatch(strings.NewReader(answer))
+	require.NoError(t, err)
+	require.Len(t, patches, 2)
+	assert.Equal(t, "src/app.go", patches[0].Path())
+	assert.Equal(t, []hunk{{OldStart: 1, Lines: []string{" package app", " ", "-var x = 1", "+var x = 2"}}}, patches[0].Hunks, "fence ends the hunk")
+	assert.Equal(t, "", patches[1].OldPath)
+	assert.Equal(t, "notes.txt", patches[1].NewPath)
+	require.Len(t, patches[1].Hunks, 1)
+	require.NotNil(t, patches[1].Hunks[0].NoEOL)
+	assert.True(t, *patches[1].Hunks[0].NoEOL)
+
+	_, err = parsePatch(strings.NewReader("no patch here\n"))
+	assert.ErrorContains(t, err, "no unified diff found")
+	_, err = parsePatch(strings.NewReader("--- a/../etc/passwd\n+++ b/../etc/passwd\n@@\n-root\n"))
+	assert.ErrorContains(t, err, "outside the CWD")
+}
+
+func TestApplyHunks(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\nfive\nsix\n"
+	for name, tc := range map[string]struct {
+		hunks     []hunk
+		want      string
+		fuzzy     int
+		present   int
+		conflicts int
+	}{
+		"exact": {
+			hunks: []hunk{{OldStart: 2, Lines: []string{" two", "-three", "+THREE", " four"}}},
+			want:  "one\ntwo\nTHREE\nfour\nfive\nsix\n",
+		},
+		"wrong line number": {
+			hunks: []hunk{{OldStart: 40, Lines: []string{" five", "-six", "+SIX"}}},
+			want:  "one\ntwo\nthree\nfour\nfive\nSIX\n",
+		},
+		"whitespace differs": {
+			hunks: []hunk{{OldStart: 1, Lines: []string{"   one", "-two  ", "+TWO"}}},
+			want:  "one\nTWO\nthree\nfour\nfive\nsix\n",
+			fuzzy: 1,
+		},
+		"stale context": {
+			hunks: []hunk{{OldStart: 3, Lines: []string{" tree", "-four", "+FOUR", " fife"}}},
+			want:  "one\ntwo\nthree\nFOUR\nfive\nsix\n",
+			fuzzy: 1,
+		},
+		"already applied": {
+			hunks:   []hunk{{OldStart: 1, Lines: []string{"-uno", "+one", " two"}}},
+			want:    content,
+			present: 1,
+		},
+		"conflict": {
+			hunks:     []hunk{{OldStart: 1, Lines: []string{" one", "-seven", "+SEVEN", " two"}}},
+			want:      content,
+			conflicts: 1,
+		},
+	} {
+		got, fuzzy, present, conflicts := applyHunks(content, tc.hunks)
+		assert.Equal(t, tc.want, got, name)
+		assert.Equal(t, tc.fuzzy, fuzzy, name)
+		assert.Equal(t, tc.present, present, name)
+		assert.Len(t, conflicts, tc.conflicts, name)
+	}
+
+	got, _, _, conflicts := applyHunks("a\r\nb\r\n", []hunk{{OldStart: 1, Lines: []string{" a", "-b", "+c"}}})
+	assert.Empty(t, conflicts)
+	assert.Equal(t, "a\r\nc\r\n", got, "line endings kept")
+}
+
+func TestApplyPatch(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":   "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n",
+		"old.txt":   "obsolete\n",
+		"keep.txt":  "unrelated\n",
+		"other.txt": "first\nsecond\n",
+	})
+	patch := "--- a/main.go\n+++ b/main.go\n@@ -3,3 +3,3 @@\n func main() {\n-\tprintln(\"hi\")\n+\tprintln(\"hello\")\n }\n" +
+		"--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-obsolete\n" +
+		"--- /dev/null\n+++ b/pkg/new.go\n@@ -0,0 +1 @@\n+package pkg\n" +
+		"--- a/other.txt\n+++ b/other.txt\n@@ -1,2 +1,2 @@\n first\n-third\n+THIRD\n"
+	patches, err := parsePatch(strings.NewReader(patch))
+	require.NoError(t, err)
+
+	changes := planChanges(tempDir, patches)
+	var report strings.Builder
+	assert.False(t, printApplyReport(&report, changes, true))
+	assert.Equal(t, "patched   main.go\n"+
+		"deleted   old.txt\n"+
+		"created   pkg/new.go\n"+
+		"conflict  other.txt\n"+
+		"          hunk 1 (near line 1) does not match the file\n"+
+		"Dry run, nothing written: 3 of 4 file(s) apply; 1 left unchanged because of conflicts or errors.\n", report.String())
+
+	writeChanges(tempDir, changes)
+	data, err := os.ReadFile(filepath.Join(tempDir, "main.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "println(\"hello\")")
+	assert.NoFileExists(t, filepath.Join(tempDir, "old.txt"))
+	data, err = os.ReadFile(filepath.Join(tempDir, "pkg", "new.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package pkg\n", string(data))
+	data, err = os.ReadFile(filepath.Join(tempDir, "other.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(data), "conflicting file untouched")
+
+	// Applying again finds everything in place.
+	changes = planChanges(tempDir, patches[:1])
+	report.Reset()
+	assert.True(t, printApplyReport(&report, changes, false))
+	assert.Equal(t, "unchanged main.go (1 already applied)\nall 1 file(s) apply.\n", report.String())
+}
+
+func TestReviewChanges(t *testing.T) {
+	changes := []fileChange{
+		{Path: "a.txt", Old: "one\n", New: "ONE\n"},
+		{Path: "b.txt", Old: "two\n", New: "TWO\n"},
+		{Path: "c.txt", Old: "three\n", New: "three\n"}, // nothing to write, not asked
+		{Path: "d.txt", Old: "four\n", Delete: true},
+		{Path: "e.txt", Old: "five\n", New: "FIVE\n"},
+	}
+	edit := func(path, content string) (string, error) {
+		assert.Equal(t, "b.txt", path)
+		assert.Equal(t, "TWO\n", content)
+		return "Two\n", nil
+	}
+	var out strings.Builder
+	in := bufio.NewReader(strings.NewReader("y\nmaybe\ne\ny\nn\n"))
+	require.NoError(t, reviewChanges(in, &out, changes, edit))
+
+	assert.False(t, changes[0].Declined)
+	assert.False(t, changes[1].Declined)
+	assert.Equal(t, "Two\n", changes[1].New, "edited content written")
+	assert.True(t, changes[3].Declined)
+	assert.True(t, changes[4].Declined, "end of input declines the rest")
+	assert.Contains(t, out.String(), "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+ONE\n")
+	assert.Contains(t, out.String(), "-two\n+Two\n", "diff shown again after editing")
+	assert.Contains(t, out.String(), "Write d.txt? [y]es, [n]o, [q]uit: ")
+	assert.NotContains(t, out.String(), "c.txt")
+
+	var report strings.Builder
+	assert.True(t, printApplyReport(&report, changes, false))
+	assert.Contains(t, report.String(), "declined  d.txt\n")
+	assert.Contains(t, report.String(), "3 of 5 file(s) written; 2 declined at review.\n")
+}
+
+func TestWriteChanges_ChangedOnDisk(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"a.txt": "edited meanwhile\n", "new.txt": "already here\n"})
+	changes := []fileChange{
+		{Path: "a.txt", Old: "one\n", New: "ONE\n", Mode: 0644},
+		{Path: "new.txt", New: "created\n", Create: true, Mode: 0644},
+	}
+	writeChanges(tempDir, changes)
+	for _, c := range changes {
+		assert.ErrorContains(t, c.Err, "changed on disk", c.Path)
+	}
+	data, err := os.ReadFile(filepath.Join(tempDir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "edited meanwhile\n", string(data))
+}
+
+func TestPlanChanges_RenameTargetAndSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need a Unix file system")
+	}
+	tempDir := setupTestDir(t, map[string]string{"old.txt": "x\n", "new.txt": "precious\n", "real.txt": "a\n"})
+	require.NoError(t, os.Symlink("real.txt", filepath.Join(tempDir, "link.txt")))
+	patch := "--- a/old.txt\n+++ b/new.txt\n@@ -1 +1 @@\n-x\n+y\n" +
+		"--- a/link.txt\n+++ b/link.txt\n@@ -1 +1 @@\n-a\n+b\n"
+	patches, err := parsePatch(strings.NewReader(patch))
+	require.NoError(t, err)
+
+	changes := planChanges(tempDir, patches)
+	var report strings.Builder
+	assert.False(t, printApplyReport(&report, changes, false))
+	assert.Contains(t, report.String(), "conflict  old.txt -> new.txt\n          the rename target already exists\n")
+	assert.Contains(t, report.String(), "conflict  link.txt\n          a symlink; patch the file it points to instead\n")
+
+	writeChanges(tempDir, changes)
+	data, err := os.ReadFile(filepath.Join(tempDir, "new.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "precious\n", string(data), "the existing file is not overwritten")
+	assert.FileExists(t, filepath.Join(tempDir, "old.txt"))
+	info, err := os.Lstat(filepath.Join(tempDir, "link.txt"))
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "the symlink stays a symlink")
+}
+
+func TestRunApply_CompressedPatch(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"a.txt": "old\n"})
+	patchPath := filepath.Join(t.TempDir(), "fix.patch.gz")
+	f, err := os.Create(patchPath)
+	require.NoError(t, err)
+	zw := gzip.NewWriter(f)
+	_, err = zw.Write([]byte("--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-old\n+new\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, 0, runApply(tempDir, defaultConfig, []string{patchPath}))
+	data, err := os.ReadFile(filepath.Join(tempDir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(data))
+}
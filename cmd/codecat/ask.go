@@ -0,0 +1,244 @@
+// cmd/codecat/ask.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const defaultPromptTemplate = `{{.Context}}
+
+Using the codebase above, answer the following question:
+{{.Question}}
+`
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "ask",
+		Summary: "Generate context and send it with a question to the [llm] endpoint.",
+		Run:     runAsk,
+	})
+}
+
+func runAsk(cwd string, appConfig Config, args []string) int {
+	question := strings.TrimSpace(strings.Join(args, " "))
+	if question == "" {
+		fmt.Fprintln(os.Stderr, "Error: usage: codecat ask [flags] \"question\"")
+		return 1
+	}
+	if appConfig.LLM.Model == "" {
+		fmt.Fprintln(os.Stderr, "Error: no model configured; set [llm] provider/model in config.toml")
+		return 1
+	}
+
+	opts, err := resolveGenerateOptions(cwd, appConfig, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	result, genErr := generate(opts)
+	if genErr != nil {
+		slog.Warn("Context generation reported errors, asking anyway.", "error", genErr)
+	}
+
+	prompt, err := renderPrompt(appConfig.LLM.PromptTemplate, result.Output, question)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	slog.Info("Sending prompt to LLM.", "provider", appConfig.LLM.Provider, "model", appConfig.LLM.Model,
+		"files", len(result.IncludedFiles), "estimated_tokens", estimateTokens(int64(len(prompt))))
+
+	if err := streamCompletion(http.DefaultClient, appConfig.LLM, prompt, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "\nError: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(os.Stdout)
+	return 0
+}
+
+// renderPrompt fills the prompt template with the generated context and question.
+func renderPrompt(tmplText, context, question string) (string, error) {
+	if tmplText == "" {
+		tmplText = defaultPromptTemplate
+	}
+	tmpl, err := template.New("prompt").Parse(tmplText)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt_template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ Context, Question string }{context, question}); err != nil {
+		return "", fmt.Errorf("rendering prompt_template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// streamCompletion posts the prompt to the configured endpoint and copies the
+// answer to w as it streams in.
+func streamCompletion(client *http.Client, cfg LLMConfig, prompt string, w io.Writer) error {
+	provider := strings.ToLower(tern(cfg.Provider == "", "openai", cfg.Provider))
+	maxTokens := tern(cfg.MaxTokens > 0, cfg.MaxTokens, 4096)
+	messages := []map[string]string{{"role": "user", "content": prompt}}
+
+	var url string
+	var body map[string]any
+	headers := map[string]string{"Content-Type": "application/json"}
+	apiKey := apiKeyFromEnv(cfg.APIKeyEnv)
+
+	switch provider {
+	case "openai":
+		url = strings.TrimRight(tern(cfg.Endpoint == "", "https://api.openai.com/v1", cfg.Endpoint), "/") + "/chat/completions"
+		body = map[string]any{"model": cfg.Model, "messages": messages, "stream": true}
+		if cfg.MaxTokens > 0 {
+			body["max_tokens"] = cfg.MaxTokens
+		}
+		if apiKey != "" {
+			headers["Authorization"] = "Bearer " + apiKey
+		}
+	case "anthropic":
+		url = strings.TrimRight(tern(cfg.Endpoint == "", "https://api.anthropic.com", cfg.Endpoint), "/") + "/v1/messages"
+		body = map[string]any{"model": cfg.Model, "messages": messages, "stream": true, "max_tokens": maxTokens}
+		headers["anthropic-version"] = "2023-06-01"
+		if apiKey != "" {
+			headers["x-api-key"] = apiKey
+		}
+	case "ollama":
+		url = strings.TrimRight(tern(cfg.Endpoint == "", "http://localhost:11434", cfg.Endpoint), "/") + "/api/chat"
+		body = map[string]any{"model": cfg.Model, "messages": messages, "stream": true}
+	default:
+		return fmt.Errorf("unknown llm provider %q (want openai, anthropic or ollama)", cfg.Provider)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		text, done, err := parseStreamLine(provider, scanner.Text())
+		if err != nil {
+			return err
+		}
+		if text != "" {
+			if _, err := io.WriteString(w, text); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// apiKeyFromEnv reads the API key from the named environment variable, if any.
+func apiKeyFromEnv(env string) string {
+	if env == "" {
+		return ""
+	}
+	apiKey := os.Getenv(env)
+	if apiKey == "" {
+		slog.Warn("API key environment variable is empty.", "env", env)
+	}
+	return apiKey
+}
+
+// parseStreamLine extracts answer text from one line of a provider's stream:
+// server-sent events for openai/anthropic, newline-delimited JSON for ollama.
+func parseStreamLine(provider, line string) (text string, done bool, err error) {
+	line = strings.TrimSpace(line)
+	if provider != "ollama" {
+		if !strings.HasPrefix(line, "data:") {
+			return "", false, nil // event names, comments, keep-alives
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if line == "[DONE]" {
+			return "", true, nil
+		}
+	}
+	if line == "" {
+		return "", false, nil
+	}
+
+	switch provider {
+	case "openai":
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return "", false, fmt.Errorf("decoding stream chunk: %w", err)
+		}
+		for _, c := range chunk.Choices {
+			text += c.Delta.Content
+		}
+		return text, false, nil
+	case "anthropic":
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Text string `json:"text"`
+			} `json:"delta"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", false, fmt.Errorf("decoding stream event: %w", err)
+		}
+		switch event.Type {
+		case "content_block_delta":
+			return event.Delta.Text, false, nil
+		case "message_stop":
+			return "", true, nil
+		case "error":
+			return "", true, errors.New(event.Error.Message)
+		}
+		return "", false, nil
+	default: // ollama
+		var chunk struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			Done  bool   `json:"done"`
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return "", false, fmt.Errorf("decoding stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return "", true, errors.New(chunk.Error)
+		}
+		return chunk.Message.Content, chunk.Done, nil
+	}
+}
@@ -0,0 +1,83 @@
+// cmd/codecat/ask_test.go
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPrompt(t *testing.T) {
+	prompt, err := renderPrompt("", "CODE", "Why?")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(prompt, "CODE\n"))
+	assert.Contains(t, prompt, "Why?")
+
+	prompt, err = renderPrompt("Q: {{.Question}}", "CODE", "Why?")
+	require.NoError(t, err)
+	assert.Equal(t, "Q: Why?", prompt)
+
+	_, err = renderPrompt("{{.Nope", "", "")
+	assert.Error(t, err)
+}
+
+func TestStreamCompletion(t *testing.T) {
+	testCases := []struct {
+		provider string
+		path     string
+		stream   string
+	}{
+		{
+			provider: "openai",
+			path:     "/chat/completions",
+			stream: "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\ndata: [DONE]\n\n",
+		},
+		{
+			provider: "anthropic",
+			path:     "/v1/messages",
+			stream: "event: message_start\ndata: {\"type\":\"message_start\"}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"text\":\"Hel\"}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"text\":\"lo\"}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+		},
+		{
+			provider: "ollama",
+			path:     "/api/chat",
+			stream: "{\"message\":{\"content\":\"Hel\"},\"done\":false}\n" +
+				"{\"message\":{\"content\":\"lo\"},\"done\":true}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.provider, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.path, r.URL.Path)
+				fmt.Fprint(w, tc.stream)
+			}))
+			defer server.Close()
+
+			var out strings.Builder
+			cfg := LLMConfig{Provider: tc.provider, Endpoint: server.URL, Model: "m"}
+			require.NoError(t, streamCompletion(server.Client(), cfg, "prompt", &out))
+			assert.Equal(t, "Hello", out.String())
+		})
+	}
+}
+
+func TestStreamCompletion_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	var out strings.Builder
+	err := streamCompletion(server.Client(), LLMConfig{Endpoint: server.URL, Model: "m"}, "p", &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad key")
+}
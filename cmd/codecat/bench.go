@@ -0,0 +1,224 @@
+// cmd/codecat/bench.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pflag "github.com/spf13/pflag"
+)
+
+var (
+	benchFiles    int
+	benchDepth    int
+	benchPatterns int
+	benchSeed     int64
+	benchRuns     int
+	benchRead     bool
+	benchKeepTree bool
+)
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "bench",
+		Summary: "Time the walk and exclusion checks on a synthetic tree (for development).",
+		Hidden:  true,
+		Flags: func(fs *pflag.FlagSet) {
+			fs.IntVar(&benchFiles, "bench-files", 5000,
+				"[bench] Files in the synthetic tree.")
+			fs.IntVar(&benchDepth, "bench-depth", 8,
+				"[bench] Deepest directory level of the synthetic tree.")
+			fs.IntVar(&benchPatterns, "bench-patterns", 50,
+				"[bench] Extra non-matching exclude patterns, to load the exclusion checks.")
+			fs.Int64Var(&benchSeed, "bench-seed", 1,
+				"[bench] Seed of the synthetic tree; the same seed gives the same tree.")
+			fs.IntVar(&benchRuns, "runs", 5,
+				"[bench] Timed runs, after one untimed warm-up run.")
+			fs.BoolVar(&benchRead, "read", false,
+				"[bench] Read and process file content too, not only walk and classify.")
+			fs.BoolVar(&benchKeepTree, "keep-tree", false,
+				"[bench] Keep the synthetic tree and print its path.")
+		},
+		Run: runBench,
+	})
+}
+
+// benchTree describes a synthetic source tree. Generation is deterministic
+// for a given spec, so timings of two builds compare the same work.
+type benchTree struct {
+	Files int
+	Depth int
+	Seed  int64
+}
+
+var (
+	benchExtensions = []string{"go", "py", "md", "json", "txt", "yaml", "js", "png"}
+	benchExcluded   = []string{"node_modules", "vendor", "build", "dist", "__pycache__"}
+)
+
+// generate writes the tree under root: source files spread over nested
+// directories, some inside commonly excluded directories, and .gitignore
+// files at a few levels.
+func (t benchTree) generate(root string) error {
+	rng := rand.New(rand.NewSource(t.Seed))
+	dirs := []string{""}
+	for i := 0; i < t.Files; i++ {
+		parent := dirs[rng.Intn(len(dirs))]
+		dir := parent
+		if depth := strings.Count(parent, "/") + 1; depth < t.Depth && rng.Intn(4) == 0 {
+			name := fmt.Sprintf("dir%d", len(dirs))
+			if rng.Intn(10) == 0 {
+				name = benchExcluded[rng.Intn(len(benchExcluded))]
+			}
+			dir = strings.TrimPrefix(parent+"/"+name, "/")
+			if !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+				if rng.Intn(8) == 0 {
+					ignore := fmt.Sprintf("*.tmp\nignored%d/\n/generated_*.go\n", rng.Intn(10))
+					if err := writeBenchFile(root, dir+"/.gitignore", ignore); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		ext := benchExtensions[rng.Intn(len(benchExtensions))]
+		if rng.Intn(20) == 0 {
+			ext = "tmp"
+		}
+		content := strings.Repeat(fmt.Sprintf("line %d of a synthetic file\n", i), 1+rng.Intn(40))
+		if err := writeBenchFile(root, fmt.Sprintf("%s/file%d.%s", dir, i, ext), content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeBenchFile(root, rel, content string) error {
+	path := filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(rel, "/")))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// benchExcludePatterns returns n CWD-relative patterns that match nothing
+// in a benchTree, so every file pays for checking them.
+func benchExcludePatterns(n int) []string {
+	patterns := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			patterns = append(patterns, fmt.Sprintf("nomatch%d/**", i))
+		case 1:
+			patterns = append(patterns, fmt.Sprintf("**/nomatch%d/*.go", i))
+		default:
+			patterns = append(patterns, fmt.Sprintf("*.nomatch%d", i))
+		}
+	}
+	return patterns
+}
+
+// benchRun is the measurement of one generate call.
+type benchRun struct {
+	Duration time.Duration
+	Allocs   uint64 // heap objects allocated
+	Bytes    uint64 // heap bytes allocated
+	Result   GenerateResult
+}
+
+func runBench(cwd string, appConfig Config, args []string) int {
+	if benchRuns < 1 {
+		fmt.Fprintln(os.Stderr, "Error: --runs must be at least 1")
+		return 1
+	}
+	root := cwd
+	if len(args) == 0 {
+		tree := benchTree{Files: benchFiles, Depth: benchDepth, Seed: benchSeed}
+		dir, err := os.MkdirTemp("", "codecat-bench-*")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if benchKeepTree {
+			fmt.Fprintf(os.Stderr, "Synthetic tree kept at %s\n", dir)
+		} else {
+			defer os.RemoveAll(dir)
+		}
+		slog.Info("Generating synthetic tree.", "path", dir, "files", tree.Files, "depth", tree.Depth, "seed", tree.Seed)
+		if err := tree.generate(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: generating the synthetic tree: %v\n", err)
+			return 1
+		}
+		root = dir
+	}
+	opts, err := resolveGenerateOptions(root, appConfig, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	opts.SkipContent = !benchRead
+	opts.FlagExcludes = append(opts.FlagExcludes, benchExcludePatterns(benchPatterns)...)
+
+	runs := make([]benchRun, 0, benchRuns)
+	for i := 0; i <= benchRuns; i++ {
+		run, err := measureGenerate(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if i > 0 { // the first run warms the file system cache
+			runs = append(runs, run)
+		}
+	}
+	printBench(os.Stdout, runs, len(opts.FlagExcludes))
+	return 0
+}
+
+func measureGenerate(opts GenerateOptions) (benchRun, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	result, err := generate(opts)
+	duration := time.Since(start)
+	runtime.ReadMemStats(&after)
+	result.Close()
+	return benchRun{
+		Duration: duration,
+		Allocs:   after.Mallocs - before.Mallocs,
+		Bytes:    after.TotalAlloc - before.TotalAlloc,
+		Result:   result,
+	}, err
+}
+
+// printBench writes the timing of each run and their median.
+func printBench(w io.Writer, runs []benchRun, patterns int) {
+	last := runs[len(runs)-1].Result
+	fmt.Fprintf(w, "Files seen: %d, included: %d, exclude patterns: %d\n",
+		last.FilesSeen, len(last.IncludedFiles), patterns)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Run\tTime\tFiles/s\tAllocs\tAlloc bytes\t")
+	for i, run := range runs {
+		fmt.Fprintf(tw, "%d\t%s\t%.0f\t%d\t%s\t\n", i+1, run.Duration.Round(time.Microsecond),
+			float64(run.Result.FilesSeen)/run.Duration.Seconds(), run.Allocs, formatBytes(int64(run.Bytes)))
+	}
+	median := medianBenchRun(runs)
+	fmt.Fprintf(tw, "median\t%s\t%.0f\t%d\t%s\t\n", median.Duration.Round(time.Microsecond),
+		float64(median.Result.FilesSeen)/median.Duration.Seconds(), median.Allocs, formatBytes(int64(median.Bytes)))
+	tw.Flush()
+}
+
+func medianBenchRun(runs []benchRun) benchRun {
+	sorted := slices.Clone(runs)
+	slices.SortFunc(sorted, func(a, b benchRun) int { return int(a.Duration - b.Duration) })
+	return sorted[len(sorted)/2]
+}
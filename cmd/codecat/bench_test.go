@@ -0,0 +1,90 @@
+// cmd/codecat/bench_test.go
+package main
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func benchTreeFiles(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	require.NoError(t, filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		rel, _ := filepath.Rel(root, path)
+		files[filepath.ToSlash(rel)] = string(content)
+		return err
+	}))
+	return files
+}
+
+func TestBenchTree_Reproducible(t *testing.T) {
+	tree := benchTree{Files: 300, Depth: 4, Seed: 7}
+	a, b := t.TempDir(), t.TempDir()
+	require.NoError(t, tree.generate(a))
+	require.NoError(t, tree.generate(b))
+	filesA := benchTreeFiles(t, a)
+	assert.Equal(t, filesA, benchTreeFiles(t, b), "the same seed gives the same tree")
+
+	c := t.TempDir()
+	require.NoError(t, benchTree{Files: 300, Depth: 4, Seed: 8}.generate(c))
+	assert.NotEqual(t, filesA, benchTreeFiles(t, c))
+
+	for path := range filesA {
+		assert.LessOrEqual(t, strings.Count(path, "/"), 4, "directories at most Depth levels deep")
+	}
+}
+
+func TestBenchExcludePatterns_MatchNothing(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, benchTree{Files: 200, Depth: 5, Seed: 1}.generate(root))
+	opts := GenerateOptions{
+		CWD:          root,
+		ScanDirs:     []string{root},
+		Extensions:   processExtensions([]string{"go", "py", "md"}),
+		UseGitignore: true,
+		SkipContent:  true,
+	}
+	plain, err := generate(opts)
+	require.NoError(t, err)
+	opts.FlagExcludes = benchExcludePatterns(30)
+	assert.Len(t, opts.FlagExcludes, 30)
+	loaded, err := generate(opts)
+	require.NoError(t, err)
+	assert.NotEmpty(t, plain.IncludedFiles)
+	assert.Equal(t, getPathsFromIncludedFiles(plain.IncludedFiles), getPathsFromIncludedFiles(loaded.IncludedFiles))
+}
+
+func TestPrintBench(t *testing.T) {
+	result := GenerateResult{FilesSeen: 100, IncludedFiles: []FileInfo{{Path: "a.go"}}}
+	runs := []benchRun{
+		{Duration: 30 * time.Millisecond, Result: result},
+		{Duration: 10 * time.Millisecond, Result: result},
+		{Duration: 20 * time.Millisecond, Result: result},
+	}
+	assert.Equal(t, 20*time.Millisecond, medianBenchRun(runs).Duration)
+
+	var buf bytes.Buffer
+	printBench(&buf, runs, 5)
+	out := buf.String()
+	assert.Contains(t, out, "Files seen: 100, included: 1, exclude patterns: 5")
+	assert.Regexp(t, `median\s+20ms\s+5000`, out)
+}
+
+func TestPrintSubcommands_SkipsHidden(t *testing.T) {
+	var buf bytes.Buffer
+	printSubcommands(&buf)
+	assert.Contains(t, buf.String(), "stats")
+	assert.NotContains(t, buf.String(), "bench")
+}
@@ -0,0 +1,49 @@
+// cmd/codecat/binary.go
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// binarySniffSize is how much of a file is searched for a NUL byte, the
+// same heuristic git uses to tell binary files from text.
+const binarySniffSize = 8000
+
+// looksBinary reports whether content has a NUL byte near its start.
+func looksBinary(content []byte) bool {
+	if len(content) > binarySniffSize {
+		content = content[:binarySniffSize]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// sniffBinary reads just the start of a file for looksBinary, for walks
+// that classify files without reading them in full.
+func sniffBinary(absPath string) (bool, error) {
+	f, err := os.Open(absPath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	head := make([]byte, binarySniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return looksBinary(head[:n]), nil
+}
+
+// binaryChecked reports whether a file is subject to binary detection:
+// formats a content adapter converts to text (PDF, docx) are binary by
+// nature and exempt unless adapters are off.
+func binaryChecked(relPath string, opts GenerateOptions) bool {
+	if !opts.SkipBinary {
+		return false
+	}
+	_, adapted := contentAdapters[strings.ToLower(filepath.Ext(relPath))]
+	return !adapted || opts.NoAdapters
+}
@@ -0,0 +1,70 @@
+// cmd/codecat/binary_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLooksBinary(t *testing.T) {
+	assert.True(t, looksBinary([]byte("GIF89a\x00\x01")))
+	assert.False(t, looksBinary([]byte("package main\n")))
+	assert.False(t, looksBinary(nil))
+	late := append([]byte(strings.Repeat("a", binarySniffSize)), 0)
+	assert.False(t, looksBinary(late), "only the start is searched")
+}
+
+func TestSniffBinary(t *testing.T) {
+	dir := t.TempDir()
+	bin, text := filepath.Join(dir, "a.bin"), filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(bin, []byte("\x7fELF\x02\x01\x01\x00"), 0644))
+	require.NoError(t, os.WriteFile(text, []byte("hello\n"), 0644))
+	binary, err := sniffBinary(bin)
+	require.NoError(t, err)
+	assert.True(t, binary)
+	binary, err = sniffBinary(text)
+	require.NoError(t, err)
+	assert.False(t, binary)
+	_, err = sniffBinary(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestGenerate_SizeAndBinary(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"src/app.go":   "package app\n",
+		"src/big.go":   "package app\n\n// " + strings.Repeat("x", 100) + "\n",
+		"src/blob.go":  "package app\x00\n",
+		"vendor/x.go":  "package x\x00\n",
+		"vendor/y.go":  "package y\n\n// " + strings.Repeat("y", 100) + "\n",
+		"docs/read.md": "# docs\n",
+	})
+	for _, skipContent := range []bool{false, true} {
+		opts := GenerateOptions{
+			CWD:           tempDir,
+			ScanDirs:      []string{tempDir},
+			Extensions:    processExtensions([]string{"go", "md"}),
+			ManualFiles:   []string{"vendor/x.go", "vendor/y.go"},
+			MaxFileSize:   64,
+			SkipBinary:    true,
+			ReportSkipped: true,
+			SkipContent:   skipContent,
+		}
+		result, err := generate(opts)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"src/big.go"}, result.Skipped["size"])
+		assert.Equal(t, []string{"src/blob.go"}, result.Skipped["binary"])
+		assert.Equal(t, 1, result.ExcludedBy["binary"])
+		assert.ElementsMatch(t, []string{"docs/read.md", "src/app.go", "vendor/x.go", "vendor/y.go"},
+			getPathsFromIncludedFiles(result.IncludedFiles), "manual files are unaffected")
+	}
+
+	assert.True(t, binaryChecked("a.go", GenerateOptions{SkipBinary: true}))
+	assert.False(t, binaryChecked("a.PDF", GenerateOptions{SkipBinary: true}), "converted by an adapter")
+	assert.True(t, binaryChecked("a.pdf", GenerateOptions{SkipBinary: true, NoAdapters: true}))
+	assert.False(t, binaryChecked("a.go", GenerateOptions{}))
+}
@@ -0,0 +1,84 @@
+// cmd/codecat/budget.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const dropListSize = 10
+
+// dropSuggestion is an included file or directory and the tokens that
+// excluding it would save.
+type dropSuggestion struct {
+	Path   string
+	Files  int
+	Tokens int64
+}
+
+// dropSuggestions ranks included files and the directories holding them by
+// estimated tokens, largest first, keeping at most limit entries. A directory
+// with a single file is left to that file's own entry.
+func dropSuggestions(files []FileInfo, limit int) []dropSuggestion {
+	byPath := map[string]*dropSuggestion{}
+	for _, f := range files {
+		tokens := estimateTokens(f.Size)
+		byPath[f.Path] = &dropSuggestion{Path: f.Path, Files: 1, Tokens: tokens}
+		parts := strings.Split(f.Path, "/")
+		for i := 1; i < len(parts); i++ {
+			dir := strings.Join(parts[:i], "/") + "/"
+			if byPath[dir] == nil {
+				byPath[dir] = &dropSuggestion{Path: dir}
+			}
+			byPath[dir].Files++
+			byPath[dir].Tokens += tokens
+		}
+	}
+
+	suggestions := make([]dropSuggestion, 0, len(byPath))
+	for _, s := range byPath {
+		if strings.HasSuffix(s.Path, "/") && s.Files < 2 {
+			continue
+		}
+		suggestions = append(suggestions, *s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Tokens != suggestions[j].Tokens {
+			return suggestions[i].Tokens > suggestions[j].Tokens
+		}
+		return suggestions[i].Path < suggestions[j].Path
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// printDropList explains a budget overrun and lists what to exclude to fit.
+func printDropList(w io.Writer, files []FileInfo, tokens, maxTokens int64) {
+	over := tokens - maxTokens
+	fmt.Fprintf(w, "Output is ~%d tokens, over the budget of %d by ~%d.\n", tokens, maxTokens, over)
+	fmt.Fprintln(w, "Largest contributors (exclude with -x <path>):")
+	for _, s := range dropSuggestions(files, dropListSize) {
+		detail := ""
+		if s.Files > 1 {
+			detail = fmt.Sprintf(" (%d files)", s.Files)
+		}
+		if s.Tokens >= over {
+			detail += " - enough on its own"
+		}
+		fmt.Fprintf(w, "  ~%-8d %s%s\n", s.Tokens, s.Path, detail)
+	}
+}
+
+// printFitReport lists the files --fit dropped to meet the budget, in the
+// order they were dropped.
+func printFitReport(w io.Writer, dropped []string, maxTokens int64) {
+	fmt.Fprintf(w, "\n--- Dropped to fit %d tokens (%d) ---\n", maxTokens, len(dropped))
+	for _, p := range dropped {
+		fmt.Fprintf(w, "- %s\n", p)
+	}
+	fmt.Fprintln(w, "---------------")
+}
@@ -0,0 +1,37 @@
+// cmd/codecat/budget_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropSuggestions(t *testing.T) {
+	files := []FileInfo{
+		{Path: "data/big.json", Size: 4000},
+		{Path: "data/small.json", Size: 400},
+		{Path: "src/main.go", Size: 2000},
+		{Path: "src/util/u.go", Size: 800},
+		{Path: "README.md", Size: 100},
+	}
+	assert.Equal(t, []dropSuggestion{
+		{Path: "data/", Files: 2, Tokens: 1100},
+		{Path: "data/big.json", Files: 1, Tokens: 1000},
+		{Path: "src/", Files: 2, Tokens: 700},
+		{Path: "src/main.go", Files: 1, Tokens: 500},
+	}, dropSuggestions(files, 4), "src/util/ holds a single file and is left to src/util/u.go")
+}
+
+func TestPrintDropList(t *testing.T) {
+	files := []FileInfo{{Path: "a/x.txt", Size: 400}, {Path: "a/y.txt", Size: 40}, {Path: "b.txt", Size: 40}}
+	var out strings.Builder
+	printDropList(&out, files, 130, 100)
+	assert.Equal(t, "Output is ~130 tokens, over the budget of 100 by ~30.\n"+
+		"Largest contributors (exclude with -x <path>):\n"+
+		"  ~110      a/ (2 files) - enough on its own\n"+
+		"  ~100      a/x.txt - enough on its own\n"+
+		"  ~10       a/y.txt\n"+
+		"  ~10       b.txt\n", out.String())
+}
@@ -0,0 +1,136 @@
+// cmd/codecat/collect.go
+package main
+
+import (
+	"container/heap"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// fileCollector gathers the per-file results of a scan. Its methods are safe
+// for concurrent use, so files can be processed by several goroutines, and
+// results hands them back in walk order whatever order they arrived in.
+type fileCollector struct {
+	mu       sync.Mutex
+	included []collectedFile
+	empty    []string
+	errors   map[string]error // shared with the files given with -f
+	spool    *contentSpool
+	limit    int           // --max-files; 0 or less is unlimited
+	first    walkOrderHeap // the limit first included paths in walk order
+}
+
+// collectedFile is an included file with its document, which is nil when
+// the scan does not read content.
+type collectedFile struct {
+	info FileInfo
+	doc  *Document
+}
+
+func newFileCollector(errors map[string]error, spool *contentSpool, limit int) *fileCollector {
+	return &fileCollector{errors: errors, spool: spool, limit: limit}
+}
+
+// include records an included file. doc may be nil under SkipContent.
+func (c *fileCollector) include(info FileInfo, doc *Document) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if doc != nil {
+		kept := c.spool.keep(*doc)
+		doc = &kept
+	}
+	c.included = append(c.included, collectedFile{info: info, doc: doc})
+	switch {
+	case c.limit <= 0:
+	case len(c.first) < c.limit:
+		heap.Push(&c.first, info.Path)
+	case compareWalkOrder(info.Path, c.first[0]) < 0:
+		c.first[0] = info.Path
+		heap.Fix(&c.first, 0)
+	}
+}
+
+// pastLimit reports whether relPath comes after the limit first files
+// included so far in walk order: it cannot make the cut, so there is no need
+// to read it.
+func (c *fileCollector) pastLimit(relPath string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit > 0 && len(c.first) == c.limit && compareWalkOrder(relPath, c.first[0]) > 0
+}
+
+// addEmpty records a file that had no content.
+func (c *fileCollector) addEmpty(relPath string) {
+	c.mu.Lock()
+	c.empty = append(c.empty, relPath)
+	c.mu.Unlock()
+}
+
+// addError records a file that could not be processed.
+func (c *fileCollector) addError(relPath string, err error) {
+	c.mu.Lock()
+	c.errors[relPath] = err
+	c.mu.Unlock()
+}
+
+// results returns the included files and their documents, and the empty
+// files, in the order a sequential walk meets them, with the total size of
+// the included files. Under a limit only the first files in that order are
+// kept, so the cut does not depend on the order files arrived in; dropped
+// lists the others.
+func (c *fileCollector) results() (included []FileInfo, documents []Document, empty, dropped []string, totalSize int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files := slices.Clone(c.included)
+	slices.SortStableFunc(files, func(a, b collectedFile) int { return compareWalkOrder(a.info.Path, b.info.Path) })
+	if c.limit > 0 && len(files) > c.limit {
+		for _, f := range files[c.limit:] {
+			dropped = append(dropped, f.info.Path)
+		}
+		files = files[:c.limit]
+	}
+	for _, f := range files {
+		included = append(included, f.info)
+		if f.doc != nil {
+			documents = append(documents, *f.doc)
+		}
+		totalSize += f.info.Size
+	}
+	empty = slices.Clone(c.empty)
+	slices.SortFunc(empty, compareWalkOrder)
+	return included, documents, empty, dropped, totalSize
+}
+
+// walkOrderHeap is a max-heap of paths in walk order, holding the first
+// files of a --max-files scan with the last of them on top.
+type walkOrderHeap []string
+
+func (h walkOrderHeap) Len() int           { return len(h) }
+func (h walkOrderHeap) Less(i, j int) bool { return compareWalkOrder(h[i], h[j]) > 0 }
+func (h walkOrderHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *walkOrderHeap) Push(x any)        { *h = append(*h, x.(string)) }
+func (h *walkOrderHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// compareWalkOrder orders slash-separated relative paths as a sequential
+// walk lists them: a directory's files by name, then its subdirectories by
+// name, each with everything below it.
+func compareWalkOrder(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		aFile, bFile := i == len(as)-1, i == len(bs)-1
+		if aFile != bFile {
+			return tern(aFile, -1, 1)
+		}
+		return strings.Compare(as[i], bs[i])
+	}
+	return len(as) - len(bs)
+}
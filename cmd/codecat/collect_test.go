@@ -0,0 +1,111 @@
+// cmd/codecat/collect_test.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareWalkOrder(t *testing.T) {
+	paths := []string{"b/z.go", "z.go", "a/b/c.go", "a.go", "a/a.go", "b.go", "a/z/y.go", "a/m.go"}
+	slices.SortFunc(paths, compareWalkOrder)
+	assert.Equal(t, []string{"a.go", "b.go", "z.go", "a/a.go", "a/m.go", "a/b/c.go", "a/z/y.go", "b/z.go"}, paths,
+		"a directory's files come before its subdirectories")
+}
+
+func TestFileCollector_Concurrent(t *testing.T) {
+	errorFiles := map[string]error{"manual.go": errors.New("from -f")}
+	c := newFileCollector(errorFiles, nil, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := fmt.Sprintf("dir%d/file%02d.go", i%5, i)
+			if i%5 == 0 {
+				path = fmt.Sprintf("top%02d.go", i)
+			}
+			switch i % 7 {
+			case 0:
+				c.addEmpty(path + ".empty")
+			case 1:
+				c.addError(path+".bad", errors.New("unreadable"))
+			default:
+				c.include(FileInfo{Path: path, Size: 1}, &Document{Path: path})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	included, documents, empty, dropped, totalSize := c.results()
+	assert.Empty(t, dropped)
+	require.Len(t, documents, len(included))
+	assert.Equal(t, int64(len(included)), totalSize)
+	paths := make([]string, len(included))
+	for i, f := range included {
+		paths[i] = f.Path
+		assert.Equal(t, f.Path, documents[i].Path, "documents stay paired with their files")
+	}
+	assert.True(t, slices.IsSortedFunc(paths, compareWalkOrder), "included files come back in walk order")
+	assert.True(t, slices.IsSortedFunc(empty, compareWalkOrder))
+	failed := 0
+	for i := 0; i < 50; i++ {
+		if i%7 == 1 {
+			failed++
+		}
+	}
+	assert.Len(t, errorFiles, 1+failed, "errors join those of the files given with -f")
+}
+
+func TestFileCollector_Limit(t *testing.T) {
+	c := newFileCollector(map[string]error{}, nil, 3)
+	for _, path := range []string{"b/z.go", "z.go", "a/a.go"} {
+		c.include(FileInfo{Path: path, Size: int64(len(path))}, &Document{Path: path})
+	}
+	assert.True(t, c.pastLimit("c/c.go"), "after the three first files")
+	assert.False(t, c.pastLimit("a.go"), "before the last of them")
+	c.include(FileInfo{Path: "a.go", Size: 4}, &Document{Path: "a.go"})
+	assert.True(t, c.pastLimit("b/a.go"), "b/z.go fell out of the first three")
+	included, documents, _, dropped, totalSize := c.results()
+	require.Len(t, documents, 3)
+	for i, want := range []string{"a.go", "z.go", "a/a.go"} {
+		assert.Equal(t, want, included[i].Path, "the first files in walk order are kept")
+		assert.Equal(t, want, documents[i].Path)
+	}
+	assert.Equal(t, []string{"b/z.go"}, dropped)
+	assert.Equal(t, int64(14), totalSize, "only the kept files are counted")
+}
+
+func TestGenerate_DeterministicOrder(t *testing.T) {
+	files := map[string]string{"root.go": "package root\n"}
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 5; j++ {
+			files[filepath.Join(fmt.Sprintf("pkg%d", i), fmt.Sprintf("f%d.go", j))] = "package p\n"
+		}
+		files[filepath.Join(fmt.Sprintf("pkg%d", i), "empty.go")] = ""
+	}
+	tempDir := setupTestDir(t, files)
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+	}
+	first, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, "root.go", first.IncludedFiles[0].Path, "top-level files come first")
+	for i := 0; i < 5; i++ {
+		again, err := generate(opts)
+		require.NoError(t, err)
+		assert.Equal(t, first.Output, again.Output, "top-level directories are walked concurrently")
+		assert.Equal(t, first.EmptyFiles, again.EmptyFiles)
+	}
+}
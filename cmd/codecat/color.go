@@ -0,0 +1,88 @@
+// cmd/codecat/color.go
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+)
+
+// colorModes are the accepted --color values.
+var colorModes = []string{"auto", "always", "never"}
+
+// ANSI styles of the summary and of file headers printed to a terminal.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[1;34m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// palette colors the parts of the summary. The zero palette leaves text
+// plain, so summary printing is unchanged unless --color enables it.
+type palette struct {
+	on bool
+}
+
+// summaryPalette is set from --color for the stream the summary goes to.
+var summaryPalette palette
+
+// useColor decides --color for f: "auto" colors terminals unless NO_COLOR
+// is set or TERM is dumb.
+func useColor(mode string, f *os.File) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return isTerminal(f) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+}
+
+// paint wraps s in style, leaving surrounding newlines outside the codes.
+func (p palette) paint(style, s string) string {
+	core := strings.Trim(s, "\n")
+	if !p.on || core == "" {
+		return s
+	}
+	start := strings.Index(s, core)
+	return s[:start] + style + core + ansiReset + s[start+len(core):]
+}
+
+func (p palette) title(s string) string  { return p.paint(ansiBold, s) }
+func (p palette) dir(s string) string    { return p.paint(ansiBlue, s) }
+func (p palette) size(s string) string   { return p.paint(ansiGreen, s) }
+func (p palette) err(s string) string    { return p.paint(ansiRed, s) }
+func (p palette) warn(s string) string   { return p.paint(ansiYellow, s) }
+func (p palette) manual(s string) string { return p.paint(ansiMagenta, s) }
+
+// colorHeaderLines paints the marker lines of text output (file headers,
+// their metadata lines and footers) for display in a terminal.
+func colorHeaderLines(data []byte, marker string) []byte {
+	if marker == "" {
+		return data
+	}
+	var out bytes.Buffer
+	out.Grow(len(data) + len(data)/20)
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i+1]
+		}
+		data = data[len(line):]
+		text := bytes.TrimSuffix(line, []byte("\n"))
+		if bytes.Equal(text, []byte(marker)) || bytes.HasPrefix(text, []byte(marker+" ")) {
+			out.WriteString(ansiCyan)
+			out.Write(text)
+			out.WriteString(ansiReset)
+			out.Write(line[len(text):])
+			continue
+		}
+		out.Write(line)
+	}
+	return out.Bytes()
+}
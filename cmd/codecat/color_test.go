@@ -0,0 +1,44 @@
+// cmd/codecat/color_test.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPalette(t *testing.T) {
+	assert.Equal(t, "\nErrors (1):\n", palette{}.title("\nErrors (1):\n"), "off leaves text plain")
+	assert.Equal(t, "\n\x1b[1mErrors (1):\x1b[0m\n", palette{on: true}.title("\nErrors (1):\n"), "newlines stay outside the codes")
+	assert.Equal(t, "", palette{on: true}.err(""))
+
+	assert.True(t, useColor("always", nil))
+	assert.False(t, useColor("never", nil))
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if assert.NoError(t, err) {
+		defer f.Close()
+		assert.False(t, useColor("auto", f), "files are not terminals")
+	}
+}
+
+func TestColorHeaderLines(t *testing.T) {
+	data := []byte("---- preamble\n--- main.go\n--- commit abc\npackage main\n---\n-- not a marker\n")
+	assert.Equal(t, "---- preamble\n\x1b[36m--- main.go\x1b[0m\n\x1b[36m--- commit abc\x1b[0m\npackage main\n\x1b[36m---\x1b[0m\n-- not a marker\n",
+		string(colorHeaderLines(data, "---")))
+	assert.Equal(t, data, colorHeaderLines(data, ""))
+}
+
+func TestPrintSummaryTree_Color(t *testing.T) {
+	summaryPalette = palette{on: true}
+	defer func() { summaryPalette = palette{} }()
+	var buf bytes.Buffer
+	printSummaryTree([]FileInfo{{Path: "src/main.go", Size: 2048}}, nil,
+		map[string]error{"src/secret.go": errors.New("permission denied")}, nil, nil, 2048, "relative to CWD 'x'", &buf)
+	out := buf.String()
+	assert.Contains(t, out, "└── \x1b[1;34msrc\x1b[0m\n")
+	assert.Contains(t, out, "main.go (\x1b[32m2 KiB\x1b[0m)")
+	assert.Contains(t, out, "- \x1b[31msrc/secret.go\x1b[0m: \x1b[31mpermission denied\x1b[0m\n")
+}
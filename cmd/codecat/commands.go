@@ -0,0 +1,63 @@
+// cmd/codecat/commands.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	pflag "github.com/spf13/pflag"
+)
+
+// Subcommand is a codecat verb selected by the first command-line argument
+// (e.g. 'codecat serve'). Without a subcommand, codecat concatenates files.
+type Subcommand struct {
+	Name    string
+	Summary string
+	// Flags registers subcommand-specific flags on the shared flag set.
+	Flags func(fs *pflag.FlagSet)
+	// Run executes the subcommand after logging and config are set up and
+	// returns the process exit code. args are the remaining positional arguments.
+	// Without Run, the subcommand continues as a normal run.
+	Run func(cwd string, appConfig Config, args []string) int
+	// Prepare runs right after flag parsing, before anything reads the flags,
+	// and returns the positional arguments left for the run.
+	Prepare func(fs *pflag.FlagSet, args []string) ([]string, error)
+	// LenientConfig falls back to defaults when the config file cannot be
+	// loaded, so commands that repair or create it still run.
+	LenientConfig bool
+	// Hidden leaves the subcommand out of the usage text.
+	Hidden bool
+}
+
+var subcommands = map[string]*Subcommand{}
+
+// registerSubcommand makes a subcommand available for dispatch.
+func registerSubcommand(sub *Subcommand) {
+	subcommands[sub.Name] = sub
+}
+
+// popSubcommand returns the subcommand named by the first argument, if any,
+// together with the arguments left for flag parsing. A directory sharing a
+// subcommand's name can still be scanned as './name'.
+func popSubcommand(args []string) (*Subcommand, []string) {
+	if len(args) == 0 {
+		return nil, args
+	}
+	if sub, ok := subcommands[args[0]]; ok {
+		return sub, args[1:]
+	}
+	return nil, args
+}
+
+// printSubcommands writes the registered subcommands in usage format.
+func printSubcommands(w io.Writer) {
+	names := mapsKeys(subcommands)
+	sort.Strings(names)
+	for _, name := range names {
+		if subcommands[name].Hidden {
+			continue
+		}
+		fmt.Fprintf(w, "  %-10s %s\n", name, subcommands[name].Summary)
+	}
+}
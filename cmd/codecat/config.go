@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
@@ -16,17 +17,68 @@ type Config struct {
 	IncludeExtensions []string `toml:"include_extensions"`
 	// exclude_basenames are glob patterns matched against the final file/directory name anywhere.
 	ExcludeBasenames []string `toml:"exclude_basenames"`
+	// include_basenames are file name globs included whatever their extension, e.g. Makefile.
+	IncludeBasenames []string `toml:"include_basenames"`
 	// comment_marker is handled by code
 	CommentMarker *string `toml:"comment_marker"`
 	// header_text is handled by code
 	HeaderText *string `toml:"header_text"`
 	// use_gitignore is handled by code
 	UseGitignore *bool `toml:"use_gitignore"`
+	// gitattributes lists the .gitattributes attributes that exclude files; [] disables
+	Gitattributes []string `toml:"gitattributes"`
+	// ignore_case matches patterns and --grep whatever the case; unset follows the OS for patterns
+	IgnoreCase *bool `toml:"ignore_case"`
+	// exclude_tests skips test files and fixture directories, like --no-tests
+	ExcludeTests bool `toml:"exclude_tests"`
+	// scrub_allow lists domains and patterns --scrub-pii leaves unmasked
+	ScrubAllow []string `toml:"scrub_allow"`
+	// obfuscate_keep lists names --obfuscate-identifiers never renames, nor their members
+	ObfuscateKeep []string `toml:"obfuscate_keep"`
+	// memory_limit bounds the file content kept in memory, e.g. "256MB"; "0" is unlimited
+	MemoryLimit string `toml:"memory_limit"`
+	// outputs are the default output targets when no -o is given
+	Outputs []string `toml:"outputs"`
+	// file_header_template and file_footer_template frame each file in the text format
+	FileHeaderTemplate string `toml:"file_header_template"`
+	FileFooterTemplate string `toml:"file_footer_template"`
+	// plugins_dir holds Go plugins (*.so) to load; empty is ~/.config/codecat/plugins
+	PluginsDir string `toml:"plugins_dir"`
+	// content samples data files per extension: [content.csv] mode = "head", lines = 50
+	Content map[string]ContentPolicy `toml:"content"`
+	// ext_presets adds or replaces --ext-preset bundles: [ext_presets] sql = ["sql", "psql"]
+	ExtPresets map[string][]string `toml:"ext_presets"`
+	// priority maps globs to weights: higher comes earlier and is dropped last by --fit
+	Priority map[string]int `toml:"priority"`
+	// limits caps what files matching a glob contribute together: [limits] "third_party/**" = "50KB"
+	Limits map[string]string `toml:"limits"`
+	// models adds or adjusts --model presets: [models.my-model] context_tokens = 32768
+	Models map[string]ModelPreset `toml:"models"`
+	// llm configures the endpoint used by 'codecat ask'
+	LLM LLMConfig `toml:"llm"`
+	// embeddings configures the endpoint used by 'codecat select'
+	Embeddings EmbeddingsConfig `toml:"embeddings"`
+	// hooks runs external commands before the scan, on matching files and after generation
+	Hooks HooksConfig `toml:"hooks"`
 	// Add future fields here
+
+	sourcePath     string          // config file the values were loaded from, if any
+	definedKeys    map[string]bool // dotted keys set explicitly in that file
+	legacyExcludes []string        // path patterns from the deprecated exclude_patterns, applied like .codecat_exclude
 	// IncludeFileListInOutput bool   `toml:"include_file_list_in_output"`
 	// IncludeEmptyFilesInOutput bool   `toml:"include_empty_files_in_output"`
 }
 
+// LLMConfig describes an OpenAI-, Anthropic- or Ollama-compatible chat endpoint.
+type LLMConfig struct {
+	Provider       string `toml:"provider"`        // openai, anthropic or ollama
+	Endpoint       string `toml:"endpoint"`        // base URL; provider default if empty
+	Model          string `toml:"model"`           // required
+	APIKeyEnv      string `toml:"api_key_env"`     // environment variable holding the API key
+	MaxTokens      int    `toml:"max_tokens"`      // answer length limit (anthropic requires one)
+	PromptTemplate string `toml:"prompt_template"` // text/template with .Context and .Question
+}
+
 var defaultConfig = Config{
 	IncludeExtensions: []string{"py", "json", "sh", "txt", "rst", "md", "go", "mod", "sum", "yaml", "yml"},
 	ExcludeBasenames: []string{ // Default universal excludes based on name
@@ -48,9 +100,43 @@ var defaultConfig = Config{
 		"dist",
 		"target", // Common in Java/Rust
 	},
-	CommentMarker: func(s string) *string { return &s }("---"),
-	HeaderText:    func(s string) *string { return &s }("----- Codebase for analysis -----\n"),
-	UseGitignore:  func(b bool) *bool { return &b }(true),
+	IncludeBasenames: []string{"Makefile", "Dockerfile", "Jenkinsfile", "LICENSE"},
+	CommentMarker:    func(s string) *string { return &s }("---"),
+	HeaderText:       func(s string) *string { return &s }("----- Codebase for analysis -----\n"),
+	UseGitignore:     func(b bool) *bool { return &b }(true),
+	Gitattributes:    gitattributeSignals,
+	MemoryLimit:      "256MB",
+}
+
+// cloneDefaultConfig returns a deep copy of defaultConfig, safe to decode TOML
+// into without writing through its shared pointers and slices.
+func cloneDefaultConfig() Config {
+	cfg := defaultConfig
+	cfg.IncludeExtensions = append([]string(nil), defaultConfig.IncludeExtensions...)
+	cfg.ExcludeBasenames = append([]string(nil), defaultConfig.ExcludeBasenames...)
+	cfg.IncludeBasenames = append([]string(nil), defaultConfig.IncludeBasenames...)
+	cfg.Outputs = append([]string(nil), defaultConfig.Outputs...)
+	cfg.Gitattributes = append([]string(nil), defaultConfig.Gitattributes...)
+	marker, header, useGitignore := *defaultConfig.CommentMarker, *defaultConfig.HeaderText, *defaultConfig.UseGitignore
+	cfg.CommentMarker, cfg.HeaderText, cfg.UseGitignore = &marker, &header, &useGitignore
+	return cfg
+}
+
+// defaultConfigPath returns ~/.config/codecat/config.toml.
+func defaultConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "codecat", "config.toml"), nil
+}
+
+// source reports where the value of a dotted config key came from.
+func (c Config) source(key string) string {
+	if c.definedKeys[key] {
+		return "config"
+	}
+	return "default"
 }
 
 // loadConfig loads configuration from default or custom paths.
@@ -80,13 +166,12 @@ func loadConfig(customConfigPath string) (Config, error) {
 				slog.Debug("Attempting to load configuration from custom path.", "resolved_absolute_path", configFile)
 			}
 		} else {
-			homeDir, err := os.UserHomeDir()
+			var err error
+			configFile, err = defaultConfigPath()
 			if err != nil {
 				slog.Warn("Could not determine user home directory. Using default settings only.", "error", err)
 				return cfg, nil // Non-fatal, just use defaults
 			}
-			configDir := filepath.Join(homeDir, ".config", "codecat")
-			configFile = filepath.Join(configDir, "config.toml")
 			slog.Debug("Attempting to load configuration from default path.", "path", configFile)
 		}
 	}
@@ -137,7 +222,7 @@ func loadConfig(customConfigPath string) (Config, error) {
 	}
 
 	slog.Info("Loading configuration.", "path", configFile)
-	loadedCfg := defaultConfig // Start with defaults, TOML overlays
+	loadedCfg := cloneDefaultConfig() // Start with defaults, TOML overlays
 	if meta, err := toml.Decode(string(content), &loadedCfg); err != nil {
 		slog.Error("Error decoding TOML config file, using default settings.", "path", configFile, "error", err)
 		// Return error only if it was a custom path, otherwise use defaults
@@ -146,8 +231,27 @@ func loadConfig(customConfigPath string) (Config, error) {
 		}
 		slog.Warn("Using default settings due to error decoding default config file.")
 		return cfg, nil
-	} else if len(meta.Undecoded()) > 0 {
-		slog.Warn("Unrecognized keys found in config file.", "path", configFile, "keys", meta.Undecoded())
+	} else {
+		var unknown []toml.Key
+		for _, key := range meta.Undecoded() {
+			if _, legacy := legacyConfigKeys[key.String()]; !legacy {
+				unknown = append(unknown, key)
+			}
+		}
+		if len(unknown) > 0 {
+			slog.Warn("Unrecognized keys found in config file.", "path", configFile, "keys", unknown)
+		}
+		loadedCfg.sourcePath = configFile
+		loadedCfg.definedKeys = make(map[string]bool)
+		for _, key := range meta.Keys() {
+			loadedCfg.definedKeys[key.String()] = true
+		}
+		if meta.IsDefined("exclude_patterns") {
+			var legacy legacyConfig
+			if _, err := toml.Decode(string(content), &legacy); err == nil {
+				applyLegacyConfig(&loadedCfg, legacy)
+			}
+		}
 	}
 
 	// Merge loaded fields with defaults carefully, ensuring pointers are handled
@@ -179,3 +283,45 @@ func loadConfig(customConfigPath string) (Config, error) {
 
 	return cfg, nil
 }
+
+// legacyConfigKeys are keys of earlier releases that are still read, mapped
+// to what replaced them.
+var legacyConfigKeys = map[string]string{
+	"exclude_patterns": "exclude_basenames", // removed in 0.4.0
+}
+
+// legacyConfig decodes the keys listed in legacyConfigKeys.
+type legacyConfig struct {
+	ExcludePatterns []string `toml:"exclude_patterns"`
+}
+
+// splitLegacyExcludes sorts exclude_patterns into name globs, which became
+// exclude_basenames, and path patterns, which belong in .codecat_exclude.
+func splitLegacyExcludes(patterns []string) (basenames, paths []string) {
+	for _, p := range patterns {
+		if strings.Contains(strings.Trim(p, "/"), "/") {
+			paths = append(paths, p)
+		} else {
+			basenames = append(basenames, strings.TrimSuffix(p, "/"))
+		}
+	}
+	return basenames, paths
+}
+
+// applyLegacyConfig maps the deprecated keys onto cfg so upgrades keep
+// their excludes: name globs join exclude_basenames and path patterns apply
+// like .codecat_exclude.
+func applyLegacyConfig(cfg *Config, legacy legacyConfig) {
+	basenames, paths := splitLegacyExcludes(legacy.ExcludePatterns)
+	for _, p := range basenames {
+		if !contains(cfg.ExcludeBasenames, p) {
+			cfg.ExcludeBasenames = append(cfg.ExcludeBasenames, p)
+		}
+	}
+	if len(basenames) > 0 {
+		cfg.definedKeys["exclude_basenames"] = true
+	}
+	cfg.legacyExcludes = paths
+	slog.Warn("Config key exclude_patterns is deprecated; run 'codecat config migrate' to update the file.",
+		"path", cfg.sourcePath, "exclude_basenames", basenames, "cwd_relative", paths)
+}
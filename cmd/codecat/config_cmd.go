@@ -0,0 +1,399 @@
+// cmd/codecat/config_cmd.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	pflag "github.com/spf13/pflag"
+)
+
+var configForce bool
+
+// configKeyDocs documents each config key for 'codecat config init'.
+var configKeyDocs = map[string]string{
+	"include_extensions":     "File extensions (without leading dot) included during scans. Overridden by -e.",
+	"exclude_basenames":      "Glob patterns matched against the final file/directory name anywhere.",
+	"comment_marker":         "Marker delimiting file sections in the output.",
+	"header_text":            "Text placed at the very beginning of the output (no automatic newline).",
+	"use_gitignore":          "Respect .gitignore/.ignore files. Overridden by --no-gitignore.",
+	"file_header_template":   "Go template written before each file (.Path, .Size, .Language, .Tokens, .Index, .Marker, .Meta); empty keeps \"<marker> <path>\".",
+	"file_footer_template":   "Go template written after each file's content; empty keeps the closing marker.",
+	"gitattributes":          "The .gitattributes attributes that exclude files (export-ignore, linguist-vendored, linguist-generated); [] disables. Overridden by --no-gitattributes.",
+	"include_basenames":      "File name globs included whatever their extension, so extensionless files like Makefile and Dockerfile are reachable by a scan.",
+	"ignore_case":            "Match exclude, include and basename patterns and --grep whatever the case; unset, patterns ignore case on macOS and Windows only. Overridden by --ignore-case.",
+	"exclude_tests":          "Skip test files and fixture directories (_test.go, test_*.py, *.spec.ts, __tests__/, ...). Overridden by --no-tests/--only-tests.",
+	"content":                "Per-extension sampling tables, e.g. [content.csv] mode = \"head\" (or \"truncate\", \"full\") and lines = 50.",
+	"ext_presets":            "Extra or replaced --ext-preset bundles, e.g. [ext_presets] data = [\"sql\", \"csv\"].",
+	"models":                 "Extra or adjusted --model presets, e.g. [models.codellama] context_tokens = 16384, reserve_tokens = 2048, bytes_per_token = 3.5.",
+	"limits":                 "Globs mapped to a size (\"50KB\") or token count (\"12000 tokens\") that the matching files may contribute together; the largest are dropped first.",
+	"priority":               "Globs mapped to weights, e.g. [priority] \"cmd/**\" = 10, \"*.md\" = -5. Higher-weighted files come first and --fit drops them last; unmatched files weigh 0.",
+	"scrub_allow":            "Domains (with subdomains) and regular expressions (matched against the whole value) that --scrub-pii leaves unmasked.",
+	"obfuscate_keep":         "Identifiers --obfuscate-identifiers leaves as they are, with their members (e.g. np keeps np.array); standard library names are always kept.",
+	"memory_limit":           "File content kept in memory before the rest is spooled to a temporary file, e.g. \"256MB\"; \"0\" is unlimited. Overridden by --memory-limit.",
+	"outputs":                "Output targets ([format:]path, \"-\" for stdout, \"clipboard\") used when no -o is given.",
+	"plugins_dir":            "Directory of Go plugins (*.so) adding formats, transforms and excluders; empty uses ~/.config/codecat/plugins.",
+	"hooks.pre_scan":         "Shell commands run before the scan, with a JSON payload on stdin; a failure aborts the run.",
+	"hooks.transform":        "[[hooks.transform]] tables with glob and command: matching files are piped through the command (stdin to stdout).",
+	"hooks.post_generate":    "Shell commands run after the outputs are written, with the included files, errors and outputs as JSON on stdin.",
+	"hooks.timeout":          "Time limit of each hook command, e.g. \"30s\" (the default).",
+	"llm.provider":           "Provider for 'codecat ask': openai, anthropic or ollama.",
+	"llm.endpoint":           "Base URL of the LLM API; empty uses the provider default.",
+	"llm.model":              "Model name sent to the LLM API.",
+	"llm.api_key_env":        "Environment variable holding the API key.",
+	"llm.max_tokens":         "Maximum answer length in tokens (0 uses a default).",
+	"llm.prompt_template":    "Go text/template with .Context and .Question; empty uses the built-in prompt.",
+	"embeddings.provider":    "Provider for 'codecat select': openai or ollama.",
+	"embeddings.endpoint":    "Base URL of the embeddings API; empty uses the provider default.",
+	"embeddings.model":       "Embedding model, e.g. text-embedding-3-small or nomic-embed-text.",
+	"embeddings.api_key_env": "Environment variable holding the embeddings API key.",
+}
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "config",
+		Summary: "Manage config.toml: init, show, edit, set <key> <value>, migrate.",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&configForce, "force", false,
+				"[config init] Overwrite an existing config file. [config migrate] Drop path patterns of exclude_patterns.")
+		},
+		Run:           runConfig,
+		LenientConfig: true,
+	})
+}
+
+func runConfig(cwd string, appConfig Config, args []string) int {
+	path, err := configCommandPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: usage: codecat config init|show|edit|set <key> <value>|migrate")
+		return 1
+	}
+
+	switch args[0] {
+	case "init":
+		err = initConfigFile(path, configForce)
+		if err == nil {
+			fmt.Printf("Wrote %s\n", path)
+		}
+	case "show":
+		err = writeEffectiveConfig(os.Stdout, appConfig)
+	case "edit":
+		err = editConfigFile(path)
+	case "set":
+		if len(args) != 3 {
+			err = errors.New("usage: codecat config set <key> <value>")
+		} else {
+			err = setConfigValue(path, args[1], args[2])
+		}
+	case "migrate":
+		err = migrateConfigFile(os.Stdout, path, configForce)
+	default:
+		err = fmt.Errorf("unknown config action %q (want init, show, edit, set or migrate)", args[0])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// configCommandPath is the file managed by 'codecat config': --config if given, else the default path.
+func configCommandPath() (string, error) {
+	if configFileFlag != "" {
+		return filepath.Abs(configFileFlag)
+	}
+	return defaultConfigPath()
+}
+
+// configEntry is one dotted key of the Config struct and its value. An unset
+// optional key (a nil pointer) has the zero value of its type.
+type configEntry struct {
+	Key   string
+	Value any
+	Unset bool
+}
+
+// configEntries flattens the toml-tagged fields of cfg into dotted keys, in declaration order.
+func configEntries(cfg Config) []configEntry {
+	var entries []configEntry
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tag := t.Field(i).Tag.Get("toml")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			fv, unset := v.Field(i), false
+			if fv.Kind() == reflect.Pointer {
+				if unset = fv.IsNil(); unset {
+					fv = reflect.Zero(fv.Type().Elem())
+				} else {
+					fv = fv.Elem()
+				}
+			}
+			if fv.Kind() == reflect.Struct {
+				walk(prefix+tag+".", fv)
+				continue
+			}
+			entries = append(entries, configEntry{Key: prefix + tag, Value: fv.Interface(), Unset: unset})
+		}
+	}
+	walk("", reflect.ValueOf(cfg))
+	return entries
+}
+
+// formatTOMLValue renders a single value in TOML syntax.
+func formatTOMLValue(v any) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Len() == 0 {
+		return "[]" // the encoder drops nil slices entirely
+	}
+	if rv.Kind() == reflect.Map || rv.Kind() == reflect.Struct {
+		return formatTOMLInlineTable(rv) // the encoder would write [tables]
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]any{"v": v}); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(buf.String(), "v = "))
+}
+
+// formatTOMLInlineTable renders a map (sorted by key) or a toml-tagged struct
+// as an inline table: { csv = { mode = "head", lines = 50 } }.
+func formatTOMLInlineTable(rv reflect.Value) string {
+	var fields []string
+	if rv.Kind() == reflect.Map {
+		for _, k := range rv.MapKeys() {
+			fields = append(fields, fmt.Sprintf("%s = %s", k, formatTOMLValue(rv.MapIndex(k).Interface())))
+		}
+		sort.Strings(fields)
+	} else {
+		for i := 0; i < rv.NumField(); i++ {
+			if tag := rv.Type().Field(i).Tag.Get("toml"); tag != "" && tag != "-" {
+				fields = append(fields, fmt.Sprintf("%s = %s", tag, formatTOMLValue(rv.Field(i).Interface())))
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(fields, ", ") + " }"
+}
+
+// writeEffectiveConfig prints every key with its merged value and source.
+func writeEffectiveConfig(w io.Writer, cfg Config) error {
+	if cfg.sourcePath != "" {
+		fmt.Fprintf(w, "# Loaded from %s\n", cfg.sourcePath)
+	} else {
+		fmt.Fprintln(w, "# No config file loaded, built-in defaults only")
+	}
+	for _, e := range configEntries(cfg) {
+		line := fmt.Sprintf("%s = %s  # %s", e.Key, formatTOMLValue(e.Value), cfg.source(e.Key))
+		if e.Unset {
+			line = "# " + line + ", unset"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initConfigFile scaffolds a config file holding the built-in defaults with a comment per key.
+func initConfigFile(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "# codecat configuration, generated by 'codecat config init' (version %s).\n", Version)
+	// Top-level keys come first: once a [table] starts, every key belongs to it.
+	entries := configEntries(defaultConfig)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return !strings.Contains(entries[i].Key, ".") && strings.Contains(entries[j].Key, ".")
+	})
+	table := ""
+	for _, e := range entries {
+		key := e.Key
+		if i := strings.LastIndex(key, "."); i >= 0 {
+			if t := key[:i]; t != table {
+				table = t
+				fmt.Fprintf(&buf, "\n[%s]\n", table)
+			}
+			key = key[i+1:]
+		}
+		fmt.Fprintf(&buf, "\n# %s\n", configKeyDocs[e.Key])
+		line := fmt.Sprintf("%s = %s\n", key, formatTOMLValue(e.Value))
+		if table != "" || e.Unset {
+			line = "# " + line // optional keys stay commented out
+		}
+		buf.WriteString(line)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(buf.String()), 0644)
+}
+
+// editConfigFile opens the config file in $EDITOR, scaffolding it first if needed.
+func editConfigFile(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if err := initConfigFile(path, false); err != nil {
+			return err
+		}
+	}
+	return runEditor(path)
+}
+
+// runEditor opens path in $VISUAL, $EDITOR or vi and waits for it to exit.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// setConfigValue updates one dotted key in the config file. The value is parsed
+// as a TOML literal when possible (true, 3, ["go","md"]) and as a string otherwise.
+// Comments in the file are not preserved.
+func setConfigValue(path, key, rawValue string) error {
+	var current *configEntry
+	for _, e := range configEntries(defaultConfig) {
+		if e.Key == key {
+			current = &e
+			break
+		}
+	}
+	if current == nil {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+
+	var value any = rawValue
+	if _, isString := current.Value.(string); !isString {
+		var parsed map[string]any
+		if _, err := toml.Decode("v = "+rawValue, &parsed); err == nil {
+			value = parsed["v"]
+		}
+	}
+
+	doc := map[string]any{}
+	if _, err := toml.DecodeFile(path, &doc); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	table := doc
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		sub, ok := table[part].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			table[part] = sub
+		}
+		table = sub
+	}
+	table[parts[len(parts)-1]] = value
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return err
+	}
+	// Make sure the result still loads into Config before replacing the file.
+	check := cloneDefaultConfig()
+	if _, err := toml.Decode(buf.String(), &check); err != nil {
+		return fmt.Errorf("value %q is not valid for %s: %w", rawValue, key, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	slog.Info("Updating config file.", "path", path, "key", key)
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// migrateConfigFile rewrites the deprecated keys of the config file as their
+// replacements, keeping the previous file as <path>.bak. Path patterns of
+// exclude_patterns have no global replacement and still apply until the key
+// is removed, so the migration refuses to drop them unless force is set.
+// Comments in the file are not preserved.
+func migrateConfigFile(w io.Writer, path string, force bool) error {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc := map[string]any{}
+	if _, err := toml.Decode(string(original), &doc); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	var legacy legacyConfig
+	if _, err := toml.Decode(string(original), &legacy); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if _, ok := doc["exclude_patterns"]; !ok {
+		fmt.Fprintf(w, "%s uses no deprecated keys.\n", path)
+		return nil
+	}
+
+	basenames, paths := splitLegacyExcludes(legacy.ExcludePatterns)
+	if len(paths) > 0 && !force {
+		return fmt.Errorf("exclude_patterns in %s has path patterns with no global equivalent, which the migration would stop applying: %s; "+
+			"add them to .codecat_exclude in the projects that need them, then run 'codecat config migrate --force'", path, strings.Join(paths, ", "))
+	}
+	// Setting exclude_basenames replaces the defaults, so start from them
+	// when the file did not set it.
+	current := append([]string(nil), defaultConfig.ExcludeBasenames...)
+	if _, ok := doc["exclude_basenames"]; ok {
+		var set struct {
+			ExcludeBasenames []string `toml:"exclude_basenames"`
+		}
+		if _, err := toml.Decode(string(original), &set); err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		current = set.ExcludeBasenames
+	}
+	for _, p := range basenames {
+		if !contains(current, p) {
+			current = append(current, p)
+		}
+	}
+	delete(doc, "exclude_patterns")
+	doc["exclude_basenames"] = current
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".bak", original, 0644); err != nil {
+		return err
+	}
+	slog.Info("Migrating config file.", "path", path, "backup", path+".bak")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Moved exclude_patterns to exclude_basenames in %s (previous file: %s.bak).\n", path, path)
+	if len(paths) > 0 {
+		fmt.Fprintln(w, "Dropped these path patterns, which have no global equivalent; add them to .codecat_exclude in the projects that need them:")
+		for _, p := range paths {
+			fmt.Fprintf(w, "  %s\n", p)
+		}
+	}
+	return nil
+}
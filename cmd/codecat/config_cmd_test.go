@@ -0,0 +1,101 @@
+// cmd/codecat/config_cmd_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitConfigFile_LoadsAsDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "codecat", "config.toml")
+	require.NoError(t, initConfigFile(path, false))
+	assert.Error(t, initConfigFile(path, false), "existing file must not be overwritten")
+	require.NoError(t, initConfigFile(path, true))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, defaultConfig.IncludeExtensions, cfg.IncludeExtensions)
+	assert.Equal(t, *defaultConfig.HeaderText, *cfg.HeaderText)
+	assert.Equal(t, "config", cfg.source("use_gitignore"))
+	assert.Equal(t, "default", cfg.source("llm.model"))
+	assert.Nil(t, cfg.IgnoreCase, "unset keys stay unset")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "\n# ignore_case = false\n")
+	assert.Less(t, strings.Index(string(data), "plugins_dir ="), strings.Index(string(data), "\n["), "top-level keys come before the first table")
+}
+
+func TestSetConfigValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("comment_marker = \"###\"\n"), 0644))
+
+	require.NoError(t, setConfigValue(path, "use_gitignore", "false"))
+	require.NoError(t, setConfigValue(path, "include_extensions", `["go", "md"]`))
+	require.NoError(t, setConfigValue(path, "llm.model", "42"))
+	require.NoError(t, setConfigValue(path, "ignore_case", "true"))
+	assert.Error(t, setConfigValue(path, "no_such_key", "1"))
+	assert.Error(t, setConfigValue(path, "use_gitignore", "maybe"))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	assert.False(t, *cfg.UseGitignore)
+	assert.Equal(t, []string{"go", "md"}, cfg.IncludeExtensions)
+	assert.Equal(t, "42", cfg.LLM.Model)
+	require.NotNil(t, cfg.IgnoreCase)
+	assert.True(t, *cfg.IgnoreCase)
+	assert.Equal(t, "###", *cfg.CommentMarker, "untouched keys are kept")
+}
+
+func TestWriteEffectiveConfig(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, writeEffectiveConfig(&out, defaultConfig))
+	assert.Contains(t, out.String(), "built-in defaults only")
+	assert.Contains(t, out.String(), "use_gitignore = true  # default\n")
+	assert.Contains(t, out.String(), `llm.provider = ""  # default`)
+	assert.Contains(t, out.String(), "content = {}  # default\n")
+	assert.Contains(t, out.String(), "# ignore_case = false  # default, unset\n")
+
+	cfg := defaultConfig
+	cfg.Content = map[string]ContentPolicy{"csv": {Mode: "head", Lines: 50}}
+	out.Reset()
+	require.NoError(t, writeEffectiveConfig(&out, cfg))
+	assert.Contains(t, out.String(), `content = { csv = { mode = "head", lines = 50 } }  # default`)
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	old := "comment_marker = \"###\"\nexclude_patterns = [\"*.tmp\", \"build/gen/*\"]\n"
+	require.NoError(t, os.WriteFile(path, []byte(old), 0644))
+
+	var out strings.Builder
+	err := migrateConfigFile(&out, path, false)
+	assert.ErrorContains(t, err, "build/gen/*", "path patterns still apply; not dropped without --force")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, old, string(data), "the file is left alone")
+
+	require.NoError(t, migrateConfigFile(&out, path, true))
+	assert.Contains(t, out.String(), "Moved exclude_patterns to exclude_basenames")
+	assert.Contains(t, out.String(), "  build/gen/*\n", "path patterns are listed for .codecat_exclude")
+	backup, err := os.ReadFile(path + ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, old, string(backup))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "exclude_patterns")
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, append(append([]string(nil), defaultConfig.ExcludeBasenames...), "*.tmp"), cfg.ExcludeBasenames,
+		"the defaults are kept, as before the migration")
+	assert.Equal(t, "###", *cfg.CommentMarker)
+
+	out.Reset()
+	require.NoError(t, migrateConfigFile(&out, path, false))
+	assert.Contains(t, out.String(), "uses no deprecated keys")
+}
@@ -1,11 +1,14 @@
 // cmd/codecat/config_test.go
 package main
 
-// Add other necessary imports for testing config later, e.g.,
-// "os"
-// "path/filepath"
-// "github.com/stretchr/testify/assert"
-// "github.com/stretchr/testify/require"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
 
 // TODO: Add tests for loadConfig function
 // func TestLoadConfig_Defaults(t *testing.T) { ... }
@@ -13,3 +16,15 @@ package main
 // func TestLoadConfig_EmptyFile(t *testing.T) { ... }
 // func TestLoadConfig_InvalidToml(t *testing.T) { ... }
 // func TestLoadConfig_NotFound(t *testing.T) { ... }
+
+func TestLoadConfig_LegacyExcludePatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`exclude_patterns = ["*.tmp", "out/", "node_modules", "docs/internal/*"]`+"\n"), 0644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, append(append([]string(nil), defaultConfig.ExcludeBasenames...), "*.tmp", "out"), cfg.ExcludeBasenames,
+		"name globs join the default exclude_basenames, without duplicates")
+	assert.Equal(t, []string{"docs/internal/*"}, cfg.legacyExcludes, "path patterns apply like .codecat_exclude")
+	assert.Equal(t, "config", cfg.source("exclude_basenames"))
+}
@@ -0,0 +1,67 @@
+// cmd/codecat/contentpolicy.go
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ContentPolicy decides how much of each file with one extension is included,
+// configured as a [content.<ext>] table, e.g. mode = "head", lines = 50.
+type ContentPolicy struct {
+	Mode  string `toml:"mode"`  // full (default), head or truncate
+	Lines int    `toml:"lines"` // lines kept by head and truncate
+}
+
+var contentModes = []string{"full", "head", "truncate"}
+
+// resolveContentPolicies validates the configured policies and keys them by
+// lower-case extension with a leading dot, as filepath.Ext returns it.
+func resolveContentPolicies(policies map[string]ContentPolicy) (map[string]ContentPolicy, error) {
+	resolved := make(map[string]ContentPolicy, len(policies))
+	for ext, p := range policies {
+		if p.Mode == "" {
+			p.Mode = "full"
+		}
+		if !contains(contentModes, p.Mode) {
+			return nil, fmt.Errorf("[content.%s]: unknown mode %q (supported: %s)", ext, p.Mode, strings.Join(contentModes, ", "))
+		}
+		if p.Mode != "full" && p.Lines < 1 {
+			return nil, fmt.Errorf("[content.%s]: mode %q needs lines >= 1", ext, p.Mode)
+		}
+		resolved["."+strings.TrimPrefix(strings.ToLower(ext), ".")] = p
+	}
+	return resolved, nil
+}
+
+// applyContentPolicy samples content according to the policy for relPath's
+// extension; files without a policy pass through.
+func applyContentPolicy(policies map[string]ContentPolicy, relPath string, content []byte) []byte {
+	p, ok := policies[strings.ToLower(filepath.Ext(relPath))]
+	if !ok {
+		return content
+	}
+	switch p.Mode {
+	case "head":
+		return headLines(content, p.Lines)
+	case "truncate":
+		return truncateMiddle(content, p.Lines)
+	}
+	return content
+}
+
+// headLines keeps the first n lines of content and notes how many followed.
+func headLines(content []byte, n int) []byte {
+	text := string(content)
+	trailingNewline := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) <= n {
+		return content
+	}
+	out := strings.Join(lines[:n], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-n)
+	if trailingNewline {
+		out += "\n"
+	}
+	return []byte(out)
+}
@@ -0,0 +1,39 @@
+// cmd/codecat/contentpolicy_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveContentPolicies(t *testing.T) {
+	var cfg Config
+	_, err := toml.Decode("[content.CSV]\nmode = \"head\"\nlines = 2\n\n[content.log]\nmode = \"truncate\"\nlines = 10\n\n[content.json]\n", &cfg)
+	require.NoError(t, err)
+
+	policies, err := resolveContentPolicies(cfg.Content)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ContentPolicy{
+		".csv":  {Mode: "head", Lines: 2},
+		".log":  {Mode: "truncate", Lines: 10},
+		".json": {Mode: "full"},
+	}, policies)
+
+	_, err = resolveContentPolicies(map[string]ContentPolicy{"csv": {Mode: "sample", Lines: 5}})
+	assert.ErrorContains(t, err, `unknown mode "sample"`)
+	_, err = resolveContentPolicies(map[string]ContentPolicy{"csv": {Mode: "head"}})
+	assert.ErrorContains(t, err, "needs lines >= 1")
+}
+
+func TestApplyContentPolicy(t *testing.T) {
+	policies := map[string]ContentPolicy{".csv": {Mode: "head", Lines: 2}, ".log": {Mode: "truncate", Lines: 2}}
+	content := []byte("a\nb\nc\nd\n")
+
+	assert.Equal(t, "a\nb\n... (2 more lines)\n", string(applyContentPolicy(policies, "data/x.CSV", content)))
+	assert.Equal(t, "a\n... (2 lines omitted) ...\nd\n", string(applyContentPolicy(policies, "app.log", content)))
+	assert.Equal(t, string(content), string(applyContentPolicy(policies, "main.go", content)))
+	assert.Equal(t, "a\nb", string(headLines([]byte("a\nb"), 2)))
+}
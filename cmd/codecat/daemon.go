@@ -0,0 +1,224 @@
+// cmd/codecat/daemon.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	pflag "github.com/spf13/pflag"
+)
+
+// daemonSocketFile is the default socket of 'codecat daemon', relative to the CWD.
+const daemonSocketFile = stateDir + "/daemon.sock"
+
+var (
+	daemonSocket string
+	daemonPoll   time.Duration
+)
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "daemon",
+		Summary: "Keep file contents warm in memory and answer JSON-RPC requests on a Unix socket.",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&daemonSocket, "socket", daemonSocketFile,
+				"[daemon] Unix socket to listen on, relative to the current directory.")
+			fs.DurationVar(&daemonPoll, "poll", 5*time.Second,
+				"[daemon] How often to rescan for changed files between requests (0 disables).")
+		},
+		Run: runDaemon,
+	})
+}
+
+func runDaemon(cwd string, appConfig Config, args []string) int {
+	opts, err := resolveGenerateOptions(cwd, appConfig, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	opts.Cache = newFileCache()
+	opts.Progress = nil
+
+	socket := daemonSocket
+	if !filepath.IsAbs(socket) {
+		socket = filepath.Join(cwd, socket)
+	}
+	listener, err := listenUnix(socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer os.Remove(socket)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	start := time.Now()
+	refreshCache(opts)
+	slog.Warn("Daemon ready.", "socket", socket, "files", opts.Cache.len(), "warmup", time.Since(start).Round(time.Millisecond).String())
+	if daemonPoll > 0 {
+		go func() {
+			ticker := time.NewTicker(daemonPoll)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					refreshCache(opts)
+				}
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			slog.Error("Accepting a connection failed.", "error", err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			runJSONRPC(conn, conn, opts)
+		}()
+	}
+	wg.Wait()
+	slog.Info("Daemon stopped.", "socket", socket)
+	return 0
+}
+
+// listenUnix listens on socket, replacing a stale socket file left by a
+// daemon that did not shut down cleanly but refusing to replace a live one.
+func listenUnix(socket string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", socket)
+	}
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(socket), 0o755); err != nil {
+		return nil, err
+	}
+	return net.Listen("unix", socket)
+}
+
+// refreshCache walks with the daemon's filters, classifying files by stat
+// alone, and reads the included files that changed or are new into the
+// cache; entries of files the walk no longer reached are dropped. Content
+// transforms, [hooks] and rendering are left to the requests.
+func refreshCache(opts GenerateOptions) {
+	sweep := opts.Cache.beginSweep()
+	walk := partOptions(opts)
+	walk.SkipContent, walk.ManualFiles, walk.Hooks = true, nil, nil
+	walk.RelativeTo, walk.StripPrefixes, walk.PathPrefix = "", nil, "" // CWD-relative paths
+	result, err := generate(walk)
+	if err != nil {
+		slog.Warn("Cache refresh reported errors.", "error", err)
+	}
+	for _, f := range result.IncludedFiles {
+		absPath := filepath.Join(opts.CWD, filepath.FromSlash(f.Path))
+		if info, errStat := os.Stat(absPath); errStat == nil {
+			if _, errRead := opts.Cache.read(absPath, info); errRead != nil {
+				slog.Debug("Could not cache file.", "path", f.Path, "error", errRead)
+			}
+		}
+	}
+	if dropped := opts.Cache.endSweep(sweep); dropped > 0 {
+		slog.Debug("Dropped cached files no longer scanned.", "files", dropped)
+	}
+}
+
+// fileCache keeps file contents in memory, keyed by absolute path and
+// trusted while the size and modification time are unchanged.
+type fileCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedFile
+	sweep   uint64
+}
+
+type cachedFile struct {
+	size    int64
+	modTime time.Time
+	content []byte
+	sweep   uint64 // last sweep that read the file
+}
+
+func newFileCache() *fileCache {
+	return &fileCache{entries: make(map[string]cachedFile)}
+}
+
+// read returns the content of absPath, from memory if info shows the file
+// unchanged since it was cached. A nil cache reads from disk.
+func (c *fileCache) read(absPath string, info fs.FileInfo) ([]byte, error) {
+	if c == nil {
+		return os.ReadFile(absPath)
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[absPath]
+	if ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		entry.sweep = c.sweep
+		c.entries[absPath] = entry
+		c.mu.Unlock()
+		return entry.content, nil
+	}
+	c.mu.Unlock()
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[absPath] = cachedFile{size: info.Size(), modTime: info.ModTime(), content: content, sweep: c.sweep}
+	c.mu.Unlock()
+	return content, nil
+}
+
+// beginSweep starts a refresh; files read from now on are kept by endSweep.
+func (c *fileCache) beginSweep() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweep++
+	return c.sweep
+}
+
+// endSweep removes the entries not read since beginSweep returned sweep and
+// returns how many it removed.
+func (c *fileCache) endSweep(sweep uint64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dropped := 0
+	for absPath, entry := range c.entries {
+		if entry.sweep < sweep {
+			delete(c.entries, absPath)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+func (c *fileCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
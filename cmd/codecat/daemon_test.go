@@ -0,0 +1,138 @@
+// cmd/codecat/daemon_test.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	require.NoError(t, os.WriteFile(path, []byte("package a\n"), 0o644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	cache := newFileCache()
+	content, err := cache.read(path, info)
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n", string(content))
+
+	// Unchanged size and modification time: served from memory.
+	require.NoError(t, os.WriteFile(path, []byte("package b\n"), 0o644))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	content, err = cache.read(path, info)
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n", string(content))
+
+	// A new modification time invalidates the entry.
+	later := info.ModTime().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	content, err = cache.read(path, info)
+	require.NoError(t, err)
+	assert.Equal(t, "package b\n", string(content))
+
+	var none *fileCache
+	content, err = none.read(path, info)
+	require.NoError(t, err)
+	assert.Equal(t, "package b\n", string(content))
+}
+
+func TestFileCache_Sweep(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"a.go": "package a\n", "b.go": "package b\n"})
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Cache:      newFileCache(),
+	}
+	refreshCache(opts)
+	assert.Equal(t, 2, opts.Cache.len())
+
+	require.NoError(t, os.Remove(filepath.Join(tempDir, "b.go")))
+	refreshCache(opts)
+	assert.Equal(t, 1, opts.Cache.len(), "deleted files leave the cache")
+
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "package a\n")
+}
+
+func TestRefreshCache_SkipsTransforms(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell command")
+	}
+	tempDir := setupTestDir(t, map[string]string{"a.go": "package a\n"})
+	marker := filepath.Join(t.TempDir(), "ran")
+	hooks, err := newHookRunner(tempDir, HooksConfig{Transform: []TransformHook{{Glob: "*.go", Command: "touch " + marker + "; cat"}}})
+	require.NoError(t, err)
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Cache:      newFileCache(),
+		Hooks:      hooks,
+		RelativeTo: "abs",
+	}
+	refreshCache(opts)
+	assert.Equal(t, 1, opts.Cache.len(), "read into the cache")
+	assert.NoFileExists(t, marker, "transform hooks wait for a request")
+}
+
+func TestListenUnix(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cc") // short: socket paths are length-limited
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "state", "d.sock")
+
+	listener, err := listenUnix(socket)
+	require.NoError(t, err)
+	_, err = listenUnix(socket)
+	assert.ErrorContains(t, err, "already listening")
+
+	tempDir := setupTestDir(t, map[string]string{"main.go": "package main\n"})
+	opts := GenerateOptions{CWD: tempDir, ScanDirs: []string{tempDir}, Extensions: processExtensions([]string{"go"}), Marker: "---", Cache: newFileCache()}
+	go func() {
+		for { // the first connection is listenUnix's liveness probe
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			runJSONRPC(conn, conn, opts)
+			conn.Close()
+		}
+	}()
+
+	conn, err := net.Dial("unix", socket)
+	require.NoError(t, err)
+	fmt.Fprintln(conn, `{"jsonrpc":"2.0","id":7,"method":"generate"}`)
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	require.NoError(t, err)
+	conn.Close()
+	var resp struct {
+		ID     int             `json:"id"`
+		Result contextResponse `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(line, &resp))
+	assert.Equal(t, 7, resp.ID)
+	assert.Contains(t, resp.Result.Output, "--- main.go\npackage main\n")
+
+	// A socket file without a listener is stale and replaced.
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	listener, err = listenUnix(socket)
+	require.NoError(t, err)
+	listener.Close()
+}
@@ -0,0 +1,225 @@
+// cmd/codecat/diff.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	pflag "github.com/spf13/pflag"
+)
+
+var diffUnified bool
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "diff",
+		Summary: "Compare two generated outputs: files added, removed and changed.",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&diffUnified, "unified", false,
+				"[diff] Print a unified diff of each changed file.")
+		},
+		Run: runDiff,
+	})
+}
+
+func runDiff(cwd string, appConfig Config, args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Error: usage: codecat diff [--unified] <old> <new>")
+		return 1
+	}
+	var contexts [2]map[string]string
+	for i, arg := range args {
+		path := arg
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		files, err := readContextFile(path, *appConfig.CommentMarker, *appConfig.HeaderText)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", arg, err)
+			return 1
+		}
+		contexts[i] = files
+	}
+	if err := printContextDiff(os.Stdout, args[0], args[1], contexts[0], contexts[1], diffUnified); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readContextFile loads a codecat output (text or JSON, optionally gzip or
+// zstd compressed) as a map from path to content.
+func readContextFile(path, marker, header string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := decompressing(f)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing: %w", err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading: %w", err)
+	}
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseJSONContext(trimmed)
+	}
+	return parseTextContext(string(data), marker, header)
+}
+
+// parseJSONContext reads the files of a --format json output.
+func parseJSONContext(data []byte) (map[string]string, error) {
+	var doc jsonOutput
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing JSON output: %w", err)
+	}
+	files := make(map[string]string, len(doc.Files))
+	for _, f := range doc.Files {
+		files[f.Path] = f.Content
+	}
+	return files, nil
+}
+
+// parseTextContext reads the files of a marker-delimited text output made
+// with the default file templates. The header, stamp and section lines are
+// skipped and a "# marker:" line switches to the longer marker it announces.
+// Content never has a line starting with the marker, so a file ends at the
+// next such line; without a trailing newline the closing marker ends the
+// last content line instead of standing alone. A quoted path (--quote-paths,
+// or one with control characters) is unquoted and the header fields after
+// it are dropped.
+func parseTextContext(text, marker, header string) (map[string]string, error) {
+	if marker == "" {
+		return nil, errors.New("cannot parse text output without a comment marker")
+	}
+	text = strings.TrimPrefix(text, header)
+	lines := strings.SplitAfter(text, "\n")
+	files := make(map[string]string)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\n")
+		if rest, ok := strings.CutPrefix(line, "# marker: "); ok && len(files) == 0 {
+			quoted, _, _ := strings.Cut(rest, " (")
+			if m, err := strconv.Unquote(quoted); err == nil {
+				marker = m
+			}
+			continue
+		}
+		path, isHeader := strings.CutPrefix(line, marker+" ")
+		if !isHeader || strings.HasPrefix(path, "=== ") {
+			continue // stamp, section heading or stray text
+		}
+		if quoted, err := strconv.QuotedPrefix(path); err == nil {
+			path, _ = strconv.Unquote(quoted)
+		}
+		for i+1 < len(lines) && strings.HasPrefix(lines[i+1], marker+" ") {
+			i++ // metadata lines
+		}
+		var content strings.Builder
+		for i+1 < len(lines) && !strings.HasPrefix(lines[i+1], marker) {
+			i++
+			content.WriteString(lines[i])
+		}
+		body := content.String()
+		if i+1 < len(lines) && strings.TrimSuffix(lines[i+1], "\n") == marker {
+			i++ // closing marker on its own line
+		} else {
+			body = strings.TrimSuffix(strings.TrimSuffix(body, "\n"), marker)
+		}
+		files[path] = body
+	}
+	if len(files) == 0 && strings.TrimSpace(text) != "" {
+		return nil, fmt.Errorf("no files found; is this a text or JSON output made with marker %q?", marker)
+	}
+	return files, nil
+}
+
+// printContextDiff reports the files added, removed and changed between two
+// outputs, with line counts, and optionally a unified diff per changed file.
+func printContextDiff(w io.Writer, oldName, newName string, oldFiles, newFiles map[string]string, unified bool) error {
+	var added, removed, changed []string
+	for path := range newFiles {
+		if _, ok := oldFiles[path]; !ok {
+			added = append(added, path)
+		}
+	}
+	for path, content := range oldFiles {
+		if newContent, ok := newFiles[path]; !ok {
+			removed = append(removed, path)
+		} else if newContent != content {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- Diff: %s -> %s ---\n", oldName, newName)
+	fmt.Fprintf(bw, "Added (%d):\n", len(added))
+	for _, path := range added {
+		fmt.Fprintf(bw, "  + %s (%d lines)\n", path, len(contentLines(newFiles[path])))
+	}
+	fmt.Fprintf(bw, "Removed (%d):\n", len(removed))
+	for _, path := range removed {
+		fmt.Fprintf(bw, "  - %s (%d lines)\n", path, len(contentLines(oldFiles[path])))
+	}
+	fmt.Fprintf(bw, "Changed (%d):\n", len(changed))
+	for _, path := range changed {
+		ins, del := countLineChanges(oldFiles[path], newFiles[path])
+		fmt.Fprintf(bw, "  ~ %s (+%d -%d)\n", path, ins, del)
+	}
+	fmt.Fprintf(bw, "Unchanged: %d\n", len(oldFiles)-len(removed)-len(changed))
+	if unified {
+		for _, path := range changed {
+			text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A: contentLines(oldFiles[path]), B: contentLines(newFiles[path]),
+				FromFile: "a/" + path, ToFile: "b/" + path, Context: 3,
+			})
+			if err != nil {
+				return fmt.Errorf("diffing %s: %w", path, err)
+			}
+			fmt.Fprintf(bw, "\n%s", text)
+		}
+	}
+	return bw.Flush()
+}
+
+// contentLines splits content into lines that each end in a newline, which
+// the unified diff output relies on.
+func contentLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(content, "\n"), "\n")
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// countLineChanges returns the number of inserted and deleted lines.
+func countLineChanges(oldContent, newContent string) (ins, del int) {
+	matcher := difflib.NewMatcher(contentLines(oldContent), contentLines(newContent))
+	for _, op := range matcher.GetOpCodes() {
+		switch op.Tag {
+		case 'r':
+			del += op.I2 - op.I1
+			ins += op.J2 - op.J1
+		case 'd':
+			del += op.I2 - op.I1
+		case 'i':
+			ins += op.J2 - op.J1
+		}
+	}
+	return ins, del
+}
@@ -0,0 +1,92 @@
+// cmd/codecat/diff_test.go
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTextContext_RoundTrip(t *testing.T) {
+	docs := []Document{
+		{Path: "a.go", Content: "package a\n"},
+		{Path: "b.txt", Content: "no trailing newline---"},
+		{Path: "c.md", Content: "title\n---\nbody\n", Meta: []string{"size: 16"}},
+		{Path: "d.go", Content: "package d\n", Root: "server"},
+	}
+	header := "----- Codebase for analysis -----\n"
+	opts := GenerateOptions{Marker: "---", Header: header}
+	var out strings.Builder
+	require.NoError(t, formatText(&out, GenerateResult{Documents: docs, Stamp: &Stamp{Version: "test"}}, opts))
+
+	files, err := parseTextContext(out.String(), "---", header)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"a.go":  "package a\n",
+		"b.txt": "no trailing newline---",
+		"c.md":  "title\n---\nbody\n",
+		"d.go":  "package d\n",
+	}, files)
+
+	_, err = parseTextContext("just some notes\n", "---", header)
+	assert.ErrorContains(t, err, "no files found")
+}
+
+func TestParseTextContext_QuotedPaths(t *testing.T) {
+	docs := []Document{
+		{Path: "line\nbreak.txt", Content: "a\n"},
+		{Path: "\u202eevil.txt", Content: "b\n"},
+		{Path: "🚀 launch.go", Content: "c\n"},
+	}
+	opts := GenerateOptions{Marker: "---", QuotePaths: true}
+	var out strings.Builder
+	require.NoError(t, formatText(&out, GenerateResult{Documents: docs}, opts))
+	assert.Contains(t, out.String(), `--- "line\nbreak.txt"`+"\n")
+	require.Contains(t, out.String(), "launch.go\"\n")
+	text := strings.Replace(out.String(), "launch.go\"\n", "launch.go\" size=2\n", 1)
+
+	files, err := parseTextContext(text, "---", "") // header fields after a quoted path are dropped
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"line\nbreak.txt": "a\n",
+		"\u202eevil.txt":  "b\n",
+		"🚀 launch.go":     "c\n",
+	}, files)
+}
+
+func TestParseJSONContext(t *testing.T) {
+	files, err := parseJSONContext([]byte(`{"files":[{"path":"a.go","content":"package a\n"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a.go": "package a\n"}, files)
+}
+
+func TestPrintContextDiff(t *testing.T) {
+	oldFiles := map[string]string{"a.go": "one\ntwo\n", "gone.go": "x\n", "same.go": "s\n"}
+	newFiles := map[string]string{"a.go": "one\n2\nthree\n", "new.go": "y\nz\n", "same.go": "s\n"}
+	var buf bytes.Buffer
+	require.NoError(t, printContextDiff(&buf, "old.txt", "new.txt", oldFiles, newFiles, true))
+	out := buf.String()
+	assert.Contains(t, out, "Added (1):\n  + new.go (2 lines)\n")
+	assert.Contains(t, out, "Removed (1):\n  - gone.go (1 lines)\n")
+	assert.Contains(t, out, "Changed (1):\n  ~ a.go (+2 -1)\n")
+	assert.Contains(t, out, "Unchanged: 1\n")
+	assert.Contains(t, out, "--- a/a.go\n+++ b/a.go\n")
+	assert.Contains(t, out, "-two\n+2\n+three\n")
+}
+
+func TestReadContextFile_Compressed(t *testing.T) {
+	result := GenerateResult{Documents: []Document{{Path: "a.go", Content: "package a\n"}}}
+	for _, name := range []string{"ctx.txt.zst", "ctx.json.gz"} {
+		path := filepath.Join(t.TempDir(), name)
+		target, err := parseOutputTarget(path, "")
+		require.NoError(t, err)
+		require.NoError(t, writeOutput(target, result, GenerateOptions{Marker: "---"}))
+		files, err := readContextFile(path, "---", "")
+		require.NoError(t, err, name)
+		assert.Equal(t, map[string]string{"a.go": "package a\n"}, files, name)
+	}
+}
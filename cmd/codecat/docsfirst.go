@@ -0,0 +1,97 @@
+// cmd/codecat/docsfirst.go
+package main
+
+import (
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// docFilePatterns are basename globs (matched case-insensitively) for
+// high-level documentation and architecture decision records.
+var docFilePatterns = []string{"readme*", "architecture*", "adr-*"}
+
+// docDirNames are directories whose whole contents count as documentation.
+var docDirNames = []string{"docs", "doc", "adr", "adrs", "decisions"}
+
+// isDocPath reports whether the slash-separated relPath is a README,
+// architecture document or ADR, or lies below a documentation directory
+// (which covers numbered ADRs such as docs/adr/0001-use-postgres.md).
+func isDocPath(relPath string) bool {
+	parts := strings.Split(strings.ToLower(relPath), "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if contains(docDirNames, dir) {
+			return true
+		}
+	}
+	for _, pattern := range docFilePatterns {
+		if match, _ := path.Match(pattern, parts[len(parts)-1]); match {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutDocs drops documentation from files, so --docs-first keeps it off
+// the list of suggested cuts.
+func withoutDocs(files []FileInfo) []FileInfo {
+	var kept []FileInfo
+	for _, f := range files {
+		if !isDocPath(f.Path) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// dirReadmePattern matches README.md in any letter case; --dir-readmes
+// includes such files whatever the extension filters say.
+const dirReadmePattern = "[Rr][Ee][Aa][Dd][Mm][Ee].[Mm][Dd]"
+
+// placeDirReadmes moves each directory's README.md immediately before the
+// first other file at or below that directory, within its root or
+// nested-repository section, so the local conventions come before the code
+// they describe whatever the global order. A parent's README goes before
+// its subdirectories' READMEs.
+func placeDirReadmes(docs []Document) {
+	for start := 0; start < len(docs); {
+		end := start + 1
+		for end < len(docs) && docs[end].Root == docs[start].Root && docs[end].NestedRepo == docs[start].NestedRepo {
+			end++
+		}
+		placeSectionReadmes(docs[start:end])
+		start = end
+	}
+}
+
+func placeSectionReadmes(section []Document) {
+	var readmes []string
+	for _, doc := range section {
+		if match, _ := path.Match(dirReadmePattern, path.Base(doc.Path)); match {
+			readmes = append(readmes, doc.Path)
+		}
+	}
+	// Deepest first, so a parent's README lands before its children's.
+	sort.SliceStable(readmes, func(i, j int) bool {
+		return strings.Count(readmes[i], "/") > strings.Count(readmes[j], "/")
+	})
+	for _, readme := range readmes {
+		from := slices.IndexFunc(section, func(doc Document) bool { return doc.Path == readme })
+		dir := path.Dir(readme)
+		to := slices.IndexFunc(section, func(doc Document) bool {
+			return doc.Path != readme && (dir == "." || strings.HasPrefix(doc.Path, dir+"/"))
+		})
+		if to < 0 || to == from+1 {
+			continue // nothing else in the directory, or already in place
+		}
+		doc := section[from]
+		if to > from {
+			copy(section[from:], section[from+1:to])
+			section[to-1] = doc
+		} else {
+			copy(section[to+1:], section[to:from])
+			section[to] = doc
+		}
+	}
+}
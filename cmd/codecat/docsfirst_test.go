@@ -0,0 +1,86 @@
+// cmd/codecat/docsfirst_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDocPath(t *testing.T) {
+	for _, p := range []string{"README.md", "readme.rst", "services/api/README", "docs/setup.md", "Doc/x.txt", "design/adr/0001-use-postgres.md", "ADR-7-caching.md", "ARCHITECTURE.md"} {
+		assert.True(t, isDocPath(p), p)
+	}
+	for _, p := range []string{"main.go", "src/readers.go", "documentation.go", "docsify.config.js", "0001-init.sql"} {
+		assert.False(t, isDocPath(p), p)
+	}
+}
+
+func TestOrderDocuments_DocsFirst(t *testing.T) {
+	docs := []Document{
+		{Path: "a.go"},
+		{Path: "docs/intro.md"},
+		{Path: "README.md"},
+		{Path: "z.go"},
+		{Path: "b/main.go", Root: "server"},
+		{Path: "b/README.md", Root: "server"},
+	}
+	orderDocuments(docs, GenerateOptions{DocsFirst: true})
+	var paths []string
+	for _, d := range docs {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"docs/intro.md", "README.md", "a.go", "z.go", "b/README.md", "b/main.go"}, paths,
+		"docs move up within their section, keeping their order")
+
+	files := withoutDocs([]FileInfo{{Path: "README.md"}, {Path: "main.go"}})
+	assert.Equal(t, []FileInfo{{Path: "main.go"}}, files)
+}
+
+func TestPlaceDirReadmes(t *testing.T) {
+	docs := []Document{
+		{Path: "main.go"},
+		{Path: "api/handler.go"},
+		{Path: "api/v2/routes.go"},
+		{Path: "api/v2/readme.md"},
+		{Path: "api/README.md"},
+		{Path: "README.md"},
+		{Path: "lone/README.md"},
+		{Path: "svc/main.go", Root: "server"},
+		{Path: "svc/README.md", Root: "server"},
+	}
+	placeDirReadmes(docs)
+	var paths []string
+	for _, d := range docs {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{
+		"README.md", "main.go", "api/README.md", "api/handler.go", "api/v2/readme.md", "api/v2/routes.go",
+		"lone/README.md", "svc/README.md", "svc/main.go",
+	}, paths, "each README right before its directory's first file, within its section")
+}
+
+func TestGenerate_DirReadmes(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":        "package main\n",
+		"api/api.go":     "package api\n",
+		"api/README.md":  "Handlers return errors, never panic.\n",
+		"docs/guide.txt": "guide\n",
+	})
+	opts := GenerateOptions{
+		CWD:              tempDir,
+		ScanDirs:         []string{tempDir},
+		Extensions:       processExtensions([]string{"go"}),
+		IncludeBasenames: []string{dirReadmePattern},
+		Marker:           "---",
+		DirReadmes:       true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	var paths []string
+	for _, d := range result.Documents {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"main.go", "api/README.md", "api/api.go"}, paths)
+}
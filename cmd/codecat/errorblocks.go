@@ -0,0 +1,52 @@
+// cmd/codecat/errorblocks.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// withErrorPlaceholders adds a document for each file in errorFiles that
+// exists but could not be read, saying why, so --include-errors-in-output
+// tells the model about the file instead of leaving it out silently. The
+// placeholders go where the walk met the files, within the section of their
+// root or repository (roots, for merged results); directories and missing
+// files given with -f get none.
+func withErrorPlaceholders(docs []Document, errorFiles map[string]error, roots map[string]string) []Document {
+	var paths []string
+	for p, err := range errorFiles {
+		if !strings.HasSuffix(p, "/") && !errors.Is(err, fs.ErrNotExist) {
+			paths = append(paths, p)
+		}
+	}
+	slices.SortFunc(paths, compareWalkOrder)
+	for _, p := range paths {
+		doc := Document{Path: p, Root: roots[p], Content: fmt.Sprintf("[codecat: this file could not be read: %s]\n", describeReadError(errorFiles[p]))}
+		at := slices.IndexFunc(docs, func(d Document) bool { return d.Root == doc.Root && compareWalkOrder(d.Path, p) > 0 })
+		if at < 0 {
+			// After the last document of the section, or at the end.
+			at = len(docs)
+			for i := len(docs) - 1; i >= 0; i-- {
+				if docs[i].Root == doc.Root {
+					at = i + 1
+					break
+				}
+			}
+		}
+		docs = slices.Insert(docs, at, doc)
+	}
+	return docs
+}
+
+// describeReadError drops the absolute path *fs.PathError puts in its
+// message; the document header already names the file.
+func describeReadError(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) && err == error(pathErr) {
+		return pathErr.Op + ": " + pathErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,40 @@
+// cmd/codecat/errorblocks_test.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithErrorPlaceholders(t *testing.T) {
+	denied := &fs.PathError{Op: "open", Path: "/home/me/project/src/secret.go", Err: syscall.EACCES}
+	errorFiles := map[string]error{
+		"src/secret.go": denied,
+		"src/broken.go": fmt.Errorf("minify: %w", errors.New("bad input")),
+		"missing.go":    fs.ErrNotExist,
+		"vendor/":       errors.New("permission denied"),
+	}
+	docs := withErrorPlaceholders([]Document{{Path: "main.go"}, {Path: "src/app.go"}, {Path: "src/util/x.go"}}, errorFiles, nil)
+	var paths []string
+	for _, d := range docs {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"main.go", "src/app.go", "src/broken.go", "src/secret.go", "src/util/x.go"}, paths,
+		"placeholders in walk order; none for missing files or directories")
+	assert.Equal(t, "[codecat: this file could not be read: open: permission denied]\n", docs[3].Content,
+		"the absolute path stays out of the output")
+	assert.Equal(t, "[codecat: this file could not be read: minify: bad input]\n", docs[2].Content)
+
+	docs = withErrorPlaceholders([]Document{{Path: "a/x.go", Root: "a"}, {Path: "b/y.go", Root: "b"}},
+		map[string]error{"a/z.go": denied, "b/a.go": denied}, map[string]string{"a/z.go": "a", "b/a.go": "b"})
+	paths = nil
+	for _, d := range docs {
+		paths = append(paths, d.Root+":"+d.Path)
+	}
+	assert.Equal(t, []string{"a:a/x.go", "a:a/z.go", "b:b/a.go", "b:b/y.go"}, paths, "each placeholder stays in its root's section")
+}
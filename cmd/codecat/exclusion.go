@@ -4,7 +4,10 @@ package main
 import (
 	"fmt"
 	"log/slog"
+	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -23,11 +26,27 @@ type Excluder interface {
 }
 
 // DefaultExcluder implements the Excluder interface using basename and CWD-relative rules.
+// Patterns are compiled once, and the checks against a file's ancestors are
+// decided once per directory and cached, so a wide directory costs one
+// ancestor walk rather than one per file.
 type DefaultExcluder struct {
 	basenamePatterns       []string
 	cwdRelativePatterns    []string
+	basenames              basenameMatcher
+	cwdRelative            []cwdPattern
 	excludedDirRelPathsCwd map[string]string // CWD-relative path -> causing pattern
+	basenameHits           map[string]int    // basename pattern -> files excluded
+	cwdRelativeHits        map[string]int    // CWD-relative pattern -> files excluded
 	mu                     sync.RWMutex
+	dirs                   map[string]*dirDecision // CWD-relative parent directory -> its ancestor checks
+	dirsMu                 sync.RWMutex
+}
+
+// ExclusionRule reports how many scanned files one exclude pattern removed.
+type ExclusionRule struct {
+	Pattern string `json:"pattern"`
+	Source  string `json:"source"` // basename, project or flag
+	Hits    int    `json:"hits"`
 }
 
 // NewDefaultExcluder creates and initializes a DefaultExcluder.
@@ -35,91 +54,334 @@ func NewDefaultExcluder(basenamePatterns, cwdRelativePatterns []string) *Default
 	return &DefaultExcluder{
 		basenamePatterns:       basenamePatterns,
 		cwdRelativePatterns:    cwdRelativePatterns,
+		basenames:              compileBasenames(basenamePatterns),
+		cwdRelative:            compileCwdPatterns(cwdRelativePatterns),
 		excludedDirRelPathsCwd: make(map[string]string),
+		basenameHits:           make(map[string]int),
+		cwdRelativeHits:        make(map[string]int),
+		dirs:                   make(map[string]*dirDecision),
 	}
 }
 
 // IsExcluded implements the Excluder interface with ancestor checking.
+// Files (not directories) are counted against the deciding pattern, which for
+// a "!pattern" re-inclusion is returned with excluded == false.
 func (e *DefaultExcluder) IsExcluded(info PathInfo) (excluded bool, reason string, pattern string) {
+	excluded, reason, pattern = e.isExcluded(info)
+	if pattern != "" && !info.IsDir {
+		e.mu.Lock()
+		if strings.Contains(reason, "basename") {
+			e.basenameHits[pattern]++
+		} else {
+			e.cwdRelativeHits[pattern]++
+		}
+		e.mu.Unlock()
+	}
+	return excluded, reason, pattern
+}
+
+// Rules lists every configured pattern with its hit count, most effective
+// first. Patterns with zero hits are kept: they are the dead rules.
+func (e *DefaultExcluder) Rules(flagPatterns []string) []ExclusionRule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var rules []ExclusionRule
+	seen := make(map[string]bool)
+	add := func(pattern, source string, hits int) {
+		if key := source + "\x00" + pattern; !seen[key] {
+			seen[key] = true
+			rules = append(rules, ExclusionRule{Pattern: pattern, Source: source, Hits: hits})
+		}
+	}
+	for _, p := range e.basenamePatterns {
+		add(p, "basename", e.basenameHits[p])
+	}
+	for _, p := range e.cwdRelativePatterns {
+		add(p, tern(contains(flagPatterns, p), "flag", "project"), e.cwdRelativeHits[p])
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Hits > rules[j].Hits })
+	return rules
+}
+
+func (e *DefaultExcluder) isExcluded(info PathInfo) (excluded bool, reason string, pattern string) {
 	// --- ANCESTOR CHECKS ---
+	relPath := filepath.ToSlash(info.RelPathCwd)
+	parent := e.dirDecision(path.Dir(relPath))
 
 	// Check 1: Robustly check if any parent directory's BASENAME is in the global exclude list.
 	// This fixes the bug where a subdirectory like 'exclude-me' wasn't being excluded by a basename rule.
-	pathParts := strings.Split(filepath.ToSlash(info.RelPathCwd), "/")
-	if len(pathParts) > 1 {
-		// Check all parts except the last one (the item's own basename)
-		for _, part := range pathParts[:len(pathParts)-1] {
-			if match, p := matchesGlob(part, e.basenamePatterns); match {
-				slog.Debug("Exclusion check: path excluded due to ancestor basename match",
-					"path", info.RelPathCwd, "ancestor", part, "pattern", p)
-				return true, fmt.Sprintf("ancestor %s basename match", part), p
-			}
-		}
+	if parent.basenameAncestor != "" {
+		slog.Debug("Exclusion check: path excluded due to ancestor basename match",
+			"path", info.RelPathCwd, "ancestor", parent.basenameAncestor, "pattern", parent.basenamePattern)
+		return true, fmt.Sprintf("ancestor %s basename match", parent.basenameAncestor), parent.basenamePattern
 	}
 
-	// Check 2: Check for CWD-relative patterns matching any ancestor path.
-	// This preserves the working logic for '.codecat_exclude' files (e.g., excluding 'sample-docs').
-	currentParent := info.RelPathCwd
-	for {
-		currentParent = filepath.Dir(currentParent)
-		if currentParent == "." || currentParent == "" || currentParent == "/" {
+	// Check 2: Basename excludes for the item itself.
+	if match, p := e.basenames.match(info.BaseName); match {
+		slog.Debug("Exclusion check: item excluded by basename",
+			"path", info.RelPathCwd, "basename", info.BaseName, "pattern", p)
+		e.markExcludedDir(info, p)
+		return true, "basename match", p
+	}
+
+	// Check 3: CWD-relative patterns, matched against the item and each of its
+	// ancestors, in order. As in .gitignore the last matching pattern decides,
+	// and a "!pattern" re-includes what an earlier pattern excluded. Patterns
+	// after the last one matching an ancestor can only decide by matching the
+	// item itself.
+	deciding, decidingReason := parent.cwdAncestor, parent.cwdReason
+	for i := len(e.cwdRelative) - 1; i >= 0 && i >= parent.cwdAncestor; i-- {
+		if e.cwdRelative[i].matchesItem(relPath, info.IsDir) {
+			deciding, decidingReason = i, "CWD-relative match"
 			break
 		}
-		// CWD-relative glob match
-		if match, p := matchesGlob(currentParent, e.cwdRelativePatterns); match {
-			slog.Debug("Exclusion check: path excluded due to ancestor CWD exact/glob match", "path", info.RelPathCwd, "ancestorDir", currentParent, "pattern", p)
-			return true, fmt.Sprintf("ancestor %s CWD match", currentParent), p
+	}
+	if deciding < 0 {
+		// Not excluded by any rule
+		slog.Debug("Exclusion check: path not excluded", "path", info.RelPathCwd)
+		return false, "", ""
+	}
+	decidingPattern := e.cwdRelative[deciding].raw
+	if e.cwdRelative[deciding].negated {
+		slog.Debug("Exclusion check: path re-included by negated pattern",
+			"path", info.RelPathCwd, "pattern", decidingPattern)
+		return false, "re-included by negation", decidingPattern
+	}
+	slog.Debug("Exclusion check: path excluded by CWD-relative pattern",
+		"path", info.RelPathCwd, "reason", decidingReason, "pattern", decidingPattern)
+	e.markExcludedDir(info, decidingPattern)
+	return true, decidingReason, decidingPattern
+}
+
+// dirDecision is what the exclusion rules say about the directories above
+// a file, shared by every file in the same directory.
+type dirDecision struct {
+	basenameAncestor string // shallowest ancestor whose basename is excluded, or ""
+	basenamePattern  string // the basename pattern excluding it
+	cwdAncestor      int    // last CWD-relative pattern matching an ancestor, or -1
+	cwdReason        string // which ancestor cwdAncestor matched, as an IsExcluded reason
+}
+
+var noAncestors = &dirDecision{cwdAncestor: -1}
+
+// dirDecision returns the cached ancestor checks for files directly in dir
+// (CWD-relative, with slashes), computing it from dir's own parent first.
+func (e *DefaultExcluder) dirDecision(dir string) *dirDecision {
+	if dir == "." || dir == "/" || dir == "" {
+		return noAncestors
+	}
+	e.dirsMu.RLock()
+	d, ok := e.dirs[dir]
+	e.dirsMu.RUnlock()
+	if ok {
+		return d
+	}
+	parent := e.dirDecision(path.Dir(dir))
+	d = &dirDecision{
+		basenameAncestor: parent.basenameAncestor,
+		basenamePattern:  parent.basenamePattern,
+		cwdAncestor:      parent.cwdAncestor,
+		cwdReason:        parent.cwdReason,
+	}
+	if d.basenameAncestor == "" {
+		if match, p := e.basenames.match(path.Base(dir)); match {
+			d.basenameAncestor, d.basenamePattern = path.Base(dir), p
 		}
-		// CWD-relative prefix match (e.g., 'docs/' matches 'docs/file.txt')
-		for _, patt := range e.cwdRelativePatterns {
-			cleanPattern := strings.TrimRight(patt, `\/`)
-			if cleanPattern != "" && strings.HasPrefix(currentParent, cleanPattern+"/") {
-				slog.Debug("Exclusion check: path excluded due to ancestor CWD prefix match", "path", info.RelPathCwd, "ancestorDir", currentParent, "pattern", patt)
-				return true, fmt.Sprintf("ancestor %s CWD prefix match", currentParent), patt
-			}
+	}
+	// The deepest ancestor names the reason, so dir wins ties with its parents.
+	for i := len(e.cwdRelative) - 1; i >= 0 && i >= parent.cwdAncestor; i-- {
+		if match, why := e.cwdRelative[i].matchesAncestor(dir); match {
+			d.cwdAncestor, d.cwdReason = i, why
+			break
 		}
 	}
+	e.dirsMu.Lock()
+	e.dirs[dir] = d
+	e.dirsMu.Unlock()
+	return d
+}
 
-	// --- CURRENT ITEM CHECKS (if not excluded by an ancestor) ---
+// foldPathCase makes exclude, include and basename patterns match paths
+// whatever their case. It defaults to on where file systems usually ignore
+// case (macOS, Windows), so that "build/" excludes the Build directory the OS
+// would open for it; --ignore-case and ignore_case override it. .gitignore
+// files keep git's case-sensitive matching.
+var foldPathCase = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
 
-	// Check Basename Excludes for the item itself
-	if match, p := matchesGlob(info.BaseName, e.basenamePatterns); match {
-		slog.Debug("Exclusion check: item excluded by basename",
-			"path", info.RelPathCwd, "basename", info.BaseName, "pattern", p)
-		if info.IsDir {
-			e.mu.Lock()
-			if _, exists := e.excludedDirRelPathsCwd[info.RelPathCwd]; !exists {
-				slog.Debug("Adding dir to excluded map (item basename match).", "relPathCwd", info.RelPathCwd, "pattern", p)
-				e.excludedDirRelPathsCwd[info.RelPathCwd] = p
-			}
-			e.mu.Unlock()
+// foldCase is s as patterns and paths are compared.
+func foldCase(s string) string {
+	if foldPathCase {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// globMatcher is a glob compiled for repeated matching: patterns without
+// wildcards compare by equality instead of going through the matcher.
+type globMatcher struct {
+	pattern string
+	literal bool
+	slash   bool // match with path.Match (forward slashes) rather than filepath.Match
+}
+
+func compileGlob(pattern string, slash bool) globMatcher {
+	pattern = foldCase(pattern)
+	return globMatcher{pattern: pattern, literal: !strings.ContainsAny(pattern, `*?[\`), slash: slash}
+}
+
+func (g globMatcher) match(name string) bool {
+	name = foldCase(name)
+	if g.literal {
+		return name == g.pattern
+	}
+	var match bool
+	if g.slash {
+		match, _ = path.Match(g.pattern, name)
+	} else {
+		match, _ = filepath.Match(g.pattern, name)
+	}
+	return match
+}
+
+// basenameMatcher matches names against basename patterns, returning the
+// first matching pattern like matchesGlob. Literal names are looked up in a
+// map; only wildcard patterns are tried one by one.
+type basenameMatcher struct {
+	patterns []string
+	literals map[string]int // literal pattern, case-folded -> its first index
+	globs    []int          // indexes of wildcard patterns, ascending
+	compiled []globMatcher
+}
+
+func compileBasenames(patterns []string) basenameMatcher {
+	m := basenameMatcher{patterns: patterns, literals: make(map[string]int), compiled: make([]globMatcher, len(patterns))}
+	for i, p := range patterns {
+		m.compiled[i] = compileGlob(p, false)
+		if !m.compiled[i].literal {
+			m.globs = append(m.globs, i)
+		} else if _, seen := m.literals[m.compiled[i].pattern]; !seen {
+			m.literals[m.compiled[i].pattern] = i
 		}
-		return true, "basename match", p
 	}
+	return m
+}
 
-	// Check CWD Relative Patterns for the item itself
-	for _, p := range e.cwdRelativePatterns {
-		match, _ := filepath.Match(p, info.RelPathCwd)
-		// Also check if a pattern like "foo/" matches directory "foo"
-		if !match && info.IsDir && strings.HasSuffix(p, "/") {
-			match, _ = filepath.Match(strings.TrimRight(p, "/"), info.RelPathCwd)
+func (m basenameMatcher) match(name string) (bool, string) {
+	first, found := m.literals[foldCase(name)]
+	for _, i := range m.globs {
+		if found && i > first {
+			break
+		}
+		if m.compiled[i].match(name) {
+			first, found = i, true
+			break
+		}
+	}
+	if !found {
+		return false, ""
+	}
+	return true, m.patterns[first]
+}
+
+// cwdPattern is a compiled CWD-relative exclude pattern. A trailing slash
+// limits the pattern to directories; "docs" and "docs/" both exclude the
+// contents of docs.
+type cwdPattern struct {
+	raw     string      // as configured, including any "!"
+	negated bool        // a "!pattern" re-inclusion
+	full    globMatcher // the pattern with forward slashes
+	dir     globMatcher // full without trailing slashes
+	prefix  string      // dir + "/" for the prefix check on ancestors, or ""
+}
+
+func compileCwdPatterns(patterns []string) []cwdPattern {
+	compiled := make([]cwdPattern, len(patterns))
+	for i, p := range patterns {
+		compiled[i] = compileCwdPattern(strings.TrimPrefix(p, "!"))
+		compiled[i].raw, compiled[i].negated = p, strings.HasPrefix(p, "!")
+	}
+	return compiled
+}
+
+func compileCwdPattern(pattern string) cwdPattern {
+	slashPattern := foldCase(filepath.ToSlash(pattern))
+	dirPattern := strings.TrimRight(slashPattern, "/")
+	c := cwdPattern{raw: pattern, full: compileGlob(slashPattern, true), dir: compileGlob(dirPattern, true)}
+	if dirPattern != "" {
+		c.prefix = dirPattern + "/"
+	}
+	return c
+}
+
+// matchesItem checks the item's own CWD-relative path.
+func (c cwdPattern) matchesItem(relPath string, isDir bool) bool {
+	if c.full.match(relPath) {
+		return true
+	}
+	return isDir && c.dir.pattern != c.full.pattern && c.dir.match(relPath)
+}
+
+// matchesAncestor checks one ancestor directory of the item.
+func (c cwdPattern) matchesAncestor(dir string) (bool, string) {
+	if c.full.match(dir) || c.dir.match(dir) {
+		return true, fmt.Sprintf("ancestor %s CWD match", dir)
+	}
+	// CWD-relative prefix match (e.g., 'docs/' matches 'docs/file.txt')
+	if c.prefix != "" && strings.HasPrefix(foldCase(dir), c.prefix) {
+		return true, fmt.Sprintf("ancestor %s CWD prefix match", dir)
+	}
+	return false, ""
+}
+
+// matchesCwdRelative checks one CWD-relative pattern (without its "!")
+// against the item itself and every ancestor directory.
+func matchesCwdRelative(pattern string, info PathInfo) (bool, string) {
+	c := compileCwdPattern(pattern)
+	relPath := filepath.ToSlash(info.RelPathCwd)
+	if c.matchesItem(relPath, info.IsDir) {
+		return true, "CWD-relative match"
+	}
+	for parent := path.Dir(relPath); parent != "." && parent != "/" && parent != ""; parent = path.Dir(parent) {
+		if match, why := c.matchesAncestor(parent); match {
+			return true, why
 		}
-		if match {
-			slog.Debug("Exclusion check: item excluded by CWD-relative pattern",
-				"path", info.RelPathCwd, "pattern", p)
-			if info.IsDir {
-				e.mu.Lock()
-				if _, exists := e.excludedDirRelPathsCwd[info.RelPathCwd]; !exists {
-					slog.Debug("Adding dir to excluded map (item CWD match).", "relPathCwd", info.RelPathCwd, "pattern", p)
-					e.excludedDirRelPathsCwd[info.RelPathCwd] = p
-				}
-				e.mu.Unlock()
-			}
-			return true, "CWD-relative match", p
+	}
+	return false, ""
+}
+
+// markExcludedDir remembers a directory excluded by pattern.
+func (e *DefaultExcluder) markExcludedDir(info PathInfo, pattern string) {
+	if !info.IsDir {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, exists := e.excludedDirRelPathsCwd[info.RelPathCwd]; !exists {
+		slog.Debug("Adding dir to excluded map.", "relPathCwd", info.RelPathCwd, "pattern", pattern)
+		e.excludedDirRelPathsCwd[info.RelPathCwd] = pattern
+	}
+}
+
+// matchesSlashGlob is matchesGlob for CWD-relative paths: patterns are
+// matched with forward slashes as separators on every platform, so "docs/*.md"
+// and (on Windows) "docs\*.md" behave the same. The original pattern is returned.
+func matchesSlashGlob(target string, patterns []string) (bool, string) {
+	for _, pattern := range patterns {
+		if match, _ := path.Match(filepath.ToSlash(pattern), target); match {
+			return true, pattern
 		}
 	}
+	return false, ""
+}
 
-	// Not excluded by any rule
-	slog.Debug("Exclusion check: path not excluded", "path", info.RelPathCwd)
-	return false, "", ""
+// exclusionSource maps an IsExcluded result to the layer that supplied the rule:
+// "basename" for config basenames, otherwise "flag" or "project" for CWD-relative patterns.
+func exclusionSource(reason, pattern string, flagPatterns []string) string {
+	if strings.Contains(reason, "basename") {
+		return "basename"
+	}
+	if reason == "plugin" {
+		return "plugin"
+	}
+	return tern(contains(flagPatterns, pattern), "flag", "project")
 }
@@ -0,0 +1,129 @@
+// cmd/codecat/exclusion_test.go
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultExcluderRules(t *testing.T) {
+	excluder := NewDefaultExcluder([]string{"*.log", "node_modules"}, []string{"build", "docs/*.md", "tmp"})
+
+	for _, info := range []PathInfo{
+		{RelPathCwd: "app.log", BaseName: "app.log"},
+		{RelPathCwd: "sub/debug.log", BaseName: "debug.log"},
+		{RelPathCwd: "build", BaseName: "build", IsDir: true}, // directories are not counted
+		{RelPathCwd: "build/a.js", BaseName: "a.js"},
+		{RelPathCwd: "build/b.js", BaseName: "b.js"},
+		{RelPathCwd: "build/c.js", BaseName: "c.js"},
+		{RelPathCwd: "docs/readme.md", BaseName: "readme.md"},
+		{RelPathCwd: "src/main.go", BaseName: "main.go"},
+	} {
+		excluder.IsExcluded(info)
+	}
+
+	assert.Equal(t, []ExclusionRule{
+		{Pattern: "build", Source: "project", Hits: 3},
+		{Pattern: "*.log", Source: "basename", Hits: 2},
+		{Pattern: "docs/*.md", Source: "flag", Hits: 1},
+		{Pattern: "node_modules", Source: "basename", Hits: 0},
+		{Pattern: "tmp", Source: "flag", Hits: 0},
+	}, excluder.Rules([]string{"docs/*.md", "tmp"}))
+}
+
+func TestDefaultExcluderNegation(t *testing.T) {
+	excluder := NewDefaultExcluder([]string{"*.log"}, []string{"docs/", "!docs/architecture.md", "!docs/adr/*", "docs/adr/draft-*"})
+
+	testCases := []struct {
+		path     string
+		excluded bool
+		pattern  string
+	}{
+		{"docs/guide.md", true, "docs/"},
+		{"docs/architecture.md", false, "!docs/architecture.md"},
+		{"docs/adr/0001-use-go.md", false, "!docs/adr/*"},
+		{"docs/adr/draft-0002.md", true, "docs/adr/draft-*"}, // later patterns win
+		{"docs/debug.log", true, "*.log"},                    // basename excludes are not negated
+		{"src/main.go", false, ""},
+	}
+	for _, tc := range testCases {
+		excluded, _, pattern := excluder.IsExcluded(PathInfo{RelPathCwd: tc.path, BaseName: filepath.Base(tc.path)})
+		assert.Equal(t, tc.excluded, excluded, tc.path)
+		assert.Equal(t, tc.pattern, pattern, tc.path)
+	}
+
+	rules := excluder.Rules(nil)
+	assert.Contains(t, rules, ExclusionRule{Pattern: "!docs/architecture.md", Source: "project", Hits: 1})
+}
+
+func TestDefaultExcluderDirectoryCache(t *testing.T) {
+	excluder := NewDefaultExcluder([]string{"node_*", "node_modules", "*.tmp"}, []string{"gen", "!gen/keep/*", "src/*/internal", "*.bak"})
+
+	testCases := []struct {
+		path     string
+		excluded bool
+		reason   string
+		pattern  string
+	}{
+		{"a/node_modules/x/y.js", true, "ancestor node_modules basename match", "node_*"}, // first basename pattern wins
+		{"a/node_modules/x/z.js", true, "ancestor node_modules basename match", "node_*"}, // same directory, from the cache
+		{"a/b.tmp", true, "basename match", "*.tmp"},
+		{"gen/a/b.go", true, "ancestor gen/a CWD prefix match", "gen"},
+		{"gen/keep/b.go", false, "re-included by negation", "!gen/keep/*"},
+		{"gen/keep/sub/c.go", false, "re-included by negation", "!gen/keep/*"},
+		{"src/pkg/internal/a.go", true, "ancestor src/pkg/internal CWD match", "src/*/internal"},
+		{"src/pkg/internal/deep/a.go", true, "ancestor src/pkg/internal CWD match", "src/*/internal"},
+		{"a.bak", true, "CWD-relative match", "*.bak"},
+		{"src/pkg/a.bak", false, "", ""}, // CWD-relative globs are anchored
+		{"src/pkg/a.go", false, "", ""},
+	}
+	for pass := 0; pass < 2; pass++ { // the second pass is answered from the directory cache
+		for _, tc := range testCases {
+			excluded, reason, pattern := excluder.IsExcluded(PathInfo{RelPathCwd: tc.path, BaseName: filepath.Base(tc.path)})
+			assert.Equal(t, tc.excluded, excluded, tc.path)
+			assert.Equal(t, tc.reason, reason, tc.path)
+			assert.Equal(t, tc.pattern, pattern, tc.path)
+		}
+	}
+}
+
+func TestDefaultExcluderIgnoreCase(t *testing.T) {
+	foldPathCase = true
+	defer func() { foldPathCase = runtime.GOOS == "darwin" || runtime.GOOS == "windows" }()
+	excluder := NewDefaultExcluder([]string{"Node_Modules", "*.LOG"}, []string{"build/", "Docs/*.md", "!BUILD/keep.go"})
+
+	testCases := []struct {
+		path     string
+		excluded bool
+		pattern  string
+	}{
+		{"Build/out.go", true, "build/"},
+		{"BUILD/keep.go", false, "!BUILD/keep.go"},
+		{"web/node_modules/a.js", true, "Node_Modules"},
+		{"app.log", true, "*.LOG"},
+		{"docs/API.MD", true, "Docs/*.md"},
+		{"src/main.go", false, ""},
+	}
+	for _, tc := range testCases {
+		excluded, _, pattern := excluder.IsExcluded(PathInfo{RelPathCwd: tc.path, BaseName: filepath.Base(tc.path)})
+		assert.Equal(t, tc.excluded, excluded, tc.path)
+		assert.Equal(t, tc.pattern, pattern, tc.path, "the pattern is reported as configured")
+	}
+	assert.True(t, compileGlob("Makefile", false).match("makefile"))
+
+	foldPathCase = false
+	excluded, _, _ := NewDefaultExcluder(nil, []string{"build/"}).IsExcluded(PathInfo{RelPathCwd: "Build/out.go", BaseName: "out.go"})
+	assert.False(t, excluded, "case-sensitive when turned off")
+}
+
+func TestBasenameMatcher(t *testing.T) {
+	m := compileBasenames([]string{"*.log", "build", "b*", "build"})
+	for name, want := range map[string]string{"app.log": "*.log", "build": "build", "bin": "b*", "src": ""} {
+		match, pattern := m.match(name)
+		assert.Equal(t, want != "", match, name)
+		assert.Equal(t, want, pattern, name)
+	}
+}
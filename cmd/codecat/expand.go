@@ -0,0 +1,338 @@
+// cmd/codecat/expand.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const defaultExpandDepth = 1
+
+var (
+	goModuleRe    = regexp.MustCompile(`(?m)^\s*module\s+"?([^\s"]+)"?`)
+	pyFromNamesRe = regexp.MustCompile(`(?m)^\s*from\s+(\.*[\w.]*)\s+import\s+(?:\(([^)]*)\)|([\w \t,]+))`)
+)
+
+// jsResolveSuffixes are tried, in order, after a relative JS/TS specifier.
+var jsResolveSuffixes = []string{"", ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs",
+	"/index.ts", "/index.tsx", "/index.js", "/index.jsx"}
+
+// seedPaths turns --seed arguments into the CWD-relative, slash-separated
+// paths the scan reports.
+func seedPaths(cwd string, seeds []string) ([]string, error) {
+	paths := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		abs := seed
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(cwd, seed)
+		}
+		rel, err := filepath.Rel(cwd, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("--seed %s is outside the current directory", seed)
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	return paths, nil
+}
+
+// importResolver maps the import specifiers of scanned files back to the
+// scanned files they name. Only imports of files in the scan are resolved;
+// the standard library and third-party packages are ignored.
+type importResolver struct {
+	paths     map[string]bool
+	dirs      map[string][]string // directory -> files directly in it
+	goModules map[string]string   // Go module path -> CWD-relative module root ("" for the CWD)
+}
+
+func newImportResolver(cwd string, docs []Document) *importResolver {
+	r := &importResolver{paths: map[string]bool{}, dirs: map[string][]string{}, goModules: map[string]string{}}
+	for _, doc := range docs {
+		r.paths[doc.Path] = true
+		dir := path.Dir(doc.Path)
+		r.dirs[dir] = append(r.dirs[dir], doc.Path)
+		if path.Base(doc.Path) == "go.mod" {
+			if m := goModuleRe.FindStringSubmatch(doc.text()); m != nil {
+				r.goModules[m[1]] = strings.TrimPrefix(dir, ".")
+			}
+		}
+	}
+	// go.mod is often left out by the extension filters; read the CWD's.
+	if content, err := os.ReadFile(filepath.Join(cwd, "go.mod")); err == nil {
+		if m := goModuleRe.FindStringSubmatch(string(content)); m != nil {
+			if _, ok := r.goModules[m[1]]; !ok {
+				r.goModules[m[1]] = ""
+			}
+		}
+	}
+	return r
+}
+
+// resolve returns the scanned files that from's import spec refers to.
+func (r *importResolver) resolve(from, spec string) []string {
+	dir := path.Dir(from)
+	switch strings.ToLower(path.Ext(from)) {
+	case ".go":
+		return r.resolveGo(spec)
+	case ".py":
+		return r.resolvePython(dir, spec)
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx":
+		if !strings.HasPrefix(spec, "./") && !strings.HasPrefix(spec, "../") {
+			return nil // a package, not a file
+		}
+		for _, suffix := range jsResolveSuffixes {
+			if p := path.Join(dir, spec) + suffix; r.paths[p] {
+				return []string{p}
+			}
+		}
+	case ".java", ".kt":
+		name := strings.ReplaceAll(spec, ".", "/")
+		if strings.HasSuffix(name, "/*") {
+			return r.filesInDirEndingWith(strings.TrimSuffix(name, "/*"), ".java", ".kt")
+		}
+		return r.pathsEndingWith(name+".java", name+".kt")
+	case ".rs":
+		return r.resolveRust(spec)
+	}
+	return nil
+}
+
+// resolveGo maps an import path inside a scanned module to the Go files of
+// that package directory.
+func (r *importResolver) resolveGo(spec string) []string {
+	best, root := "", ""
+	for module, dir := range r.goModules {
+		if (spec == module || strings.HasPrefix(spec, module+"/")) && len(module) > len(best) {
+			best, root = module, dir
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	pkgDir := path.Join(root, strings.TrimPrefix(strings.TrimPrefix(spec, best), "/"))
+	if pkgDir == "" {
+		pkgDir = "."
+	}
+	var files []string
+	for _, p := range r.dirs[pkgDir] {
+		if strings.HasSuffix(p, ".go") {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// resolvePython maps a dotted (or leading-dot relative) module to its file or
+// package __init__.py. Absolute modules match at any depth, so sources below
+// src/ or a project directory resolve too.
+func (r *importResolver) resolvePython(dir, spec string) []string {
+	if strings.HasPrefix(spec, ".") {
+		rest := strings.TrimLeft(spec, ".")
+		for i := 1; i < len(spec)-len(rest); i++ {
+			dir = path.Dir(dir)
+		}
+		base := path.Join(dir, strings.ReplaceAll(rest, ".", "/"))
+		for _, p := range []string{base + ".py", base + "/__init__.py"} {
+			if r.paths[strings.TrimPrefix(p, "./")] {
+				return []string{strings.TrimPrefix(p, "./")}
+			}
+		}
+		return nil
+	}
+	name := strings.ReplaceAll(spec, ".", "/")
+	return r.pathsEndingWith(name+".py", name+"/__init__.py")
+}
+
+// pythonSubmoduleImports returns "pkg.name" for every "from pkg import
+// name", since the name may be a submodule rather than an attribute.
+func pythonSubmoduleImports(content string) []string {
+	var specs []string
+	for _, m := range pyFromNamesRe.FindAllStringSubmatch(content, -1) {
+		for _, name := range strings.Split(m[2]+m[3], ",") {
+			name = pyAliasSuffixRe.ReplaceAllString(strings.TrimSpace(name), "")
+			if name == "" || strings.ContainsAny(name, " \t\n") {
+				continue
+			}
+			specs = append(specs, tern(strings.HasSuffix(m[1], "."), m[1]+name, m[1]+"."+name))
+		}
+	}
+	return specs
+}
+
+// resolveRust maps crate:: paths to src/<mod>.rs or src/<mod>/mod.rs,
+// dropping trailing item names until a module file is found.
+func (r *importResolver) resolveRust(spec string) []string {
+	rest, ok := strings.CutPrefix(spec, "crate::")
+	if !ok {
+		return nil
+	}
+	parts := strings.Split(rest, "::")
+	for n := len(parts); n > 0; n-- {
+		name := strings.Join(parts[:n], "/")
+		if found := r.pathsEndingWith("src/"+name+".rs", "src/"+name+"/mod.rs"); len(found) > 0 {
+			return found
+		}
+	}
+	return nil
+}
+
+// pathsEndingWith returns the scanned files equal to, or ending in "/" plus,
+// any of the suffixes.
+func (r *importResolver) pathsEndingWith(suffixes ...string) []string {
+	var found []string
+	for p := range r.paths {
+		for _, suffix := range suffixes {
+			if p == suffix || strings.HasSuffix(p, "/"+suffix) {
+				found = append(found, p)
+				break
+			}
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+// filesInDirEndingWith returns the files with one of exts directly inside
+// directories ending in dirSuffix.
+func (r *importResolver) filesInDirEndingWith(dirSuffix string, exts ...string) []string {
+	var found []string
+	for dir, files := range r.dirs {
+		if dir != dirSuffix && !strings.HasSuffix(dir, "/"+dirSuffix) {
+			continue
+		}
+		for _, p := range files {
+			if contains(exts, path.Ext(p)) {
+				found = append(found, p)
+			}
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+// importGraph links each document to the scanned files it imports and to
+// the files importing it.
+func importGraph(cwd string, docs []Document) map[string][]string {
+	resolver := newImportResolver(cwd, docs)
+	graph := make(map[string][]string)
+	for _, doc := range docs {
+		parse := importParsers[strings.ToLower(path.Ext(doc.Path))]
+		if parse == nil {
+			continue
+		}
+		content := doc.text()
+		_, imports, ok := parse(doc.Path, content)
+		if !ok {
+			continue
+		}
+		if strings.HasSuffix(doc.Path, ".py") {
+			imports = append(imports, pythonSubmoduleImports(content)...)
+		}
+		for _, spec := range imports {
+			for _, target := range resolver.resolve(doc.Path, spec) {
+				if target != doc.Path {
+					graph[doc.Path] = append(graph[doc.Path], target)
+					graph[target] = append(graph[target], doc.Path)
+				}
+			}
+		}
+	}
+	for p, linked := range graph {
+		sort.Strings(linked)
+		graph[p] = slices.Compact(linked)
+	}
+	return graph
+}
+
+// expandSeeds walks the import graph breadth-first from the seeds, up to
+// depth hops, and returns the files reached nearest first. With a budget,
+// files that would exceed it are left out and the walk does not continue
+// through them.
+func expandSeeds(graph map[string][]string, docs []Document, seeds []string, depth int, maxTokens int64) []string {
+	sizes := make(map[string]int, len(docs))
+	for _, doc := range docs {
+		sizes[doc.Path] = len(doc.Path) + doc.size()
+	}
+	seen := make(map[string]bool)
+	var reached []string
+	var tokens int64
+	frontier := seeds
+	for level := 0; level <= depth && len(frontier) > 0; level++ {
+		var next []string
+		for _, p := range frontier {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			cost := estimateTokens(int64(sizes[p]))
+			if maxTokens > 0 && tokens+cost > maxTokens && level > 0 {
+				continue
+			}
+			reached = append(reached, p)
+			tokens += cost
+			next = append(next, graph[p]...)
+		}
+		frontier = next
+	}
+	return reached
+}
+
+// applySeeds reduces result to the seed files and the files within
+// opts.ExpandDepth import hops of them. Files given with -f are kept. It
+// returns the paths it left out.
+func applySeeds(result *GenerateResult, opts GenerateOptions) ([]string, error) {
+	known := make(map[string]bool, len(result.Documents))
+	for _, doc := range result.Documents {
+		known[doc.Path] = true
+	}
+	var seeds []string
+	for _, seed := range opts.Seeds {
+		if known[seed] {
+			seeds = append(seeds, seed)
+		} else {
+			slog.Warn("Seed file was not scanned, ignoring.", "path", seed)
+		}
+	}
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("none of the --seed files were scanned: %s", strings.Join(opts.Seeds, ", "))
+	}
+
+	graph := importGraph(opts.CWD, result.Documents)
+	keep := make(map[string]bool)
+	for _, p := range expandSeeds(graph, result.Documents, seeds, opts.ExpandDepth, opts.MaxTokens) {
+		keep[p] = true
+	}
+	for _, doc := range result.Documents {
+		if doc.IsManual {
+			keep[doc.Path] = true
+		}
+	}
+	slog.Info("Expanded seed files.", "seeds", len(seeds), "depth", opts.ExpandDepth, "files", len(keep))
+
+	var left []string
+	docs := result.Documents[:0]
+	for _, doc := range result.Documents {
+		if keep[doc.Path] {
+			docs = append(docs, doc)
+		} else {
+			left = append(left, doc.Path)
+		}
+	}
+	result.Documents = docs
+	files := result.IncludedFiles[:0]
+	result.TotalSize = 0
+	for _, f := range result.IncludedFiles {
+		if keep[f.Path] {
+			files = append(files, f)
+			result.TotalSize += f.Size
+		}
+	}
+	result.IncludedFiles = files
+	return left, nil
+}
@@ -0,0 +1,122 @@
+// cmd/codecat/expand_test.go
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedPaths(t *testing.T) {
+	cwd := t.TempDir()
+	paths, err := seedPaths(cwd, []string{"cmd/main.go", filepath.Join(cwd, "lib", "a.go")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cmd/main.go", "lib/a.go"}, paths)
+
+	_, err = seedPaths(cwd, []string{"../elsewhere.go"})
+	assert.Error(t, err)
+}
+
+func TestImportResolver(t *testing.T) {
+	docs := []Document{
+		{Path: "go.mod", Content: "module example.com/app\n\ngo 1.21\n"},
+		{Path: "main.go"},
+		{Path: "store/db.go"},
+		{Path: "store/db_test.go"},
+		{Path: "store/schema.sql"},
+		{Path: "src/pkg/util.py"},
+		{Path: "src/pkg/__init__.py"},
+		{Path: "src/pkg/sub/mod.py"},
+		{Path: "web/app.ts"},
+		{Path: "web/lib/index.ts"},
+		{Path: "java/com/acme/Repo.java"},
+		{Path: "java/com/acme/Svc.kt"},
+		{Path: "src/net/mod.rs"},
+		{Path: "src/net/http.rs"},
+	}
+	r := newImportResolver(t.TempDir(), docs)
+
+	assert.Equal(t, []string{"store/db.go", "store/db_test.go"}, r.resolve("main.go", "example.com/app/store"))
+	assert.Equal(t, []string{"main.go"}, r.resolve("store/db.go", "example.com/app"))
+	assert.Empty(t, r.resolve("main.go", "fmt"))
+	assert.Empty(t, r.resolve("main.go", "example.com/application"))
+
+	assert.Equal(t, []string{"src/pkg/util.py"}, r.resolve("app.py", "pkg.util"))
+	assert.Equal(t, []string{"src/pkg/__init__.py"}, r.resolve("app.py", "pkg"))
+	assert.Equal(t, []string{"src/pkg/util.py"}, r.resolve("src/pkg/sub/mod.py", "..util"))
+	assert.Equal(t, []string{"src/pkg/sub/mod.py"}, r.resolve("src/pkg/util.py", ".sub.mod"))
+	assert.Empty(t, r.resolve("app.py", "os.path"))
+
+	assert.Equal(t, []string{"web/lib/index.ts"}, r.resolve("web/app.ts", "./lib"))
+	assert.Empty(t, r.resolve("web/app.ts", "react"))
+
+	assert.Equal(t, []string{"java/com/acme/Repo.java"}, r.resolve("Main.java", "com.acme.Repo"))
+	assert.Equal(t, []string{"java/com/acme/Repo.java", "java/com/acme/Svc.kt"}, r.resolve("Main.java", "com.acme.*"))
+
+	assert.Equal(t, []string{"src/net/http.rs"}, r.resolve("src/main.rs", "crate::net::http::Client"))
+	assert.Equal(t, []string{"src/net/mod.rs"}, r.resolve("src/main.rs", "crate::net"))
+	assert.Empty(t, r.resolve("src/main.rs", "serde::Deserialize"))
+}
+
+func TestExpandSeeds(t *testing.T) {
+	graph := map[string][]string{
+		"a.py": {"b.py", "x.py"},
+		"b.py": {"a.py", "c.py"},
+		"c.py": {"b.py"},
+		"x.py": {"a.py"},
+	}
+	docs := []Document{
+		{Path: "a.py", Content: strings.Repeat("a", 396)},
+		{Path: "b.py", Content: strings.Repeat("b", 396)},
+		{Path: "c.py", Content: strings.Repeat("c", 396)},
+		{Path: "x.py", Content: strings.Repeat("x", 3996)},
+	}
+	assert.Equal(t, []string{"a.py"}, expandSeeds(graph, docs, []string{"a.py"}, 0, 0))
+	assert.Equal(t, []string{"a.py", "b.py", "x.py"}, expandSeeds(graph, docs, []string{"a.py"}, 1, 0))
+	assert.Equal(t, []string{"a.py", "b.py", "x.py", "c.py"}, expandSeeds(graph, docs, []string{"a.py"}, 2, 0))
+	assert.Equal(t, []string{"a.py", "b.py", "c.py"}, expandSeeds(graph, docs, []string{"a.py"}, 5, 300), "x.py does not fit the budget")
+}
+
+func TestGenerate_Seeds(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"app/main.py":      "from app import models\n",
+		"app/models.py":    "import app.db\n",
+		"app/db.py":        "import sqlite3\n",
+		"app/unrelated.py": "print('hi')\n",
+		"app/views.py":     "from . import models\n",
+	})
+	opts := GenerateOptions{
+		CWD:           tempDir,
+		ScanDirs:      []string{tempDir},
+		Extensions:    processExtensions([]string{"py"}),
+		Seeds:         []string{"app/main.py"},
+		ExpandDepth:   1,
+		ReportSkipped: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	var paths []string
+	for _, f := range result.IncludedFiles {
+		paths = append(paths, f.Path)
+	}
+	assert.ElementsMatch(t, []string{"app/main.py", "app/models.py"}, paths)
+
+	opts.ExpandDepth = 2
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Len(t, result.IncludedFiles, 4, "models.py links db.py and views.py")
+	assert.Equal(t, []string{"app/unrelated.py"}, result.Skipped["seed"])
+	assert.Equal(t, 1, result.ExcludedBy["seed"])
+
+	opts.Seeds = []string{"missing.py"}
+	_, err = generate(opts)
+	assert.ErrorContains(t, err, "none of the --seed files were scanned")
+}
+
+func TestPythonSubmoduleImports(t *testing.T) {
+	content := "from app import models, views as v\nimport os\nfrom . import (\n    db,\n    cache,\n)\nfrom .. import util\n"
+	assert.Equal(t, []string{"app.models", "app.views", ".db", ".cache", "..util"}, pythonSubmoduleImports(content))
+}
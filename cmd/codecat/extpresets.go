@@ -0,0 +1,46 @@
+// cmd/codecat/extpresets.go
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// builtinExtPresets are the --ext-preset bundles known without configuration.
+var builtinExtPresets = map[string][]string{
+	"go":     {"go", "mod", "sum", "tmpl", "proto"},
+	"python": {"py", "pyi", "toml", "cfg", "ini", "txt"},
+	"web":    {"js", "jsx", "mjs", "cjs", "ts", "tsx", "html", "css", "scss", "vue", "svelte", "json"},
+	"infra":  {"tf", "tfvars", "hcl", "yaml", "yml", "sh", "nix", "conf"},
+	"docs":   {"md", "rst", "txt", "adoc"},
+}
+
+// resolveExtPresets returns the union of the named presets' extensions, in
+// first-seen order. Names are matched case-insensitively; an [ext_presets]
+// entry in config.toml replaces a built-in preset of the same name.
+func resolveExtPresets(names []string, configured map[string][]string) ([]string, error) {
+	var exts []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		key := strings.ToLower(name)
+		preset, found := builtinExtPresets[key]
+		for k, p := range configured {
+			if strings.ToLower(k) == key {
+				preset, found = p, true
+			}
+		}
+		if !found {
+			known := append(mapsKeys(builtinExtPresets), mapsKeys(configured)...)
+			return nil, fmt.Errorf("%w: unknown --ext-preset %q (known: %s; add more under [ext_presets] in config.toml)",
+				errUsage, name, strings.Join(known, ", "))
+		}
+		for _, ext := range preset {
+			ext = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+			if ext != "" && !seen[ext] {
+				seen[ext] = true
+				exts = append(exts, ext)
+			}
+		}
+	}
+	return exts, nil
+}
@@ -0,0 +1,28 @@
+// cmd/codecat/extpresets_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveExtPresets(t *testing.T) {
+	exts, err := resolveExtPresets([]string{"go"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, builtinExtPresets["go"], exts)
+
+	exts, err = resolveExtPresets([]string{"Docs", "python"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"md", "rst", "txt", "adoc", "py", "pyi", "toml", "cfg", "ini"}, exts, "union without duplicates")
+
+	configured := map[string][]string{"data": {".SQL", "csv"}, "docs": {"md"}}
+	exts, err = resolveExtPresets([]string{"data", "docs"}, configured)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sql", "csv", "md"}, exts, "config presets add to and replace built-ins")
+
+	_, err = resolveExtPresets([]string{"cobol"}, configured)
+	assert.ErrorIs(t, err, errUsage)
+	assert.ErrorContains(t, err, "data, docs")
+}
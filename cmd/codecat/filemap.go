@@ -0,0 +1,40 @@
+// cmd/codecat/filemap.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// fileMapEntry is one line of the --file-map block.
+type fileMapEntry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// fileMap lists the included files, sorted by path, with their size on
+// disk: a block for tools to parse, unlike the summary tree meant for
+// people. Generated sections (the preamble, sampling notes) are not files
+// and are left out.
+func fileMap(result GenerateResult) []fileMapEntry {
+	entries := make([]fileMapEntry, 0, len(result.IncludedFiles))
+	for _, f := range result.IncludedFiles {
+		entries = append(entries, fileMapEntry{Path: f.Path, Size: f.Size})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// fileMapBlock renders the file map as a <file_map> block with one
+// tab-separated "path size" line per file, written alike in the text and
+// Markdown formats.
+func fileMapBlock(result GenerateResult) string {
+	var b strings.Builder
+	b.WriteString("<file_map>\n")
+	for _, e := range fileMap(result) {
+		fmt.Fprintf(&b, "%s\t%d\n", e.Path, e.Size)
+	}
+	b.WriteString("</file_map>\n")
+	return b.String()
+}
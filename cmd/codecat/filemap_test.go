@@ -0,0 +1,49 @@
+// cmd/codecat/filemap_test.go
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fileMapResult() GenerateResult {
+	return GenerateResult{
+		Documents:     []Document{{Path: "main.go", Content: "package main\n"}, {Path: "a&b.txt", Content: "x\n"}},
+		IncludedFiles: []FileInfo{{Path: "main.go", Size: 13}, {Path: "a&b.txt", Size: 2}},
+	}
+}
+
+func TestFileMap(t *testing.T) {
+	assert.Equal(t, []fileMapEntry{{Path: "a&b.txt", Size: 2}, {Path: "main.go", Size: 13}}, fileMap(fileMapResult()))
+	assert.Equal(t, "<file_map>\na&b.txt\t2\nmain.go\t13\n</file_map>\n", fileMapBlock(fileMapResult()))
+}
+
+func TestFormats_FileMap(t *testing.T) {
+	opts := GenerateOptions{Marker: "---", FileMap: true}
+
+	var text strings.Builder
+	require.NoError(t, formatText(&text, fileMapResult(), opts))
+	assert.True(t, strings.HasPrefix(text.String(), "<file_map>\na&b.txt\t2\nmain.go\t13\n</file_map>\n--- main.go\n"), text.String())
+
+	var md strings.Builder
+	require.NoError(t, formatMarkdown(&md, fileMapResult(), opts))
+	assert.True(t, strings.HasPrefix(md.String(), "<file_map>\na&b.txt\t2\nmain.go\t13\n</file_map>\n\n## main.go\n"), md.String())
+
+	var xmlOut strings.Builder
+	require.NoError(t, formatXML(&xmlOut, fileMapResult(), opts))
+	assert.Contains(t, xmlOut.String(), "<documents>\n<file_map>\na&amp;b.txt\t2\nmain.go\t13\n</file_map>\n<document index=\"1\">")
+
+	var js strings.Builder
+	require.NoError(t, formatJSON(&js, fileMapResult(), opts))
+	var decoded jsonOutput
+	require.NoError(t, json.Unmarshal([]byte(js.String()), &decoded))
+	assert.Equal(t, fileMap(fileMapResult()), decoded.FileMap)
+
+	var plain strings.Builder
+	require.NoError(t, formatText(&plain, fileMapResult(), GenerateOptions{Marker: "---"}))
+	assert.NotContains(t, plain.String(), "<file_map>", "off by default")
+}
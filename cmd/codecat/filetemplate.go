@@ -0,0 +1,71 @@
+// cmd/codecat/filetemplate.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Built-in equivalents of appendFileContent, used for whichever of the two
+// templates is not configured.
+const (
+	defaultFileHeaderTemplate = "{{.Marker}} {{.Path}}{{with .Fields}} {{.}}{{end}}\n{{range .Meta}}{{$.Marker}} {{.}}\n{{end}}"
+	defaultFileFooterTemplate = "{{.Marker}}\n"
+)
+
+// fileTemplateData is what file_header_template and file_footer_template see.
+type fileTemplateData struct {
+	Path     string
+	Size     int
+	Language string
+	Tokens   int64
+	Index    int // 1-based position in the output
+	Marker   string
+	Meta     []string
+	Fields   string // --header-fields as "name=value" pairs, or ""
+}
+
+// fileTemplates renders the text written before and after each file.
+type fileTemplates struct {
+	header, footer *template.Template
+}
+
+// newFileTemplates parses the configured templates; nil when neither is set,
+// leaving the classic appendFileContent layout in place.
+func newFileTemplates(header, footer string) (*fileTemplates, error) {
+	if header == "" && footer == "" {
+		return nil, nil
+	}
+	t := &fileTemplates{}
+	var err error
+	if t.header, err = template.New("file_header_template").Parse(tern(header == "", defaultFileHeaderTemplate, header)); err != nil {
+		return nil, err
+	}
+	if t.footer, err = template.New("file_footer_template").Parse(tern(footer == "", defaultFileFooterTemplate, footer)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// write appends one file framed by the header and footer templates.
+func (t *fileTemplates) write(b *strings.Builder, doc Document, index int, marker, fields string) error {
+	data := fileTemplateData{
+		Path:     doc.Path,
+		Size:     doc.size(),
+		Language: languageForPath(doc.Path),
+		Tokens:   estimateTokens(int64(doc.size())),
+		Index:    index,
+		Marker:   marker,
+		Meta:     doc.Meta,
+		Fields:   fields,
+	}
+	if err := t.header.Execute(b, data); err != nil {
+		return fmt.Errorf("%s: %w", doc.Path, err)
+	}
+	b.WriteString(doc.text())
+	if err := t.footer.Execute(b, data); err != nil {
+		return fmt.Errorf("%s: %w", doc.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// cmd/codecat/filetemplate_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFileTemplates(t *testing.T) {
+	templates, err := newFileTemplates("", "")
+	require.NoError(t, err)
+	assert.Nil(t, templates, "no templates keeps appendFileContent")
+
+	_, err = newFileTemplates("{{.Path", "")
+	assert.ErrorContains(t, err, "file_header_template")
+}
+
+func TestFileTemplates_DefaultsMatchAppendFileContent(t *testing.T) {
+	doc := Document{Path: "a.go", Content: "package a\n", Meta: []string{"git: abc"}}
+	var want strings.Builder
+	appendFileContent(&want, "---", doc.Path, []byte(doc.Content), doc.Meta)
+
+	templates, err := newFileTemplates(defaultFileHeaderTemplate, "")
+	require.NoError(t, err)
+	var got strings.Builder
+	require.NoError(t, templates.write(&got, doc, 1, "---", ""))
+	assert.Equal(t, want.String(), got.String())
+}
+
+func TestFormatText_FileTemplates(t *testing.T) {
+	result := GenerateResult{Documents: []Document{
+		{Path: "main.go", Content: "package main\n"},
+		{Path: "README.md", Content: "# hi\n"},
+	}}
+	opts := GenerateOptions{
+		Marker:             "---",
+		FileHeaderTemplate: `<file n="{{.Index}}" path="{{.Path}}" lang="{{.Language}}" bytes="{{.Size}}" tokens="{{.Tokens}}">` + "\n",
+		FileFooterTemplate: "</file>\n",
+	}
+	var out strings.Builder
+	require.NoError(t, formatText(&out, result, opts))
+	assert.Equal(t, `<file n="1" path="main.go" lang="Go" bytes="13" tokens="4">`+"\npackage main\n</file>\n"+
+		`<file n="2" path="README.md" lang="Markdown" bytes="5" tokens="2">`+"\n# hi\n</file>\n", out.String())
+
+	opts.FileFooterTemplate = "{{.Missing}}"
+	assert.Error(t, formatText(&out, result, opts))
+}
@@ -0,0 +1,349 @@
+// cmd/codecat/format.go
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Document is one included file's processed content, in output order.
+type Document struct {
+	Path       string            `json:"path"`
+	Content    string            `json:"content"` // empty when spooled; read it with text()
+	Meta       []string          `json:"meta,omitempty"`
+	IsManual   bool              `json:"is_manual,omitempty"`
+	NestedRepo string            `json:"nested_repo,omitempty"` // set under --nested-repos=separate
+	Root       string            `json:"root,omitempty"`        // CWD-relative -d root with its own filters, or the repository in codecat multi
+	Fields     map[string]string `json:"fields,omitempty"`      // --header-fields metadata, set when rendering
+
+	spooled *spooledContent // where Content went past the memory limit
+	modTime time.Time       // of the file on disk; zero for generated documents
+	mode    fs.FileMode
+}
+
+// MarshalJSON writes spooled content back in place.
+func (d Document) MarshalJSON() ([]byte, error) {
+	type plain Document
+	p := plain(d)
+	p.Content = d.text()
+	return json.Marshal(p)
+}
+
+// OutputFormatter renders the documents of a generation run in one format.
+type OutputFormatter func(w io.Writer, result GenerateResult, opts GenerateOptions) error
+
+// outputFormatters is the registry of formats keyed by name.
+var outputFormatters = map[string]OutputFormatter{
+	"text":     formatText,
+	"markdown": formatMarkdown,
+	"json":     formatJSON,
+	"xml":      formatXML,
+}
+
+// formatExtensions picks the format of an output file from its extension;
+// anything else is written as text.
+var formatExtensions = map[string]string{
+	".md":       "markdown",
+	".markdown": "markdown",
+	".json":     "json",
+	".xml":      "xml",
+}
+
+// flushBuilder writes what b holds to w and empties it, so the formatters
+// keep one file in memory at a time rather than the whole output.
+func flushBuilder(w io.Writer, b *strings.Builder) error {
+	_, err := io.WriteString(w, b.String())
+	b.Reset()
+	return err
+}
+
+// formatText is the classic marker-delimited format, also kept in GenerateResult.Output.
+// If any file has a line starting with the marker, a longer marker is used
+// and announced after the header so the blocks stay unambiguous.
+func formatText(w io.Writer, result GenerateResult, opts GenerateOptions) error {
+	templates, err := newFileTemplates(opts.FileHeaderTemplate, opts.FileFooterTemplate)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString(opts.Header)
+	if marker := collisionSafeMarker(opts.Marker, result.Documents); marker != opts.Marker {
+		slog.Debug("Content contains the comment marker, using a longer one.", "marker", opts.Marker, "replacement", marker)
+		b.WriteString(fmt.Sprintf("# marker: %q (content has lines starting with %q)\n", marker, opts.Marker))
+		opts.Marker = marker
+	}
+	if result.Stamp != nil {
+		for _, line := range result.Stamp.Lines() {
+			b.WriteString("# " + line + "\n")
+		}
+	}
+	for _, repo := range result.Repos {
+		b.WriteString("# " + repo.Line() + "\n")
+	}
+	if opts.FileMap {
+		b.WriteString(fileMapBlock(result))
+	}
+	root, section := "", ""
+	for i, doc := range result.Documents {
+		if doc.Root != root {
+			root, section = doc.Root, ""
+			b.WriteString(fmt.Sprintf("%s === root: %s ===\n", opts.Marker, root))
+		}
+		if doc.NestedRepo != section {
+			section = doc.NestedRepo
+			b.WriteString(fmt.Sprintf("%s === nested repository: %s ===\n", opts.Marker, section))
+		}
+		if templates == nil {
+			appendFileContent(&b, opts.Marker, withHeaderFields(doc, opts.HeaderFields, opts.QuotePaths), []byte(doc.text()), doc.Meta)
+		} else if err := templates.write(&b, doc, i+1, opts.Marker, formatHeaderFields(doc.Fields, opts.HeaderFields)); err != nil {
+			return err
+		}
+		if err := flushBuilder(w, &b); err != nil {
+			return err
+		}
+	}
+	return flushBuilder(w, &b)
+}
+
+// formatMarkdown writes one heading and fenced code block per file. Fences are
+// made longer than any backtick run inside the content.
+func formatMarkdown(w io.Writer, result GenerateResult, opts GenerateOptions) error {
+	var b strings.Builder
+	if opts.Header != "" {
+		b.WriteString(strings.TrimRight(opts.Header, "\n") + "\n\n")
+	}
+	if result.Stamp != nil {
+		for _, line := range result.Stamp.Lines() {
+			b.WriteString("- " + line + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if len(result.Repos) > 0 {
+		for _, repo := range result.Repos {
+			b.WriteString("- " + repo.Line() + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if opts.FileMap {
+		b.WriteString(fileMapBlock(result) + "\n")
+	}
+	root, section := "", ""
+	for _, doc := range result.Documents {
+		if doc.Root != root {
+			root, section = doc.Root, ""
+			b.WriteString(fmt.Sprintf("# Root: %s\n\n", root))
+		}
+		if doc.NestedRepo != section {
+			section = doc.NestedRepo
+			b.WriteString(fmt.Sprintf("# Nested repository: %s\n\n", section))
+		}
+		b.WriteString(fmt.Sprintf("## %s\n\n", withHeaderFields(doc, opts.HeaderFields, opts.QuotePaths)))
+		for _, line := range doc.Meta {
+			b.WriteString(fmt.Sprintf("> %s\n", line))
+		}
+		if len(doc.Meta) > 0 {
+			b.WriteString("\n")
+		}
+		content := doc.text()
+		fence := markdownFence(content)
+		b.WriteString(fence + strings.TrimPrefix(strings.ToLower(filepath.Ext(doc.Path)), ".") + "\n")
+		b.WriteString(content)
+		if !strings.HasSuffix(content, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString(fence + "\n\n")
+		if err := flushBuilder(w, &b); err != nil {
+			return err
+		}
+	}
+	return flushBuilder(w, &b)
+}
+
+// collisionSafeMarker lengthens marker by repeating its last character until
+// no line of any document starts with it. The documents are read once: the
+// marker is made one longer than the longest run of that character found
+// after the marker's stem at a line start.
+func collisionSafeMarker(marker string, docs []Document) string {
+	if marker == "" {
+		return marker
+	}
+	last, size := utf8.DecodeLastRuneInString(marker)
+	stem := strings.TrimRight(marker, string(last))
+	run := (len(marker) - len(stem)) / size
+	longest := 0
+	for _, doc := range docs {
+		content := doc.text()
+		for start := 0; start < len(content); {
+			end := strings.IndexByte(content[start:], '\n')
+			if end < 0 {
+				end = len(content)
+			} else {
+				end += start
+			}
+			if line := content[start:end]; strings.HasPrefix(line, stem) {
+				rest := line[len(stem):]
+				n := 0
+				for strings.HasPrefix(rest, string(last)) {
+					rest, n = rest[size:], n+1
+				}
+				if n >= run {
+					longest = max(longest, n)
+				}
+			}
+			start = end + 1
+		}
+	}
+	if longest == 0 {
+		return marker
+	}
+	return stem + strings.Repeat(string(last), longest+1)
+}
+
+func markdownFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// formatJSON writes the documents together with the run's bookkeeping.
+// Unlike the other formats it is encoded whole, so --memory-limit does not
+// bound it.
+func formatJSON(w io.Writer, result GenerateResult, opts GenerateOptions) error {
+	errorsByPath := make(map[string]string, len(result.ErrorFiles))
+	for path, err := range result.ErrorFiles {
+		errorsByPath[path] = err.Error()
+	}
+	emptyFiles := append([]string{}, result.EmptyFiles...)
+	sort.Strings(emptyFiles)
+	out := jsonOutput{
+		Header:     opts.Header,
+		Stamp:      result.Stamp,
+		Repos:      result.Repos,
+		Files:      append([]Document{}, result.Documents...),
+		EmptyFiles: emptyFiles,
+		Errors:     errorsByPath,
+		TotalSize:  result.TotalSize,
+	}
+	if opts.FileMap {
+		out.FileMap = fileMap(result)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// jsonOutput is the document written by the JSON format.
+type jsonOutput struct {
+	Header     string            `json:"header,omitempty"`
+	Stamp      *Stamp            `json:"stamp,omitempty"`
+	Repos      []RepoInfo        `json:"repos,omitempty"`
+	FileMap    []fileMapEntry    `json:"file_map,omitempty"`
+	Files      []Document        `json:"files"`
+	EmptyFiles []string          `json:"empty_files"`
+	Errors     map[string]string `json:"errors"`
+	TotalSize  int64             `json:"total_size"`
+}
+
+// formatXML writes the <documents> structure recommended for long-context
+// prompts. Content goes into CDATA sections, split around any "]]>" it holds;
+// content with characters XML cannot carry at all is escaped instead.
+func formatXML(w io.Writer, result GenerateResult, opts GenerateOptions) error {
+	var b strings.Builder
+	b.WriteString("<documents>\n")
+	if result.Stamp != nil {
+		b.WriteString("<stamp>\n")
+		for _, line := range result.Stamp.Lines() {
+			xmlEscape(&b, line)
+			b.WriteString("\n")
+		}
+		b.WriteString("</stamp>\n")
+	}
+	for _, repo := range result.Repos {
+		b.WriteString("<repo path=\"")
+		xmlEscape(&b, repo.Path)
+		b.WriteString("\" remote=\"")
+		xmlEscape(&b, repo.Remote)
+		b.WriteString("\" branch=\"")
+		xmlEscape(&b, repo.Branch)
+		fmt.Fprintf(&b, "\" commit=\"%s\"/>\n", repo.Commit)
+	}
+	if opts.FileMap {
+		b.WriteString("<file_map>\n")
+		for _, e := range fileMap(result) {
+			xmlEscape(&b, e.Path)
+			fmt.Fprintf(&b, "\t%d\n", e.Size)
+		}
+		b.WriteString("</file_map>\n")
+	}
+	for i, doc := range result.Documents {
+		b.WriteString(fmt.Sprintf("<document index=\"%d\"", i+1))
+		for _, name := range opts.HeaderFields {
+			if value, ok := doc.Fields[name]; ok {
+				b.WriteString(" " + name + "=\"")
+				xmlEscape(&b, value)
+				b.WriteString("\"")
+			}
+		}
+		if doc.Root != "" {
+			b.WriteString(" root=\"")
+			xmlEscape(&b, doc.Root)
+			b.WriteString("\"")
+		}
+		if doc.NestedRepo != "" {
+			b.WriteString(" nested_repo=\"")
+			xmlEscape(&b, doc.NestedRepo)
+			b.WriteString("\"")
+		}
+		b.WriteString(">\n<source>")
+		xmlEscape(&b, doc.Path)
+		b.WriteString("</source>\n")
+		for _, line := range doc.Meta {
+			b.WriteString("<metadata>")
+			xmlEscape(&b, line)
+			b.WriteString("</metadata>\n")
+		}
+		b.WriteString("<document_contents>\n")
+		if content := doc.text(); xmlCharsValid(content) {
+			b.WriteString("<![CDATA[" + strings.ReplaceAll(content, "]]>", "]]]]><![CDATA[>") + "]]>")
+		} else {
+			xmlEscape(&b, content)
+		}
+		b.WriteString("\n</document_contents>\n</document>\n")
+		if err := flushBuilder(w, &b); err != nil {
+			return err
+		}
+	}
+	b.WriteString("</documents>\n")
+	return flushBuilder(w, &b)
+}
+
+func xmlEscape(b *strings.Builder, s string) {
+	xml.EscapeText(b, []byte(s)) // writes to a strings.Builder never fail
+}
+
+// xmlCharsValid reports whether s only holds characters allowed in XML 1.0.
+func xmlCharsValid(s string) bool {
+	for _, r := range s {
+		if r == utf8.RuneError || !(r == 0x09 || r == 0x0A || r == 0x0D || r >= 0x20 && r <= 0xD7FF ||
+			r >= 0xE000 && r <= 0xFFFD || r >= 0x10000 && r <= 0x10FFFF) {
+			return false
+		}
+	}
+	return true
+}
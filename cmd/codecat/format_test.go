@@ -0,0 +1,105 @@
+// cmd/codecat/format_test.go
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleResult() GenerateResult {
+	return GenerateResult{
+		Documents: []Document{
+			{Path: "main.go", Content: "package main\n", Meta: []string{"git: abc"}},
+			{Path: "lib/doc.md", Content: "```go\nx\n```", NestedRepo: "lib"},
+		},
+		EmptyFiles: []string{"empty.go"},
+		ErrorFiles: map[string]error{"bad.go": errors.New("permission denied")},
+		TotalSize:  24,
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, formatText(&out, sampleResult(), GenerateOptions{Header: "HEAD\n", Marker: "---"}))
+	assert.Equal(t, "HEAD\n--- main.go\n--- git: abc\npackage main\n---\n"+
+		"--- === nested repository: lib ===\n--- lib/doc.md\n```go\nx\n```---\n", out.String())
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, formatMarkdown(&out, sampleResult(), GenerateOptions{Header: "HEAD\n"}))
+	assert.Equal(t, "HEAD\n\n## main.go\n\n> git: abc\n\n```go\npackage main\n```\n\n"+
+		"# Nested repository: lib\n\n## lib/doc.md\n\n````md\n```go\nx\n```\n````\n\n", out.String())
+}
+
+func TestFormatJSON(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, formatJSON(&out, sampleResult(), GenerateOptions{}))
+
+	var decoded struct {
+		Files      []Document        `json:"files"`
+		EmptyFiles []string          `json:"empty_files"`
+		Errors     map[string]string `json:"errors"`
+		TotalSize  int64             `json:"total_size"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &decoded))
+	assert.Equal(t, sampleResult().Documents, decoded.Files)
+	assert.Equal(t, []string{"empty.go"}, decoded.EmptyFiles)
+	assert.Equal(t, map[string]string{"bad.go": "permission denied"}, decoded.Errors)
+	assert.EqualValues(t, 24, decoded.TotalSize)
+}
+
+func TestFormatXML(t *testing.T) {
+	result := GenerateResult{Documents: []Document{
+		{Path: "a&b.xml", Content: "<x>]]></x>\n", Meta: []string{"git: <abc>"}},
+		{Path: "bin.txt", Content: "nul\x00<here>", NestedRepo: "lib"},
+	}}
+	var out strings.Builder
+	require.NoError(t, formatXML(&out, result, GenerateOptions{}))
+	assert.Equal(t, "<documents>\n"+
+		"<document index=\"1\">\n<source>a&amp;b.xml</source>\n<metadata>git: &lt;abc&gt;</metadata>\n"+
+		"<document_contents>\n<![CDATA[<x>]]]]><![CDATA[></x>\n]]>\n</document_contents>\n</document>\n"+
+		"<document index=\"2\" nested_repo=\"lib\">\n<source>bin.txt</source>\n"+
+		"<document_contents>\nnul�&lt;here&gt;\n</document_contents>\n</document>\n"+
+		"</documents>\n", out.String())
+
+	// The first document must round-trip through a real XML parser.
+	var parsed struct {
+		Documents []struct {
+			Source   string `xml:"source"`
+			Contents string `xml:"document_contents"`
+		} `xml:"document"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(out.String()), &parsed))
+	require.Len(t, parsed.Documents, 2)
+	assert.Equal(t, "a&b.xml", parsed.Documents[0].Source)
+	assert.Equal(t, "\n<x>]]></x>\n\n", parsed.Documents[0].Contents)
+}
+
+func TestCollisionSafeMarker(t *testing.T) {
+	docs := []Document{{Path: "a.md", Content: "title\n---\nbody\n-----x\n"}}
+	assert.Equal(t, "------", collisionSafeMarker("---", docs))
+	assert.Equal(t, "---", collisionSafeMarker("---", []Document{{Path: "a.go", Content: "x --- y\n"}}))
+	assert.Equal(t, "//==", collisionSafeMarker("//=", []Document{{Path: "a.go", Content: "//= note\n"}}))
+	assert.Equal(t, "---", collisionSafeMarker("---", []Document{{Path: "a.md", Content: "--\n- item\n"}}), "shorter runs do not collide")
+	docs = []Document{{Path: "a.md", Content: "----"}, {Path: "b.md", Content: "x\n" + strings.Repeat("-", 400) + "\n"}}
+	assert.Equal(t, strings.Repeat("-", 401), collisionSafeMarker("---", docs), "one longer than the longest run, without a newline at the end too")
+	assert.Equal(t, "#»»»", collisionSafeMarker("#»", []Document{{Path: "a.txt", Content: "#»» quote\n"}}))
+}
+
+func TestFormatText_MarkerCollision(t *testing.T) {
+	result := GenerateResult{Documents: []Document{
+		{Path: "a.md", Content: "title\n---\n"},
+		{Path: "b.go", Content: "package b\n"},
+	}}
+	var out strings.Builder
+	require.NoError(t, formatText(&out, result, GenerateOptions{Header: "HEAD\n", Marker: "---"}))
+	assert.Equal(t, "HEAD\n# marker: \"----\" (content has lines starting with \"---\")\n"+
+		"---- a.md\ntitle\n---\n----\n---- b.go\npackage b\n----\n", out.String())
+}
@@ -0,0 +1,134 @@
+// cmd/codecat/gitattributes.go
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// gitattributeSignals are the .gitattributes attributes that mark files as
+// not interesting source, in the order they are reported.
+var gitattributeSignals = []string{"export-ignore", "linguist-vendored", "linguist-generated"}
+
+// attrRule is one .gitattributes line: a pattern and the attributes it sets
+// (true) or unsets (false).
+type attrRule struct {
+	pattern string
+	attrs   map[string]bool
+}
+
+// gitattributes answers which honored attributes are set on a path, reading
+// the .gitattributes file of each directory from the CWD down on first use.
+// A nil *gitattributes sets nothing.
+type gitattributes struct {
+	cwd     string
+	honored []string
+	mu      sync.Mutex
+	rules   map[string][]attrRule // CWD-relative directory ("" for the CWD) -> rules
+}
+
+// newGitattributes returns nil when no attribute is honored.
+func newGitattributes(cwd string, honored []string) *gitattributes {
+	if len(honored) == 0 {
+		return nil
+	}
+	return &gitattributes{cwd: cwd, honored: honored, rules: make(map[string][]attrRule)}
+}
+
+// excludedBy returns the first honored attribute set on the CWD-relative,
+// slash-separated relPath. As in git, later lines override earlier ones
+// and deeper .gitattributes files override shallower ones. A rule matching
+// a directory applies to everything below it.
+func (g *gitattributes) excludedBy(relPath string) (string, bool) {
+	if g == nil || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return "", false
+	}
+	segments := strings.Split(relPath, "/")
+	state := make(map[string]bool)
+	for depth := 0; depth < len(segments); depth++ {
+		for _, rule := range g.dirRules(strings.Join(segments[:depth], "/")) {
+			if matchAttrPattern(rule.pattern, segments[depth:]) {
+				for attr, set := range rule.attrs {
+					state[attr] = set
+				}
+			}
+		}
+	}
+	for _, attr := range g.honored {
+		if state[attr] {
+			return attr, true
+		}
+	}
+	return "", false
+}
+
+func (g *gitattributes) dirRules(dir string) []attrRule {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	rules, ok := g.rules[dir]
+	if !ok {
+		rules = parseGitattributes(filepath.Join(g.cwd, filepath.FromSlash(dir), ".gitattributes"))
+		g.rules[dir] = rules
+	}
+	return rules
+}
+
+// parseGitattributes reads the rules of one .gitattributes file; a missing
+// or unreadable file has none. Only the attributes codecat acts on are kept.
+func parseGitattributes(file string) []attrRule {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var rules []attrRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		rule := attrRule{pattern: fields[0], attrs: make(map[string]bool)}
+		for _, field := range fields[1:] {
+			name, value, hasValue := strings.Cut(strings.TrimLeft(field, "-!"), "=")
+			if !contains(gitattributeSignals, name) {
+				continue
+			}
+			// "-attr" unsets, "!attr" returns it to unspecified; both mean off here.
+			rule.attrs[name] = !strings.HasPrefix(field, "-") && !strings.HasPrefix(field, "!") && !(hasValue && value == "false")
+		}
+		if len(rule.attrs) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// matchAttrPattern matches a .gitattributes pattern against a path relative
+// to that file's directory, or any directory above it within that
+// directory. A pattern without "/" matches a name at any depth; otherwise it
+// is anchored, and "**" spans directories. A trailing "/" matches only
+// directories.
+func matchAttrPattern(pattern string, rel []string) bool {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	parts := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	for i := 1; i <= len(rel); i++ {
+		if dirOnly && i == len(rel) {
+			break
+		}
+		if anchored {
+			if matchPathSegments(parts, rel[:i]) {
+				return true
+			}
+		} else if match, _ := path.Match(pattern, rel[i-1]); match {
+			return true
+		}
+	}
+	return false
+}
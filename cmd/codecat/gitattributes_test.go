@@ -0,0 +1,97 @@
+// cmd/codecat/gitattributes_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchAttrPattern(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		rel     []string
+		want    bool
+	}{
+		{"*.pb.go", []string{"api", "v1", "types.pb.go"}, true},
+		{"vendor", []string{"third", "vendor", "lib.go"}, true},
+		{"/vendor", []string{"third", "vendor", "lib.go"}, false},
+		{"/vendor", []string{"vendor", "lib.go"}, true},
+		{"docs/**", []string{"docs", "api", "index.md"}, true},
+		{"gen/*.go", []string{"gen", "types.go"}, true},
+		{"gen/*.go", []string{"pkg", "gen", "types.go"}, false},
+		{"dist/", []string{"dist"}, false},
+		{"dist/", []string{"dist", "app.js"}, true},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, matchAttrPattern(tc.pattern, tc.rel), "%s vs %v", tc.pattern, tc.rel)
+	}
+}
+
+func TestGitattributes_ExcludedBy(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		".gitattributes": "# comment\n" +
+			"*.pb.go linguist-generated\n" +
+			"third_party/** linguist-vendored\n" +
+			"/scripts export-ignore\n" +
+			"keep.pb.go -linguist-generated\n" +
+			"*.md text eol=lf\n",
+		"pkg/.gitattributes": "local.pb.go linguist-generated=false\n",
+	})
+	attrs := newGitattributes(tempDir, gitattributeSignals)
+
+	testCases := []struct {
+		path string
+		want string
+	}{
+		{"api/types.pb.go", "linguist-generated"},
+		{"api/keep.pb.go", ""},
+		{"pkg/local.pb.go", ""},
+		{"pkg/other.pb.go", "linguist-generated"},
+		{"third_party/lib/x.go", "linguist-vendored"},
+		{"scripts/release.sh", "export-ignore"},
+		{"pkg/scripts/run.sh", ""},
+		{"README.md", ""},
+		{"../outside.pb.go", ""},
+	}
+	for _, tc := range testCases {
+		attr, ok := attrs.excludedBy(tc.path)
+		assert.Equal(t, tc.want != "", ok, tc.path)
+		assert.Equal(t, tc.want, attr, tc.path)
+	}
+
+	only := newGitattributes(tempDir, []string{"export-ignore"})
+	_, ok := only.excludedBy("api/types.pb.go")
+	assert.False(t, ok, "attributes not honored are ignored")
+	var none *gitattributes
+	_, ok = none.excludedBy("api/types.pb.go")
+	assert.False(t, ok)
+	assert.Nil(t, newGitattributes(tempDir, nil))
+}
+
+func TestGenerate_Gitattributes(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		".gitattributes": "gen/** linguist-generated\n",
+		"main.go":        "package main\n",
+		"gen/types.go":   "package gen\n",
+	})
+	opts := GenerateOptions{
+		CWD:           tempDir,
+		ScanDirs:      []string{tempDir},
+		Extensions:    processExtensions([]string{"go"}),
+		Marker:        "---",
+		Gitattributes: gitattributeSignals,
+		ReportSkipped: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "--- main.go\n")
+	assert.NotContains(t, result.Output, "gen/types.go")
+	assert.Equal(t, []string{"gen/types.go"}, result.Skipped["gitattributes"])
+
+	opts.Gitattributes = nil
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "--- gen/types.go\n")
+}
@@ -0,0 +1,81 @@
+// cmd/codecat/gitdiff.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// diffDocumentPath labels the generated --diff section.
+const diffDocumentPath = "codecat:diff"
+
+// checkDiffRange rejects a --diff value git would read as an option.
+func checkDiffRange(rangeSpec string) error {
+	if rangeSpec == "" || strings.HasPrefix(rangeSpec, "-") {
+		return fmt.Errorf("invalid --diff range %q (want a revision or range such as main..HEAD)", rangeSpec)
+	}
+	return nil
+}
+
+// runGitDiff runs git diff for rangeSpec in dir, limited to and relative to
+// dir. A failure carries git's own message, such as an unknown revision.
+func runGitDiff(dir, rangeSpec string, args ...string) (string, error) {
+	args = append(append([]string{"-C", dir, "diff", "--relative", "--no-color", "--no-ext-diff"}, args...), rangeSpec, "--")
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			err = errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git diff %s: %w", rangeSpec, err)
+	}
+	return string(out), nil
+}
+
+// diffFiles lists the files under dir changed by rangeSpec, relative to dir,
+// for inclusion in full next to the diff. Deleted files are left out.
+func diffFiles(dir, rangeSpec string) ([]string, error) {
+	out, err := runGitDiff(dir, rangeSpec, "--name-only", "--diff-filter=d", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" {
+			continue
+		}
+		// Submodules show up as changed directories.
+		if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil && info.Mode().IsRegular() {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// gitDiffPatch returns the unified diff of rangeSpec under dir. A non-empty
+// prefix is put in front of every path, as codecat multi labels the files of
+// each repository.
+func gitDiffPatch(dir, rangeSpec, prefix string) (string, error) {
+	var args []string
+	if prefix != "" {
+		args = []string{"--src-prefix=a/" + prefix + "/", "--dst-prefix=b/" + prefix + "/"}
+	}
+	return runGitDiff(dir, rangeSpec, args...)
+}
+
+// diffDocument renders the patch as a generated section placed before the
+// files. It returns false when the range changes nothing.
+func diffDocument(patch, rangeSpec string) (Document, bool) {
+	if strings.TrimSpace(patch) == "" {
+		return Document{}, false
+	}
+	return Document{
+		Path:    diffDocumentPath,
+		Content: patch,
+		Meta:    []string{fmt.Sprintf("generated section: git diff %s; the changed files follow in full", rangeSpec)},
+	}, true
+}
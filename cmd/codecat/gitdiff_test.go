@@ -0,0 +1,78 @@
+// cmd/codecat/gitdiff_test.go
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDiffRange(t *testing.T) {
+	assert.NoError(t, checkDiffRange("main..HEAD"))
+	assert.NoError(t, checkDiffRange("HEAD~3"))
+	assert.Error(t, checkDiffRange(""))
+	assert.Error(t, checkDiffRange("--output=/tmp/x"))
+}
+
+func TestGenerate_Diff(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"api/main.go":  "package main\n",
+		"api/old.go":   "package main // old\n",
+		"api/other.go": "package main // untouched\n",
+		"README.md":    "# App\n",
+	})
+	initTestRepo(t, tempDir)
+	commitTestFile(t, tempDir, "api/main.go", "package main\n\nfunc main() {}\n", "Add main")
+	out, err := exec.Command("git", "-C", tempDir, "rm", "-q", "api/old.go").CombinedOutput()
+	require.NoError(t, err, string(out))
+	commitTestFile(t, tempDir, "README.md", "# App\n\nUsage.\n", "Document usage")
+
+	files, err := diffFiles(tempDir, "HEAD~2..HEAD")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"README.md", "api/main.go"}, files, "deleted files left out")
+
+	_, err = diffFiles(tempDir, "nosuchrev..HEAD")
+	assert.ErrorContains(t, err, "git diff nosuchrev..HEAD")
+
+	result, err := generate(GenerateOptions{
+		CWD:         tempDir,
+		Extensions:  processExtensions([]string{"go"}),
+		Marker:      "---",
+		NoScan:      true,
+		ManualFiles: files,
+		DiffRange:   "HEAD~2..HEAD",
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Documents, 3)
+	diff := result.Documents[0]
+	assert.Equal(t, diffDocumentPath, diff.Path, "before the files")
+	assert.Equal(t, []string{"generated section: git diff HEAD~2..HEAD; the changed files follow in full"}, diff.Meta)
+	assert.Contains(t, diff.Content, "diff --git a/api/main.go b/api/main.go\n")
+	assert.Contains(t, diff.Content, "deleted file mode", "deletions stay in the diff")
+	assert.Contains(t, diff.Content, "+Usage.\n")
+	assert.NotContains(t, result.Output, "untouched")
+
+	result, err = generate(GenerateOptions{
+		CWD:        filepath.Join(tempDir, "api"),
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+		NoScan:     true,
+		DiffRange:  "HEAD~2..HEAD",
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Documents, 1)
+	assert.Contains(t, result.Documents[0].Content, "diff --git a/main.go b/main.go\n", "relative to the CWD")
+	assert.NotContains(t, result.Documents[0].Content, "README.md")
+
+	result, err = generate(GenerateOptions{
+		CWD:       tempDir,
+		Marker:    "---",
+		NoScan:    true,
+		DiffRange: "HEAD..HEAD",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, result.Documents, "no changes, no section")
+}
@@ -0,0 +1,174 @@
+// cmd/codecat/github.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// githubClient fetches --github-issue threads; requests give up after a
+// minute rather than hang the run.
+var githubClient = &http.Client{Timeout: time.Minute}
+
+const (
+	githubPerPage     = 100
+	githubMaxComments = 1000 // comments fetched per issue at most
+)
+
+// issueRef names one issue or pull request.
+type issueRef struct {
+	Owner, Repo string
+	Number      int
+}
+
+func (r issueRef) String() string { return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number) }
+
+var (
+	issueRefPattern = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)#(\d+)$`)
+	issueURLPattern = regexp.MustCompile(`^https?://[^/]+/([\w.-]+)/([\w.-]+)/(?:issues|pull)/(\d+)/?(?:[#?].*)?$`)
+)
+
+// parseIssueRef accepts "owner/repo#123" or an issue or pull request URL.
+func parseIssueRef(s string) (issueRef, error) {
+	m := issueRefPattern.FindStringSubmatch(s)
+	if m == nil {
+		m = issueURLPattern.FindStringSubmatch(s)
+	}
+	if m == nil {
+		return issueRef{}, fmt.Errorf("invalid issue %q (want owner/repo#123 or an issue URL)", s)
+	}
+	n, err := strconv.Atoi(m[3])
+	if err != nil || n <= 0 {
+		return issueRef{}, fmt.Errorf("invalid issue number in %q", s)
+	}
+	return issueRef{Owner: m[1], Repo: m[2], Number: n}, nil
+}
+
+// githubAPIURL is the API root: GITHUB_API_URL, as set for GitHub
+// Enterprise and in GitHub Actions, or api.github.com.
+func githubAPIURL() string {
+	return strings.TrimRight(tern(os.Getenv("GITHUB_API_URL") == "", "https://api.github.com", os.Getenv("GITHUB_API_URL")), "/")
+}
+
+// githubToken reads GITHUB_TOKEN, else GH_TOKEN; public repositories work
+// without one, at a lower rate limit.
+func githubToken() string {
+	return tern(os.Getenv("GITHUB_TOKEN") != "", os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN"))
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubIssue struct {
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	HTMLURL   string     `json:"html_url"`
+	User      githubUser `json:"user"`
+	CreatedAt string     `json:"created_at"`
+	Labels    []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+type githubComment struct {
+	Body      string     `json:"body"`
+	User      githubUser `json:"user"`
+	CreatedAt string     `json:"created_at"`
+}
+
+// githubGet decodes the JSON answer to an API GET into v.
+func githubGet(client *http.Client, url string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token := githubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s response: %w", url, err)
+	}
+	return nil
+}
+
+// fetchIssueDocument fetches an issue or pull request with its conversation
+// comments and renders them as one document. Review comments on a pull
+// request's diff are a separate thread and are not included.
+func fetchIssueDocument(client *http.Client, ref issueRef) (Document, error) {
+	base := fmt.Sprintf("%s/repos/%s/%s/issues/%d", githubAPIURL(), ref.Owner, ref.Repo, ref.Number)
+	var issue githubIssue
+	if err := githubGet(client, base, &issue); err != nil {
+		return Document{}, fmt.Errorf("fetching %s: %w", ref, err)
+	}
+	var comments []githubComment
+	for page := 1; len(comments) < githubMaxComments; page++ {
+		var batch []githubComment
+		if err := githubGet(client, fmt.Sprintf("%s/comments?per_page=%d&page=%d", base, githubPerPage, page), &batch); err != nil {
+			return Document{}, fmt.Errorf("fetching the comments of %s: %w", ref, err)
+		}
+		comments = append(comments, batch...)
+		if len(batch) < githubPerPage {
+			break
+		}
+	}
+	return issueDocument(ref, issue, comments), nil
+}
+
+// issueDocument renders a fetched thread: the title and facts, the body,
+// then each comment under a "Comment by" line.
+func issueDocument(ref issueRef, issue githubIssue, comments []githubComment) Document {
+	var b strings.Builder
+	kind := tern(issue.PullRequest != nil, "Pull request", "Issue")
+	fmt.Fprintf(&b, "%s %s: %s\n", kind, ref, issue.Title)
+	fmt.Fprintf(&b, "State: %s, opened by @%s on %s\n", issue.State, issue.User.Login, githubDate(issue.CreatedAt))
+	if issue.HTMLURL != "" {
+		fmt.Fprintf(&b, "URL: %s\n", issue.HTMLURL)
+	}
+	if len(issue.Labels) > 0 {
+		names := make([]string, len(issue.Labels))
+		for i, l := range issue.Labels {
+			names[i] = l.Name
+		}
+		fmt.Fprintf(&b, "Labels: %s\n", strings.Join(names, ", "))
+	}
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		b.WriteString("\n" + body + "\n")
+	}
+	for _, c := range comments {
+		fmt.Fprintf(&b, "\nComment by @%s on %s:\n%s\n", c.User.Login, githubDate(c.CreatedAt), strings.TrimSpace(c.Body))
+	}
+	return Document{
+		Path:    "github:" + ref.String(),
+		Content: b.String(),
+		Meta:    []string{fmt.Sprintf("fetched from GitHub: %s with %d comment(s)", strings.ToLower(kind), len(comments))},
+	}
+}
+
+// githubDate shortens an API timestamp to its date.
+func githubDate(timestamp string) string {
+	if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		return t.UTC().Format(time.DateOnly)
+	}
+	return timestamp
+}
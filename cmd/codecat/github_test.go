@@ -0,0 +1,95 @@
+// cmd/codecat/github_test.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIssueRef(t *testing.T) {
+	for input, want := range map[string]issueRef{
+		"acme/app#42": {"acme", "app", 42},
+		"https://github.com/acme/app.js/issues/7":       {"acme", "app.js", 7},
+		"https://github.com/acme/app/pull/9/":           {"acme", "app", 9},
+		"https://ghe.example.com/acme/app/pull/9#issue": {"acme", "app", 9},
+	} {
+		ref, err := parseIssueRef(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, ref, input)
+	}
+	for _, bad := range []string{"acme/app", "app#1", "acme/app#0", "https://github.com/acme/app/commit/1"} {
+		_, err := parseIssueRef(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// githubTestServer answers the issue and comments endpoints of acme/app#42,
+// with comments spread over two pages.
+func githubTestServer(t *testing.T, comments int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/repos/acme/app/issues/42":
+			fmt.Fprint(w, `{"title":"Crash on empty input","body":"Steps:\n1. run it\n","state":"open",
+				"html_url":"https://github.com/acme/app/issues/42","user":{"login":"jane"},
+				"created_at":"2025-06-01T10:00:00Z","labels":[{"name":"bug"}]}`)
+		case "/repos/acme/app/issues/42/comments":
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			first := (max(page, 1) - 1) * githubPerPage
+			batch := []githubComment{}
+			for i := first; i < comments && i < first+githubPerPage; i++ {
+				batch = append(batch, githubComment{Body: fmt.Sprintf("comment %d", i+1), User: githubUser{Login: "joe"}, CreatedAt: "2025-06-02T08:00:00Z"})
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(batch))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GITHUB_TOKEN", "tok")
+	return srv
+}
+
+func TestFetchIssueDocument(t *testing.T) {
+	srv := githubTestServer(t, githubPerPage+1)
+	doc, err := fetchIssueDocument(srv.Client(), issueRef{"acme", "app", 42})
+	require.NoError(t, err)
+	assert.Equal(t, "github:acme/app#42", doc.Path)
+	assert.True(t, strings.HasPrefix(doc.Content, "Issue acme/app#42: Crash on empty input\n"+
+		"State: open, opened by @jane on 2025-06-01\n"+
+		"URL: https://github.com/acme/app/issues/42\n"+
+		"Labels: bug\n\n"+
+		"Steps:\n1. run it\n\n"+
+		"Comment by @joe on 2025-06-02:\ncomment 1\n"), doc.Content)
+	assert.Contains(t, doc.Content, "comment 101\n", "second page")
+	assert.Equal(t, []string{"fetched from GitHub: issue with 101 comment(s)"}, doc.Meta)
+
+	_, err = fetchIssueDocument(srv.Client(), issueRef{"acme", "app", 7})
+	assert.ErrorContains(t, err, "fetching acme/app#7")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestGenerate_GitHubIssue(t *testing.T) {
+	githubTestServer(t, 1)
+	tempDir := setupTestDir(t, map[string]string{"main.go": "package main\n"})
+	result, err := generate(GenerateOptions{
+		CWD:          tempDir,
+		ScanDirs:     []string{tempDir},
+		Extensions:   processExtensions([]string{"go"}),
+		Marker:       "---",
+		GitHubIssues: []string{"acme/app#42"},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Documents, 2)
+	assert.Equal(t, "github:acme/app#42", result.Documents[0].Path, "before the files")
+	assert.Contains(t, result.Output, "--- github:acme/app#42\n--- fetched from GitHub: issue with 1 comment(s)\nIssue acme/app#42:")
+}
@@ -0,0 +1,125 @@
+// cmd/codecat/gitmeta.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// annotationKinds lists the values accepted by --annotate.
+var annotationKinds = []string{"git"}
+
+// fileAnnotator returns extra metadata lines written under a file's header.
+type fileAnnotator func(absPath string) []string
+
+// newFileAnnotator builds the annotator for --annotate; nil when disabled.
+func newFileAnnotator(kind string) fileAnnotator {
+	switch kind {
+	case "git":
+		return gitFileAnnotation
+	default:
+		return nil
+	}
+}
+
+// gitFileAnnotation describes the last commit touching absPath, shelling out to
+// git in the file's directory so nested repositories resolve correctly.
+func gitFileAnnotation(absPath string) []string {
+	cmd := exec.Command("git", "-C", filepath.Dir(absPath), "log", "-1",
+		"--format=%h%x09%an%x09%ad%x09%s", "--date=short", "--", filepath.Base(absPath))
+	out, err := cmd.Output()
+	if err != nil {
+		slog.Debug("git log failed for annotation.", "path", absPath, "error", err)
+		return []string{"git: unavailable"}
+	}
+	line := strings.TrimSpace(string(out))
+	if line == "" {
+		return []string{"git: not committed"}
+	}
+	return []string{formatGitAnnotation(line)}
+}
+
+// formatGitAnnotation turns a tab-separated "hash author date subject" line into the annotation text.
+func formatGitAnnotation(line string) string {
+	parts := strings.SplitN(line, "\t", 4)
+	if len(parts) < 4 {
+		return "git: " + line
+	}
+	subject := parts[3]
+	if runes := []rune(subject); len(runes) > 72 {
+		subject = string(runes[:69]) + "..."
+	}
+	return fmt.Sprintf("git: %s %s by %s: %q", parts[0], parts[2], parts[1], subject)
+}
+
+// gitTrackedFiles lists the files in the git index under dir (git ls-files),
+// keyed by cleaned absolute path.
+func gitTrackedFiles(dir string) (map[string]bool, error) {
+	out, err := exec.Command("git", "-C", dir, "ls-files", "-z").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git ls-files in %s: %s", dir, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git ls-files in %s: %w", dir, err)
+	}
+	tracked := make(map[string]bool)
+	for _, rel := range strings.Split(string(out), "\x00") {
+		if rel != "" {
+			tracked[filepath.Clean(filepath.Join(dir, filepath.FromSlash(rel)))] = true
+		}
+	}
+	return tracked, nil
+}
+
+// nestedRepoPolicies are the accepted --nested-repos values.
+var nestedRepoPolicies = []string{"include", "skip", "separate"}
+
+// nestedRepoFinder locates git repositories (including submodules, whose .git
+// is a file) nested below the walk root. Lookups are cached per directory.
+type nestedRepoFinder struct {
+	root  string
+	cache map[string]string // directory -> outermost nested repo root, "" for none
+}
+
+func newNestedRepoFinder(root string) *nestedRepoFinder {
+	return &nestedRepoFinder{root: filepath.Clean(root), cache: make(map[string]string)}
+}
+
+// repoRoot returns the outermost nested repository containing absPath, or ""
+// when the path belongs to the repository (if any) of the walk root itself.
+func (n *nestedRepoFinder) repoRoot(absPath string) string {
+	return n.dirRepoRoot(filepath.Dir(absPath))
+}
+
+func (n *nestedRepoFinder) dirRepoRoot(dir string) string {
+	if dir == n.root || !strings.HasPrefix(dir, n.root+string(filepath.Separator)) {
+		return ""
+	}
+	if root, ok := n.cache[dir]; ok {
+		return root
+	}
+	root := n.dirRepoRoot(filepath.Dir(dir))
+	if root == "" {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			root = dir
+		}
+	}
+	n.cache[dir] = root
+	return root
+}
+
+// gitTopLevel returns the root of the git work tree containing dir.
+func gitTopLevel(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git rev-parse in %s: %s", dir, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git rev-parse in %s: %w", dir, err)
+	}
+	return filepath.Clean(strings.TrimSpace(string(out))), nil
+}
@@ -0,0 +1,130 @@
+// cmd/codecat/gitmeta_test.go
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initTestRepo turns dir into a git repository with one commit of everything in it.
+func initTestRepo(t *testing.T, dir string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=Tester", "-c", "user.email=t@example.com", "commit", "-q", "-m", "Initial import"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+}
+
+func TestFormatGitAnnotation(t *testing.T) {
+	assert.Equal(t, `git: abc1234 2025-06-12 by Jane Doe: "Fix walker"`,
+		formatGitAnnotation("abc1234\tJane Doe\t2025-06-12\tFix walker"))
+	assert.Equal(t, "git: garbage", formatGitAnnotation("garbage"))
+	long := strings.Repeat("é", 80)
+	assert.Equal(t, `git: abc1234 2025-06-12 by Jane Doe: "`+strings.Repeat("é", 69)+`..."`,
+		formatGitAnnotation("abc1234\tJane Doe\t2025-06-12\t"+long), "truncated by characters, not bytes")
+}
+
+func TestGitFileAnnotation(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"committed.go": "package a"})
+	initTestRepo(t, tempDir)
+
+	meta := gitFileAnnotation(filepath.Join(tempDir, "committed.go"))
+	require.Len(t, meta, 1)
+	assert.Regexp(t, `^git: [0-9a-f]+ \d{4}-\d{2}-\d{2} by Tester: "Initial import"$`, meta[0])
+
+	assert.Equal(t, []string{"git: unavailable"}, gitFileAnnotation(filepath.Join(t.TempDir(), "loose.go")))
+}
+
+func TestGenerate_GitTracked(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"tracked.go":  "package a",
+		"sub/also.go": "package sub",
+	})
+	initTestRepo(t, tempDir)
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "scratch.go"), []byte("package scratch"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "manual_new.txt"), []byte("manual"), 0644))
+
+	result, err := generate(GenerateOptions{
+		CWD:         tempDir,
+		ScanDirs:    []string{tempDir},
+		Extensions:  processExtensions([]string{"go"}),
+		ManualFiles: []string{"manual_new.txt"},
+		Marker:      "---",
+		GitTracked:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"manual_new.txt", "sub/also.go", "tracked.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.Equal(t, 1, result.ExcludedBy["untracked"])
+
+	notRepo := t.TempDir()
+	_, err = generate(GenerateOptions{
+		CWD:        notRepo,
+		ScanDirs:   []string{notRepo},
+		Extensions: processExtensions([]string{"go"}),
+		GitTracked: true,
+	})
+	assert.ErrorContains(t, err, "--git-tracked")
+}
+
+func TestNestedRepoFinder(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		".git/HEAD":           "ref: refs/heads/main",
+		"main.go":             "package main",
+		"vendor/lib/.git":     "gitdir: ../../.git/modules/lib",
+		"vendor/lib/lib.go":   "package lib",
+		"vendor/lib/sub/x.go": "package sub",
+	})
+	finder := newNestedRepoFinder(tempDir)
+	libRoot := filepath.Join(tempDir, "vendor", "lib")
+
+	assert.Empty(t, finder.repoRoot(filepath.Join(tempDir, "main.go")), "the walk root's own repo is not nested")
+	assert.Equal(t, libRoot, finder.repoRoot(filepath.Join(libRoot, "lib.go")))
+	assert.Equal(t, libRoot, finder.repoRoot(filepath.Join(libRoot, "sub", "x.go")))
+}
+
+func TestGenerate_NestedRepos(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":         "package main\n",
+		"lib/.git":        "gitdir: ../.git/modules/lib",
+		"lib/lib.go":      "package lib\n",
+		"other/.git/HEAD": "ref: refs/heads/main",
+		"other/o.go":      "package other\n",
+	})
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+	}
+
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lib/lib.go", "main.go", "other/o.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.Equal(t, []string{"lib", "other"}, result.NestedRepos)
+
+	opts.NestedRepos = "skip"
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.Equal(t, 2, result.ExcludedBy["nested-repo"])
+
+	opts.NestedRepos = "separate"
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, "--- main.go\npackage main\n---\n"+
+		"--- === nested repository: lib ===\n--- lib/lib.go\npackage lib\n---\n"+
+		"--- === nested repository: other ===\n--- other/o.go\npackage other\n---\n", result.Output)
+}
@@ -0,0 +1,91 @@
+// cmd/codecat/grep.go
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// grepFilter keeps only files whose path or content matches a regular
+// expression, for --grep. A nil filter keeps everything.
+type grepFilter struct {
+	re      *regexp.Regexp
+	context int // lines kept around each matching line; 0 keeps whole files
+}
+
+// newGrepFilter compiles expr (Go RE2 syntax), matching whatever the case
+// with ignoreCase. An empty expr returns a nil filter.
+func newGrepFilter(expr string, context int, ignoreCase bool) (*grepFilter, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	if context < 0 {
+		return nil, fmt.Errorf("--grep-context must not be negative")
+	}
+	re, err := regexp.Compile(tern(ignoreCase, "(?i)", "") + expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --grep expression: %w", err)
+	}
+	return &grepFilter{re: re, context: context}, nil
+}
+
+// matchesPath reports whether the CWD-relative path matches.
+func (g *grepFilter) matchesPath(relPath string) bool {
+	return g == nil || g.re.MatchString(relPath)
+}
+
+// matches reports whether the file should be kept: its path or content
+// matches.
+func (g *grepFilter) matches(relPath string, content []byte) bool {
+	return g.matchesPath(relPath) || g.re.Match(content)
+}
+
+// regions cuts content down to the matching lines and the context lines
+// around them, marking each gap. Content without matching lines (a file
+// kept for its path) and filters without a context are returned unchanged.
+func (g *grepFilter) regions(content []byte) []byte {
+	if g == nil || g.context == 0 {
+		return content
+	}
+	text := string(content)
+	trailingNewline := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	keep := make([]bool, len(lines))
+	found := false
+	for i, line := range lines {
+		if !g.re.MatchString(line) {
+			continue
+		}
+		found = true
+		for j := max(0, i-g.context); j <= min(len(lines)-1, i+g.context); j++ {
+			keep[j] = true
+		}
+	}
+	if !found {
+		return content
+	}
+
+	var kept []string
+	omitted := 0
+	for i, line := range lines {
+		if !keep[i] {
+			omitted++
+			continue
+		}
+		if omitted > 0 {
+			kept = append(kept, fmt.Sprintf("... (%d lines omitted) ...", omitted))
+			omitted = 0
+		}
+		kept = append(kept, line)
+	}
+	if omitted > 0 {
+		kept = append(kept, fmt.Sprintf("... (%d lines omitted) ...", omitted))
+	}
+	out := strings.Join(kept, "\n")
+	if trailingNewline {
+		out += "\n"
+	}
+	return []byte(out)
+}
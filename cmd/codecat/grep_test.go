@@ -0,0 +1,82 @@
+// cmd/codecat/grep_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGrepFilter(t *testing.T) {
+	g, err := newGrepFilter("", 3, false)
+	require.NoError(t, err)
+	assert.Nil(t, g)
+
+	_, err = newGrepFilter("pay(", 0, false)
+	assert.ErrorContains(t, err, "invalid --grep expression")
+	_, err = newGrepFilter("pay", -1, false)
+	assert.Error(t, err)
+}
+
+func TestGrepFilter_Matches(t *testing.T) {
+	g, err := newGrepFilter("payment|invoice", 0, false)
+	require.NoError(t, err)
+	assert.True(t, g.matches("billing/invoice.go", []byte("package billing\n")), "path match")
+	assert.True(t, g.matches("api.go", []byte("func payment() {}\n")), "content match")
+	assert.False(t, g.matches("api.go", []byte("func users() {}\n")))
+
+	var none *grepFilter
+	assert.True(t, none.matches("api.go", nil))
+
+	folded, err := newGrepFilter("Payment", 0, true)
+	require.NoError(t, err)
+	assert.True(t, folded.matches("api.go", []byte("func payment() {}\n")), "--ignore-case")
+	assert.False(t, g.matches("api.go", []byte("func Payment() {}\n")))
+}
+
+func TestGrepFilter_Regions(t *testing.T) {
+	content := []byte("l1\nl2\npayment\nl4\nl5\nl6\nl7\ninvoice\nl9\n")
+	g, err := newGrepFilter("payment|invoice", 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, "... (1 lines omitted) ...\nl2\npayment\nl4\n... (2 lines omitted) ...\nl7\ninvoice\nl9\n", string(g.regions(content)))
+
+	g.context = 3
+	assert.Equal(t, "l1\nl2\npayment\nl4\nl5\nl6\nl7\ninvoice\nl9\n", string(g.regions(content)), "overlapping regions merge")
+
+	assert.Equal(t, "no match\n", string(g.regions([]byte("no match\n"))), "files kept for their path stay whole")
+
+	g.context = 0
+	assert.Equal(t, content, g.regions(content))
+}
+
+func TestGenerate_Grep(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"billing/payment.go": "package billing\n",
+		"api/handler.go":     "package api\n\nfunc a() {}\n\n// creates an invoice\nfunc b() {}\n",
+		"api/users.go":       "package api\n",
+	})
+	opts := GenerateOptions{
+		CWD:           tempDir,
+		ScanDirs:      []string{tempDir},
+		Extensions:    processExtensions([]string{"go"}),
+		Grep:          "payment|invoice",
+		GrepContext:   1,
+		ReportSkipped: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	var paths []string
+	for _, f := range result.IncludedFiles {
+		paths = append(paths, f.Path)
+	}
+	assert.ElementsMatch(t, []string{"billing/payment.go", "api/handler.go"}, paths)
+	assert.Equal(t, []string{"api/users.go"}, result.Skipped["grep"])
+	assert.Equal(t, 1, result.ExcludedBy["grep"])
+	assert.Contains(t, result.Output, "... (3 lines omitted) ...\n\n// creates an invoice\nfunc b() {}\n")
+
+	opts.SkipContent = true
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Len(t, result.IncludedFiles, 2)
+}
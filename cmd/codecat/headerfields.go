@@ -0,0 +1,82 @@
+// cmd/codecat/headerfields.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// headerFieldNames lists the values accepted by --header-fields.
+var headerFieldNames = []string{"size", "mtime", "mode", "lang", "tokens"}
+
+// headerFields returns the requested metadata of doc. Fields that do not
+// apply, such as the modification time of a generated document, are left
+// out.
+func headerFields(doc Document, names []string) map[string]string {
+	fields := make(map[string]string, len(names))
+	for _, name := range names {
+		var value string
+		switch name {
+		case "size":
+			value = fmt.Sprint(doc.size())
+		case "mtime":
+			if !doc.modTime.IsZero() {
+				value = doc.modTime.UTC().Format(time.RFC3339)
+			}
+		case "mode":
+			if doc.mode != 0 {
+				value = fmt.Sprintf("%04o", doc.mode.Perm())
+			}
+		case "lang":
+			value = languageForPath(doc.Path)
+		case "tokens":
+			value = fmt.Sprint(estimateTokens(int64(doc.size())))
+		}
+		if value != "" {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+// formatHeaderFields renders fields as "name=value" pairs in the order
+// they were requested, for the text and Markdown headers. Values with
+// spaces, like lang="Go Module", are quoted.
+func formatHeaderFields(fields map[string]string, names []string) string {
+	var pairs []string
+	for _, name := range names {
+		if value, ok := fields[name]; ok {
+			if strings.ContainsAny(value, " \t\"") {
+				value = strconv.Quote(value)
+			}
+			pairs = append(pairs, name+"="+value)
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+// headerPath renders a path for a text or Markdown header, quoted in Go
+// syntax under --quote-paths. Paths that would break the header or hide what
+// it says are quoted regardless: those with a line break or another control
+// character, a bidirectional override or an unusual space. So is a path
+// starting with a quote, so that a header starting with one always holds a
+// quoted path.
+func headerPath(p string, quote bool) string {
+	unsafe := strings.IndexFunc(p, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0
+	if quote || unsafe || strings.HasPrefix(p, `"`) {
+		return strconv.Quote(p)
+	}
+	return p
+}
+
+// withHeaderFields returns the document's path followed by its fields, as
+// written on its marker line.
+func withHeaderFields(doc Document, names []string, quote bool) string {
+	if fields := formatHeaderFields(doc.Fields, names); fields != "" {
+		return headerPath(doc.Path, quote) + " " + fields
+	}
+	return headerPath(doc.Path, quote)
+}
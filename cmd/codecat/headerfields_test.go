@@ -0,0 +1,59 @@
+// cmd/codecat/headerfields_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderFields(t *testing.T) {
+	doc := Document{Path: "go.mod", Content: strings.Repeat("x", 40), modTime: time.Date(2025, 3, 1, 12, 0, 0, 0, time.FixedZone("", 3600)), mode: 0640}
+	fields := headerFields(doc, []string{"tokens", "size", "mtime", "mode", "lang"})
+	assert.Equal(t, map[string]string{"size": "40", "mtime": "2025-03-01T11:00:00Z", "mode": "0640", "lang": "Go Module", "tokens": "10"}, fields)
+	assert.Equal(t, `tokens=10 lang="Go Module" size=40`, formatHeaderFields(fields, []string{"tokens", "lang", "size"}))
+
+	generated := headerFields(Document{Path: "IMPORTS.md", Content: "x"}, []string{"mtime", "mode", "size"})
+	assert.Equal(t, map[string]string{"size": "1"}, generated, "no file on disk, no mtime or mode")
+}
+
+func TestHeaderPath(t *testing.T) {
+	assert.Equal(t, "src/🚀 app.go", headerPath("src/🚀 app.go", false))
+	assert.Equal(t, `"src/🚀 app.go"`, headerPath("src/🚀 app.go", true))
+	assert.Equal(t, `"a\nb.go"`, headerPath("a\nb.go", false), "newline forces quoting")
+	assert.Equal(t, `"\u202egnp.go"`, headerPath("\u202egnp.go", false), "bidi override forces quoting")
+	assert.Equal(t, `"\"q.go"`, headerPath(`"q.go`, false), "a leading quote would read as quoted")
+}
+
+func TestGenerate_HeaderFields(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"main.go": "package main\n", "notes.txt": "hi\n"})
+	mtime := time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(tempDir, "main.go"), mtime, mtime))
+	opts := GenerateOptions{
+		CWD:          tempDir,
+		ScanDirs:     []string{tempDir},
+		ManualFiles:  []string{"notes.txt"},
+		Extensions:   processExtensions([]string{"go"}),
+		Marker:       "---",
+		HeaderFields: []string{"size", "mtime", "lang"},
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "--- main.go size=13 mtime=2024-06-01T08:30:00Z lang=Go\npackage main\n")
+	assert.Contains(t, result.Output, "--- notes.txt size=3 mtime=", "files given with -f too")
+
+	var xml strings.Builder
+	require.NoError(t, formatXML(&xml, result, opts))
+	assert.Contains(t, xml.String(), `<document index="2" size="13" mtime="2024-06-01T08:30:00Z" lang="Go">`)
+	var md strings.Builder
+	require.NoError(t, formatMarkdown(&md, result, opts))
+	assert.Contains(t, md.String(), "## main.go size=13 mtime=2024-06-01T08:30:00Z lang=Go\n")
+	var js strings.Builder
+	require.NoError(t, formatJSON(&js, result, opts))
+	assert.Contains(t, js.String(), `"mtime": "2024-06-01T08:30:00Z"`)
+}
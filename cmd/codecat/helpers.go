@@ -3,9 +3,12 @@ package main
 
 import (
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"math"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -46,23 +49,65 @@ func mapsKeys[M ~map[K]V, K comparable, V any](m M) []K {
 	})
 	return r
 }
+
+// siUnits makes formatBytes report decimal units (kB, MB; --si) rather than
+// binary ones (KiB, MiB).
+var siUnits bool
+
 func formatBytes(b int64) string {
-	const unit = 1024
+	unit, prefixes, suffix := int64(1024), "KMGTPE", "iB"
+	if siUnits {
+		unit, prefixes, suffix = 1000, "kMGTPE", "B"
+	}
 	if b < unit {
 		return fmt.Sprintf("%d B", b)
 	}
-	div, exp := int64(unit), 0
+	div, exp := unit, 0
 	for n := b / unit; n >= unit; n /= unit {
 		div *= unit
 		exp++
 	}
 	val := float64(b) / float64(div)
-	unitPrefix := "KMGTPE"[exp]
+	unitPrefix := prefixes[exp]
 	if val == float64(int64(val)) {
-		return fmt.Sprintf("%d %ciB", int64(val), unitPrefix)
+		return fmt.Sprintf("%d %c%s", int64(val), unitPrefix, suffix)
+	}
+	return fmt.Sprintf("%.1f %c%s", val, unitPrefix, suffix)
+}
+
+// formatCount writes n with its thousands grouped by commas (12,345).
+func formatCount(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// parseSize reads a byte size such as "500", "64k", "10MB" or "1.5GiB".
+// Units are binary (k = 1024) to match formatBytes.
+func parseSize(s string) (int64, error) {
+	num := strings.TrimSpace(s)
+	unit := strings.TrimLeft(num, "0123456789.")
+	num = strings.TrimSpace(strings.TrimSuffix(num, unit))
+	multipliers := map[string]float64{"": 1, "k": 1 << 10, "m": 1 << 20, "g": 1 << 30, "t": 1 << 40}
+	key := strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(unit)), "b"), "i")
+	mult, ok := multipliers[key]
+	value, err := strconv.ParseFloat(num, 64)
+	if !ok || err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500k, 10MB, 1.5GiB)", s)
 	}
-	return fmt.Sprintf("%.1f %ciB", val, unitPrefix)
+	return int64(value * mult), nil
 }
+
 func matchesGlob(target string, patterns []string) (bool, string) {
 	for _, pattern := range patterns {
 		match, _ := filepath.Match(pattern, target)
@@ -80,10 +125,13 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
-func appendFileContent(builder *strings.Builder, marker, relPathCwd string, content []byte) {
+func appendFileContent(builder *strings.Builder, marker, relPathCwd string, content []byte, meta []string) {
 	slog.Debug("Adding file content to output.", "path", relPathCwd, "size", len(content))
-	builder.WriteString(fmt.Sprintf("%s %s\n%s%s\n",
-		marker, relPathCwd, string(content), marker))
+	builder.WriteString(fmt.Sprintf("%s %s\n", marker, relPathCwd))
+	for _, line := range meta {
+		builder.WriteString(fmt.Sprintf("%s %s\n", marker, line))
+	}
+	builder.WriteString(fmt.Sprintf("%s%s\n", string(content), marker))
 }
 func tern[T any](condition bool, trueVal, falseVal T) T {
 	if condition {
@@ -91,3 +139,32 @@ func tern[T any](condition bool, trueVal, falseVal T) T {
 	}
 	return falseVal
 }
+
+// specialFileKind names a non-regular, non-directory file mode (FIFO, socket,
+// device), or returns "" for files that are safe to read.
+func specialFileKind(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular() || mode.IsDir():
+		return ""
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "character device"
+	case mode&fs.ModeDevice != 0:
+		return "device"
+	default:
+		return "irregular file"
+	}
+}
+
+// defaultBytesPerToken is the rough ratio for source code and prose.
+const defaultBytesPerToken = 4.0
+
+// bytesPerToken is the ratio estimateTokens uses; --model may change it.
+var bytesPerToken = defaultBytesPerToken
+
+func estimateTokens(sizeBytes int64) int64 {
+	return int64(math.Ceil(float64(sizeBytes) / bytesPerToken))
+}
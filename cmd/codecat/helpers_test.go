@@ -6,6 +6,7 @@ import (
 
 	// Use testify for assertions as the original test likely did
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestProcessExtensions moved from walk_test.go
@@ -63,5 +64,34 @@ func TestProcessExtensions(t *testing.T) {
 // TODO: Add tests for mapsKeys function if needed
 // func TestMapsKeys(t *testing.T) { ... }
 
-// TODO: Add tests for formatBytes function
-// func TestFormatBytes(t *testing.T) { ... }
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "999 B", formatBytes(999))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2 MiB", formatBytes(2<<20))
+
+	siUnits = true
+	defer func() { siUnits = false }()
+	assert.Equal(t, "999 B", formatBytes(999))
+	assert.Equal(t, "1.5 kB", formatBytes(1536))
+	assert.Equal(t, "2.1 MB", formatBytes(2<<20))
+	assert.Equal(t, "3 GB", formatBytes(3e9))
+}
+
+func TestFormatCount(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 999: "999", 1000: "1,000", 12345: "12,345", 1234567: "1,234,567", -4321: "-4,321"} {
+		assert.Equal(t, want, formatCount(n), n)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{"500": 500, "64k": 64 << 10, "10MB": 10 << 20, "1.5GiB": 3 << 29, "2 m": 2 << 20, "7b": 7}
+	for in, want := range cases {
+		got, err := parseSize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "ten", "5 parsecs", "-1k"} {
+		_, err := parseSize(in)
+		assert.Error(t, err, in)
+	}
+}
@@ -0,0 +1,86 @@
+// cmd/codecat/history.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// recentCommitsPath labels the generated --recent-commits section.
+const recentCommitsPath = "codecat:recent-commits"
+
+// commitLog is the recent history of one scan root, for --recent-commits.
+type commitLog struct {
+	Root    string   // CWD-relative scan root
+	Commits []string // newest first: "hash date author: subject", with the --stat summary on a second line
+}
+
+// historyDirs returns the directories --recent-commits reads the history of:
+// the scan roots, or the CWD when nothing is scanned.
+func historyDirs(opts GenerateOptions) []string {
+	if opts.NoScan || len(opts.ScanDirs) == 0 {
+		return []string{opts.CWD}
+	}
+	return opts.ScanDirs
+}
+
+// recentCommits reads the last n commits touching each of dirs, with their
+// --shortstat summary if stat is set. Directories outside a git checkout, or
+// without commits, are left out.
+func recentCommits(cwd string, dirs []string, n int, stat bool) []commitLog {
+	args := []string{"log", "-n", strconv.Itoa(n), "--date=short", "--format=%x1e%h %ad %an: %s"}
+	if stat {
+		args = append(args, "--shortstat")
+	}
+	var logs []commitLog
+	for _, dir := range dirs {
+		out := gitOutput(dir, append(args, "--", ".")...)
+		if out == "" {
+			slog.Debug("No git history for --recent-commits.", "dir", dir)
+			continue
+		}
+		label, err := cwdRelPath(cwd, dir)
+		if err != nil {
+			label = dir
+		}
+		log := commitLog{Root: label}
+		for _, record := range strings.Split(out, "\x1e") {
+			var lines []string
+			for _, line := range strings.Split(record, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			if len(lines) > 0 {
+				log.Commits = append(log.Commits, strings.Join(lines, "\n"))
+			}
+		}
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+// recentCommitsDocument renders the logs as a generated section placed after
+// the files. It returns false when there is no history to show.
+func recentCommitsDocument(logs []commitLog, n int) (Document, bool) {
+	if len(logs) == 0 {
+		return Document{}, false
+	}
+	var b strings.Builder
+	for i, log := range logs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s:\n", log.Root)
+		for _, commit := range log.Commits {
+			b.WriteString("  " + strings.ReplaceAll(commit, "\n", "\n    ") + "\n")
+		}
+	}
+	return Document{
+		Path:    recentCommitsPath,
+		Content: b.String(),
+		Meta:    []string{fmt.Sprintf("generated section: the last %d commits of each scan root, newest first", n)},
+	}, true
+}
@@ -0,0 +1,68 @@
+// cmd/codecat/history_test.go
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commitTestFile rewrites one file in the repository at dir and commits it.
+func commitTestFile(t *testing.T, dir, rel, content, subject string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.FromSlash(rel)), []byte(content), 0644))
+	for _, args := range [][]string{
+		{"add", "-A"},
+		{"-c", "user.name=Tester", "-c", "user.email=t@example.com", "commit", "-q", "-m", subject},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+}
+
+func TestRecentCommitsDocument(t *testing.T) {
+	_, ok := recentCommitsDocument(nil, 5)
+	assert.False(t, ok)
+
+	doc, ok := recentCommitsDocument([]commitLog{
+		{Root: "api", Commits: []string{"abc1234 2025-06-12 Jane: Fix walker\n1 file changed, 2 insertions(+)"}},
+		{Root: "web", Commits: []string{"def5678 2025-06-11 Joe: Add page"}},
+	}, 5)
+	require.True(t, ok)
+	assert.Equal(t, recentCommitsPath, doc.Path)
+	assert.Equal(t, "api:\n  abc1234 2025-06-12 Jane: Fix walker\n    1 file changed, 2 insertions(+)\n\nweb:\n  def5678 2025-06-11 Joe: Add page\n", doc.Content)
+}
+
+func TestGenerate_RecentCommits(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"api/main.go": "package main\n", "web/app.go": "package web\n"})
+	initTestRepo(t, tempDir)
+	commitTestFile(t, tempDir, "api/main.go", "package main\n\nfunc main() {}\n", "Add main")
+	commitTestFile(t, tempDir, "web/app.go", "package web // v2\n", "Touch web")
+
+	opts := GenerateOptions{
+		CWD:               tempDir,
+		ScanDirs:          []string{filepath.Join(tempDir, "api")},
+		Extensions:        processExtensions([]string{"go"}),
+		Marker:            "---",
+		RecentCommits:     5,
+		RecentCommitsStat: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	require.Len(t, result.Documents, 2)
+	last := result.Documents[1]
+	assert.Equal(t, recentCommitsPath, last.Path, "appended after the files")
+	assert.Regexp(t, `^api:\n  [0-9a-f]+ \d{4}-\d{2}-\d{2} Tester: Add main\n    1 file changed, 2 insertions\(\+\)\n  [0-9a-f]+ \d{4}-\d{2}-\d{2} Tester: Initial import\n`, last.Content)
+	assert.NotContains(t, last.Content, "Touch web", "only commits touching the scan root")
+	assert.True(t, strings.HasSuffix(result.Output, last.Content+"---\n"), result.Output)
+
+	opts.RecentCommits, opts.RecentCommitsStat = 1, false
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(result.Documents[1].Content, "Tester:"))
+}
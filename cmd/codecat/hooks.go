@@ -0,0 +1,200 @@
+// cmd/codecat/hooks.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HooksConfig runs external commands at fixed points of a run, so teams can
+// add their own steps (a custom redactor, a notification) without forking.
+// Commands run through the shell in the CWD; hookPayload and hookEnv
+// describe what they receive.
+type HooksConfig struct {
+	PreScan      []string        `toml:"pre_scan"`      // before the scan; a failure aborts the run
+	Transform    []TransformHook `toml:"transform"`     // stdin -> stdout filters for matching files
+	PostGenerate []string        `toml:"post_generate"` // after the outputs are written
+	Timeout      string          `toml:"timeout"`       // per command, e.g. "30s" (the default)
+}
+
+// TransformHook filters the content of files matching Glob through Command,
+// configured as [[hooks.transform]] tables.
+type TransformHook struct {
+	Glob    string `toml:"glob"` // as [priority] globs, e.g. "*.sql" or "services/**"
+	Command string `toml:"command"`
+}
+
+// hookContractVersion is passed to every hook as CODECAT_HOOK_VERSION and
+// in the JSON payload. It changes only when the contract breaks.
+const hookContractVersion = 1
+
+const defaultHookTimeout = 30 * time.Second
+
+// hookPayload is the JSON pre_scan and post_generate hooks read on stdin.
+type hookPayload struct {
+	Version   int               `json:"version"`
+	Hook      string            `json:"hook"` // pre-scan or post-generate
+	CWD       string            `json:"cwd"`
+	ScanDirs  []string          `json:"scan_dirs"`
+	Outputs   []string          `json:"outputs,omitempty"` // output targets: file paths, "-" (stdout) or "clipboard"
+	Files     []FileInfo        `json:"files,omitempty"`   // included files
+	Errors    map[string]string `json:"errors,omitempty"`  // files that could not be read -> error
+	TotalSize int64             `json:"total_size,omitempty"`
+	Tokens    int64             `json:"tokens,omitempty"` // estimated tokens of the text output
+	Partial   bool              `json:"partial,omitempty"`
+}
+
+// hookRunner runs the configured hooks. A nil runner runs nothing.
+type hookRunner struct {
+	cwd     string
+	timeout time.Duration
+	hooks   HooksConfig
+}
+
+// newHookRunner validates the [hooks] section; nil when it sets no hooks.
+func newHookRunner(cwd string, cfg HooksConfig) (*hookRunner, error) {
+	if len(cfg.PreScan) == 0 && len(cfg.Transform) == 0 && len(cfg.PostGenerate) == 0 {
+		return nil, nil
+	}
+	h := &hookRunner{cwd: cwd, timeout: defaultHookTimeout, hooks: cfg}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("[hooks]: invalid timeout %q (e.g. \"30s\")", cfg.Timeout)
+		}
+		h.timeout = timeout
+	}
+	for i, t := range cfg.Transform {
+		if t.Glob == "" || strings.TrimSpace(t.Command) == "" {
+			return nil, fmt.Errorf("[[hooks.transform]] #%d: glob and command are required", i+1)
+		}
+	}
+	return h, nil
+}
+
+// hookEnv is the environment of a hook: codecat's own plus CODECAT_HOOK,
+// CODECAT_HOOK_VERSION and CODECAT_CWD, and for transforms CODECAT_PATH
+// (CWD-relative, with forward slashes) and CODECAT_LANGUAGE.
+func (h *hookRunner) hookEnv(kind string, extra ...string) []string {
+	env := append(os.Environ(),
+		"CODECAT_HOOK="+kind,
+		"CODECAT_HOOK_VERSION="+strconv.Itoa(hookContractVersion),
+		"CODECAT_CWD="+h.cwd)
+	return append(env, extra...)
+}
+
+// run executes command through the shell with stdin and returns its stdout.
+// A non-zero exit is an error that quotes the command's stderr.
+func (h *hookRunner) run(command string, env []string, stdin []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	cmd := shellCommand(ctx, command)
+	cmd.Dir, cmd.Env, cmd.Stdin = h.cwd, env, bytes.NewReader(stdin)
+	cmd.WaitDelay = time.Second // children of the shell may hold its output open
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%q timed out after %s", command, h.timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// shellCommand runs command with sh -c, or cmd /C on Windows.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// runAll runs commands in order with the JSON payload on stdin, stopping at
+// the first failure. What they print goes to the log writer.
+func (h *hookRunner) runAll(commands []string, payload hookPayload) error {
+	if h == nil || len(commands) == 0 {
+		return nil
+	}
+	payload.Version, payload.CWD = hookContractVersion, h.cwd
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	for _, command := range commands {
+		slog.Info("Running hook.", "hook", payload.Hook, "command", command)
+		out, err := h.run(command, h.hookEnv(payload.Hook), data)
+		if err != nil {
+			return fmt.Errorf("%s hook %w", payload.Hook, err)
+		}
+		if len(out) > 0 {
+			slog.Info("Hook output.", "hook", payload.Hook, "command", command, "output", strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// preScan runs the pre_scan hooks.
+func (h *hookRunner) preScan(scanDirs []string) error {
+	if h == nil {
+		return nil
+	}
+	return h.runAll(h.hooks.PreScan, hookPayload{Hook: "pre-scan", ScanDirs: scanDirs})
+}
+
+// postGenerate runs the post_generate hooks with the run's outcome.
+func (h *hookRunner) postGenerate(scanDirs []string, targets []OutputTarget, result GenerateResult) error {
+	if h == nil {
+		return nil
+	}
+	payload := hookPayload{
+		Hook:      "post-generate",
+		ScanDirs:  scanDirs,
+		Files:     result.IncludedFiles,
+		TotalSize: result.TotalSize,
+		Tokens:    estimateTokens(result.OutputSize),
+		Partial:   result.Partial,
+	}
+	for _, t := range targets {
+		payload.Outputs = append(payload.Outputs, t.Path)
+	}
+	if len(result.ErrorFiles) > 0 {
+		payload.Errors = make(map[string]string, len(result.ErrorFiles))
+		for p, err := range result.ErrorFiles {
+			payload.Errors[p] = err.Error()
+		}
+	}
+	return h.runAll(h.hooks.PostGenerate, payload)
+}
+
+// transform filters content through every transform hook whose glob matches
+// relPath, in the configured order. A failing hook fails the file, so its
+// content is never passed on unfiltered.
+func (h *hookRunner) transform(relPath string, content []byte) ([]byte, error) {
+	if h == nil {
+		return content, nil
+	}
+	for _, t := range h.hooks.Transform {
+		if !matchesTreeGlob(t.Glob, relPath) {
+			continue
+		}
+		env := h.hookEnv("transform", "CODECAT_PATH="+relPath, "CODECAT_LANGUAGE="+languageForPath(relPath))
+		out, err := h.run(t.Command, env, content)
+		if err != nil {
+			return nil, err
+		}
+		content = out
+	}
+	return content, nil
+}
@@ -0,0 +1,90 @@
+// cmd/codecat/hooks_test.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHookRunner(t *testing.T) {
+	h, err := newHookRunner("/p", HooksConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, h, "no hooks, no runner")
+
+	_, err = newHookRunner("/p", HooksConfig{Transform: []TransformHook{{Glob: "*.go"}}})
+	assert.ErrorContains(t, err, "[[hooks.transform]] #1: glob and command are required")
+	_, err = newHookRunner("/p", HooksConfig{PreScan: []string{"true"}, Timeout: "soon"})
+	assert.ErrorContains(t, err, `invalid timeout "soon"`)
+}
+
+func TestHookRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands here use sh")
+	}
+	tempDir := setupTestDir(t, map[string]string{"db/schema.sql": "create table users;\n", "main.go": "package main\n"})
+	h, err := newHookRunner(tempDir, HooksConfig{
+		PreScan: []string{"cat > pre.json"},
+		Transform: []TransformHook{
+			{Glob: "*.sql", Command: "tr a-z A-Z"},
+			{Glob: "db/**", Command: `printf -- '-- %s (%s, v%s)\n' "$CODECAT_PATH" "$CODECAT_LANGUAGE" "$CODECAT_HOOK_VERSION"; cat`},
+		},
+		PostGenerate: []string{"cat > post.json"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, h.preScan([]string{tempDir}))
+	var pre hookPayload
+	data, err := os.ReadFile(filepath.Join(tempDir, "pre.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &pre))
+	assert.Equal(t, hookPayload{Version: 1, Hook: "pre-scan", CWD: tempDir, ScanDirs: []string{tempDir}}, pre)
+
+	result, err := generate(GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go", "sql"}),
+		Marker:     "---",
+		Hooks:      h,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "--- db/schema.sql\n-- db/schema.sql (SQL, v1)\nCREATE TABLE USERS;\n", "matching hooks run in order")
+	assert.Contains(t, result.Output, "--- main.go\npackage main\n", "other files are untouched")
+
+	result.ErrorFiles = map[string]error{"secret.go": errors.New("permission denied")}
+	require.NoError(t, h.postGenerate([]string{tempDir}, []OutputTarget{{Format: "text", Path: "out.txt"}}, result))
+	var post hookPayload
+	data, err = os.ReadFile(filepath.Join(tempDir, "post.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &post))
+	assert.Equal(t, "post-generate", post.Hook)
+	assert.Equal(t, []string{"out.txt"}, post.Outputs)
+	assert.Equal(t, map[string]string{"secret.go": "permission denied"}, post.Errors)
+	assert.Len(t, post.Files, 2)
+}
+
+func TestHookRunner_Failures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands here use sh")
+	}
+	h, err := newHookRunner(t.TempDir(), HooksConfig{
+		PreScan:   []string{"echo not ready >&2; exit 3"},
+		Transform: []TransformHook{{Glob: "*", Command: "sleep 5"}},
+		Timeout:   "100ms",
+	})
+	require.NoError(t, err)
+	assert.ErrorContains(t, h.preScan(nil), `pre-scan hook "echo not ready >&2; exit 3": exit status 3: not ready`)
+	_, err = h.transform("a.go", []byte("x"))
+	assert.ErrorContains(t, err, `"sleep 5" timed out after 100ms`)
+
+	var none *hookRunner
+	out, err := none.transform("a.go", []byte("x"))
+	assert.NoError(t, err)
+	assert.Equal(t, "x", string(out))
+}
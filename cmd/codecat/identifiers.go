@@ -0,0 +1,427 @@
+// cmd/codecat/identifiers.go
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// identifierSyntax is what the --obfuscate-identifiers lexer knows about a
+// language: enough to skip comments and string literals and to tell
+// identifiers that must keep their names from project-specific ones.
+type identifierSyntax struct {
+	lineComments []string
+	blockComment [2]string
+	quotes       string // characters that open a string literal
+	rawQuote     byte   // quote with no escapes that may span lines, or 0
+	tripleQuotes bool   // Python's """ and '''
+	prefixes     string // letters that may prefix a string literal (f"...", rb'...')
+	dollar       bool   // $ is an identifier character
+	keywords     map[string]bool
+	builtins     map[string]bool // predeclared names and common standard library members
+	qualifiers   map[string]bool // names whose members keep their names too (console.log)
+	imports      func(content string) []string
+}
+
+// identifierSyntaxes maps languageForPath names to their syntax. Files in
+// other languages pass through unchanged.
+var identifierSyntaxes = map[string]*identifierSyntax{
+	"Go": {
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		rawQuote:     '`',
+		keywords: wordSet(`break case chan const continue default defer else fallthrough for func go goto if
+			import interface map package range return select struct switch type var`),
+		builtins: wordSet(`any append bool byte cap clear close comparable complex complex64 complex128 copy
+			delete error false float32 float64 imag int int8 int16 int32 int64 iota len make max min new nil
+			panic print println real recover rune string true uint uint8 uint16 uint32 uint64 uintptr
+			Error String Len Less Swap Read Write Close Lock Unlock RLock RUnlock Done Err Value Deadline
+			ServeHTTP Unwrap Is As MarshalJSON UnmarshalJSON Format Seek Flush Reset Bytes WriteString
+			Add Wait Header StatusCode Body Context Run Helper Fatal Fatalf Errorf Logf Log Skip Cleanup TempDir main init`),
+		imports: goStdlibImports,
+	},
+	"Python": {
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		tripleQuotes: true,
+		prefixes:     "rRbBfFuU",
+		keywords: wordSet(`False None True and as assert async await break class continue def del elif else
+			except finally for from global if import in is lambda nonlocal not or pass raise return try
+			while with yield match case`),
+		builtins: wordSet(`self cls abs all any ascii bin bool breakpoint bytearray bytes callable chr
+			classmethod compile complex delattr dict dir divmod enumerate eval exec filter float format
+			frozenset getattr globals hasattr hash help hex id input int isinstance issubclass iter len
+			list locals map max memoryview min next object oct open ord pow print property range repr
+			reversed round set setattr slice sorted staticmethod str sum super tuple type vars zip
+			Exception BaseException ValueError TypeError KeyError IndexError AttributeError RuntimeError
+			NotImplementedError StopIteration OSError IOError FileNotFoundError ImportError
+			append extend insert remove pop clear copy count index sort reverse keys values items get
+			update setdefault join split rsplit strip lstrip rstrip replace startswith endswith lower
+			upper encode decode read write readlines close add discard args kwargs`),
+		imports: pythonStdlibImports,
+	},
+	"JavaScript": javaScriptSyntax,
+	"TypeScript": javaScriptSyntax,
+}
+
+var javaScriptSyntax = &identifierSyntax{
+	lineComments: []string{"//"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       "\"'`",
+	rawQuote:     '`',
+	dollar:       true,
+	keywords: wordSet(`break case catch class const continue debugger default delete do else export
+		extends finally for function if import in instanceof new return super switch this throw try
+		typeof var void while with yield let static async await of get set from as true false null
+		undefined interface type enum implements private protected public readonly declare namespace
+		abstract keyof infer is never unknown any string number boolean object symbol bigint`),
+	builtins: wordSet(`require module exports arguments constructor prototype length push pop shift
+		unshift slice splice map filter reduce forEach find findIndex includes indexOf join split
+		replace trim toString valueOf then catch finally resolve reject keys values entries
+		hasOwnProperty toLowerCase toUpperCase startsWith endsWith parseInt parseFloat isNaN
+		setTimeout clearTimeout setInterval clearInterval addEventListener default`),
+	qualifiers: wordSet(`console Math JSON Object Array Promise Number String Boolean Symbol Date
+		RegExp Error TypeError Map Set WeakMap Reflect Proxy Intl window document globalThis process
+		Buffer fetch navigator localStorage`),
+	imports: nodeBuiltinImports,
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	goImportBlock  = regexp.MustCompile(`(?m)^import\s*\(([^)]*)\)`)
+	goImportSingle = regexp.MustCompile(`(?m)^import\s+((?:[\w.]+\s+)?"[^"]+")`)
+	goImportSpec   = regexp.MustCompile(`(?m)^\s*([\w.]+\s+)?"([^"]+)"`)
+)
+
+// goStdlibImports returns the names of the standard library packages a Go
+// file imports: paths whose first element has no dot.
+func goStdlibImports(content string) []string {
+	var specs []string
+	for _, m := range goImportBlock.FindAllStringSubmatch(content, -1) {
+		specs = append(specs, strings.Split(m[1], "\n")...)
+	}
+	for _, m := range goImportSingle.FindAllStringSubmatch(content, -1) {
+		specs = append(specs, m[1])
+	}
+	var names []string
+	for _, spec := range specs {
+		m := goImportSpec.FindStringSubmatch(spec)
+		if m == nil || strings.Contains(strings.SplitN(m[2], "/", 2)[0], ".") {
+			continue
+		}
+		name := strings.TrimSpace(m[1])
+		if name == "" {
+			name = m[2][strings.LastIndexByte(m[2], '/')+1:]
+		}
+		if name != "_" && name != "." {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// pythonStdlib lists the commonly imported standard library modules.
+var pythonStdlib = wordSet(`abc argparse array ast asyncio base64 bisect builtins calendar collections
+	concurrent contextlib copy csv ctypes dataclasses datetime decimal difflib enum errno functools gc
+	getpass glob gzip hashlib heapq hmac html http importlib inspect io ipaddress itertools json
+	logging math mimetypes multiprocessing operator os pathlib pickle platform pprint queue random re
+	secrets select shlex shutil signal socket sqlite3 ssl stat statistics string struct subprocess sys
+	tempfile textwrap threading time timeit traceback types typing unittest urllib uuid warnings
+	weakref xml zipfile zlib`)
+
+var (
+	pythonImport     = regexp.MustCompile(`(?m)^\s*import\s+([\w., ]+)`)
+	pythonFromImport = regexp.MustCompile(`(?m)^\s*from\s+([\w.]+)\s+import\s+\(?([\w, ]+)`)
+)
+
+// pythonStdlibImports returns the names standard library imports bind.
+func pythonStdlibImports(content string) []string {
+	var names []string
+	for _, m := range pythonImport.FindAllStringSubmatch(content, -1) {
+		for _, spec := range strings.Split(m[1], ",") {
+			fields := strings.Fields(spec)
+			if len(fields) == 0 || !pythonStdlib[strings.SplitN(fields[0], ".", 2)[0]] {
+				continue
+			}
+			names = append(names, strings.Split(fields[0], ".")...)
+			names = append(names, fields[len(fields)-1])
+		}
+	}
+	for _, m := range pythonFromImport.FindAllStringSubmatch(content, -1) {
+		if !pythonStdlib[strings.SplitN(m[1], ".", 2)[0]] {
+			continue
+		}
+		names = append(names, strings.Split(m[1], ".")...)
+		for _, spec := range strings.Split(m[2], ",") {
+			if fields := strings.Fields(spec); len(fields) > 0 {
+				names = append(names, fields[len(fields)-1])
+			}
+		}
+	}
+	return names
+}
+
+// nodeBuiltins lists the Node.js modules whose imports keep their names.
+var nodeBuiltins = wordSet(`assert buffer child_process crypto events fs http https net os path
+	process querystring readline stream url util zlib worker_threads`)
+
+var (
+	jsDefaultImport = regexp.MustCompile(`import\s+(?:\*\s+as\s+)?([\w$]+)\s+from\s+["'](?:node:)?([\w/]+)["']`)
+	jsNamedImport   = regexp.MustCompile(`import\s*\{([^}]*)\}\s*from\s+["'](?:node:)?([\w/]+)["']`)
+	jsRequire       = regexp.MustCompile(`(?:const|let|var)\s+([\w$]+|\{[^}]*\})\s*=\s*require\(\s*["'](?:node:)?([\w/]+)["']`)
+)
+
+// nodeBuiltinImports returns the names imports of Node.js modules bind.
+func nodeBuiltinImports(content string) []string {
+	var names []string
+	add := func(binding, module string) {
+		if !nodeBuiltins[strings.SplitN(module, "/", 2)[0]] {
+			return
+		}
+		for _, spec := range strings.Split(strings.Trim(binding, "{} "), ",") {
+			if fields := strings.Fields(strings.ReplaceAll(spec, ":", " as ")); len(fields) > 0 {
+				names = append(names, fields[0], fields[len(fields)-1])
+			}
+		}
+	}
+	for _, re := range []*regexp.Regexp{jsDefaultImport, jsNamedImport, jsRequire} {
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			add(m[1], m[2])
+		}
+	}
+	return names
+}
+
+// identifierObfuscator renames project-specific identifiers for
+// --obfuscate-identifiers. A name always gets the same pseudonym, in every
+// file and on every run with the same map file: the pseudonym is an HMAC of
+// the name under a key kept in the map, so it cannot be reversed by hashing
+// a dictionary of likely names without the map.
+type identifierObfuscator struct {
+	keep map[string]bool // obfuscate_keep: names kept with their members
+
+	mu    sync.Mutex
+	key   []byte
+	names map[string]string // pseudonym -> original name
+	seen  map[string]string // original name -> pseudonym
+}
+
+// identifierMap is the file --identifier-map reads and writes.
+type identifierMap struct {
+	Key         string            `json:"key"`
+	Identifiers map[string]string `json:"identifiers"` // pseudonym -> original name
+}
+
+// newIdentifierObfuscator loads the mapping saved at mapPath, if any, so
+// names keep the pseudonyms of earlier runs; otherwise it draws a new key.
+func newIdentifierObfuscator(keep []string, mapPath string) (*identifierObfuscator, error) {
+	o := &identifierObfuscator{keep: wordSet(strings.Join(keep, " ")), names: make(map[string]string), seen: make(map[string]string)}
+	data, err := os.ReadFile(mapPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		o.key = make([]byte, 32)
+		if _, err := rand.Read(o.key); err != nil {
+			return nil, err
+		}
+		return o, nil
+	case err != nil:
+		return nil, err
+	}
+	var saved identifierMap
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("%s: %w", mapPath, err)
+	}
+	if o.key, err = hex.DecodeString(saved.Key); err != nil || len(o.key) == 0 {
+		return nil, fmt.Errorf("%s: invalid key", mapPath)
+	}
+	for pseudonym, name := range saved.Identifiers {
+		o.names[pseudonym] = name
+		o.seen[name] = pseudonym
+	}
+	return o, nil
+}
+
+// pseudonym returns the replacement for name. It starts with a capital
+// letter when name does, so Go's exported names stay exported.
+func (o *identifierObfuscator) pseudonym(name string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if p, ok := o.seen[name]; ok {
+		return p
+	}
+	mac := hmac.New(sha256.New, o.key)
+	mac.Write([]byte(name))
+	sum := hex.EncodeToString(mac.Sum(nil))
+	first, _ := utf8.DecodeRuneInString(name)
+	prefix := tern(unicode.IsUpper(first), "Ident_", "ident_")
+	p := prefix + sum[:8]
+	for n := 12; o.names[p] != ""; n += 4 { // another name took it
+		p = prefix + sum[:n]
+	}
+	o.names[p] = name
+	o.seen[name] = p
+	return p
+}
+
+// mapping returns pseudonym -> original name for every name renamed so far.
+func (o *identifierObfuscator) mapping() map[string]string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	m := make(map[string]string, len(o.names))
+	for p, name := range o.names {
+		m[p] = name
+	}
+	return m
+}
+
+// save writes the key and mapping as JSON for --identifier-map.
+func (o *identifierObfuscator) save(path string) error {
+	data, err := json.MarshalIndent(identifierMap{Key: hex.EncodeToString(o.key), Identifiers: o.mapping()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// obfuscate renames the identifiers in a file whose language has a known
+// syntax. Comments and string literals are copied unchanged, as are
+// keywords, builtins, imported standard library names and their members.
+func (o *identifierObfuscator) obfuscate(relPath string, content []byte) []byte {
+	syntax := identifierSyntaxes[languageForPath(relPath)]
+	if syntax == nil {
+		return content
+	}
+	text := string(content)
+	qualifiers := make(map[string]bool)
+	if syntax.imports != nil {
+		for _, name := range syntax.imports(text) {
+			qualifiers[name] = true
+		}
+	}
+	var out strings.Builder
+	out.Grow(len(text))
+	afterDot, qualified := false, false // the previous token was a ".", following a kept qualifier
+	for i := 0; i < len(text); {
+		start := i
+		c := text[i]
+		switch {
+		case syntax.skipComment(text, &i) || syntax.skipString(text, &i):
+			afterDot = false
+		case c >= '0' && c <= '9':
+			for i < len(text) && (isIdentByte(text[i], syntax.dollar) || text[i] == '.') {
+				i++
+			}
+			afterDot = false
+		case isIdentStart(text[i:], syntax.dollar):
+			for i < len(text) && isIdentPart(text[i:], syntax.dollar) {
+				_, size := utf8.DecodeRuneInString(text[i:])
+				i += size
+			}
+			name := text[start:i]
+			if syntax.prefixes != "" && i < len(text) && strings.ContainsRune(syntax.quotes, rune(text[i])) &&
+				len(name) <= 2 && strings.Trim(name, syntax.prefixes) == "" {
+				out.WriteString(name) // a string prefix; the literal follows on the next turn
+				continue
+			}
+			isQualifier := qualifiers[name] || syntax.qualifiers[name] || o.keep[name]
+			kept := isQualifier || name == "_" || syntax.keywords[name] || syntax.builtins[name] ||
+				(afterDot && qualified) || (strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__"))
+			if kept {
+				out.WriteString(name)
+			} else {
+				out.WriteString(o.pseudonym(name))
+			}
+			qualified = isQualifier && !afterDot
+			afterDot = false
+			continue
+		default:
+			i++
+			if c == '.' {
+				afterDot = true
+			} else if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				afterDot = false
+			}
+		}
+		out.WriteString(text[start:i])
+	}
+	return []byte(out.String())
+}
+
+// skipComment advances *i past a comment starting there.
+func (s *identifierSyntax) skipComment(text string, i *int) bool {
+	rest := text[*i:]
+	for _, prefix := range s.lineComments {
+		if strings.HasPrefix(rest, prefix) {
+			end := strings.IndexByte(rest, '\n')
+			*i += tern(end < 0, len(rest), end)
+			return true
+		}
+	}
+	if open := s.blockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+		end := strings.Index(rest[len(open):], s.blockComment[1])
+		*i += tern(end < 0, len(rest), len(open)+end+len(s.blockComment[1]))
+		return true
+	}
+	return false
+}
+
+// skipString advances *i past a string literal starting there. Only raw and
+// triple-quoted strings may span lines; an unterminated one ends at the line.
+func (s *identifierSyntax) skipString(text string, i *int) bool {
+	rest := text[*i:]
+	q := rest[0]
+	if !strings.ContainsRune(s.quotes, rune(q)) {
+		return false
+	}
+	if s.tripleQuotes && len(rest) >= 3 && rest[1] == q && rest[2] == q {
+		end := strings.Index(rest[3:], rest[:3])
+		*i += tern(end < 0, len(rest), 3+end+3)
+		return true
+	}
+	j := 1
+	for j < len(rest) && rest[j] != q {
+		if rest[j] == '\n' && q != s.rawQuote {
+			break
+		}
+		if rest[j] == '\\' && q != s.rawQuote {
+			j++
+		}
+		j++
+	}
+	*i += min(j+1, len(rest))
+	return true
+}
+
+func isIdentByte(c byte, dollar bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || dollar && c == '$'
+}
+
+func isIdentStart(s string, dollar bool) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r) || dollar && r == '$'
+}
+
+func isIdentPart(s string, dollar bool) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return isIdentStart(s, dollar) || unicode.IsDigit(r)
+}
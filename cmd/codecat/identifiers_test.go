@@ -0,0 +1,63 @@
+// cmd/codecat/identifiers_test.go
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentifierObfuscator_Go(t *testing.T) {
+	o, err := newIdentifierObfuscator(nil, filepath.Join(t.TempDir(), "identifier-map.json"))
+	require.NoError(t, err)
+	src := "package main\n\nimport \"fmt\"\n\n// Invoice is billed.\ntype Invoice struct{ total int }\n\n" +
+		"func (inv *Invoice) Print() {\n\tfor _, x := range []int{inv.total} {\n\t\tfmt.Println(\"Invoice\", x, len(`inv`))\n\t}\n}\n"
+	out := string(o.obfuscate("main.go", []byte(src)))
+
+	invoice, inv, print := o.pseudonym("Invoice"), o.pseudonym("inv"), o.pseudonym("Print")
+	assert.Regexp(t, `^Ident_[0-9a-f]{8}$`, invoice, "exported names stay exported")
+	assert.Regexp(t, `^ident_[0-9a-f]{8}$`, inv)
+	assert.Contains(t, out, "// Invoice is billed.\ntype "+invoice+" struct{ "+o.pseudonym("total")+" int }", "comments are unchanged")
+	assert.Contains(t, out, "func ("+inv+" *"+invoice+") "+print+"() {")
+	assert.Contains(t, out, "for _, "+o.pseudonym("x")+" := range []int{")
+	assert.Contains(t, out, "fmt.Println(\"Invoice\", ", "standard library names and strings are kept")
+	assert.Contains(t, out, "len(`inv`)")
+	assert.Equal(t, "Invoice", o.mapping()[invoice])
+
+	assert.Equal(t, "a,b\n", string(o.obfuscate("data.csv", []byte("a,b\n"))), "other languages pass through")
+}
+
+func TestIdentifierObfuscator_PythonAndJavaScript(t *testing.T) {
+	o, err := newIdentifierObfuscator([]string{"np"}, filepath.Join(t.TempDir(), "identifier-map.json"))
+	require.NoError(t, err)
+	py := "import os\nfrom collections import defaultdict\nimport numpy as np\n\n" +
+		"class Gateway:\n    def __init__(self, account):\n        self.path = os.path.join(f\"{account}\", '''doc''')\n        self.counts = defaultdict(int, np.zeros(3))\n"
+	out := string(o.obfuscate("app.py", []byte(py)))
+	assert.Contains(t, out, "class "+o.pseudonym("Gateway")+":\n    def __init__(self, "+o.pseudonym("account")+"):")
+	assert.Contains(t, out, "self."+o.pseudonym("path")+" = os.path.join(f\"{account}\", '''doc''')", "members of stdlib modules are kept")
+	assert.Contains(t, out, "defaultdict(int, np.zeros(3))", "obfuscate_keep names keep their members")
+	assert.Contains(t, out, "from collections import defaultdict")
+
+	js := "const fs = require(\"fs\");\nfunction loadUser($id) {\n  console.log(fs.readFileSync($id));\n}\n"
+	out = string(o.obfuscate("user.js", []byte(js)))
+	assert.Equal(t, "const fs = require(\"fs\");\nfunction "+o.pseudonym("loadUser")+"("+o.pseudonym("$id")+") {\n  console.log(fs.readFileSync("+o.pseudonym("$id")+"));\n}\n", out)
+}
+
+func TestIdentifierObfuscator_MapKeepsPseudonyms(t *testing.T) {
+	mapPath := filepath.Join(t.TempDir(), "identifier-map.json")
+	first, err := newIdentifierObfuscator(nil, mapPath)
+	require.NoError(t, err)
+	name := first.pseudonym("customerID")
+	require.NoError(t, first.save(mapPath))
+
+	second, err := newIdentifierObfuscator(nil, mapPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{name: "customerID"}, second.mapping())
+	assert.Equal(t, first.pseudonym("orderID"), second.pseudonym("orderID"), "the saved key derives the same pseudonyms")
+
+	other, err := newIdentifierObfuscator(nil, filepath.Join(t.TempDir(), "identifier-map.json"))
+	require.NoError(t, err)
+	assert.NotEqual(t, name, other.pseudonym("customerID"), "a new map draws a new key")
+}
@@ -0,0 +1,141 @@
+// cmd/codecat/imports.go
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// preambleKinds lists the values accepted by --preamble.
+var preambleKinds = []string{"imports"}
+
+// importMapPath labels the generated import map document.
+const importMapPath = "codecat:imports"
+
+// importParser extracts the package/module name and the imports of a file.
+type importParser func(relPath, content string) (pkg string, imports []string, ok bool)
+
+// importParsers are keyed by lower-case file extension.
+var importParsers = map[string]importParser{
+	".go":   goImports,
+	".py":   pythonImports,
+	".js":   jsImports,
+	".jsx":  jsImports,
+	".mjs":  jsImports,
+	".cjs":  jsImports,
+	".ts":   jsImports,
+	".tsx":  jsImports,
+	".java": javaImports,
+	".kt":   javaImports,
+	".rs":   rustImports,
+}
+
+var (
+	pyImportRe      = regexp.MustCompile(`(?m)^\s*import\s+([\w.]+(?:\s+as\s+\w+)?(?:\s*,\s*[\w.]+(?:\s+as\s+\w+)?)*)`)
+	pyFromRe        = regexp.MustCompile(`(?m)^\s*from\s+(\.*[\w.]*)\s+import\b`)
+	jsImportRe      = regexp.MustCompile(`(?m)^\s*(?:import|export)\s+(?:[^'"]*?\s+from\s+)?['"]([^'"]+)['"]`)
+	jsRequireRe     = regexp.MustCompile(`\brequire\(\s*['"]([^'"]+)['"]\s*\)`)
+	javaPackageRe   = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)`)
+	javaImportRe    = regexp.MustCompile(`(?m)^\s*import\s+(?:static\s+)?([\w.*]+)`)
+	rustUseRe       = regexp.MustCompile(`(?m)^\s*(?:pub\s+)?use\s+([\w:]+)`)
+	rustExternRe    = regexp.MustCompile(`(?m)^\s*extern\s+crate\s+(\w+)`)
+	pyAliasSuffixRe = regexp.MustCompile(`\s+as\s+\w+$`)
+)
+
+func goImports(relPath, content string) (string, []string, bool) {
+	f, err := parser.ParseFile(token.NewFileSet(), relPath, content, parser.ImportsOnly)
+	if err != nil {
+		return "", nil, false
+	}
+	var imports []string
+	for _, spec := range f.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil {
+			imports = append(imports, p)
+		}
+	}
+	return "package " + f.Name.Name, imports, true
+}
+
+func pythonImports(relPath, content string) (string, []string, bool) {
+	module := strings.TrimSuffix(strings.TrimSuffix(relPath, ".py"), "/__init__")
+	var imports []string
+	for _, m := range pyImportRe.FindAllStringSubmatch(content, -1) {
+		for _, name := range strings.Split(m[1], ",") {
+			imports = append(imports, pyAliasSuffixRe.ReplaceAllString(strings.TrimSpace(name), ""))
+		}
+	}
+	for _, m := range pyFromRe.FindAllStringSubmatch(content, -1) {
+		imports = append(imports, m[1])
+	}
+	return "module " + strings.ReplaceAll(module, "/", "."), imports, true
+}
+
+func jsImports(relPath, content string) (string, []string, bool) {
+	var imports []string
+	for _, re := range []*regexp.Regexp{jsImportRe, jsRequireRe} {
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			imports = append(imports, m[1])
+		}
+	}
+	return "module " + strings.TrimSuffix(relPath, path.Ext(relPath)), imports, true
+}
+
+func javaImports(relPath, content string) (string, []string, bool) {
+	pkg := "(default package)"
+	if m := javaPackageRe.FindStringSubmatch(content); m != nil {
+		pkg = "package " + m[1]
+	}
+	var imports []string
+	for _, m := range javaImportRe.FindAllStringSubmatch(content, -1) {
+		imports = append(imports, m[1])
+	}
+	return pkg, imports, true
+}
+
+func rustImports(relPath, content string) (string, []string, bool) {
+	var imports []string
+	for _, re := range []*regexp.Regexp{rustExternRe, rustUseRe} {
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			imports = append(imports, m[1])
+		}
+	}
+	return "module " + strings.TrimSuffix(relPath, ".rs"), imports, true
+}
+
+// importMapDocument lists, for each document in a supported language, its
+// package or module and what it imports, sorted and de-duplicated. It
+// returns false when no document could be parsed.
+func importMapDocument(docs []Document) (Document, bool) {
+	var b strings.Builder
+	mapped := 0
+	for _, doc := range docs {
+		parse := importParsers[strings.ToLower(path.Ext(doc.Path))]
+		if parse == nil {
+			continue
+		}
+		pkg, imports, ok := parse(doc.Path, doc.text())
+		if !ok {
+			continue
+		}
+		mapped++
+		sort.Strings(imports)
+		imports = slices.Compact(imports)
+		list := tern(len(imports) == 0, "(no imports)", strings.Join(imports, ", "))
+		fmt.Fprintf(&b, "%s (%s): %s\n", doc.Path, pkg, list)
+	}
+	if mapped == 0 {
+		return Document{}, false
+	}
+	return Document{
+		Path:    importMapPath,
+		Content: b.String(),
+		Meta:    []string{fmt.Sprintf("generated preamble: packages and imports of %d of %d files", mapped, len(docs))},
+	}, true
+}
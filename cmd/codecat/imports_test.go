@@ -0,0 +1,51 @@
+// cmd/codecat/imports_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportMapDocument(t *testing.T) {
+	docs := []Document{
+		{Path: "cmd/app/main.go", Content: "package main\n\nimport (\n\t\"fmt\"\n\tlog \"log/slog\"\n\t\"fmt\"\n)\n"},
+		{Path: "app/models/__init__.py", Content: "import os, sys as system\nfrom .base import Model\nfrom typing import List\n"},
+		{Path: "web/src/api.ts", Content: "import { get } from './http'\nimport 'reflect-metadata'\nconst x = require(\"lodash\")\nexport * from './types'\n"},
+		{Path: "src/main/java/App.java", Content: "package com.acme;\nimport java.util.List;\nimport static org.junit.Assert.*;\n"},
+		{Path: "src/lib.rs", Content: "extern crate serde;\nuse std::collections::HashMap;\npub use crate::config;\n"},
+		{Path: "notes.md", Content: "# import nothing\n"},
+		{Path: "broken.go", Content: "not go at all"},
+	}
+	doc, ok := importMapDocument(docs)
+	require.True(t, ok)
+	assert.Equal(t, importMapPath, doc.Path)
+	assert.Equal(t, []string{"generated preamble: packages and imports of 5 of 7 files"}, doc.Meta)
+	assert.Equal(t, `cmd/app/main.go (package main): fmt, log/slog
+app/models/__init__.py (module app.models): .base, os, sys, typing
+web/src/api.ts (module web/src/api): ./http, ./types, lodash, reflect-metadata
+src/main/java/App.java (package com.acme): java.util.List, org.junit.Assert.*
+src/lib.rs (module src/lib): crate::config, serde, std::collections::HashMap
+`, doc.Content)
+
+	_, ok = importMapDocument([]Document{{Path: "README.md", Content: "hi\n"}})
+	assert.False(t, ok)
+}
+
+func TestGenerate_ImportsPreamble(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go": "package main\n\nimport \"os\"\n\nfunc main() { os.Exit(0) }\n",
+	})
+	result, err := generate(GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+		Preamble:   "imports",
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Documents, 2)
+	assert.Contains(t, result.Output, "--- codecat:imports\n--- generated preamble: packages and imports of 1 of 1 files\nmain.go (package main): os\n---\n--- main.go\n")
+	assert.Len(t, result.IncludedFiles, 1, "the preamble is not a file")
+}
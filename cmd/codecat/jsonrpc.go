@@ -0,0 +1,345 @@
+// cmd/codecat/jsonrpc.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// rpcScanParams narrow the scan for one request; unset fields keep the
+// options codecat was started with. Paths are relative to the CWD and may
+// not leave it.
+type rpcScanParams struct {
+	Dirs      []string `json:"dirs"`
+	Files     []string `json:"files"`
+	Exclude   []string `json:"exclude"`
+	Ext       []string `json:"ext"`
+	MaxTokens int64    `json:"max_tokens"`
+}
+
+// rpcCandidates is the result of listCandidates.
+type rpcCandidates struct {
+	Files      []FileInfo `json:"files"`
+	EmptyFiles []string   `json:"empty_files"`
+	TotalSize  int64      `json:"total_size"`
+}
+
+// rpcExplanation is the result of explainExclusion.
+type rpcExplanation struct {
+	Path     string `json:"path"`
+	Included bool   `json:"included"`
+	Reason   string `json:"reason"`            // included, empty, gitignore, basename, project, flag, extension, ...
+	Pattern  string `json:"pattern,omitempty"` // the deciding exclude pattern, when there is one
+}
+
+// rpcMethods are the methods served by --json-rpc.
+var rpcMethods = map[string]func(base GenerateOptions, params json.RawMessage) (any, error){
+	"generate":         rpcGenerate,
+	"listCandidates":   rpcListCandidates,
+	"explainExclusion": rpcExplainExclusion,
+}
+
+// runJSONRPC serves JSON-RPC 2.0 requests, one JSON object per line, from in
+// (stdin for --json-rpc, a connection for the daemon) and writes one
+// response per line to out until in is closed. Requests are handled in
+// order; notifications (requests without an id) get no response.
+func runJSONRPC(in io.Reader, out io.Writer, base GenerateOptions) int {
+	slog.Info("Serving JSON-RPC.", "cwd", base.CWD)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(out)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		resp, reply := handleRPC(base, []byte(line))
+		if !reply {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			slog.Error("Failed to write JSON-RPC response.", "error", err)
+			return 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Error("Failed to read JSON-RPC request.", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// handleRPC runs one request. reply is false for notifications.
+func handleRPC(base GenerateOptions, line []byte) (resp rpcResponse, reply bool) {
+	resp.JSONRPC = "2.0"
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp.ID = json.RawMessage("null")
+		resp.Error = &rpcError{Code: rpcParseError, Message: err.Error()}
+		return resp, true
+	}
+	resp.ID = tern(len(req.ID) == 0, json.RawMessage("null"), req.ID)
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: rpcInvalidRequest, Message: "expected a JSON-RPC 2.0 request with a method"}
+		return resp, true
+	}
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		resp.Error = &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+		return resp, len(req.ID) > 0
+	}
+	slog.Debug("Handling JSON-RPC request.", "method", req.Method, "id", string(req.ID))
+	result, err := method(base, req.Params)
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return resp, len(req.ID) > 0
+}
+
+// decodeParams unmarshals params into v, allowing them to be omitted.
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// scanOptions applies p to a copy of base.
+func (p rpcScanParams) scanOptions(base GenerateOptions) (GenerateOptions, error) {
+	opts := base
+	if len(p.Dirs) > 0 {
+		opts.ScanDirs = nil
+		for _, dir := range p.Dirs {
+			abs, err := rpcPath(base.CWD, dir)
+			if err != nil {
+				return opts, err
+			}
+			opts.ScanDirs = append(opts.ScanDirs, abs)
+		}
+		opts.NoScan = false
+	}
+	if p.Files != nil {
+		for _, f := range p.Files {
+			if _, err := rpcPath(base.CWD, f); err != nil {
+				return opts, err
+			}
+		}
+		opts.ManualFiles = p.Files
+	}
+	if p.Exclude != nil {
+		opts.FlagExcludes = append(slices.Clone(base.FlagExcludes), p.Exclude...)
+	}
+	if p.Ext != nil {
+		opts.Extensions = processExtensions(p.Ext)
+	}
+	if p.MaxTokens < 0 {
+		return opts, &rpcError{Code: rpcInvalidParams, Message: "max_tokens must not be negative"}
+	}
+	if p.MaxTokens > 0 {
+		opts.MaxTokens = p.MaxTokens
+	}
+	opts.Progress = nil
+	return opts, nil
+}
+
+// rpcPath resolves a CWD-relative request path, refusing ones outside cwd.
+func rpcPath(cwd, p string) (string, error) {
+	abs := filepath.Clean(filepath.Join(cwd, p))
+	if rel, err := filepath.Rel(cwd, abs); filepath.IsAbs(p) || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("path %q is outside the working directory", p)}
+	}
+	return abs, nil
+}
+
+// rpcGenerate returns the same body as GET /context?format=json. Over the
+// budget it fails with the drop list as the error message.
+func rpcGenerate(base GenerateOptions, params json.RawMessage) (any, error) {
+	var p rpcScanParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	opts, err := p.scanOptions(base)
+	if err != nil {
+		return nil, err
+	}
+	result, genErr := generate(opts)
+	if genErr != nil {
+		slog.Warn("Context generation reported errors.", "error", genErr)
+	}
+	tokens := estimateTokens(int64(len(result.Output)))
+	if opts.MaxTokens > 0 && tokens > opts.MaxTokens {
+		var report strings.Builder
+		printDropList(&report, result.IncludedFiles, tokens, opts.MaxTokens)
+		return nil, &rpcError{Code: rpcInternalError, Message: report.String()}
+	}
+	resp := contextResponse{
+		Output:          result.Output,
+		Files:           result.IncludedFiles,
+		EmptyFiles:      result.EmptyFiles,
+		Errors:          make(map[string]string, len(result.ErrorFiles)),
+		TotalSize:       result.TotalSize,
+		EstimatedTokens: tokens,
+	}
+	for path, e := range result.ErrorFiles {
+		resp.Errors[path] = e.Error()
+	}
+	return resp, nil
+}
+
+// rpcListCandidates lists the files a generate call would include, without
+// reading their content.
+func rpcListCandidates(base GenerateOptions, params json.RawMessage) (any, error) {
+	var p rpcScanParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	opts, err := p.scanOptions(base)
+	if err != nil {
+		return nil, err
+	}
+	opts.SkipContent = true
+	result, genErr := generate(opts)
+	if genErr != nil {
+		slog.Warn("Candidate scan reported errors.", "error", genErr)
+	}
+	return rpcCandidates{Files: result.IncludedFiles, EmptyFiles: result.EmptyFiles, TotalSize: result.TotalSize}, nil
+}
+
+// rpcExplainExclusion reports whether one file would be included and, if
+// not, which rule drops it.
+func rpcExplainExclusion(base GenerateOptions, params json.RawMessage) (any, error) {
+	var p struct {
+		rpcScanParams
+		Path string `json:"path"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Path == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "path is required"}
+	}
+	abs, err := rpcPath(base.CWD, p.Path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	opts, err := p.scanOptions(base)
+	if err != nil {
+		return nil, err
+	}
+	rel, _ := filepath.Rel(base.CWD, abs)
+	return explainExclusion(opts, filepath.ToSlash(rel))
+}
+
+// explainExclusion scans with opts (without reading content) and finds
+// where relPath ended up.
+func explainExclusion(opts GenerateOptions, relPath string) (rpcExplanation, error) {
+	explanation := rpcExplanation{Path: relPath}
+	opts.SkipContent, opts.ReportSkipped = true, true
+	result, genErr := generate(opts)
+	if genErr != nil {
+		slog.Warn("Scan reported errors.", "error", genErr)
+	}
+	for _, f := range result.IncludedFiles {
+		if f.Path == relPath {
+			explanation.Included, explanation.Reason = true, "included"
+			return explanation, nil
+		}
+	}
+	if slices.Contains(result.EmptyFiles, relPath) {
+		explanation.Reason = "empty"
+		return explanation, nil
+	}
+	if _, ok := result.SpecialFiles[relPath]; ok {
+		explanation.Reason = "special file"
+		return explanation, nil
+	}
+	for reason, paths := range result.Skipped {
+		if slices.Contains(paths, relPath) {
+			explanation.Reason = reason
+			if reason == "basename" || reason == "project" || reason == "flag" {
+				explanation.Pattern = decidingPattern(opts, relPath)
+			}
+			return explanation, nil
+		}
+	}
+	if opts.UseGitignore {
+		unignored := opts
+		unignored.UseGitignore = false
+		if res, err := generate(unignored); err == nil && slices.Contains(gitignoredFiles(result, res), relPath) {
+			explanation.Reason = "gitignore"
+			return explanation, nil
+		}
+	}
+	explanation.Reason = "not scanned"
+	for _, dir := range opts.ScanDirs {
+		if rel, err := filepath.Rel(dir, filepath.Join(opts.CWD, relPath)); err == nil && !strings.HasPrefix(rel, "..") {
+			explanation.Reason = "extension" // inside the scan but failing the extension/language filters
+		}
+	}
+	return explanation, nil
+}
+
+// decidingPattern returns the basename or CWD-relative pattern that
+// excludes relPath under opts.
+func decidingPattern(opts GenerateOptions, relPath string) string {
+	excluder := NewDefaultExcluder(opts.ExcludeBasenames, append(slices.Clone(opts.ProjectExcludes), opts.FlagExcludes...))
+	parts := strings.Split(relPath, "/")
+	for i := range parts {
+		rel := strings.Join(parts[:i+1], "/")
+		info := PathInfo{AbsPath: filepath.Join(opts.CWD, rel), RelPathCwd: rel, BaseName: parts[i], IsDir: i < len(parts)-1}
+		if excluded, _, pattern := excluder.IsExcluded(info); excluded {
+			return pattern
+		}
+	}
+	return ""
+}
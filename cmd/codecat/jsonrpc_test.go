@@ -0,0 +1,113 @@
+// cmd/codecat/jsonrpc_test.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rpcTestOptions(t *testing.T) GenerateOptions {
+	t.Helper()
+	tempDir := setupTestDir(t, map[string]string{
+		".gitignore":    "secret.go\n",
+		"main.go":       "package main\n",
+		"secret.go":     "package main\n",
+		"lib/util.go":   "package lib\n",
+		"lib/notes.txt": "notes\n",
+		"build.log":     "log\n",
+		"gen/types.go":  "package gen\n",
+	})
+	return GenerateOptions{
+		CWD:              tempDir,
+		ScanDirs:         []string{tempDir},
+		Extensions:       processExtensions([]string{"go", "log"}),
+		ExcludeBasenames: []string{"*.log"},
+		FlagExcludes:     []string{"gen"},
+		UseGitignore:     true,
+		Marker:           "---",
+	}
+}
+
+func TestRunJSONRPC(t *testing.T) {
+	base := rpcTestOptions(t)
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"listCandidates"}`,
+		`{"jsonrpc":"2.0","id":"g","method":"generate","params":{"dirs":["lib"]}}`,
+		`{"jsonrpc":"2.0","method":"listCandidates"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"nope"}`,
+		`not json`,
+	}, "\n")
+	var out bytes.Buffer
+	require.Equal(t, 0, runJSONRPC(strings.NewReader(in), &out, base))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4, "notifications get no response")
+
+	var candidates struct {
+		ID     int           `json:"id"`
+		Result rpcCandidates `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &candidates))
+	var paths []string
+	for _, f := range candidates.Result.Files {
+		paths = append(paths, f.Path)
+	}
+	assert.ElementsMatch(t, []string{"main.go", "lib/util.go"}, paths)
+
+	var generated struct {
+		ID     string          `json:"id"`
+		Result contextResponse `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &generated))
+	assert.Equal(t, "g", generated.ID)
+	assert.Contains(t, generated.Result.Output, "--- lib/util.go\npackage lib\n")
+	assert.NotContains(t, generated.Result.Output, "main.go")
+
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"unknown method \"nope\""}}`, lines[2])
+	assert.Contains(t, lines[3], `"id":null,"error":{"code":-32700`)
+}
+
+func TestHandleRPC_InvalidParams(t *testing.T) {
+	base := rpcTestOptions(t)
+	for _, req := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"dirs":["../other"]}}`,
+		`{"jsonrpc":"2.0","id":1,"method":"generate","params":{"dirs":"lib"}}`,
+		`{"jsonrpc":"2.0","id":1,"method":"explainExclusion","params":{}}`,
+		`{"jsonrpc":"2.0","id":1,"method":"explainExclusion","params":{"path":"missing.go"}}`,
+	} {
+		resp, reply := handleRPC(base, []byte(req))
+		require.True(t, reply)
+		require.NotNil(t, resp.Error, req)
+		assert.Equal(t, rpcInvalidParams, resp.Error.Code, req)
+	}
+
+	resp, _ := handleRPC(base, []byte(`{"id":1,"method":"generate"}`))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, rpcInvalidRequest, resp.Error.Code)
+}
+
+func TestExplainExclusion(t *testing.T) {
+	opts := rpcTestOptions(t)
+	testCases := []struct {
+		path string
+		want rpcExplanation
+	}{
+		{"main.go", rpcExplanation{Path: "main.go", Included: true, Reason: "included"}},
+		{"build.log", rpcExplanation{Path: "build.log", Reason: "basename", Pattern: "*.log"}},
+		{"gen/types.go", rpcExplanation{Path: "gen/types.go", Reason: "flag", Pattern: "gen"}},
+		{"secret.go", rpcExplanation{Path: "secret.go", Reason: "gitignore"}},
+		{"lib/notes.txt", rpcExplanation{Path: "lib/notes.txt", Reason: "extension"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := explainExclusion(opts, tc.path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
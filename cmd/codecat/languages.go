@@ -0,0 +1,144 @@
+// cmd/codecat/languages.go
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// extensionLanguages maps lower-case file extensions to language names.
+var extensionLanguages = map[string]string{
+	".go": "Go", ".mod": "Go Module", ".sum": "Go Module",
+	".py": "Python", ".pyi": "Python", ".ipynb": "Jupyter Notebook",
+	".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript", ".jsx": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript",
+	".html": "HTML", ".htm": "HTML", ".css": "CSS", ".scss": "SCSS",
+	".json": "JSON", ".jsonc": "JSON", ".yaml": "YAML", ".yml": "YAML", ".toml": "TOML", ".xml": "XML",
+	".md": "Markdown", ".rst": "reStructuredText", ".txt": "Text",
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell",
+	".tf": "Terraform", ".hcl": "HCL",
+	".java": "Java", ".kt": "Kotlin", ".gradle": "Gradle", ".cs": "C#",
+	".rb": "Ruby", ".php": "PHP", ".swift": "Swift", ".sql": "SQL", ".rs": "Rust",
+	".c": "C", ".h": "C", ".cpp": "C++", ".cc": "C++", ".hpp": "C++",
+	".csv": "CSV", ".proto": "Protocol Buffers", ".lua": "Lua", ".r": "R",
+}
+
+// languageForPath names the language of a file from its extension, falling
+// back to the extension itself (or "Other" when there is none).
+func languageForPath(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if lang, ok := extensionLanguages[ext]; ok {
+		return lang
+	}
+	if ext == "" {
+		return "Other"
+	}
+	return ext
+}
+
+// filenameLanguages maps lower-case file names without a telling extension.
+var filenameLanguages = map[string]string{
+	"makefile": "Makefile", "gnumakefile": "Makefile", "cmakelists.txt": "CMake",
+	"dockerfile": "Dockerfile", "containerfile": "Dockerfile",
+	"jenkinsfile": "Groovy", "rakefile": "Ruby", "gemfile": "Ruby", "vagrantfile": "Ruby",
+	"justfile": "Just", "procfile": "Procfile",
+}
+
+// interpreterLanguages maps shebang interpreters, version suffixes removed.
+var interpreterLanguages = map[string]string{
+	"python": "Python", "sh": "Shell", "bash": "Shell", "zsh": "Shell", "dash": "Shell", "ksh": "Shell",
+	"node": "JavaScript", "deno": "TypeScript", "ruby": "Ruby", "perl": "Perl", "php": "PHP",
+	"lua": "Lua", "rscript": "R", "pwsh": "PowerShell", "make": "Makefile",
+}
+
+// detectLanguage classifies a file by extension, then by well-known file
+// name, then by the shebang line at the start of head. It returns "" when
+// none of them identify the language.
+func detectLanguage(path string, head []byte) string {
+	if lang, ok := extensionLanguages[strings.ToLower(filepath.Ext(path))]; ok {
+		return lang
+	}
+	base := strings.ToLower(filepath.Base(path))
+	if lang, ok := filenameLanguages[base]; ok {
+		return lang
+	}
+	if strings.HasPrefix(base, "dockerfile.") || strings.HasSuffix(base, ".dockerfile") {
+		return "Dockerfile"
+	}
+	return shebangLanguage(head)
+}
+
+// shebangLanguage reads "#!/usr/bin/python3" or "#!/usr/bin/env -S bash -e" style lines.
+func shebangLanguage(head []byte) string {
+	return interpreterLanguages[shebangInterpreter(head)]
+}
+
+// shebangInterpreter returns the lower-case interpreter of the shebang line
+// at the start of head, version suffix removed ("python" for python3.11),
+// or "" when there is none.
+func shebangInterpreter(head []byte) string {
+	line, _, _ := strings.Cut(string(head), "\n")
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) > 0 && filepath.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimRight(strings.ToLower(filepath.Base(fields[0])), "0123456789.")
+}
+
+// matchShebang reports whether the shebang in head names one of the allowed
+// interpreters (python, bash) or their languages (shell), for --shebang.
+func matchShebang(head []byte, allowed map[string]struct{}) bool {
+	interpreter := shebangInterpreter(head)
+	if interpreter == "" {
+		return false
+	}
+	_, ok := allowed[interpreter]
+	if lang, known := interpreterLanguages[interpreter]; known && !ok {
+		_, ok = allowed[strings.ToLower(lang)]
+	}
+	return ok
+}
+
+// fileLanguage detects the language of a file on disk, reading its first
+// bytes only when the name alone is not enough.
+func fileLanguage(relPath, absPath string) string {
+	if lang := detectLanguage(relPath, nil); lang != "" {
+		return lang
+	}
+	return detectLanguage(relPath, readHead(absPath))
+}
+
+// readHead returns the first bytes of a file, enough for a shebang line; nil
+// when it cannot be read.
+func readHead(absPath string) []byte {
+	f, err := os.Open(absPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	head := make([]byte, 256)
+	n, _ := io.ReadFull(f, head)
+	return head[:n]
+}
+
+// processLanguages normalises --lang values to lower-case language names.
+func processLanguages(values []string) map[string]struct{} {
+	langs := make(map[string]struct{})
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			langs[v] = struct{}{}
+		}
+	}
+	return langs
+}
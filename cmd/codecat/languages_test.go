@@ -0,0 +1,89 @@
+// cmd/codecat/languages_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := []struct {
+		path, head, want string
+	}{
+		{"main.go", "", "Go"},
+		{"Makefile", "", "Makefile"},
+		{"build/Dockerfile.prod", "", "Dockerfile"},
+		{"bin/deploy", "#!/usr/bin/env bash\nset -e\n", "Shell"},
+		{"bin/tool", "#!/usr/bin/python3.11\n", "Python"},
+		{"bin/run", "#!/usr/bin/env -S node --no-warnings\n", "JavaScript"},
+		{"bin/data", "just text\n", ""},
+		{"LICENSE", "", ""},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, detectLanguage(c.path, []byte(c.head)), c.path)
+	}
+}
+
+func TestGenerate_Languages(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"app.py":        "print('hi')\n",
+		"bin/deploy":    "#!/bin/sh\necho deploy\n",
+		"bin/migrate":   "#!/usr/bin/env python3\nprint('m')\n",
+		"Makefile":      "all:\n\ttrue\n",
+		"notes":         "plain text\n",
+		"pkg/lib.go":    "package lib\n",
+		"scripts/a.txt": "#!/bin/sh\n",
+	})
+	opts := GenerateOptions{
+		CWD:       tempDir,
+		ScanDirs:  []string{tempDir},
+		Languages: processLanguages([]string{"Python", " shell"}),
+		Marker:    "---",
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.py", "bin/deploy", "bin/migrate"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	for _, f := range result.IncludedFiles {
+		assert.Equal(t, tern(f.Path == "bin/deploy", "Shell", "Python"), f.Language, f.Path)
+	}
+
+	// --lang adds to an explicit extension list.
+	opts.Extensions = processExtensions([]string{"go"})
+	opts.Languages = processLanguages([]string{"makefile"})
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Makefile", "pkg/lib.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+}
+
+func TestMatchShebang(t *testing.T) {
+	allowed := processLanguages([]string{"python", "Shell"})
+	assert.True(t, matchShebang([]byte("#!/usr/bin/env python3.11\n"), allowed))
+	assert.True(t, matchShebang([]byte("#!/bin/zsh\n"), allowed), "languages match every interpreter of theirs")
+	assert.False(t, matchShebang([]byte("#!/usr/bin/perl\n"), allowed))
+	assert.False(t, matchShebang([]byte("print('no shebang')\n"), allowed))
+	assert.True(t, matchShebang([]byte("#!/usr/bin/env -S awk -f\n"), processLanguages([]string{"awk"})), "unknown interpreters match by name")
+}
+
+func TestGenerate_Shebangs(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":         "package main\n",
+		"bin/deploy":      "#!/bin/bash\necho deploy\n",
+		"bin/report.cgi":  "#!/usr/bin/env python3\nprint('r')\n",
+		"bin/cleanup":     "#!/usr/bin/perl\n",
+		"bin/notes":       "plain text\n",
+		"scripts/gen.txt": "#!/bin/bash\n",
+	})
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Shebangs:   processLanguages([]string{"python", "bash"}),
+		Marker:     "---",
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bin/deploy", "bin/report.cgi", "main.go"}, getPathsFromIncludedFiles(result.IncludedFiles),
+		"only files without a known extension are sniffed")
+}
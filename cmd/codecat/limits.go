@@ -0,0 +1,91 @@
+// cmd/codecat/limits.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dirLimit caps how much content the files matching Glob contribute
+// together, so a directory can be represented without dominating.
+type dirLimit struct {
+	Glob  string
+	Bytes int64
+}
+
+// parseLimits reads the [limits] table, mapping globs (the [priority]
+// syntax) to a size ("50KB") or a token count ("12000 tokens"), converted
+// with the current bytes per token. Limits come out sorted by glob.
+func parseLimits(raw map[string]string) ([]dirLimit, error) {
+	limits := make([]dirLimit, 0, len(raw))
+	for glob, value := range raw {
+		var limit int64
+		if tokens, ok := strings.CutSuffix(strings.TrimSpace(value), "tokens"); ok {
+			n, err := strconv.ParseInt(strings.TrimSpace(tokens), 10, 64)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("[limits] %q: invalid token count %q", glob, value)
+			}
+			limit = int64(float64(n) * bytesPerToken)
+		} else {
+			size, err := parseSize(value)
+			if err != nil {
+				return nil, fmt.Errorf("[limits] %q: %w", glob, err)
+			}
+			limit = size
+		}
+		limits = append(limits, dirLimit{Glob: glob, Bytes: limit})
+	}
+	sort.Slice(limits, func(i, j int) bool { return limits[i].Glob < limits[j].Glob })
+	return limits, nil
+}
+
+// applyLimits drops the largest files matching each limit until the rest
+// fit it, removing them from result's documents and included files. Files
+// given with -f neither count nor are dropped unless withManual is set
+// (--manual-respects-excludes). The dropped paths are returned in the order
+// they were dropped.
+func applyLimits(result *GenerateResult, limits []dirLimit, withManual bool) []string {
+	var dropped []string
+	gone := make(map[string]bool)
+	for _, limit := range limits {
+		var matching []int
+		var total int64
+		for i, doc := range result.Documents {
+			if (withManual || !doc.IsManual) && !gone[doc.Path] && matchesTreeGlob(limit.Glob, doc.Path) {
+				matching = append(matching, i)
+				total += int64(doc.size())
+			}
+		}
+		if total <= limit.Bytes {
+			continue
+		}
+		sort.SliceStable(matching, func(a, b int) bool {
+			return result.Documents[matching[a]].size() > result.Documents[matching[b]].size()
+		})
+		before := len(dropped)
+		for _, i := range matching {
+			if total <= limit.Bytes {
+				break
+			}
+			gone[result.Documents[i].Path] = true
+			dropped = append(dropped, result.Documents[i].Path)
+			total -= int64(result.Documents[i].size())
+		}
+		slog.Warn("Dropped files over a [limits] cap.", "glob", limit.Glob, "limit", formatBytes(limit.Bytes), "files", len(dropped)-before)
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+	kept := result.Documents[:0]
+	for _, doc := range result.Documents {
+		if !gone[doc.Path] {
+			kept = append(kept, doc)
+		}
+	}
+	result.Documents = kept
+	dropIncludedFiles(result, gone)
+	return dropped
+}
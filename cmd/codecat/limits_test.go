@@ -0,0 +1,71 @@
+// cmd/codecat/limits_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := parseLimits(map[string]string{"third_party/**": "50KB", "testdata": "1000 tokens"})
+	require.NoError(t, err)
+	assert.Equal(t, []dirLimit{{Glob: "testdata", Bytes: int64(1000 * bytesPerToken)}, {Glob: "third_party/**", Bytes: 50 << 10}}, limits)
+
+	_, err = parseLimits(map[string]string{"vendor": "lots"})
+	assert.ErrorContains(t, err, `[limits] "vendor": invalid size "lots"`)
+	_, err = parseLimits(map[string]string{"vendor": "many tokens"})
+	assert.ErrorContains(t, err, "invalid token count")
+}
+
+func TestApplyLimits(t *testing.T) {
+	doc := func(path string, size int) Document { return Document{Path: path, Content: strings.Repeat("x", size)} }
+	result := GenerateResult{
+		Documents: []Document{
+			doc("main.go", 500),
+			doc("third_party/a/big.go", 400),
+			doc("third_party/a/small.go", 100),
+			doc("third_party/b/mid.go", 300),
+			{Path: "third_party/b/pinned.go", Content: strings.Repeat("x", 900), IsManual: true},
+		},
+	}
+	for _, d := range result.Documents {
+		result.IncludedFiles = append(result.IncludedFiles, FileInfo{Path: d.Path, Size: int64(len(d.Content))})
+		result.TotalSize += int64(len(d.Content))
+	}
+
+	dropped := applyLimits(&result, []dirLimit{{Glob: "third_party/**", Bytes: 350}}, false)
+	assert.Equal(t, []string{"third_party/a/big.go", "third_party/b/mid.go"}, dropped, "largest first, until under the cap")
+	var paths []string
+	for _, d := range result.Documents {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"main.go", "third_party/a/small.go", "third_party/b/pinned.go"}, paths, "-f files are kept")
+	assert.Len(t, result.IncludedFiles, 3)
+	assert.Equal(t, int64(1500), result.TotalSize)
+
+	assert.Nil(t, applyLimits(&result, []dirLimit{{Glob: "third_party/**", Bytes: 350}}, false), "already within the cap")
+	assert.Equal(t, []string{"third_party/b/pinned.go"}, applyLimits(&result, []dirLimit{{Glob: "third_party/**", Bytes: 350}}, true), "with --manual-respects-excludes")
+}
+
+func TestGenerate_Limits(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":          "package main\n",
+		"vendor/big.go":    "package vendor // " + strings.Repeat("x", 200) + "\n",
+		"vendor/little.go": "package vendor\n",
+	})
+	result, err := generate(GenerateOptions{
+		CWD:           tempDir,
+		ScanDirs:      []string{tempDir},
+		Extensions:    processExtensions([]string{"go"}),
+		Marker:        "---",
+		Limits:        []dirLimit{{Glob: "vendor", Bytes: 100}},
+		ReportSkipped: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "vendor/little.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.NotContains(t, result.Output, "xxxx")
+	assert.Equal(t, []string{"vendor/big.go"}, result.Skipped["limit"])
+}
@@ -3,14 +3,17 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	pflag "github.com/spf13/pflag"
@@ -22,42 +25,277 @@ var (
 	targetDirFlagValues []string
 	extensions          []string
 	manualFiles         []string
+	fileListFlag        []string
 	excludePatterns     []string
 	noGitignore         bool
+	noGitattributes     bool
 	logLevelStr         string // Flag variable
-	outputFile          string
+	logFileFlag         string
+	outputSpecs         []string
+	compressFlag        string
+	manifestFlag        string
+	summaryFlag         string
+	summaryOutputFlag   string
+	siFlag              bool
+	pagerFlag           bool
+	confirmOverFlag     string
+	memoryLimitFlag     string
+	modelFlag           string
+	scrubPIIFlag        bool
+	scrubAllowFlag      []string
+	preambleFlag        string
+	docsFirstFlag       bool
+	dirReadmesFlag      bool
+	sampleDirsFlag      int
+	trimOutliersFlag    bool
+	outlierPctFlag      float64
+	outlierMultFlag     float64
+	includeErrorsFlag   bool
+	colorFlag           string
+	fitFlag             bool
+	grepFlag            string
+	seedFlag            []string
+	jsonRPCFlag         bool
+	expandDepthFlag     int
+	maxDepthFlag        int
+	maxFilesFlag        int
+	minDepthFlag        int
+	grepContextFlag     int
 	configFileFlag      string
 	versionFlag         bool
+	cpuProfileFlag      string
+	memProfileFlag      string
 	noScanFlag          bool
+	showSettingsFlag    bool
+	noAdaptersFlag      bool
+	csvRowsFlag         int
+	notebookIndexFlag   bool
+	minifyFlag          bool
+	dedentFlag          bool
+	annotateFlag        string
+	headerFieldsFlag    []string
+	gitTrackedFlag      bool
+	nestedReposFlag     string
+	stampFlag           bool
+	repoInfoFlag        bool
+	recentCommitsFlag   int
+	recentStatFlag      bool
+	githubIssueFlag     []string
+	diffRangeFlag       string
+	jailFlag            string
+	allowOutsideFlag    bool
+	fileMapFlag         bool
+	quotePathsFlag      bool
+	ignoreCaseFlag      bool
+	anonymizePathsFlag  bool
+	pathMapFlag         string
+	obfuscateFlag       bool
+	identifierMapFlag   string
+	formatFlag          string
+	thirdPartyFlag      string
+	langFlag            []string
+	extPresetFlag       []string
+	shebangFlag         []string
+	maxTokensFlag       int64
+	progressFlag        bool
+	timeoutFlag         time.Duration
+	reportSkippedFlag   bool
+	noTestsFlag         bool
+	noMinifiedFlag      bool
+	maxFileSizeFlag     string
+	skipBinaryFlag      bool
+	manualExcludesFlag  bool
+	onlyTestsFlag       bool
+	truncateLinesFlag   int
+	relativeToFlag      string
+	stripPrefixFlag     []string
+	pathPrefixFlag      string
 )
 
 func init() {
-	pflag.StringSliceVarP(&targetDirFlagValues, "directory", "d", []string{},
-		"Target directory/directories to scan. Can be used multiple times or as a comma-separated list.")
+	pflag.StringArrayVarP(&targetDirFlagValues, "directory", "d", []string{},
+		"Target directory/directories to scan. Can be used multiple times or as a comma-separated list. Add per-root filters as dir:ext=go,mod:x=gen.")
 	pflag.StringSliceVarP(&extensions, "extensions", "e", []string{},
 		"Extensions to include (overrides config, comma-separated).")
+	pflag.StringSliceVar(&extPresetFlag, "ext-preset", []string{},
+		"Named extension bundles (go, python, web, infra, docs or [ext_presets] from config; comma-separated). Replaces the config extensions; adds to -e.")
+	pflag.StringSliceVar(&shebangFlag, "shebang", []string{},
+		"Include files without a known extension whose shebang names one of these interpreters or languages (e.g. python,bash; comma-separated).")
+	pflag.StringSliceVar(&langFlag, "lang", []string{},
+		"Languages to include, detected from extension, file name or shebang (e.g. python,shell,makefile). Replaces the config extensions unless -e is also given.")
 	pflag.StringSliceVarP(&manualFiles, "files", "f", []string{},
-		"Manual files to include (paths relative to CWD, comma-separated).")
+		"Manual files to include (paths, globs or dir[:depth=N] relative to CWD, ** matches directories; comma-separated).")
+	pflag.StringArrayVarP(&fileListFlag, "files-from", "F", nil,
+		"File listing manual files, one path, glob or dir[:depth=N] per line as for -f, with # comments; repeatable.")
 	pflag.StringSliceVarP(&excludePatterns, "exclude", "x", []string{},
 		"CWD-relative path glob patterns to exclude (adds to .codecat_exclude, comma-separated).")
 	pflag.BoolVar(&noGitignore, "no-gitignore", false,
-		"Disable .gitignore processing.")
+		"Disable .gitignore, .ignore, .hgignore and svn:ignore processing.")
+	pflag.BoolVar(&noGitattributes, "no-gitattributes", false,
+		"Ignore .gitattributes export-ignore, linguist-vendored and linguist-generated markings.")
 	// Default log level changed to WARN
 	pflag.StringVar(&logLevelStr, "loglevel", "warn",
 		"Log level (debug, info, warn, error).")
-	pflag.StringVarP(&outputFile, "output", "o", "",
-		"Output file path (instead of stdout). Summary/Logs go to stderr/stdout respectively.")
+	pflag.StringVar(&logFileFlag, "log-file", "",
+		"Append log records to this file instead of stderr/stdout, keeping the summary's stream clean.")
+	pflag.StringArrayVarP(&outputSpecs, "output", "o", nil,
+		"Output target [format:]path instead of stdout; repeat for several outputs from one scan. Path '-' is stdout, 'clipboard' the clipboard. Format (text, markdown, json, xml) defaults from the extension (.md, .json, .xml).")
+	pflag.StringVar(&compressFlag, "compress", "",
+		"Compress every output (gz or zstd); -o targets ending in .gz or .zst are compressed regardless.")
+	pflag.StringVar(&manifestFlag, "manifest", "",
+		"Write a JSON manifest of every included file's path, size, SHA-256 and mtime to this path.")
+	pflag.StringVar(&summaryFlag, "summary", "auto",
+		"Print the summary: auto (not when the output is piped from stdout), always or never.")
+	pflag.BoolVar(&siFlag, "si", false,
+		"Report sizes in decimal SI units (kB, MB) instead of binary ones (KiB, MiB).")
+	pflag.StringVar(&summaryOutputFlag, "summary-output", "",
+		"Where the summary goes: stdout, stderr or a file path, whatever -o is (default: with the logs).")
+	pflag.BoolVar(&pagerFlag, "pager", false,
+		"Page output printed to a terminal through $PAGER (default less).")
+	pflag.StringVar(&confirmOverFlag, "confirm-over", "",
+		"Ask before writing an output larger than this size (e.g. 10MB); fails when stdin is not interactive.")
+	pflag.StringVar(&memoryLimitFlag, "memory-limit", "",
+		"File content kept in memory before the rest is spooled to a temporary file (e.g. 512MB, 0 for unlimited; default from config, 256MB).")
+	pflag.StringVar(&modelFlag, "model", "",
+		"Model preset (e.g. gpt-4o, claude-3.7, llama3-8b) setting the token estimate and the --max-tokens budget from its context window.")
+	pflag.BoolVar(&scrubPIIFlag, "scrub-pii", false,
+		"Mask emails, phone numbers and IP addresses in included content.")
+	pflag.BoolVar(&obfuscateFlag, "obfuscate-identifiers", false,
+		"Experimental: consistently rename project-specific identifiers in Go, Python, JavaScript and TypeScript files, keeping keywords and standard library names.")
+	pflag.StringVar(&identifierMapFlag, "identifier-map", filepath.Join(stateDir, "identifier-map.json"),
+		"With --obfuscate-identifiers, where the key and the pseudonym -> identifier mapping are kept between runs.")
+	pflag.StringSliceVar(&scrubAllowFlag, "scrub-allow", []string{},
+		"Domains or regular expressions --scrub-pii keeps (comma-separated, adds to scrub_allow in config).")
+	pflag.StringVar(&preambleFlag, "preamble", "",
+		"Generated section before the files: 'imports' lists each file's package/module and imports.")
+	pflag.BoolVar(&docsFirstFlag, "docs-first", false,
+		"Put READMEs, docs/ and ADRs before source files, and keep them off the --max-tokens cut list.")
+	pflag.BoolVar(&includeErrorsFlag, "include-errors-in-output", false,
+		"Emit a block with the error message for each file that could not be read, instead of leaving it out of the output.")
+	pflag.BoolVar(&dirReadmesFlag, "dir-readmes", false,
+		"Emit each directory's README.md immediately before that directory's files, whatever the ordering and extension filters.")
+	pflag.IntVar(&sampleDirsFlag, "sample-dirs", 0,
+		"Keep at most N files of one extension per directory, spread from first to last, and note the rest (0 keeps all).")
+	pflag.BoolVar(&trimOutliersFlag, "auto-trim-outliers", false,
+		"Leave out files far larger than the rest: over --outlier-multiplier times the --outlier-percentile of file sizes.")
+	pflag.Float64Var(&outlierPctFlag, "outlier-percentile", 95,
+		"With --auto-trim-outliers, the percentile of file sizes the limit is based on.")
+	pflag.Float64Var(&outlierMultFlag, "outlier-multiplier", 2,
+		"With --auto-trim-outliers, how many times the percentile a file may be before it is left out.")
+	pflag.StringSliceVar(&seedFlag, "seed", []string{},
+		"Start from these files (comma-separated or repeated) and keep only files they import or are imported by, see --expand-depth.")
+	pflag.IntVar(&expandDepthFlag, "expand-depth", defaultExpandDepth,
+		"With --seed, how many import hops to follow from the seed files (0 keeps only the seeds).")
+	pflag.IntVar(&maxDepthFlag, "max-depth", 0,
+		"Include only files at most this many levels below each scan root (1 is the root's own files; 0 is unlimited).")
+	pflag.IntVar(&minDepthFlag, "min-depth", 0,
+		"Include only files at least this many levels below each scan root (1 is the root's own files).")
+	pflag.IntVar(&maxFilesFlag, "max-files", 0,
+		"Stop the scan after this many included files (0 is unlimited); files given with -f are not counted.")
+	pflag.StringVar(&grepFlag, "grep", "",
+		"Include only scanned files whose path or content matches this regular expression (e.g. 'payment|invoice').")
+	pflag.IntVar(&grepContextFlag, "grep-context", 0,
+		"With --grep, keep only matching lines plus this many lines around each (0 keeps whole files).")
+	pflag.BoolVar(&ignoreCaseFlag, "ignore-case", false,
+		"Match exclude, include and basename patterns and --grep whatever the case. Patterns already do on macOS and Windows; --ignore-case=false turns that off.")
+	pflag.BoolVar(&jsonRPCFlag, "json-rpc", false,
+		"Serve JSON-RPC 2.0 on stdin/stdout (one request per line: generate, listCandidates, explainExclusion) for editor plugins.")
+	pflag.StringVar(&formatFlag, "format", "",
+		"Output format (text, markdown, json, xml) for stdout and for -o targets without a format prefix.")
 	pflag.StringVarP(&configFileFlag, "config", "c", "",
 		"Custom config file path.")
 	pflag.BoolVarP(&versionFlag, "version", "v", false,
 		"Print version and exit.")
+	pflag.StringVar(&cpuProfileFlag, "cpuprofile", "",
+		"Write a CPU profile (pprof) of the run to this path.")
+	pflag.StringVar(&memProfileFlag, "memprofile", "",
+		"Write a heap profile (pprof) at the end of the run to this path.")
 	pflag.BoolVarP(&noScanFlag, "no-scan", "n", false,
 		"Skip directory scanning. Requires -f flag.")
+	pflag.BoolVar(&noAdaptersFlag, "no-adapters", false,
+		"Include notebooks, PDFs, docx and CSV files verbatim instead of converting them to text.")
+	pflag.IntVar(&csvRowsFlag, "csv-rows", 0,
+		"Keep only the header and first N data rows of CSV files (0 keeps all).")
+	pflag.BoolVar(&notebookIndexFlag, "notebook-cell-index", false,
+		"Number cells (\"# %% cell 3\") when converting Jupyter notebooks.")
+	pflag.BoolVar(&minifyFlag, "minify", false,
+		"Trim trailing whitespace and collapse runs of blank lines in each file.")
+	pflag.IntVar(&truncateLinesFlag, "truncate-lines", 0,
+		"Keep only the first and last N/2 lines of files longer than N lines, with a marker for the omitted middle (0 keeps all).")
+	pflag.BoolVar(&dedentFlag, "dedent", false,
+		"With --minify, also remove indentation common to all lines of a file.")
+	pflag.StringSliceVar(&headerFieldsFlag, "header-fields", []string{},
+		"Metadata appended to each file's header line, in this order: "+strings.Join(headerFieldNames, ", ")+" (e.g. size,mtime,lang,tokens).")
+	pflag.StringVar(&annotateFlag, "annotate", "",
+		"Add a metadata line under each file header. Supported: git (last commit hash, date, author, subject).")
+	pflag.BoolVar(&gitTrackedFlag, "git-tracked", false,
+		"Only scan files tracked in the git index (git ls-files). Manual files (-f) are unaffected.")
+	pflag.StringVar(&nestedReposFlag, "nested-repos", "include",
+		"Policy for git repositories and submodules below CWD: include, skip, or separate (own section after the main files).")
+	pflag.StringVar(&thirdPartyFlag, "third-party", "include",
+		"Vendored code (vendor/, node_modules/, third_party/, go.sum): include, exclude, or summarize as a package/dependency list.")
+	pflag.BoolVar(&noTestsFlag, "no-tests", false,
+		"Skip test files and fixture directories (_test.go, test_*.py, *.spec.ts, __tests__/, testdata/, ...). Overrides config's exclude_tests.")
+	pflag.BoolVar(&onlyTestsFlag, "only-tests", false,
+		"Include only test files and fixture directories.")
+	pflag.BoolVar(&noMinifiedFlag, "no-minified-assets", true,
+		"Skip minified and bundled assets: *.min.js, *.min.css, source maps and .js/.css files with very long lines. Use --no-minified-assets=false to keep them.")
+	pflag.StringVar(&maxFileSizeFlag, "max-file-size", "",
+		"Skip scanned files larger than this (e.g. 500k, 2MB). Manual files (-f) are unaffected.")
+	pflag.BoolVar(&skipBinaryFlag, "skip-binary", true,
+		"Skip scanned files with a NUL byte in their first 8000 bytes, as git treats binary files. Use --skip-binary=false to keep them.")
+	pflag.BoolVar(&manualExcludesFlag, "manual-respects-excludes", false,
+		"Apply exclude rules, --max-file-size, --skip-binary and [limits] to manual files (-f) too.")
+	pflag.Int64Var(&maxTokensFlag, "max-tokens", 0,
+		"Token budget (estimated at ~4 bytes per token). If exceeded, nothing is written and the largest files/directories to exclude are listed.")
+	pflag.BoolVar(&fitFlag, "fit", false,
+		"With --max-tokens, drop the lowest-priority files (see [priority] in config) until the output fits, instead of failing.")
+	pflag.BoolVar(&progressFlag, "progress", false,
+		"Show live scan progress (files scanned, included, bytes read, ETA) on stderr.")
+	pflag.DurationVar(&timeoutFlag, "timeout", 0,
+		"Stop scanning after this long (e.g. 30s) and write what was gathered, marked as partial. 0 disables.")
+	pflag.BoolVar(&reportSkippedFlag, "report-skipped", false,
+		"After the summary, list every file that matched the extensions but was excluded, grouped by reason.")
+	pflag.StringVar(&relativeToFlag, "relative-to", "cwd",
+		"Base for paths in file headers and the summary: cwd, scan-root, git-root, or abs.")
+	pflag.StringSliceVar(&stripPrefixFlag, "strip-prefix", []string{},
+		"Directory prefix(es) to remove from displayed paths, e.g. services/payments/ (comma-separated; first match wins).")
+	pflag.StringVar(&pathPrefixFlag, "path-prefix", "",
+		"Directory prefix to prepend to displayed paths, e.g. repo1/, applied after --strip-prefix.")
+	pflag.BoolVar(&stampFlag, "stamp", false,
+		"Add a header with the version, timestamp, scan roots, filters and a SHA-256 of the included content.")
+	pflag.BoolVar(&repoInfoFlag, "repo-info", false,
+		"Add the git remote URL, branch and HEAD commit of each scan root's repository to the header.")
+	pflag.IntVar(&recentCommitsFlag, "recent-commits", 0,
+		"Append a section with the last N commit subjects touching each scan root (0 is none).")
+	pflag.BoolVar(&recentStatFlag, "recent-commits-stat", false,
+		"With --recent-commits, add each commit's files-changed, insertions and deletions summary.")
+	pflag.StringArrayVar(&githubIssueFlag, "github-issue", nil,
+		"Fetch a GitHub issue or pull request (owner/repo#123 or its URL) with its comments into a document before the files; repeatable. Uses GITHUB_TOKEN or GH_TOKEN if set.")
+	pflag.StringVar(&diffRangeFlag, "diff", "",
+		"Add the git diff of a revision or range (main..HEAD, HEAD~3) as a document before the files, and include the files it changes in full.")
+	pflag.StringVar(&jailFlag, "jail", "",
+		"Refuse scan roots, symlinked files and -f paths that resolve outside this directory (default: the git root, or the CWD outside a repository).")
+	pflag.BoolVar(&allowOutsideFlag, "allow-outside", false,
+		"Include files outside the --jail directory, such as symlink targets elsewhere on disk.")
+	pflag.BoolVar(&quotePathsFlag, "quote-paths", false,
+		"Quote the file paths in text and Markdown headers (\"src/my file.go\"), so they read back unambiguously.")
+	pflag.BoolVar(&fileMapFlag, "file-map", false,
+		"Add a <file_map> block before the files listing every included path and its size, one tab-separated line each, for tools to parse.")
+	pflag.BoolVar(&anonymizePathsFlag, "anonymize-paths", false,
+		"Replace file and directory names in the output with stable pseudonyms (dir_01/file_001.go); file content is unchanged.")
+	pflag.StringVar(&pathMapFlag, "path-map", filepath.Join(stateDir, "path-map.json"),
+		"With --anonymize-paths, where to save the pseudonym -> real path mapping.")
+	pflag.StringVar(&colorFlag, "color", "auto",
+		"Color the summary, and file headers printed to a terminal: auto (terminals, unless NO_COLOR is set), always or never.")
+	pflag.BoolVar(&showSettingsFlag, "show-settings", false,
+		"Print the resolved settings and where each came from before running.")
 
 	pflag.Usage = func() {
 		// Usage string formatting remains the same
 		fmt.Fprintf(os.Stderr, `Usage: %s [target_directory] [flags]
    or: %s [flags]
+   or: %s <command> [flags]
 
 Concatenate source code files relative to the Current Working Directory (CWD).
 
@@ -74,11 +312,13 @@ Exclusion Hierarchy:
 4. .gitignore rules (if enabled).
 
 Output:
-- Code to stdout (default) or -o <file>.
-- Summary/Logs to stderr (default) or stdout (if -o is used).
+- Code to stdout (default) or one or more -o targets (or 'outputs' in config).
+- Summary/Logs to stderr (default) or stdout (if no target is stdout).
 
-Flags:
-`, os.Args[0], os.Args[0], filepath.Join("~", ".config", "codecat", "config.toml"))
+Commands:
+`, os.Args[0], os.Args[0], os.Args[0], filepath.Join("~", ".config", "codecat", "config.toml"))
+		printSubcommands(os.Stderr)
+		fmt.Fprintln(os.Stderr, "\nFlags:")
 		pflag.PrintDefaults()
 	}
 }
@@ -101,122 +341,121 @@ func parseCommaSeparatedSlice(flagValues []string) []string {
 	return result
 }
 
-// loadProjectExcludes remains the same
+// loadProjectExcludes reads the CWD-relative exclude patterns from '.codecat_exclude'.
 func loadProjectExcludes(cwd string) []string {
-	excludeFilePath := filepath.Join(cwd, ".codecat_exclude")
+	return loadProjectPatterns(cwd, ".codecat_exclude", "exclude")
+}
+
+// loadProjectIncludes reads the force-include patterns from '.codecat_include'.
+func loadProjectIncludes(cwd string) []string {
+	return loadProjectPatterns(cwd, ".codecat_include", "include")
+}
+
+// ignoreCaseSetting returns --ignore-case, else the ignore_case config key,
+// and whether either was given; without them only path patterns follow the
+// OS default (foldPathCase).
+func ignoreCaseSetting(appConfig Config) (ignore, set bool) {
+	if pflag.CommandLine.Changed("ignore-case") {
+		return ignoreCaseFlag, true
+	}
+	if appConfig.IgnoreCase != nil {
+		return *appConfig.IgnoreCase, true
+	}
+	return false, false
+}
+
+// trimPatternLine strips the whitespace around a pattern file line but for
+// a trailing space escaped with a backslash, as .gitignore does, so that a
+// name ending in a space can be matched ("notes\ ").
+func trimPatternLine(line string) string {
+	line = strings.TrimLeft(line, " \t")
+	trimmed := strings.TrimRight(line, " \t\r")
+	escapes := len(trimmed) - len(strings.TrimRight(trimmed, `\`))
+	if escapes%2 == 1 && len(trimmed) < len(line) && line[len(trimmed)] == ' ' {
+		return trimmed + " "
+	}
+	return trimmed
+}
+
+// loadProjectPatterns reads one glob per line from fileName in CWD, skipping
+// blank lines, '#' comments and invalid patterns. A missing file yields none.
+func loadProjectPatterns(cwd, fileName, kind string) []string {
+	patternFilePath := filepath.Join(cwd, fileName)
 	patterns := []string{}
 
-	file, err := os.Open(excludeFilePath)
+	file, err := os.Open(patternFilePath)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
-			slog.Debug("No .codecat_exclude file found in CWD.", "path", excludeFilePath)
+			slog.Debug("No "+fileName+" file found in CWD.", "path", patternFilePath)
 		} else {
-			slog.Warn("Error opening .codecat_exclude file, ignoring.",
-				"path", excludeFilePath, "error", err)
+			slog.Warn("Error opening "+fileName+" file, ignoring.",
+				"path", patternFilePath, "error", err)
 		}
 		return patterns
 	}
 	defer file.Close()
 
 	// Log at INFO level as it's a significant action if the file exists
-	slog.Info("Loading project-specific excludes.", "path", excludeFilePath)
+	slog.Info("Loading project-specific "+kind+"s.", "path", patternFilePath)
 	scanner := bufio.NewScanner(file)
 	lineNumber := 0
 	for scanner.Scan() {
 		lineNumber++
-		line := strings.TrimSpace(scanner.Text())
+		line := trimPatternLine(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 		if _, errMatch := filepath.Match(line, "a/b"); errMatch != nil {
-			slog.Warn("Invalid pattern in .codecat_exclude, skipping.",
-				"path", excludeFilePath, "line", lineNumber, "pattern", line, "error", errMatch)
+			slog.Warn("Invalid pattern in "+fileName+", skipping.",
+				"path", patternFilePath, "line", lineNumber, "pattern", line, "error", errMatch)
 			continue
 		}
 		patterns = append(patterns, line)
 	}
 
 	if err := scanner.Err(); err != nil {
-		slog.Warn("Error reading .codecat_exclude file, using patterns read so far.",
-			"path", excludeFilePath, "error", err)
+		slog.Warn("Error reading "+fileName+" file, using patterns read so far.",
+			"path", patternFilePath, "error", err)
 	}
 
-	slog.Debug("Loaded project exclude patterns", "patterns", patterns)
+	slog.Debug("Loaded project "+kind+" patterns", "patterns", patterns)
 	return patterns
 }
 
-func main() {
-	startTime := time.Now()
-	pflag.Parse()
-
-	if versionFlag {
-		fmt.Printf("codecat version %s\n", Version)
-		os.Exit(0)
-	}
-
-	// --- Setup Logging ---
-	var logLevel slog.Level
-	// Update the default level in the error message
-	if err := logLevel.UnmarshalText([]byte(logLevelStr)); err != nil {
-		slog.Error("Invalid log level specified, using 'warn'.",
-			"input", logLevelStr, "error", err)
-		logLevel = slog.LevelWarn // Default to WARN if parsing fails
-	}
-	logOpts := &slog.HandlerOptions{Level: logLevel, AddSource: logLevel <= slog.LevelDebug}
-	logOutput := os.Stderr
-	if outputFile != "" {
-		logOutput = os.Stdout
-	}
-	handler := slog.NewTextHandler(logOutput, logOpts)
-	slog.SetDefault(slog.New(handler))
-	slog.Debug("Logging setup complete.", "level", logLevel.String())
-
-	// --- Get CWD ---
-	cwd, errCwd := os.Getwd()
-	if errCwd != nil {
-		slog.Error("Failed to get current working directory. Cannot proceed.", "error", errCwd)
-		fmt.Fprintf(os.Stderr, "Fatal Error: Could not determine current working directory: %v\n", errCwd)
-		os.Exit(1)
-	}
-	slog.Debug("Current working directory determined.", "cwd", cwd)
-
-	// --- Load Configuration ---
-	appConfig, loadErr := loadConfig(configFileFlag)
-	if loadErr != nil {
-		slog.Error("Fatal error loading configuration.", "error", loadErr)
-		fmt.Fprintf(os.Stderr, "Fatal Error loading configuration: %v\n", loadErr)
-		os.Exit(1)
-	}
+// errUsage marks option errors caused by an invalid combination of arguments.
+var errUsage = errors.New("invalid usage")
 
+// resolveGenerateOptions merges flags, config and project files into GenerateOptions.
+func resolveGenerateOptions(cwd string, appConfig Config, positionalArgs []string) (GenerateOptions, error) {
 	// --- Determine Scan Directories ---
 	scanDirs := []string{}
-	positionalArgs := pflag.Args()
+	var scanRoots []ScanRoot
 	targetDirFlagProvided := pflag.CommandLine.Changed("directory")
 
 	if len(positionalArgs) > 1 {
 		slog.Error("Too many positional arguments.", "args", positionalArgs)
-		fmt.Fprintf(os.Stderr,
-			"Error: Expected at most one positional argument (target directory), got %d: %v\n",
-			len(positionalArgs), positionalArgs)
-		pflag.Usage()
-		os.Exit(1)
+		return GenerateOptions{}, fmt.Errorf(
+			"%w: expected at most one positional argument (target directory), got %d: %v",
+			errUsage, len(positionalArgs), positionalArgs)
 	}
 
 	if len(positionalArgs) == 1 {
 		if targetDirFlagProvided {
 			slog.Error("Cannot use both positional argument and -d flag.",
 				"positional", positionalArgs[0], "flag", targetDirFlagValues)
-			fmt.Fprintf(os.Stderr,
-				"Error: Cannot specify a target directory via positional argument ('%s') and the -d flag ('%s') simultaneously.\n",
-				positionalArgs[0], strings.Join(targetDirFlagValues, ", "))
-			pflag.Usage()
-			os.Exit(1)
+			return GenerateOptions{}, fmt.Errorf(
+				"%w: cannot specify a target directory via positional argument ('%s') and the -d flag ('%s') simultaneously",
+				errUsage, positionalArgs[0], strings.Join(targetDirFlagValues, ", "))
 		}
 		scanDirs = []string{positionalArgs[0]}
 		slog.Debug("Using scan directory from positional argument.", "dir", scanDirs[0])
 	} else if targetDirFlagProvided {
-		scanDirs = parseCommaSeparatedSlice(targetDirFlagValues)
-		slog.Debug("Using scan directories from -d flag.", "dirs", scanDirs)
+		var errRoots error
+		scanDirs, scanRoots, errRoots = parseScanDirValues(targetDirFlagValues)
+		if errRoots != nil {
+			return GenerateOptions{}, errRoots
+		}
+		slog.Debug("Using scan directories from -d flag.", "dirs", scanDirs, "perRootFilters", scanRoots != nil)
 	} else if !noScanFlag {
 		scanDirs = []string{"."}
 		slog.Debug("Defaulting to scan CWD.", "dir", scanDirs[0])
@@ -235,6 +474,10 @@ func main() {
 		absScanDirs = append(absScanDirs, filepath.Clean(absDir))
 	}
 	scanDirs = absScanDirs
+	for i := range scanRoots {
+		scanRoots[i].Dir = scanDirs[i]
+	}
+	scanDirs, scanRoots = dedupeScanRoots(scanDirs, scanRoots)
 	if len(scanDirs) > 0 {
 		slog.Debug("Resolved absolute scan directories.", "dirs", scanDirs)
 	}
@@ -242,6 +485,24 @@ func main() {
 	// --- Process Flags and Config Values ---
 	finalNoScan := noScanFlag
 	finalManualFiles := parseCommaSeparatedSlice(manualFiles)
+	for _, listFile := range fileListFlag {
+		listed, err := readManualFileList(cwd, listFile)
+		if err != nil {
+			return GenerateOptions{}, fmt.Errorf("-F %s: %w", listFile, err)
+		}
+		finalManualFiles = append(finalManualFiles, listed...)
+	}
+	if diffRangeFlag != "" {
+		if err := checkDiffRange(diffRangeFlag); err != nil {
+			return GenerateOptions{}, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		changed, err := diffFiles(cwd, diffRangeFlag)
+		if err != nil {
+			return GenerateOptions{}, err
+		}
+		slog.Debug("Adding the files changed by --diff.", "range", diffRangeFlag, "files", changed)
+		finalManualFiles = append(finalManualFiles, changed...)
+	}
 	if len(finalManualFiles) > 0 {
 		slog.Debug("Using manual files.", "files", finalManualFiles)
 	}
@@ -249,7 +510,11 @@ func main() {
 	if len(finalFlagExcludes) > 0 {
 		slog.Debug("Using command-line CWD-relative excludes.", "patterns", finalFlagExcludes)
 	}
-	projectExcludes := loadProjectExcludes(cwd)
+	projectExcludes := append(loadProjectExcludes(cwd), appConfig.legacyExcludes...)
+	projectIncludes := loadProjectIncludes(cwd)
+	if info, err := os.Stat(filepath.Join(cwd, stateDir)); err == nil && info.IsDir() {
+		projectExcludes = append(projectExcludes, stateDir) // never feed snapshots or caches back in
+	}
 	basenameExcludes := appConfig.ExcludeBasenames
 
 	finalUseGitignore := *appConfig.UseGitignore
@@ -260,6 +525,17 @@ func main() {
 		slog.Debug("Using gitignore setting from config/default.", "use_gitignore", finalUseGitignore)
 	}
 
+	gitattributeAttrs := appConfig.Gitattributes
+	for _, attr := range gitattributeAttrs {
+		if !contains(gitattributeSignals, attr) {
+			return GenerateOptions{}, fmt.Errorf("unknown attribute %q in config's gitattributes (supported: %s)",
+				attr, strings.Join(gitattributeSignals, ", "))
+		}
+	}
+	if noGitattributes {
+		gitattributeAttrs = nil
+	}
+
 	finalExtensionsList := appConfig.IncludeExtensions
 	if pflag.CommandLine.Changed("extensions") {
 		finalExtensionsList = parseCommaSeparatedSlice(extensions)
@@ -267,41 +543,468 @@ func main() {
 	} else {
 		slog.Debug("Using extensions from config/default.", "extensions", finalExtensionsList)
 	}
+	explicitExtensions := pflag.CommandLine.Changed("extensions")
+	if len(extPresetFlag) > 0 {
+		presetExts, errPreset := resolveExtPresets(extPresetFlag, appConfig.ExtPresets)
+		if errPreset != nil {
+			return GenerateOptions{}, errPreset
+		}
+		finalExtensionsList = append(tern(explicitExtensions, finalExtensionsList, nil), presetExts...)
+		explicitExtensions = true
+		slog.Debug("Using extension presets.", "presets", extPresetFlag, "extensions", finalExtensionsList)
+	}
+	finalLanguages := processLanguages(langFlag)
+	if len(finalLanguages) > 0 && !explicitExtensions {
+		slog.Debug("Selecting files by --lang instead of config extensions.", "languages", mapsKeys(finalLanguages))
+		finalExtensionsList = nil
+	}
 	finalExtensionsSet := processExtensions(finalExtensionsList)
 	slog.Debug("Final extension set prepared.", "set_keys", mapsKeys(finalExtensionsSet))
 
+	if !contains(nestedRepoPolicies, nestedReposFlag) {
+		return GenerateOptions{}, fmt.Errorf("%w: unknown --nested-repos value %q (supported: %s)",
+			errUsage, nestedReposFlag, strings.Join(nestedRepoPolicies, ", "))
+	}
+	if !contains(thirdPartyPolicies, thirdPartyFlag) {
+		return GenerateOptions{}, fmt.Errorf("%w: unknown --third-party value %q (supported: %s)",
+			errUsage, thirdPartyFlag, strings.Join(thirdPartyPolicies, ", "))
+	}
+	limits, errLimits := parseLimits(appConfig.Limits)
+	if errLimits != nil {
+		return GenerateOptions{}, fmt.Errorf("config: %w", errLimits)
+	}
+	if _, err := newFileTemplates(appConfig.FileHeaderTemplate, appConfig.FileFooterTemplate); err != nil {
+		return GenerateOptions{}, fmt.Errorf("invalid file template in config: %w", err)
+	}
+	contentPolicies, errPolicies := resolveContentPolicies(appConfig.Content)
+	if errPolicies != nil {
+		return GenerateOptions{}, fmt.Errorf("config: %w", errPolicies)
+	}
+	hooks, errHooks := newHookRunner(cwd, appConfig.Hooks)
+	if errHooks != nil {
+		return GenerateOptions{}, fmt.Errorf("config: %w", errHooks)
+	}
+	if !contains(relativeToModes, relativeToFlag) {
+		return GenerateOptions{}, fmt.Errorf("%w: unknown --relative-to value %q (supported: %s)",
+			errUsage, relativeToFlag, strings.Join(relativeToModes, ", "))
+	}
+	scrubAllow := append(append([]string{}, appConfig.ScrubAllow...), parseCommaSeparatedSlice(scrubAllowFlag)...)
+	if _, err := newPIIScrubber(scrubAllow); err != nil {
+		return GenerateOptions{}, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	var obfuscator *identifierObfuscator
+	if obfuscateFlag {
+		var err error
+		if obfuscator, err = newIdentifierObfuscator(appConfig.ObfuscateKeep, identifierMapFlag); err != nil {
+			return GenerateOptions{}, fmt.Errorf("loading --identifier-map: %w", err)
+		}
+	}
+	ignoreCase, _ := ignoreCaseSetting(appConfig)
+	if _, err := newGrepFilter(grepFlag, grepContextFlag, ignoreCase); err != nil {
+		return GenerateOptions{}, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	seeds, err := seedPaths(cwd, parseCommaSeparatedSlice(seedFlag))
+	if err != nil {
+		return GenerateOptions{}, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if expandDepthFlag < 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --expand-depth must not be negative", errUsage)
+	}
+	if sampleDirsFlag < 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --sample-dirs must not be negative", errUsage)
+	}
+	if outlierPctFlag <= 0 || outlierPctFlag > 100 {
+		return GenerateOptions{}, fmt.Errorf("%w: --outlier-percentile must be above 0 and at most 100", errUsage)
+	}
+	if outlierMultFlag <= 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --outlier-multiplier must be positive", errUsage)
+	}
+	outlierPercentile := 0.0
+	if trimOutliersFlag {
+		outlierPercentile = outlierPctFlag
+	}
+	if maxDepthFlag < 0 || minDepthFlag < 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --max-depth and --min-depth must not be negative", errUsage)
+	}
+	if maxDepthFlag > 0 && minDepthFlag > maxDepthFlag {
+		return GenerateOptions{}, fmt.Errorf("%w: --min-depth %d is greater than --max-depth %d", errUsage, minDepthFlag, maxDepthFlag)
+	}
+	if maxFilesFlag < 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --max-files must not be negative", errUsage)
+	}
+	var maxFileSize int64
+	if maxFileSizeFlag != "" {
+		var errSize error
+		if maxFileSize, errSize = parseSize(maxFileSizeFlag); errSize != nil {
+			return GenerateOptions{}, fmt.Errorf("%w: --max-file-size: %v", errUsage, errSize)
+		}
+	}
+	if grepContextFlag != 0 && grepFlag == "" {
+		return GenerateOptions{}, fmt.Errorf("%w: --grep-context requires --grep", errUsage)
+	}
+	if truncateLinesFlag < 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --truncate-lines must not be negative", errUsage)
+	}
+	if preambleFlag != "" && !contains(preambleKinds, preambleFlag) {
+		return GenerateOptions{}, fmt.Errorf("%w: unknown --preamble value %q (supported: %s)",
+			errUsage, preambleFlag, strings.Join(preambleKinds, ", "))
+	}
+	for _, field := range headerFieldsFlag {
+		if !contains(headerFieldNames, field) {
+			return GenerateOptions{}, fmt.Errorf("%w: unknown --header-fields value %q (supported: %s)",
+				errUsage, field, strings.Join(headerFieldNames, ", "))
+		}
+	}
+	if anonymizePathsFlag && stampFlag {
+		return GenerateOptions{}, fmt.Errorf("%w: --stamp lists the scan roots and filters, so it cannot be combined with --anonymize-paths", errUsage)
+	}
+	if anonymizePathsFlag && repoInfoFlag {
+		return GenerateOptions{}, fmt.Errorf("%w: --repo-info names the repository, so it cannot be combined with --anonymize-paths", errUsage)
+	}
+	if recentCommitsFlag < 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --recent-commits must not be negative", errUsage)
+	}
+	if recentStatFlag && recentCommitsFlag == 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --recent-commits-stat requires --recent-commits", errUsage)
+	}
+	for _, issue := range githubIssueFlag {
+		if _, err := parseIssueRef(issue); err != nil {
+			return GenerateOptions{}, fmt.Errorf("%w: --github-issue: %v", errUsage, err)
+		}
+	}
+	if anonymizePathsFlag && len(githubIssueFlag) > 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --github-issue names the repository, so it cannot be combined with --anonymize-paths", errUsage)
+	}
+	if allowOutsideFlag && jailFlag != "" {
+		return GenerateOptions{}, fmt.Errorf("%w: --jail and --allow-outside are mutually exclusive", errUsage)
+	}
+	jail := ""
+	if !allowOutsideFlag {
+		jail = jailFlag
+		if jail == "" {
+			jail = defaultJail(cwd)
+		} else if !filepath.IsAbs(jail) {
+			jail = filepath.Join(cwd, jail)
+		}
+	}
+	if anonymizePathsFlag && diffRangeFlag != "" {
+		return GenerateOptions{}, fmt.Errorf("%w: --diff shows the changed paths, so it cannot be combined with --anonymize-paths", errUsage)
+	}
+	if anonymizePathsFlag && recentCommitsFlag > 0 {
+		return GenerateOptions{}, fmt.Errorf("%w: --recent-commits shows authors and commit subjects, so it cannot be combined with --anonymize-paths", errUsage)
+	}
+	if annotateFlag != "" && !contains(annotationKinds, annotateFlag) {
+		return GenerateOptions{}, fmt.Errorf("%w: unknown --annotate value %q (supported: %s)",
+			errUsage, annotateFlag, strings.Join(annotationKinds, ", "))
+	}
+
+	testsPolicy := tern(appConfig.ExcludeTests, "exclude", "include")
+	if pflag.CommandLine.Changed("no-tests") {
+		testsPolicy = tern(noTestsFlag, "exclude", "include")
+	}
+	if onlyTestsFlag {
+		if noTestsFlag {
+			return GenerateOptions{}, fmt.Errorf("%w: --no-tests and --only-tests are mutually exclusive", errUsage)
+		}
+		testsPolicy = "only"
+	}
+
 	commentMarker := *appConfig.CommentMarker
 	headerText := *appConfig.HeaderText
 
 	// --- Input Validation ---
-	if finalNoScan && len(finalManualFiles) == 0 {
+	if finalNoScan && len(finalManualFiles) == 0 && diffRangeFlag == "" {
 		slog.Error("Processing criteria missing. --no-scan used and no manual files (-f) provided.")
-		fmt.Fprintln(os.Stderr, "Error: --no-scan flag requires specifying files to include with -f.")
-		os.Exit(1)
+		return GenerateOptions{}, errors.New("--no-scan flag requires specifying files to include with -f")
 	}
-	if !finalNoScan && len(finalExtensionsSet) == 0 && len(finalManualFiles) == 0 && len(scanDirs) > 0 {
+	if !finalNoScan && len(finalExtensionsSet) == 0 && len(finalLanguages) == 0 && len(finalManualFiles) == 0 && len(scanDirs) > 0 {
 		slog.Error(
 			"Processing criteria missing. Scan requested but no extensions/manual files given.")
-		fmt.Fprintln(os.Stderr,
-			"Error: No file extensions specified (config or -e) and no manual files (-f) given, but a scan was requested.")
+		return GenerateOptions{}, errors.New(
+			"no file extensions specified (config or -e) and no manual files (-f) given, but a scan was requested")
+	}
+
+	return GenerateOptions{
+		CWD:                cwd,
+		ScanDirs:           scanDirs,
+		Extensions:         finalExtensionsSet,
+		Languages:          finalLanguages,
+		Shebangs:           processLanguages(shebangFlag),
+		ManualFiles:        finalManualFiles,
+		ExcludeBasenames:   basenameExcludes,
+		IncludeBasenames:   tern(dirReadmesFlag, append(append([]string(nil), appConfig.IncludeBasenames...), dirReadmePattern), appConfig.IncludeBasenames),
+		ProjectExcludes:    projectExcludes,
+		ProjectIncludes:    projectIncludes,
+		FlagExcludes:       finalFlagExcludes,
+		UseGitignore:       finalUseGitignore,
+		Header:             headerText,
+		Marker:             commentMarker,
+		NoScan:             finalNoScan,
+		NoAdapters:         noAdaptersFlag,
+		CSVRows:            csvRowsFlag,
+		NotebookCellIndex:  notebookIndexFlag,
+		Minify:             minifyFlag,
+		Dedent:             dedentFlag,
+		TruncateLines:      truncateLinesFlag,
+		ContentPolicies:    contentPolicies,
+		Roots:              scanRoots,
+		RelativeTo:         relativeToFlag,
+		StripPrefixes:      parseCommaSeparatedSlice(stripPrefixFlag),
+		PathPrefix:         pathPrefixFlag,
+		Checksums:          manifestFlag != "",
+		ScrubPII:           scrubPIIFlag,
+		ScrubAllow:         scrubAllow,
+		Obfuscator:         obfuscator,
+		Hooks:              hooks,
+		Preamble:           preambleFlag,
+		DocsFirst:          docsFirstFlag,
+		DirReadmes:         dirReadmesFlag,
+		SampleDirs:         sampleDirsFlag,
+		OutlierPercentile:  outlierPercentile,
+		OutlierMultiplier:  outlierMultFlag,
+		IncludeErrors:      includeErrorsFlag,
+		Priorities:         appConfig.Priority,
+		Limits:             limits,
+		MaxTokens:          maxTokensFlag,
+		Fit:                fitFlag,
+		Seeds:              seeds,
+		ExpandDepth:        expandDepthFlag,
+		MaxDepth:           maxDepthFlag,
+		MaxFiles:           maxFilesFlag,
+		MinDepth:           minDepthFlag,
+		Grep:               grepFlag,
+		GrepContext:        grepContextFlag,
+		IgnoreCase:         ignoreCase,
+		Annotate:           annotateFlag,
+		HeaderFields:       headerFieldsFlag,
+		GitTracked:         gitTrackedFlag,
+		NestedRepos:        nestedReposFlag,
+		Stamp:              stampFlag,
+		RepoInfo:           repoInfoFlag,
+		RecentCommits:      recentCommitsFlag,
+		RecentCommitsStat:  recentStatFlag,
+		GitHubIssues:       githubIssueFlag,
+		DiffRange:          diffRangeFlag,
+		Jail:               jail,
+		FileMap:            fileMapFlag,
+		QuotePaths:         quotePathsFlag,
+		AnonymizePaths:     anonymizePathsFlag,
+		ThirdParty:         thirdPartyFlag,
+		Tests:              testsPolicy,
+		NoMinifiedAssets:   noMinifiedFlag,
+		MaxFileSize:        maxFileSize,
+		SkipBinary:         skipBinaryFlag,
+		ManualExcludes:     manualExcludesFlag,
+		Gitattributes:      gitattributeAttrs,
+		Progress:           tern[io.Writer](progressFlag, os.Stderr, nil),
+		ReportSkipped:      reportSkippedFlag,
+		FileHeaderTemplate: appConfig.FileHeaderTemplate,
+		FileFooterTemplate: appConfig.FileFooterTemplate,
+	}, nil
+}
+
+func main() {
+	startTime := time.Now()
+	sub, args := popSubcommand(os.Args[1:])
+	if sub != nil && sub.Flags != nil {
+		sub.Flags(pflag.CommandLine)
+	}
+	pflag.CommandLine.Parse(args)
+	positional := pflag.Args()
+	if sub != nil && sub.Prepare != nil {
+		var errPrepare error
+		if positional, errPrepare = sub.Prepare(pflag.CommandLine, positional); errPrepare != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", errPrepare)
+			os.Exit(1)
+		}
+	}
+
+	if versionFlag {
+		fmt.Printf("codecat version %s\n", Version)
+		os.Exit(0)
+	}
+
+	// --- Setup Logging ---
+	var logLevel slog.Level
+	// Update the default level in the error message
+	if err := logLevel.UnmarshalText([]byte(logLevelStr)); err != nil {
+		slog.Error("Invalid log level specified, using 'warn'.",
+			"input", logLevelStr, "error", err)
+		logLevel = slog.LevelWarn // Default to WARN if parsing fails
+	}
+	logOpts := &slog.HandlerOptions{Level: logLevel, AddSource: logLevel <= slog.LevelDebug}
+	var logFile *os.File
+	if logFileFlag != "" {
+		f, err := os.OpenFile(logFileFlag, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --log-file: %v\n", err)
+			os.Exit(1)
+		}
+		logFile = f
+	}
+	// setLogger sends log records to w, or to --log-file when given, so the
+	// summary's stream stays clean whatever the level.
+	setLogger := func(w io.Writer) {
+		if logFile != nil {
+			w = logFile
+		}
+		slog.SetDefault(slog.New(slog.NewTextHandler(w, logOpts)))
+	}
+	// The -o targets decide where logs go, so they are checked before anything
+	// runs; config outputs are resolved with the rest of the options below.
+	flagTargets, errFlagTargets := parseOutputTargets(outputSpecs, formatFlag)
+	if errFlagTargets != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", errFlagTargets)
+		os.Exit(1)
+	}
+	logOutput := logWriterFor(flagTargets)
+	setLogger(logOutput)
+	slog.Debug("Logging setup complete.", "level", logLevel.String())
+
+	stopProfiles, errProfile := startProfiles(cpuProfileFlag, memProfileFlag)
+	if errProfile != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", errProfile)
+		os.Exit(1)
+	}
+
+	// --- Get CWD ---
+	cwd, errCwd := os.Getwd()
+	if errCwd != nil {
+		slog.Error("Failed to get current working directory. Cannot proceed.", "error", errCwd)
+		fmt.Fprintf(os.Stderr, "Fatal Error: Could not determine current working directory: %v\n", errCwd)
+		os.Exit(1)
+	}
+	slog.Debug("Current working directory determined.", "cwd", cwd)
+
+	// --- Load Configuration ---
+	appConfig, loadErr := loadConfig(configFileFlag)
+	if loadErr != nil && sub != nil && sub.LenientConfig {
+		slog.Warn("Ignoring configuration error for this command.", "command", sub.Name, "error", loadErr)
+		appConfig, loadErr = defaultConfig, nil
+	}
+	if loadErr != nil {
+		slog.Error("Fatal error loading configuration.", "error", loadErr)
+		fmt.Fprintf(os.Stderr, "Fatal Error loading configuration: %v\n", loadErr)
+		os.Exit(1)
+	}
+	activeRecipe.applyConfig(&appConfig)
+	if ignore, set := ignoreCaseSetting(appConfig); set {
+		foldPathCase = ignore
+	}
+
+	if modelFlag != "" {
+		preset, errModel := resolveModelPreset(modelFlag, appConfig.Models)
+		if errModel != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", errModel)
+			os.Exit(1)
+		}
+		bytesPerToken = preset.BytesPerToken
+		if !pflag.CommandLine.Changed("max-tokens") {
+			maxTokensFlag = preset.Budget()
+		}
+		slog.Info("Using model preset.", "model", modelFlag, "context_tokens", preset.ContextTokens,
+			"max_tokens", maxTokensFlag, "bytes_per_token", preset.BytesPerToken)
+	}
+
+	pluginsDir := appConfig.PluginsDir
+	if pluginsDir == "" {
+		pluginsDir, _ = defaultPluginsDir()
+	}
+	if errPlugins := loadPlugins(pluginsDir); errPlugins != nil {
+		fmt.Fprintf(os.Stderr, "Error loading plugins from %s: %v\n", pluginsDir, errPlugins)
+		os.Exit(1)
+	}
+
+	if sub != nil && sub.Run != nil {
+		code := sub.Run(cwd, appConfig, positional)
+		stopProfiles()
+		os.Exit(code)
+	}
+
+	opts, optsErr := resolveGenerateOptions(cwd, appConfig, positional)
+	if optsErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", optsErr)
+		if errors.Is(optsErr, errUsage) {
+			pflag.Usage()
+		}
+		os.Exit(1)
+	}
+
+	if jsonRPCFlag {
+		// stdout carries the protocol; keep every log line off it.
+		setLogger(os.Stderr)
+		code := runJSONRPC(os.Stdin, os.Stdout, opts)
+		stopProfiles()
+		os.Exit(code)
+	}
+
+	// --- Resolve Output Targets ---
+	targets, targetsErr := resolveOutputTargets(appConfig)
+	if logWriterFor(targets) != logOutput {
+		logOutput = logWriterFor(targets)
+		setLogger(logOutput)
+	}
+	if targetsErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", targetsErr)
+		os.Exit(1)
+	}
+	summaryOutput, errSummary := openSummaryOutput(summaryOutputFlag, targets, logOutput)
+	if errSummary != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", errSummary)
+		os.Exit(1)
+	}
+
+	opts.OutputPaths = runOutputPaths(cwd, targets)
+	confirmOver, errSize := int64(0), error(nil)
+	if confirmOverFlag != "" {
+		if confirmOver, errSize = parseSize(confirmOverFlag); errSize != nil {
+			fmt.Fprintf(os.Stderr, "Error: --confirm-over: %v\n", errSize)
+			os.Exit(1)
+		}
+	}
+	// Only the main run spools: subcommands and --json-rpc need Output in full.
+	memoryLimit := appConfig.MemoryLimit
+	if memoryLimitFlag != "" {
+		memoryLimit = memoryLimitFlag
+	}
+	if opts.MemoryLimit, errSize = parseSize(memoryLimit); errSize != nil {
+		fmt.Fprintf(os.Stderr, "Error: --memory-limit: %v\n", errSize)
+		os.Exit(1)
+	}
+	if !contains(colorModes, colorFlag) {
+		fmt.Fprintf(os.Stderr, "Error: unknown --color value %q (supported: %s)\n", colorFlag, strings.Join(colorModes, ", "))
+		os.Exit(1)
+	}
+	summaryPalette = palette{on: useColor(colorFlag, summaryOutput)}
+	siUnits = siFlag
+	if !contains(summaryModes, summaryFlag) {
+		fmt.Fprintf(os.Stderr, "Error: unknown --summary value %q (supported: %s)\n", summaryFlag, strings.Join(summaryModes, ", "))
+		os.Exit(1)
+	}
+
+	if showSettingsFlag {
+		printSettings(logOutput, opts, appConfig, targets)
+	}
+
+	if errHook := opts.Hooks.preScan(opts.ScanDirs); errHook != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", errHook)
 		os.Exit(1)
 	}
 
 	// --- Generate Output ---
 	// Log start at INFO level as it's a key operation beginning
 	slog.Info("Starting code concatenation process.")
-	concatenatedOutput, includedFiles, emptyFiles, errorFiles, totalSize, genErr := generateConcatenatedCode(
-		cwd,
-		scanDirs,
-		finalExtensionsSet,
-		finalManualFiles,
-		basenameExcludes,
-		projectExcludes,
-		finalFlagExcludes,
-		finalUseGitignore,
-		headerText, commentMarker,
-		finalNoScan,
-	)
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	cancelTimeout := context.CancelFunc(func() {})
+	if timeoutFlag > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeoutFlag)
+	}
+	result, genErr := generateContext(ctx, opts)
+	timedOut := result.Partial && errors.Is(genErr, context.DeadlineExceeded)
+	cancelTimeout()
+	stopSignals() // a second Ctrl-C while writing terminates immediately
+	includedFiles := result.IncludedFiles
+	emptyFiles, errorFiles, totalSize := result.EmptyFiles, result.ErrorFiles, result.TotalSize
 
 	// --- Error Handling After Generation ---
 	exitCode := 0
@@ -316,65 +1019,139 @@ func main() {
 		slog.Warn("Individual file errors were encountered during processing.")
 	}
 
-	// --- Determine Output Target ---
-	var codeWriter io.Writer
-	var summaryWriter io.Writer = logOutput
-	var outputFileHandle *os.File
-	if outputFile != "" {
-		var errCreate error
-		outputFileHandle, errCreate = os.Create(outputFile)
-		if errCreate != nil {
-			slog.Error("Failed to create output file, writing to stdout instead.",
-				"path", outputFile, "error", errCreate)
-			fmt.Fprintf(os.Stderr, "Error creating output file '%s': %v\n", outputFile, errCreate)
-			fmt.Fprintln(os.Stderr, "Writing code output to standard output.")
-			codeWriter = os.Stdout
-			if exitCode == 0 {
-				exitCode = 1
-			}
-		} else {
-			codeWriter = outputFileHandle
-			// Log at INFO level as it's a key successful action
-			slog.Info("Writing concatenated code to file.", "path", outputFile)
+	if result.Partial && !timedOut {
+		fmt.Fprintln(os.Stderr, "Interrupted: scan cancelled, no output written.")
+		targets = nil
+	}
+
+	// --- Check Token Budget ---
+	if tokens := estimateTokens(result.OutputSize); maxTokensFlag > 0 && tokens > maxTokensFlag {
+		slog.Error("Token budget exceeded, no output written.", "tokens", tokens, "max_tokens", maxTokensFlag)
+		printDropList(os.Stderr, tern(opts.DocsFirst, withoutDocs(includedFiles), includedFiles), tokens, maxTokensFlag)
+		targets = nil
+		exitCode = 1
+	}
+
+	// --- Confirm Large Outputs ---
+	if size := result.OutputSize; confirmOver > 0 && size > confirmOver && len(targets) > 0 {
+		if errConfirm := confirmOutputSize(os.Stdin, os.Stderr, size, confirmOver, targets, isTerminal(os.Stdin)); errConfirm != nil {
+			slog.Error("Output not written.", "error", errConfirm)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", errConfirm)
+			targets = nil
+			exitCode = 1
 		}
-	} else {
-		codeWriter = os.Stdout
-		// Log at INFO level as it's a key successful action
-		slog.Info("Writing concatenated code to stdout.")
 	}
 
-	// --- Write Concatenated Code ---
-	if concatenatedOutput != "" {
-		_, errWrite := io.WriteString(codeWriter, concatenatedOutput)
-		if errWrite != nil {
-			slog.Error("Failed to write concatenated code output.", "error", errWrite)
-			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", errWrite)
+	// --- Write Outputs ---
+	for _, target := range targets {
+		slog.Info("Writing output.", "target", target.String())
+		if errWrite := writeOutput(target, result, opts); errWrite != nil {
+			slog.Error("Failed to write output.", "target", target.String(), "error", errWrite)
+			fmt.Fprintf(os.Stderr, "Error writing output %s: %v\n", target, errWrite)
 			if exitCode == 0 {
 				exitCode = 1
 			}
 		}
-	} else if exitCode == 0 && len(includedFiles) == 0 {
+	}
+	if manifestFlag != "" && targets != nil {
+		if errManifest := writeManifest(manifestFlag, result, time.Now()); errManifest != nil {
+			slog.Error("Failed to write manifest.", "path", manifestFlag, "error", errManifest)
+			fmt.Fprintf(os.Stderr, "Error writing manifest %s: %v\n", manifestFlag, errManifest)
+			exitCode = 1
+		}
+	}
+	if result.PathMap != nil {
+		errMap := os.MkdirAll(filepath.Dir(pathMapFlag), 0755)
+		if errMap == nil {
+			errMap = writePathMap(pathMapFlag, result.PathMap)
+		}
+		if errMap != nil {
+			slog.Error("Failed to write path map.", "path", pathMapFlag, "error", errMap)
+			fmt.Fprintf(os.Stderr, "Error writing path map %s: %v\n", pathMapFlag, errMap)
+			exitCode = 1
+		} else {
+			slog.Info("Wrote path map.", "path", pathMapFlag, "entries", len(result.PathMap))
+		}
+	}
+	if opts.Obfuscator != nil {
+		errMap := os.MkdirAll(filepath.Dir(identifierMapFlag), 0755)
+		if errMap == nil {
+			errMap = opts.Obfuscator.save(identifierMapFlag)
+		}
+		if errMap != nil {
+			slog.Error("Failed to write identifier map.", "path", identifierMapFlag, "error", errMap)
+			fmt.Fprintf(os.Stderr, "Error writing identifier map %s: %v\n", identifierMapFlag, errMap)
+			exitCode = 1
+		}
+	}
+	if errHook := opts.Hooks.postGenerate(opts.ScanDirs, targets, result); errHook != nil {
+		slog.Error("Hook failed.", "error", errHook)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", errHook)
+		exitCode = 1
+	}
+	if exitCode == 0 && len(includedFiles) == 0 {
 		// Log at WARN level as it's potentially unexpected but not an error
 		slog.Warn("No content generated. Output is empty.")
 	}
 
-	if outputFileHandle != nil {
-		errClose := outputFileHandle.Close()
-		if errClose != nil {
-			slog.Error("Failed to close output file.", "path", outputFile, "error", errClose)
-			if exitCode == 0 {
-				exitCode = 1
+	// --- Print Summary ---
+	// An explicit --summary-output asks for the summary even when auto would hide it.
+	if showSummary(summaryFlag, targets, isTerminal(os.Stdout)) || (summaryFlag == "auto" && summaryOutputFlag != "") {
+		printSummaryTree(includedFiles, emptyFiles, errorFiles, result.SpecialFiles, result.ExcludeRules, totalSize, result.PathBase, summaryOutput)
+		if len(result.Dropped) > 0 {
+			printFitReport(summaryOutput, result.Dropped, maxTokensFlag)
+		}
+		if len(result.Outliers) > 0 {
+			printOutlierReport(summaryOutput, result.Outliers, result.OutlierLimit)
+		}
+		if n := result.ExcludedBy["minified"]; n > 0 {
+			fmt.Fprintf(summaryOutput, "Note: skipped %d minified or bundled asset(s); use --no-minified-assets=false to include them.\n", n)
+		}
+		if n := result.ExcludedBy["binary"]; n > 0 {
+			fmt.Fprintf(summaryOutput, "Note: skipped %d binary file(s); use --skip-binary=false to include them.\n", n)
+		}
+		if result.MaxFilesHit {
+			fmt.Fprintf(summaryOutput, "Note: the scan stopped at --max-files %d; more files may match.\n", opts.MaxFiles)
+		}
+	}
+	if timedOut {
+		fmt.Fprintf(summaryOutput, "Run timed out after %s: the output and summary above are partial.\n", timeoutFlag)
+	} else if result.Partial {
+		fmt.Fprintln(summaryOutput, "Run cancelled: the summary above is partial.")
+	}
+	if reportSkippedFlag && !result.Partial {
+		// Gitignored files never reach the exclusion checks; find them as the
+		// difference to a walk without ignore files, as the stats command does.
+		// Skipped under --anonymize-paths: the walk below sees real paths.
+		if opts.UseGitignore && !opts.NoScan && !opts.AnonymizePaths {
+			unignored := opts
+			unignored.UseGitignore = false
+			unignored.ManualFiles = nil
+			unignored.SkipContent = true
+			unignored.Progress = nil
+			if res, err := generate(unignored); err == nil {
+				result.Skipped["gitignore"] = gitignoredFiles(result, res)
+			} else {
+				slog.Warn("Could not determine gitignored files.", "error", err)
 			}
 		}
+		printSkippedReport(summaryOutput, result.Skipped)
+	}
+	if summaryFilePath(summaryOutputFlag) != "" {
+		if errClose := summaryOutput.Close(); errClose != nil {
+			slog.Error("Failed to write the summary.", "path", summaryOutputFlag, "error", errClose)
+			exitCode = 1
+		}
 	}
-
-	// --- Print Summary ---
-	printSummaryTree(includedFiles, emptyFiles, errorFiles, totalSize, cwd, summaryWriter)
 
 	endTime := time.Now()
 	duration := endTime.Sub(startTime)
 	// Log at INFO level as it's the final status
 	slog.Info("Execution finished.", "duration", duration.String())
 
+	if errClose := result.Close(); errClose != nil {
+		slog.Warn("Could not remove the content spool file.", "error", errClose)
+	}
+	stopProfiles()
 	os.Exit(exitCode)
 }
@@ -3,8 +3,9 @@ package main
 
 import (
 	"testing"
-	// Add imports needed for any main-specific tests later
-	// e.g., "os/exec", "github.com/stretchr/testify/assert"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Placeholder test - can be removed or expanded later
@@ -15,4 +16,34 @@ func TestMainFunctionality_Placeholder(t *testing.T) {
 	t.Log("No specific main() unit tests implemented yet.")
 }
 
+func TestTrimPatternLine(t *testing.T) {
+	assert.Equal(t, "*.log", trimPatternLine("  *.log \t\r"))
+	assert.Equal(t, `notes\ `, trimPatternLine(`notes\ `+"  "), "escaped trailing space kept")
+	assert.Equal(t, `dir\\`, trimPatternLine(`dir\\ `), "escaped backslash, plain space")
+	assert.Equal(t, "", trimPatternLine("   "))
+}
+
+func TestGenerate_UnicodeNames(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"docs/🚀 launch.txt": "emoji\n",
+		"docs/שלום.txt":     "rtl\n",
+		"notes.txt ":        "trailing space\n",
+		"notes.txt":         "plain\n",
+		".codecat_exclude":  "notes.txt\\ \n",
+	})
+	opts := GenerateOptions{
+		CWD:             tempDir,
+		ScanDirs:        []string{tempDir},
+		Extensions:      processExtensions([]string{"txt"}),
+		ProjectExcludes: loadProjectExcludes(tempDir),
+		Marker:          "---",
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "--- docs/🚀 launch.txt\nemoji\n")
+	assert.Contains(t, result.Output, "--- docs/שלום.txt\nrtl\n")
+	assert.Contains(t, result.Output, "--- notes.txt\nplain\n")
+	assert.NotContains(t, result.Output, "trailing space", "excluded by the escaped pattern")
+}
+
 // NOTE: The TestGenerateConcatenatedCode_* tests have been moved to walk_test.go
@@ -0,0 +1,58 @@
+// cmd/codecat/manifest.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// FileSource describes a file as it was on disk before any transform, so a
+// manifest can later be checked against the working tree.
+type FileSource struct {
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// newFileSource records the on-disk size, hash and mtime of content.
+func newFileSource(info os.FileInfo, content []byte) *FileSource {
+	sum := sha256.Sum256(content)
+	return &FileSource{Size: int64(len(content)), SHA256: hex.EncodeToString(sum[:]), ModTime: info.ModTime().UTC()}
+}
+
+// ManifestEntry is one included file in a --manifest file.
+type ManifestEntry struct {
+	Path string `json:"path"`
+	FileSource
+}
+
+// Manifest lists the exact file versions an output was built from. Generated
+// summaries (e.g. third-party listings) have no source file and are left out.
+type Manifest struct {
+	Version   string          `json:"version"`
+	Generated string          `json:"generated"`
+	Files     []ManifestEntry `json:"files"`
+}
+
+// newManifest collects the sources recorded for result's included files.
+func newManifest(result GenerateResult, now time.Time) Manifest {
+	manifest := Manifest{Version: Version, Generated: now.UTC().Format(time.RFC3339), Files: []ManifestEntry{}}
+	for _, f := range result.IncludedFiles {
+		if f.Source != nil {
+			manifest.Files = append(manifest.Files, ManifestEntry{Path: f.Path, FileSource: *f.Source})
+		}
+	}
+	return manifest
+}
+
+// writeManifest writes the manifest of result to path as indented JSON.
+func writeManifest(path string, result GenerateResult, now time.Time) error {
+	data, err := json.MarshalIndent(newManifest(result, now), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
@@ -0,0 +1,63 @@
+// cmd/codecat/manifest_test.go
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_ChecksumsForManifest(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":  "package main\n",
+		"notes.md": "# notes\n",
+	})
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(tempDir, "main.go"), mtime, mtime))
+
+	result, err := generate(GenerateOptions{
+		CWD:         tempDir,
+		ScanDirs:    []string{tempDir},
+		Extensions:  processExtensions([]string{"go"}),
+		ManualFiles: []string{"notes.md"},
+		Marker:      "---",
+		Minify:      true,
+		Checksums:   true,
+	})
+	require.NoError(t, err)
+
+	manifest := newManifest(result, time.Now())
+	require.Len(t, manifest.Files, 2)
+	byPath := map[string]ManifestEntry{}
+	for _, e := range manifest.Files {
+		byPath[e.Path] = e
+	}
+	mainGo := byPath["main.go"]
+	assert.Equal(t, int64(13), mainGo.Size, "size is measured before transforms")
+	assert.Equal(t, "df1d036cbbf3df46e2045071e082245ece204c7f53ecf0a4e022bff9bb228f47", mainGo.SHA256)
+	assert.True(t, mtime.Equal(mainGo.ModTime))
+	assert.NotEmpty(t, byPath["notes.md"].SHA256, "manual files are recorded too")
+}
+
+func TestWriteManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	result := GenerateResult{IncludedFiles: []FileInfo{
+		{Path: "a.go", Size: 3, Source: &FileSource{Size: 3, SHA256: "abc"}},
+		{Path: "vendor (summary)", Size: 10},
+	}}
+	require.NoError(t, writeManifest(path, result, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, "2024-01-02T03:04:05Z", got["generated"])
+	files := got["files"].([]any)
+	require.Len(t, files, 1, "generated summaries have no source")
+	assert.Equal(t, map[string]any{"path": "a.go", "size": float64(3), "sha256": "abc", "mtime": "0001-01-01T00:00:00Z"}, files[0])
+}
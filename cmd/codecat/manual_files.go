@@ -2,36 +2,56 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+// manualSkipCheck decides, for --manual-respects-excludes, whether a -f file
+// is left out before it is read. It returns the --report-skipped reason, or
+// "" to keep it.
+type manualSkipCheck func(relPathCwd, absPath string, info os.FileInfo) string
+
 // processManualFiles handles the inclusion of files explicitly specified via the -f flag.
-// It bypasses ALL exclusion rules (basename, CWD-relative, gitignore).
+// It bypasses ALL exclusion rules (basename, CWD-relative, gitignore) unless skip is set.
 // It modifies the provided maps and slices directly.
 func processManualFiles(
+	ctx context.Context, // Stops processing further files once done
 	cwd string,
 	manualFilePaths []string,
 	// --- Exclude patterns are no longer needed here ---
 	// basenameExcludes []string,
 	// cwdRelativeExcludePatterns []string,
-	marker string,
-	outputBuilder *strings.Builder,
+	pipeline *contentPipeline, // Transforms applied to each file's content
+	annotate fileAnnotator, // Optional metadata lines under each header
+	documents *[]Document, // Included content, appended in order
 	processedAbsPaths map[string]bool, // Keep track of processed files
 	includedFiles *[]FileInfo, // Pointer to modify the slice
 	emptyFiles *[]string, // Pointer to modify the slice
 	errorFiles map[string]error, // Modify directly
 	totalSize *int64, // Pointer to modify total size
+	checksums bool, // Record each file's FileSource for --manifest
+	jail string, // Refuse files resolving outside this directory; "" allows any
+	skip manualSkipCheck, // Optional exclude, size and binary checks (--manual-respects-excludes)
 ) {
 	if len(manualFilePaths) == 0 {
 		return // Nothing to do
 	}
 
+	manualFilePaths = expandManualFiles(cwd, manualFilePaths, errorFiles)
 	slog.Debug("Processing manually specified files (-f overrides excludes).", "count", len(manualFilePaths))
 	for _, manualPathRaw := range manualFilePaths {
+		if ctx.Err() != nil {
+			return
+		}
 		// Resolve paths relative to CWD
 		absManualPath := filepath.Join(cwd, manualPathRaw)
 		if !filepath.IsAbs(manualPathRaw) {
@@ -39,15 +59,13 @@ func processManualFiles(
 		} else {
 			absManualPath = manualPathRaw // It was already absolute
 		}
-		absManualPath = filepath.Clean(absManualPath)
+		absManualPath = normalizeVolumePath(filepath.Clean(absManualPath))
 
-		relPathCwd, errRel := filepath.Rel(cwd, absManualPath)
+		relPathCwd, errRel := cwdRelPath(cwd, absManualPath)
 		if errRel != nil {
 			slog.Warn("Could not get relative path for manual file, using absolute.",
 				"absolutePath", absManualPath, "cwd", cwd, "error", errRel)
-			relPathCwd = filepath.ToSlash(absManualPath) // Use absolute if relative fails
-		} else {
-			relPathCwd = filepath.ToSlash(relPathCwd) // Ensure slash format for consistency
+			relPathCwd = filepath.ToSlash(absManualPath) // Use absolute if relative fails (e.g. another drive)
 		}
 
 		// Skip duplicates
@@ -59,6 +77,13 @@ func processManualFiles(
 		slog.Debug("Attempting to process manual file.", "raw", manualPathRaw,
 			"absolute", absManualPath, "relativeToCwd", relPathCwd)
 
+		if errJail := checkJail(jail, absManualPath); errJail != nil {
+			slog.Warn("Refusing manual file outside the jail.", "path", relPathCwd, "error", errJail)
+			errorFiles[relPathCwd] = errJail
+			processedAbsPaths[absManualPath] = true
+			continue
+		}
+
 		// Stat the file
 		fileInfo, errStat := os.Stat(absManualPath)
 		if errStat != nil {
@@ -80,8 +105,17 @@ func processManualFiles(
 			continue
 		}
 
-		// --- NO EXCLUSION CHECKS for -f files ---
-		slog.Debug("Including manual file (bypassing excludes).", "path", relPathCwd)
+		if kind := specialFileKind(fileInfo.Mode()); kind != "" {
+			slog.Warn("Manual path is not a regular file, skipping.", "path", relPathCwd, "kind", kind)
+			errorFiles[relPathCwd] = fmt.Errorf("not a regular file (%s)", kind)
+			processedAbsPaths[absManualPath] = true
+			continue
+		}
+
+		if skip != nil && skip(relPathCwd, absManualPath, fileInfo) != "" {
+			processedAbsPaths[absManualPath] = true
+			continue
+		}
 
 		// Read file content
 		content, errRead := os.ReadFile(absManualPath)
@@ -91,6 +125,7 @@ func processManualFiles(
 			processedAbsPaths[absManualPath] = true
 			continue
 		}
+		slog.Debug("Including manual file.", "path", relPathCwd, "excludesApplied", skip != nil)
 
 		// Handle empty files
 		if len(content) == 0 {
@@ -100,13 +135,226 @@ func processManualFiles(
 			continue
 		}
 
-		// Use the helper function (now in helpers.go) to append content
-		appendFileContent(outputBuilder, marker, relPathCwd, content)
+		var source *FileSource
+		if checksums {
+			source = newFileSource(fileInfo, content)
+		}
+		content, errRead = pipeline.process(relPathCwd, content)
+		if errRead != nil {
+			slog.Warn("Error transforming manual file content.", "path", relPathCwd, "error", errRead)
+			errorFiles[relPathCwd] = errRead
+			processedAbsPaths[absManualPath] = true
+			continue
+		}
+
+		var meta []string
+		if annotate != nil {
+			meta = annotate(absManualPath)
+		}
+		*documents = append(*documents, Document{Path: relPathCwd, Content: string(content), Meta: meta, IsManual: true, modTime: fileInfo.ModTime(), mode: fileInfo.Mode()})
 
 		// Append to slices/maps via pointers or direct map access
 		*includedFiles = append(*includedFiles, FileInfo{
-			Path: relPathCwd, Size: fileInfo.Size(), IsManual: true})
-		*totalSize += fileInfo.Size()           // Add to total size via pointer
+			Path: relPathCwd, Size: int64(len(content)), IsManual: true, Source: source})
+		*totalSize += int64(len(content))       // Add to total size via pointer
 		processedAbsPaths[absManualPath] = true // Mark as processed
 	}
 }
+
+// expandManualFiles replaces -f values containing glob characters with the
+// files they match, in lexical order. "**" matches any number of directories.
+// Directories ("dir" or "dir:depth=N") are replaced by the files below them.
+// A pattern matching no files is recorded in errorFiles.
+func expandManualFiles(cwd string, manualFilePaths []string, errorFiles map[string]error) []string {
+	expanded := make([]string, 0, len(manualFilePaths))
+	for _, raw := range manualFilePaths {
+		dir, depth, errDepth := parseManualDepth(raw)
+		if errDepth != nil {
+			slog.Warn("Invalid manual directory depth.", "value", raw, "error", errDepth)
+			errorFiles[filepath.ToSlash(raw)] = errDepth
+			continue
+		}
+		absDir := dir
+		if !filepath.IsAbs(absDir) {
+			absDir = filepath.Join(cwd, dir)
+		}
+		if info, err := os.Stat(absDir); err == nil && info.IsDir() {
+			files, errList := listManualDir(absDir, dir, depth)
+			if errList == nil && len(files) == 0 {
+				errList = fmt.Errorf("directory contains no files")
+			}
+			if errList != nil {
+				slog.Warn("Manual directory could not be listed.", "path", dir, "error", errList)
+				errorFiles[filepath.ToSlash(dir)] = errList
+				continue
+			}
+			slog.Debug("Expanded manual directory.", "path", dir, "depth", depth, "files", len(files))
+			expanded = append(expanded, files...)
+			continue
+		} else if depth > 0 {
+			slog.Warn("Manual depth given for a path that is not a directory.", "value", raw)
+			errorFiles[filepath.ToSlash(raw)] = fmt.Errorf("depth applies only to directories")
+			continue
+		}
+		if !strings.ContainsAny(raw, "*?[") {
+			expanded = append(expanded, raw)
+			continue
+		}
+		matches, err := globManualFiles(cwd, raw)
+		if err == nil && len(matches) == 0 {
+			err = fmt.Errorf("no files match pattern")
+		}
+		if err != nil {
+			slog.Warn("Manual file pattern failed.", "pattern", raw, "error", err)
+			errorFiles[filepath.ToSlash(raw)] = err
+			continue
+		}
+		slog.Debug("Expanded manual file pattern.", "pattern", raw, "matches", len(matches))
+		expanded = append(expanded, matches...)
+	}
+	return expanded
+}
+
+// parseManualDepth splits a "path:depth=N" -f value. depth is 0 when no
+// limit is given; N must be at least 1 (1 = files directly in the directory).
+func parseManualDepth(raw string) (string, int, error) {
+	i := strings.LastIndex(raw, ":depth=")
+	if i < 0 {
+		return raw, 0, nil
+	}
+	depth, err := strconv.Atoi(raw[i+len(":depth="):])
+	if err != nil || depth < 1 {
+		return "", 0, fmt.Errorf("invalid depth in %q: must be a positive integer", raw)
+	}
+	return raw[:i], depth, nil
+}
+
+// listManualDir returns the files below absDir, at most depth levels deep
+// (0 = unlimited), named in the form the directory was given (display).
+// .git directories are skipped.
+func listManualDir(absDir, display string, depth int) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(absDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == absDir {
+				return err
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(absDir, p)
+		if rel == "." {
+			return nil
+		}
+		level := strings.Count(filepath.ToSlash(rel), "/") + 1
+		if d.IsDir() {
+			if d.Name() == ".git" || (depth > 0 && level >= depth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, filepath.Join(display, rel))
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// globManualFiles walks the directory before the first wildcard segment of
+// pattern and returns the files below it that match, in the pattern's form
+// (relative to CWD or absolute).
+func globManualFiles(cwd, pattern string) ([]string, error) {
+	segs := strings.Split(filepath.ToSlash(pattern), "/")
+	static := 0
+	for static < len(segs) && !strings.ContainsAny(segs[static], "*?[") {
+		static++
+	}
+	for _, seg := range segs[static:] {
+		if _, err := path.Match(seg, ""); err != nil {
+			return nil, err
+		}
+	}
+	prefix := strings.Join(segs[:static], "/")
+	root := filepath.FromSlash(prefix)
+	if prefix == "" && static > 0 {
+		root = string(filepath.Separator) // pattern like "/*.go"
+	}
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(cwd, root)
+	}
+	wildSegs := segs[static:]
+	hasDoubleStar := contains(wildSegs, "**")
+
+	var matches []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil // unreadable subdirectories simply contribute no matches
+		}
+		rel, _ := filepath.Rel(root, p)
+		if rel == "." {
+			return nil
+		}
+		relSegs := strings.Split(filepath.ToSlash(rel), "/")
+		if d.IsDir() {
+			if d.Name() == ".git" || (!hasDoubleStar && len(relSegs) >= len(wildSegs)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchPathSegments(wildSegs, relSegs) {
+			matches = append(matches, tern(filepath.IsAbs(pattern), p, filepath.Join(filepath.FromSlash(prefix), rel)))
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	sort.Strings(matches)
+	return matches, err
+}
+
+// matchPathSegments matches slash-separated name segments against pattern
+// segments, where a "**" segment matches zero or more name segments.
+func matchPathSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchPathSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	match, _ := path.Match(pattern[0], name[0])
+	return match && matchPathSegments(pattern[1:], name[1:])
+}
+
+// readManualFileList reads a -F list file: one -f value per line (a path,
+// glob or dir[:depth=N], relative to the CWD), skipping blank lines and '#'
+// comments. Unlike -f, lines are not split at commas.
+func readManualFileList(cwd, listFile string) ([]string, error) {
+	if !filepath.IsAbs(listFile) {
+		listFile = filepath.Join(cwd, listFile)
+	}
+	data, err := os.ReadFile(listFile)
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	slog.Debug("Read manual file list.", "path", listFile, "entries", len(entries))
+	return entries, nil
+}
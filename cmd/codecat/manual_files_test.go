@@ -0,0 +1,135 @@
+// cmd/codecat/manual_files_test.go
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPathSegments(t *testing.T) {
+	testCases := []struct {
+		pattern, name string
+		expected      bool
+	}{
+		{"*.sql", "a.sql", true},
+		{"*.sql", "x/a.sql", false},
+		{"**/*.sql", "a.sql", true},
+		{"**/*.sql", "x/y/a.sql", true},
+		{"x/**/a.sql", "x/a.sql", true},
+		{"x/**/a.sql", "x/y/z/a.sql", true},
+		{"x/**", "x/y/z", true},
+		{"x/**/a.sql", "y/a.sql", false},
+	}
+	for _, tc := range testCases {
+		got := matchPathSegments(strings.Split(tc.pattern, "/"), strings.Split(tc.name, "/"))
+		assert.Equal(t, tc.expected, got, "%s vs %s", tc.pattern, tc.name)
+	}
+}
+
+func TestExpandManualFiles(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"migrations/001_init.sql":       "CREATE TABLE a();",
+		"migrations/2024/002_users.sql": "CREATE TABLE b();",
+		"migrations/README.md":          "docs",
+		"Makefile":                      "all:",
+	})
+	errorFiles := map[string]error{}
+
+	got := expandManualFiles(tempDir, []string{"Makefile", "migrations/**/*.sql", "nothing/*.go"}, errorFiles)
+	assert.Equal(t, []string{"Makefile", filepath.FromSlash("migrations/001_init.sql"), filepath.FromSlash("migrations/2024/002_users.sql")}, got)
+	require.Contains(t, errorFiles, "nothing/*.go")
+	assert.ErrorContains(t, errorFiles["nothing/*.go"], "no files match")
+
+	got = expandManualFiles(tempDir, []string{filepath.Join(tempDir, "migrations", "*.sql")}, errorFiles)
+	assert.Equal(t, []string{filepath.Join(tempDir, "migrations", "001_init.sql")}, got)
+}
+
+func TestExpandManualFiles_Directories(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"conf/app.toml":        "a = 1",
+		"conf/env/prod.toml":   "b = 2",
+		"conf/env/eu/fra.toml": "c = 3",
+		"main.go":              "package main",
+	})
+	errorFiles := map[string]error{}
+
+	got := expandManualFiles(tempDir, []string{"conf"}, errorFiles)
+	assert.Equal(t, []string{
+		filepath.FromSlash("conf/app.toml"),
+		filepath.FromSlash("conf/env/eu/fra.toml"),
+		filepath.FromSlash("conf/env/prod.toml"),
+	}, got)
+
+	got = expandManualFiles(tempDir, []string{"conf:depth=1"}, errorFiles)
+	assert.Equal(t, []string{filepath.FromSlash("conf/app.toml")}, got)
+
+	got = expandManualFiles(tempDir, []string{"conf:depth=2"}, errorFiles)
+	assert.Equal(t, []string{filepath.FromSlash("conf/app.toml"), filepath.FromSlash("conf/env/prod.toml")}, got)
+	assert.Empty(t, errorFiles)
+
+	got = expandManualFiles(tempDir, []string{"conf:depth=0", "main.go:depth=1"}, errorFiles)
+	assert.Empty(t, got)
+	assert.ErrorContains(t, errorFiles["conf:depth=0"], "positive integer")
+	assert.ErrorContains(t, errorFiles["main.go:depth=1"], "only to directories")
+}
+
+func TestReadManualFileList(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"recipes/auth.txt": "# auth flow\ninternal/auth/**/*.go\n\n  cmd/login.go  \ndocs/auth, sessions.md\nconfig:depth=1\n",
+	})
+	entries, err := readManualFileList(tempDir, "recipes/auth.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"internal/auth/**/*.go", "cmd/login.go", "docs/auth, sessions.md", "config:depth=1"}, entries,
+		"comments and blank lines are skipped; commas are part of the path")
+
+	_, err = readManualFileList(tempDir, filepath.Join(tempDir, "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestGenerate_ManualRespectsExcludes(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":             "package main // mail admin@corp.dev\n",
+		"node_modules/x.js":   "module.exports = 1;\n",
+		"secrets/key.go":      "package secrets\n",
+		"big.sql":             strings.Repeat("x", 200),
+		"logo.go":             "GIF89a\x00\x01",
+		"third_party/a.go":    strings.Repeat("a", 100),
+		"third_party/b.go":    strings.Repeat("b", 50),
+		"third_party/keep.go": "package k\n",
+	})
+	limits, err := parseLimits(map[string]string{"third_party/**": "70"})
+	require.NoError(t, err)
+	opts := GenerateOptions{
+		CWD:              tempDir,
+		ManualFiles:      []string{"main.go", "node_modules/x.js", "secrets/key.go", "big.sql", "logo.go", "third_party/a.go", "third_party/b.go", "third_party/keep.go"},
+		NoScan:           true,
+		ExcludeBasenames: []string{"node_modules"},
+		FlagExcludes:     []string{"secrets"},
+		MaxFileSize:      128,
+		SkipBinary:       true,
+		ScrubPII:         true,
+		Limits:           limits,
+		ReportSkipped:    true,
+		Marker:           "---",
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Len(t, result.IncludedFiles, len(opts.ManualFiles), "-f bypasses everything by default")
+	assert.NotContains(t, result.Output, "admin@corp.dev", "--scrub-pii applies to -f files either way")
+
+	opts.ManualExcludes = true
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"main.go", "third_party/b.go", "third_party/keep.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.NotContains(t, result.Output, "admin@corp.dev")
+	assert.Equal(t, []string{"node_modules/x.js"}, result.Skipped["basename"])
+	assert.Equal(t, []string{"secrets/key.go"}, result.Skipped["flag"])
+	assert.Equal(t, []string{"big.sql"}, result.Skipped["size"])
+	assert.Equal(t, []string{"logo.go"}, result.Skipped["binary"])
+	assert.Equal(t, []string{"third_party/a.go"}, result.Skipped["limit"])
+	assert.Equal(t, 1, result.ExcludedBy["binary"])
+}
@@ -0,0 +1,52 @@
+// cmd/codecat/minified.go
+package main
+
+import (
+	"bytes"
+	"path"
+	"strings"
+)
+
+const (
+	// minifiedLineLength is the line length past which a .js or .css file is
+	// taken for minified or bundled: hand-written code stays far below it.
+	minifiedLineLength = 1000
+	// minifiedSniffSize is how much of a file is searched for such a line.
+	minifiedSniffSize = 64 * 1024
+)
+
+// minifiedExts are the extensions minified and bundled assets come with.
+var minifiedExts = map[string]bool{".js": true, ".mjs": true, ".cjs": true, ".css": true}
+
+// isMinifiedName reports whether relPath is named like a minified asset
+// (app.min.js, style.min.css) or a source map (app.js.map).
+func isMinifiedName(relPath string) bool {
+	base := strings.ToLower(path.Base(relPath))
+	if inner, ok := strings.CutSuffix(base, ".map"); ok {
+		return minifiedExts[path.Ext(inner)]
+	}
+	return minifiedExts[path.Ext(base)] && strings.Contains(base, ".min.")
+}
+
+// hasMinifiedLines reports whether a .js or .css file has a line longer than
+// minifiedLineLength near its start, as minifiers and bundlers produce.
+func hasMinifiedLines(relPath string, content []byte) bool {
+	if !minifiedExts[strings.ToLower(path.Ext(relPath))] {
+		return false
+	}
+	if len(content) > minifiedSniffSize {
+		content = content[:minifiedSniffSize]
+	}
+	for len(content) > 0 {
+		line := content
+		if i := bytes.IndexByte(content, '\n'); i >= 0 {
+			line, content = content[:i], content[i+1:]
+		} else {
+			content = nil
+		}
+		if len(line) > minifiedLineLength {
+			return true
+		}
+	}
+	return false
+}
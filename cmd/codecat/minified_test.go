@@ -0,0 +1,61 @@
+// cmd/codecat/minified_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsMinifiedName(t *testing.T) {
+	for _, p := range []string{"static/app.min.js", "css/Site.MIN.css", "dist/app.js.map", "dist/style.css.map", "lib/x.min.mjs"} {
+		assert.True(t, isMinifiedName(p), p)
+	}
+	for _, p := range []string{"src/app.js", "cmd/min.go", "admin.min.go", "geo/world.map", "src/minimal.js"} {
+		assert.False(t, isMinifiedName(p), p)
+	}
+}
+
+func TestHasMinifiedLines(t *testing.T) {
+	long := "var a=1;" + strings.Repeat("b()", minifiedLineLength)
+	assert.True(t, hasMinifiedLines("dist/bundle.js", []byte("/*! banner */\n"+long+"\n")))
+	assert.False(t, hasMinifiedLines("src/app.js", []byte("function f() {\n  return 1;\n}\n")))
+	assert.False(t, hasMinifiedLines("data/rows.csv", []byte(long)), "only .js and .css are checked")
+	late := strings.Repeat("x;\n", minifiedSniffSize/3+1) + long
+	assert.False(t, hasMinifiedLines("src/big.js", []byte(late)), "only the start is searched")
+}
+
+func TestGenerate_NoMinifiedAssets(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"src/app.js":         "export const a = 1;\n",
+		"static/jq.min.js":   "!function(){}();\n",
+		"dist/bundle.js":     strings.Repeat("a", minifiedLineLength+1) + "\n",
+		"dist/bundle.js.map": `{"version":3}` + "\n",
+		"vendor/keep.min.js": "kept by -f\n",
+	})
+	for _, skipContent := range []bool{false, true} {
+		opts := GenerateOptions{
+			CWD:              tempDir,
+			ScanDirs:         []string{tempDir},
+			ManualFiles:      []string{"vendor/keep.min.js"},
+			NoMinifiedAssets: true,
+			ReportSkipped:    true,
+			SkipContent:      skipContent,
+		}
+		result, err := generate(opts)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"static/jq.min.js", "dist/bundle.js", "dist/bundle.js.map"}, result.Skipped["minified"])
+		assert.Equal(t, 3, result.ExcludedBy["minified"])
+		var included []string
+		for _, f := range result.IncludedFiles {
+			included = append(included, f.Path)
+		}
+		assert.ElementsMatch(t, []string{"src/app.js", "vendor/keep.min.js"}, included)
+	}
+
+	result, err := generate(GenerateOptions{CWD: tempDir, ScanDirs: []string{tempDir}})
+	require.NoError(t, err)
+	assert.Len(t, result.IncludedFiles, 5, "kept when the policy is off")
+}
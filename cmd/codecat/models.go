@@ -0,0 +1,72 @@
+// cmd/codecat/models.go
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModelPreset describes a model's context window for --model. Zero fields
+// of a [models.<name>] config entry fall back to the built-in preset.
+type ModelPreset struct {
+	ContextTokens int64   `toml:"context_tokens"`  // context window size
+	ReserveTokens int64   `toml:"reserve_tokens"`  // left free for the question and answer
+	BytesPerToken float64 `toml:"bytes_per_token"` // tokenizer estimate used for all token counts
+}
+
+// Budget is the --max-tokens value the preset implies.
+func (p ModelPreset) Budget() int64 {
+	return max(p.ContextTokens-p.ReserveTokens, 0)
+}
+
+// builtinModels are the presets known without configuration. Byte ratios are
+// rough averages for code; tokenizers of the Llama 3 and Claude families
+// split code a little finer than OpenAI's.
+var builtinModels = map[string]ModelPreset{
+	"gpt-4o":            {ContextTokens: 128000, ReserveTokens: 16384, BytesPerToken: 4},
+	"gpt-4o-mini":       {ContextTokens: 128000, ReserveTokens: 16384, BytesPerToken: 4},
+	"gpt-4.1":           {ContextTokens: 1047576, ReserveTokens: 32768, BytesPerToken: 4},
+	"claude-3.5-sonnet": {ContextTokens: 200000, ReserveTokens: 8192, BytesPerToken: 3.5},
+	"claude-3.7":        {ContextTokens: 200000, ReserveTokens: 8192, BytesPerToken: 3.5},
+	"gemini-1.5-pro":    {ContextTokens: 2097152, ReserveTokens: 8192, BytesPerToken: 4},
+	"llama3-8b":         {ContextTokens: 8192, ReserveTokens: 1024, BytesPerToken: 3.7},
+	"llama3.1-8b":       {ContextTokens: 131072, ReserveTokens: 4096, BytesPerToken: 3.7},
+	"mistral-7b":        {ContextTokens: 32768, ReserveTokens: 2048, BytesPerToken: 3.5},
+	"qwen2.5-coder-7b":  {ContextTokens: 32768, ReserveTokens: 2048, BytesPerToken: 3.5},
+	"phi3-mini":         {ContextTokens: 4096, ReserveTokens: 1024, BytesPerToken: 3.5},
+}
+
+// resolveModelPreset looks name up in the config's [models] table and the
+// built-in presets (case-insensitively), the config taking precedence field
+// by field.
+func resolveModelPreset(name string, configured map[string]ModelPreset) (ModelPreset, error) {
+	key := strings.ToLower(name)
+	preset, builtin := builtinModels[key]
+	custom, found := ModelPreset{}, false
+	for k, p := range configured {
+		if strings.ToLower(k) == key {
+			custom, found = p, true
+		}
+	}
+	if !builtin && !found {
+		names := append(mapsKeys(builtinModels), mapsKeys(configured)...)
+		return ModelPreset{}, fmt.Errorf("%w: unknown --model %q (known: %s; add more under [models] in config.toml)",
+			errUsage, name, strings.Join(names, ", "))
+	}
+	if custom.ContextTokens > 0 {
+		preset.ContextTokens = custom.ContextTokens
+	}
+	if custom.ReserveTokens > 0 {
+		preset.ReserveTokens = custom.ReserveTokens
+	}
+	if custom.BytesPerToken > 0 {
+		preset.BytesPerToken = custom.BytesPerToken
+	}
+	if preset.ContextTokens <= 0 {
+		return ModelPreset{}, fmt.Errorf("model %q: context_tokens must be set", name)
+	}
+	if preset.BytesPerToken <= 0 {
+		preset.BytesPerToken = defaultBytesPerToken
+	}
+	return preset, nil
+}
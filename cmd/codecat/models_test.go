@@ -0,0 +1,43 @@
+// cmd/codecat/models_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveModelPreset(t *testing.T) {
+	preset, err := resolveModelPreset("GPT-4o", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(128000-16384), preset.Budget())
+	assert.Equal(t, 4.0, preset.BytesPerToken)
+
+	configured := map[string]ModelPreset{
+		"llama3-8b": {ReserveTokens: 2048},
+		"codellama": {ContextTokens: 16384, ReserveTokens: 2048},
+		"broken":    {ReserveTokens: 10},
+	}
+	preset, err = resolveModelPreset("llama3-8b", configured)
+	require.NoError(t, err)
+	assert.Equal(t, ModelPreset{ContextTokens: 8192, ReserveTokens: 2048, BytesPerToken: 3.7}, preset, "config fields override the built-in preset")
+
+	preset, err = resolveModelPreset("codellama", configured)
+	require.NoError(t, err)
+	assert.Equal(t, int64(14336), preset.Budget())
+	assert.Equal(t, defaultBytesPerToken, preset.BytesPerToken)
+
+	_, err = resolveModelPreset("broken", configured)
+	assert.ErrorContains(t, err, "context_tokens must be set")
+	_, err = resolveModelPreset("gpt-9", nil)
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestEstimateTokens_BytesPerToken(t *testing.T) {
+	t.Cleanup(func() { bytesPerToken = defaultBytesPerToken })
+	assert.Equal(t, int64(3), estimateTokens(9))
+	bytesPerToken = 3.5
+	assert.Equal(t, int64(2), estimateTokens(7))
+	assert.Equal(t, int64(286), estimateTokens(1000))
+}
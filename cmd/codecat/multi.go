@@ -0,0 +1,185 @@
+// cmd/codecat/multi.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "multi",
+		Summary: "Concatenate several repositories into one output with a section per repository.",
+		Run:     runMulti,
+	})
+}
+
+// multiRepo is one repository of a 'codecat multi' run.
+type multiRepo struct {
+	Name string // section label and path prefix
+	Opts GenerateOptions
+}
+
+func runMulti(cwd string, appConfig Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: usage: codecat multi [flags] <dir> <dir>...")
+		return 1
+	}
+	dirs := make([]string, len(args))
+	for i, arg := range args {
+		dirs[i] = arg
+		if !filepath.IsAbs(arg) {
+			dirs[i] = filepath.Join(cwd, arg)
+		}
+		if info, err := os.Stat(dirs[i]); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: %s is not a directory\n", arg)
+			return 1
+		}
+	}
+
+	// Each repository is resolved as if codecat ran inside it, so its own
+	// .codecat_exclude, .gitignore and relative paths apply.
+	repos := make([]multiRepo, len(dirs))
+	for i, name := range multiRepoNames(dirs) {
+		opts, err := resolveGenerateOptions(filepath.Clean(dirs[i]), appConfig, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", args[i], err)
+			return 1
+		}
+		opts.PathPrefix = path.Join(opts.PathPrefix, name)
+		repos[i] = multiRepo{Name: name, Opts: opts}
+	}
+
+	targets, err := resolveOutputTargets(appConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	logOutput := logWriterFor(targets)
+	outputs := runOutputPaths(cwd, targets)
+	for i := range repos {
+		repos[i].Opts.OutputPaths = outputs
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	result, genErr := generateMulti(ctx, repos)
+	stop()
+	exitCode := 0
+	if genErr != nil || len(result.ErrorFiles) > 0 {
+		slog.Error("Error(s) reported during multi-repository processing.", "error", genErr)
+		exitCode = 1
+	}
+	if result.Partial {
+		fmt.Fprintln(os.Stderr, "Interrupted: scan cancelled, no output written.")
+		return 1
+	}
+	for _, target := range targets {
+		slog.Info("Writing output.", "target", target.String())
+		if errWrite := writeOutput(target, result, repos[0].Opts); errWrite != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output %s: %v\n", target, errWrite)
+			exitCode = 1
+		}
+	}
+	if showSummary(summaryFlag, targets, isTerminal(os.Stdout)) {
+		printSummaryTree(result.IncludedFiles, result.EmptyFiles, result.ErrorFiles, result.SpecialFiles,
+			result.ExcludeRules, result.TotalSize, result.PathBase, logOutput)
+	}
+	return exitCode
+}
+
+// multiRepoNames labels each directory with its base name, adding the parent
+// directory (and then a counter) when two repositories share a name.
+func multiRepoNames(dirs []string) []string {
+	names := make([]string, len(dirs))
+	count := make(map[string]int)
+	for i, dir := range dirs {
+		names[i] = filepath.Base(filepath.Clean(dir))
+		count[names[i]]++
+	}
+	used := make(map[string]bool)
+	for i, dir := range dirs {
+		if count[names[i]] > 1 {
+			clean := filepath.Clean(dir)
+			names[i] = filepath.Base(filepath.Dir(clean)) + "-" + filepath.Base(clean)
+		}
+		name := names[i]
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", names[i], n)
+		}
+		names[i] = name
+		used[name] = true
+	}
+	return names
+}
+
+// generateMulti runs the pipeline once per repository and merges the results
+// into one document, labelling every file with its repository so the
+// formatters write one section per repository. The merged output is rendered
+// with the first repository's marker, header and stamp settings.
+func generateMulti(ctx context.Context, repos []multiRepo) (GenerateResult, error) {
+	var parts []GenerateResult
+	var errs []error
+	names := make([]string, 0, len(repos))
+	included := 0   // counted against --max-files across the repositories
+	capped := false // repositories left unscanned by --max-files
+	for _, repo := range repos {
+		run := repo.Opts
+		if ctx.Err() != nil {
+			break
+		}
+		if run.MaxFiles > 0 && included >= run.MaxFiles {
+			capped = true
+			break
+		}
+		if run.MaxFiles > 0 {
+			run.MaxFiles -= included
+		}
+		run = partOptions(run)
+		slog.Info("Scanning repository.", "repo", repo.Name, "dir", run.CWD)
+		res, err := generateContext(ctx, run)
+		res.setRoot(repo.Name)
+		included += len(res.IncludedFiles)
+		for i := range res.Repos {
+			res.Repos[i].Path = path.Join(repo.Name, res.Repos[i].Path) // relative to the repository's own CWD
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		parts, errs = append(parts, res), append(errs, err)
+		names = append(names, repo.Name)
+	}
+
+	result := mergeResults(parts)
+	result.Partial = result.Partial || ctx.Err() != nil
+	result.MaxFilesHit = result.MaxFilesHit || capped
+	for _, repo := range repos[:len(names)] {
+		if n := repo.Opts.RecentCommits; n > 0 {
+			for _, log := range recentCommits(repo.Opts.CWD, historyDirs(repo.Opts), n, repo.Opts.RecentCommitsStat) {
+				log.Root = path.Join(repo.Name, log.Root)
+				result.history = append(result.history, log)
+			}
+		}
+		if r := repo.Opts.DiffRange; r != "" {
+			patch, err := gitDiffPatch(repo.Opts.CWD, r, repo.Name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", repo.Name, err))
+			}
+			result.patch += patch
+		}
+	}
+	result.PathBase = fmt.Sprintf("across %d repositories (%s)", len(names), strings.Join(names, ", "))
+	if len(repos) > 0 {
+		if err := renderResult(&result, repos[0].Opts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+// cmd/codecat/multi_test.go
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiRepoNames(t *testing.T) {
+	assert.Equal(t, []string{"client", "server"}, multiRepoNames([]string{"/src/client", "/src/server/"}))
+	assert.Equal(t, []string{"a-app", "b-app", "x"}, multiRepoNames([]string{"/a/app", "/b/app", "/x"}))
+	assert.Equal(t, []string{"a-app", "a-app-2"}, multiRepoNames([]string{"/a/app", "/c/a/app"}))
+}
+
+func TestGenerateMulti(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"client/src/app.go":       "package app\n",
+		"client/.codecat_exclude": "gen\n",
+		"client/gen/api.go":       "package gen\n",
+		"server/main.go":          "package main\n",
+		"server/gen/keep.go":      "package gen\n",
+	})
+	repoOpts := func(dir string) GenerateOptions {
+		return GenerateOptions{
+			CWD:             dir,
+			ScanDirs:        []string{dir},
+			Extensions:      processExtensions([]string{"go"}),
+			ProjectExcludes: loadProjectExcludes(dir),
+			Marker:          "---",
+		}
+	}
+	var repos []multiRepo
+	for _, name := range []string{"client", "server"} {
+		opts := repoOpts(filepath.Join(tempDir, name))
+		opts.PathPrefix = name
+		repos = append(repos, multiRepo{Name: name, Opts: opts})
+	}
+
+	result, err := generateMulti(context.Background(), repos)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"client/src/app.go", "server/gen/keep.go", "server/main.go"},
+		getPathsFromIncludedFiles(result.IncludedFiles), "each repository uses its own project excludes")
+	assert.Equal(t, "across 2 repositories (client, server)", result.PathBase)
+	assert.Contains(t, result.Output, "--- === root: client ===\n--- client/src/app.go\n")
+	assert.Contains(t, result.Output, "--- === root: server ===\n")
+}
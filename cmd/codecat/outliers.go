@@ -0,0 +1,70 @@
+// cmd/codecat/outliers.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+)
+
+// minOutlierFiles is the fewest candidates --auto-trim-outliers needs for
+// a percentile to mean anything.
+const minOutlierFiles = 20
+
+// sizePercentile returns the p-th percentile (nearest rank) of sizes, which
+// must be sorted.
+func sizePercentile(sizes []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sizes))))
+	return sizes[max(rank, 1)-1]
+}
+
+// trimOutliers drops the files larger than multiplier times the percentile
+// of the files' sizes, for --auto-trim-outliers: giant generated files are
+// what usually blows the budget. Files given with -f neither count nor are
+// dropped. It returns the dropped files, largest first, and the limit.
+func trimOutliers(result *GenerateResult, percentile, multiplier float64) ([]FileInfo, int64) {
+	var sizes []int64
+	for _, doc := range result.Documents {
+		if !doc.IsManual {
+			sizes = append(sizes, int64(doc.size()))
+		}
+	}
+	if len(sizes) < minOutlierFiles {
+		slog.Debug("Too few files to trim outliers.", "files", len(sizes), "min", minOutlierFiles)
+		return nil, 0
+	}
+	slices.Sort(sizes)
+	limit := int64(float64(sizePercentile(sizes, percentile)) * multiplier)
+
+	var outliers []FileInfo
+	gone := make(map[string]bool)
+	kept := result.Documents[:0]
+	for _, doc := range result.Documents {
+		if !doc.IsManual && int64(doc.size()) > limit {
+			outliers = append(outliers, FileInfo{Path: doc.Path, Size: int64(doc.size())})
+			gone[doc.Path] = true
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	if len(outliers) == 0 {
+		return nil, limit
+	}
+	result.Documents = kept
+	dropIncludedFiles(result, gone)
+	sort.SliceStable(outliers, func(i, j int) bool { return outliers[i].Size > outliers[j].Size })
+	slog.Warn("Dropped files far above the typical size.", "files", len(outliers), "limit", formatBytes(limit))
+	return outliers, limit
+}
+
+// printOutlierReport lists the files --auto-trim-outliers dropped.
+func printOutlierReport(w io.Writer, outliers []FileInfo, limit int64) {
+	fmt.Fprintf(w, "\n--- Trimmed outliers over %s (%d) ---\n", formatBytes(limit), len(outliers))
+	for _, f := range outliers {
+		fmt.Fprintf(w, "- %s: %s\n", f.Path, formatBytes(f.Size))
+	}
+	fmt.Fprintln(w, "---------------")
+}
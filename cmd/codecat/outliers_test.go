@@ -0,0 +1,54 @@
+// cmd/codecat/outliers_test.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizePercentile(t *testing.T) {
+	sizes := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, int64(10), sizePercentile(sizes, 95))
+	assert.Equal(t, int64(5), sizePercentile(sizes, 50))
+	assert.Equal(t, int64(1), sizePercentile(sizes, 1))
+	assert.Equal(t, int64(10), sizePercentile(sizes, 100))
+}
+
+func TestTrimOutliers(t *testing.T) {
+	var result GenerateResult
+	add := func(p string, size int, manual bool) {
+		result.Documents = append(result.Documents, Document{Path: p, Content: strings.Repeat("x", size), IsManual: manual})
+		result.IncludedFiles = append(result.IncludedFiles, FileInfo{Path: p, Size: int64(size)})
+	}
+	for i := 0; i < 40; i++ {
+		add(fmt.Sprintf("src/f%02d.go", i), 100, false)
+	}
+	add("dist/bundle.js", 5000, false)
+	add("data/dump.json", 900, false)
+	add("notes.txt", 9000, true)
+
+	outliers, limit := trimOutliers(&result, 95, 2)
+	assert.Equal(t, int64(200), limit, "twice the 95th percentile, 100 bytes")
+	assert.Equal(t, []FileInfo{{Path: "dist/bundle.js", Size: 5000}, {Path: "data/dump.json", Size: 900}}, outliers, "largest first")
+	assert.Len(t, result.Documents, 41, "the -f file is kept")
+	assert.Len(t, result.IncludedFiles, 41)
+
+	outliers, _ = trimOutliers(&result, 95, 2)
+	assert.Nil(t, outliers, "nothing left to trim")
+
+	small := GenerateResult{Documents: []Document{{Path: "a", Content: "x"}, {Path: "b", Content: strings.Repeat("x", 1000)}}}
+	outliers, _ = trimOutliers(&small, 95, 2)
+	assert.Nil(t, outliers, "too few files to judge")
+	assert.Len(t, small.Documents, 2)
+}
+
+func TestPrintOutlierReport(t *testing.T) {
+	var buf bytes.Buffer
+	printOutlierReport(&buf, []FileInfo{{Path: "dist/bundle.js", Size: 5000}}, 200)
+	assert.Contains(t, buf.String(), "--- Trimmed outliers over 200 B (1) ---")
+	assert.Contains(t, buf.String(), "- dist/bundle.js: 4.9 KiB")
+}
@@ -0,0 +1,339 @@
+// cmd/codecat/output.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	pflag "github.com/spf13/pflag"
+)
+
+// Special output target paths.
+const (
+	stdoutTarget    = "-"
+	clipboardTarget = "clipboard"
+)
+
+// OutputTarget is one destination of a run, written in Format.
+type OutputTarget struct {
+	Format   string
+	Path     string // file path, stdoutTarget or clipboardTarget
+	Compress string // "" or a key of compressors
+}
+
+func (t OutputTarget) String() string {
+	format := t.Format + tern(t.Compress != "", "+"+t.Compress, "")
+	return fmt.Sprintf("%s (%s)", tern(t.Path == stdoutTarget, "stdout", t.Path), format)
+}
+
+// compressors wrap an output writer for --compress.
+var compressors = map[string]func(io.Writer) io.WriteCloser{
+	"gz": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+	"zstd": func(w io.Writer) io.WriteCloser {
+		zw, _ := zstd.NewWriter(w) // fails only for invalid options
+		return zw
+	},
+}
+
+// compressionExtensions select compression from an output file name; the
+// format is then taken from the extension before it (context.md.gz).
+var compressionExtensions = map[string]string{
+	".gz":  "gz",
+	".zst": "zstd",
+}
+
+// decompressing returns r, transparently decompressed if it starts with the
+// gzip or zstd magic number, for commands reading codecat outputs and patches.
+func decompressing(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return gzip.NewReader(br)
+	case bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return br, nil
+}
+
+// parseOutputTarget reads an -o value: "[format:]path", where path may be "-"
+// for stdout or "clipboard". Without a format prefix, defaultFormat (--format)
+// is used if set, else the format is taken from the file extension, defaulting
+// to text. A ".gz" or ".zst" file is compressed.
+func parseOutputTarget(spec, defaultFormat string) (OutputTarget, error) {
+	if spec == "" {
+		return OutputTarget{}, errors.New("empty output target")
+	}
+	if name, path, ok := strings.Cut(spec, ":"); ok {
+		if _, known := outputFormatters[name]; known {
+			if path == "" {
+				return OutputTarget{}, fmt.Errorf("output target %q has no path", spec)
+			}
+			return OutputTarget{Format: name, Path: path, Compress: compressionExtensions[filepath.Ext(path)]}, nil
+		}
+	}
+	compress := compressionExtensions[filepath.Ext(spec)]
+	if defaultFormat != "" {
+		return OutputTarget{Format: defaultFormat, Path: spec, Compress: compress}, nil
+	}
+	name := tern(compress != "", strings.TrimSuffix(spec, filepath.Ext(spec)), spec)
+	format := formatExtensions[strings.ToLower(filepath.Ext(name))]
+	return OutputTarget{Format: tern(format == "", "text", format), Path: spec, Compress: compress}, nil
+}
+
+// withCompression applies --compress to every file and stdout target.
+func withCompression(targets []OutputTarget, compress string) ([]OutputTarget, error) {
+	if compress == "" {
+		return targets, nil
+	}
+	if _, ok := compressors[compress]; !ok {
+		return nil, fmt.Errorf("%w: unsupported --compress %q (supported: %s)",
+			errUsage, compress, strings.Join(mapsKeys(compressors), ", "))
+	}
+	for i := range targets {
+		if targets[i].Path == clipboardTarget {
+			return nil, fmt.Errorf("%w: --compress cannot be used with the clipboard target", errUsage)
+		}
+		targets[i].Compress = compress
+	}
+	return targets, nil
+}
+
+// parseOutputTargets parses every spec, failing on the first invalid one.
+func parseOutputTargets(specs []string, defaultFormat string) ([]OutputTarget, error) {
+	if _, ok := outputFormatters[defaultFormat]; defaultFormat != "" && !ok {
+		return nil, fmt.Errorf("unknown --format %q (supported: %s)", defaultFormat, strings.Join(mapsKeys(outputFormatters), ", "))
+	}
+	targets := make([]OutputTarget, 0, len(specs))
+	for _, spec := range specs {
+		target, err := parseOutputTarget(spec, defaultFormat)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// resolveOutputTargets returns the -o targets, else the config outputs, else
+// stdout, each in the --format format.
+func resolveOutputTargets(appConfig Config) ([]OutputTarget, error) {
+	specs := outputSpecs
+	if !pflag.CommandLine.Changed("output") {
+		specs = appConfig.Outputs
+	}
+	if len(specs) == 0 {
+		specs = []string{stdoutTarget}
+	}
+	targets, err := parseOutputTargets(specs, formatFlag)
+	if err != nil {
+		return nil, err
+	}
+	return withCompression(targets, compressFlag)
+}
+
+// outputFilePaths returns the absolute paths of the file targets and of any
+// extra files (e.g. --manifest) a run writes, so its scan can skip them.
+func outputFilePaths(cwd string, targets []OutputTarget, extra ...string) []string {
+	var paths []string
+	for _, target := range targets {
+		if target.Path != stdoutTarget && target.Path != clipboardTarget {
+			extra = append(extra, target.Path)
+		}
+	}
+	for _, path := range extra {
+		if path == "" {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		paths = append(paths, normalizeVolumePath(filepath.Clean(path)))
+	}
+	return paths
+}
+
+// runOutputPaths returns outputFilePaths for targets plus every side file the
+// flags make a run write: the manifest, summary, log file and path and
+// identifier maps. The main run, multi and select all skip the same set.
+func runOutputPaths(cwd string, targets []OutputTarget) []string {
+	return outputFilePaths(cwd, targets, manifestFlag, summaryFilePath(summaryOutputFlag), logFileFlag,
+		tern(anonymizePathsFlag, pathMapFlag, ""), tern(obfuscateFlag, identifierMapFlag, ""))
+}
+
+// isOutputFile reports whether absPath is one of outputs or a temporary file
+// left by writeFileAtomic while writing one.
+func isOutputFile(absPath string, outputs []string) bool {
+	absPath = normalizeVolumePath(absPath)
+	for _, out := range outputs {
+		if absPath == out {
+			return true
+		}
+		if filepath.Dir(absPath) == filepath.Dir(out) && strings.HasPrefix(filepath.Base(absPath), "."+filepath.Base(out)+".tmp") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOutput renders result in the target's format and delivers it.
+// Files and piped stdout are streamed one document at a time; the clipboard
+// and a terminal need the whole output in memory.
+func writeOutput(target OutputTarget, result GenerateResult, opts GenerateOptions) error {
+	if target.Compress != "" && target.Path == clipboardTarget {
+		return fmt.Errorf("cannot copy %s-compressed output to the clipboard", target.Compress)
+	}
+	encode := func(w io.Writer) error {
+		out := w
+		var zw io.WriteCloser
+		if target.Compress != "" {
+			zw = compressors[target.Compress](w)
+			out = zw
+		}
+		bw := bufio.NewWriter(out)
+		if err := outputFormatters[target.Format](bw, result, opts); err != nil {
+			return fmt.Errorf("formatting %s: %w", target.Format, err)
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if zw != nil {
+			if err := zw.Close(); err != nil {
+				return fmt.Errorf("compressing output: %w", err)
+			}
+		}
+		return nil
+	}
+	switch {
+	case target.Path == stdoutTarget && !isTerminal(os.Stdout):
+		return encode(os.Stdout)
+	case target.Path != stdoutTarget && target.Path != clipboardTarget:
+		return writeFileAtomicFunc(target.Path, encode)
+	}
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		return err
+	}
+	if target.Path == clipboardTarget {
+		return copyToClipboard(buf.Bytes())
+	}
+	data := buf.Bytes()
+	if target.Format == "text" && target.Compress == "" && useColor(colorFlag, os.Stdout) {
+		data = colorHeaderLines(data, opts.Marker)
+	}
+	return writeToStdout(data)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so an interrupted run never leaves a truncated output behind.
+// Symlinks are followed, the file keeps its mode, and targets that are not
+// regular files (/dev/null, FIFOs) are written in place.
+func writeFileAtomic(path string, data []byte) error {
+	return writeFileAtomicFunc(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFileAtomicFunc is writeFileAtomic with the content written by write.
+func writeFileAtomicFunc(path string, write func(io.Writer) error) error {
+	mode := os.FileMode(0644)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return writeFileInPlace(path, write)
+		}
+		mode = info.Mode().Perm()
+	} else if info, errLstat := os.Lstat(path); errLstat == nil && info.Mode()&os.ModeSymlink != 0 {
+		return writeFileInPlace(path, write) // dangling link: create its target
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeFileInPlace truncates and writes path through an ordinary open, for
+// targets a rename would replace rather than write to.
+func writeFileInPlace(path string, write func(io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// logWriterFor sends logs and the summary to stdout unless stdout carries output.
+func logWriterFor(targets []OutputTarget) *os.File {
+	if len(targets) == 0 {
+		return os.Stderr
+	}
+	for _, t := range targets {
+		if t.Path == stdoutTarget {
+			return os.Stderr
+		}
+	}
+	return os.Stdout
+}
+
+// clipboardCommands are tried in order; the first one on PATH is used.
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// copyToClipboard pipes data into the platform clipboard tool.
+func copyToClipboard(data []byte) error {
+	for _, args := range clipboardCommands {
+		bin, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		slog.Debug("Copying output to clipboard.", "command", args[0], "bytes", len(data))
+		cmd := exec.Command(bin, args[1:]...)
+		cmd.Stdin = bytes.NewReader(data)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return errors.New("no clipboard tool found (install pbcopy, wl-copy, xclip or xsel)")
+}
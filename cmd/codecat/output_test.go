@@ -0,0 +1,234 @@
+// cmd/codecat/output_test.go
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputTarget(t *testing.T) {
+	cases := map[string]OutputTarget{
+		"ctx.txt":             {Format: "text", Path: "ctx.txt"},
+		"out/ctx.MD":          {Format: "markdown", Path: "out/ctx.MD"},
+		"ctx.json":            {Format: "json", Path: "ctx.json"},
+		"ctx.xml":             {Format: "xml", Path: "ctx.xml"},
+		"json:ctx.out":        {Format: "json", Path: "ctx.out"},
+		"-":                   {Format: "text", Path: stdoutTarget},
+		"markdown:clipboard":  {Format: "markdown", Path: clipboardTarget},
+		`C:\work\context.txt`: {Format: "text", Path: `C:\work\context.txt`},
+		"ctx.md.gz":           {Format: "markdown", Path: "ctx.md.gz", Compress: "gz"},
+		"json:ctx.gz":         {Format: "json", Path: "ctx.gz", Compress: "gz"},
+		"ctx.json.zst":        {Format: "json", Path: "ctx.json.zst", Compress: "zstd"},
+	}
+	for spec, want := range cases {
+		got, err := parseOutputTarget(spec, "")
+		require.NoError(t, err, spec)
+		assert.Equal(t, want, got, spec)
+	}
+
+	got, err := parseOutputTarget("ctx.md", "xml")
+	require.NoError(t, err)
+	assert.Equal(t, OutputTarget{Format: "xml", Path: "ctx.md"}, got, "--format overrides the extension")
+	got, err = parseOutputTarget("json:ctx.md", "xml")
+	require.NoError(t, err)
+	assert.Equal(t, OutputTarget{Format: "json", Path: "ctx.md"}, got, "a prefix overrides --format")
+	_, err = parseOutputTargets([]string{"-"}, "yaml")
+	assert.ErrorContains(t, err, "unknown --format")
+
+	_, err = parseOutputTarget("json:", "")
+	assert.Error(t, err)
+	_, err = parseOutputTargets([]string{"a.txt", ""}, "")
+	assert.Error(t, err)
+}
+
+func TestWriteOutput_Files(t *testing.T) {
+	dir := t.TempDir()
+	result := GenerateResult{Documents: []Document{{Path: "a.go", Content: "package a\n"}}}
+	opts := GenerateOptions{Marker: "---"}
+
+	for _, spec := range []string{"ctx.txt", "ctx.md", "ctx.json"} {
+		target, err := parseOutputTarget(filepath.Join(dir, spec), "")
+		require.NoError(t, err)
+		require.NoError(t, writeOutput(target, result, opts))
+	}
+
+	text, _ := os.ReadFile(filepath.Join(dir, "ctx.txt"))
+	assert.Equal(t, "--- a.go\npackage a\n---\n", string(text))
+	md, _ := os.ReadFile(filepath.Join(dir, "ctx.md"))
+	assert.Contains(t, string(md), "## a.go\n\n```go\npackage a\n```\n")
+	js, _ := os.ReadFile(filepath.Join(dir, "ctx.json"))
+	assert.Contains(t, string(js), `"path": "a.go"`)
+}
+
+func TestWriteOutput_Compressed(t *testing.T) {
+	result := GenerateResult{Documents: []Document{{Path: "a.go", Content: "package a\n"}}}
+	for _, name := range []string{"ctx.txt.gz", "ctx.txt.zst"} {
+		path := filepath.Join(t.TempDir(), name)
+		target, err := parseOutputTarget(path, "")
+		require.NoError(t, err)
+		require.NoError(t, writeOutput(target, result, GenerateOptions{Marker: "---"}))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotEqual(t, byte('-'), data[0], "%s is compressed", name)
+		r, err := decompressing(bytes.NewReader(data))
+		require.NoError(t, err)
+		text, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "--- a.go\npackage a\n---\n", string(text), name)
+	}
+}
+
+func TestDecompressing(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err := zw.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	for name, input := range map[string][]byte{"gzip": gz.Bytes(), "plain": []byte("hello\n")} {
+		r, err := decompressing(bytes.NewReader(input))
+		require.NoError(t, err, name)
+		out, err := io.ReadAll(r)
+		require.NoError(t, err, name)
+		assert.Equal(t, "hello\n", string(out), name)
+	}
+	r, err := decompressing(strings.NewReader(""))
+	require.NoError(t, err)
+	out, _ := io.ReadAll(r)
+	assert.Empty(t, out, "shorter than a magic number")
+}
+
+func TestWithCompression(t *testing.T) {
+	targets, err := withCompression([]OutputTarget{{Format: "text", Path: "a.txt"}, {Format: "text", Path: stdoutTarget}}, "gz")
+	require.NoError(t, err)
+	assert.Equal(t, "gz", targets[0].Compress)
+	assert.Equal(t, "gz", targets[1].Compress)
+
+	_, err = withCompression([]OutputTarget{{Format: "text", Path: clipboardTarget}}, "gz")
+	assert.ErrorIs(t, err, errUsage)
+	targets, err = withCompression([]OutputTarget{{Format: "text", Path: "a.txt"}}, "zstd")
+	require.NoError(t, err)
+	assert.Equal(t, "zstd", targets[0].Compress)
+	_, err = withCompression(nil, "bz2")
+	assert.ErrorContains(t, err, "unsupported --compress")
+}
+
+func TestLogWriterFor(t *testing.T) {
+	assert.Equal(t, os.Stderr, logWriterFor(nil))
+	assert.Equal(t, os.Stdout, logWriterFor([]OutputTarget{{Format: "text", Path: "a.txt"}}))
+	assert.Equal(t, os.Stderr, logWriterFor([]OutputTarget{{Format: "text", Path: "a.txt"}, {Format: "json", Path: stdoutTarget}}))
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+	require.NoError(t, writeFileAtomic(path, []byte("new")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, entries, 1, "temporary file is renamed away")
+}
+
+func TestWriteFileAtomic_KeepsTarget(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks and FIFOs need a Unix file system")
+	}
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real.txt")
+	require.NoError(t, os.WriteFile(real, []byte("old"), 0600))
+	link := filepath.Join(dir, "link.txt")
+	require.NoError(t, os.Symlink("real.txt", link))
+	require.NoError(t, writeFileAtomic(link, []byte("new")))
+	data, err := os.ReadFile(real)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data), "written through the symlink")
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "the symlink stays a symlink")
+	info, err = os.Stat(real)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the file keeps its mode")
+
+	dangling := filepath.Join(dir, "dangling.txt")
+	require.NoError(t, os.Symlink("made.txt", dangling))
+	require.NoError(t, writeFileAtomic(dangling, []byte("made")))
+	data, err = os.ReadFile(filepath.Join(dir, "made.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "made", string(data))
+
+	mkfifo, err := exec.LookPath("mkfifo")
+	if err != nil {
+		return
+	}
+	fifo := filepath.Join(dir, "fifo")
+	require.NoError(t, exec.Command(mkfifo, fifo).Run())
+	read := make(chan string)
+	go func() {
+		data, _ := os.ReadFile(fifo)
+		read <- string(data)
+	}()
+	require.NoError(t, writeFileAtomic(fifo, []byte("piped")))
+	assert.Equal(t, "piped", <-read, "the FIFO's reader gets the output")
+}
+
+func TestOutputFilePaths(t *testing.T) {
+	cwd := filepath.FromSlash("/work")
+	targets := []OutputTarget{{Path: "ctx.md"}, {Path: stdoutTarget}, {Path: clipboardTarget}, {Path: filepath.FromSlash("/tmp/a.txt")}}
+	outputs := outputFilePaths(cwd, targets, "manifest.json", "")
+	assert.Equal(t, []string{
+		filepath.Join(cwd, "manifest.json"), filepath.Join(cwd, "ctx.md"), filepath.FromSlash("/tmp/a.txt"),
+	}, outputs)
+
+	assert.True(t, isOutputFile(filepath.Join(cwd, "ctx.md"), outputs))
+	assert.True(t, isOutputFile(filepath.Join(cwd, ".ctx.md.tmp123"), outputs), "interrupted atomic writes")
+	assert.False(t, isOutputFile(filepath.Join(cwd, "sub", "ctx.md"), outputs))
+}
+
+func TestRunOutputPaths(t *testing.T) {
+	manifest, logFile, anonymize, pathMap := manifestFlag, logFileFlag, anonymizePathsFlag, pathMapFlag
+	defer func() {
+		manifestFlag, logFileFlag, anonymizePathsFlag, pathMapFlag = manifest, logFile, anonymize, pathMap
+	}()
+	cwd := filepath.FromSlash("/work")
+	targets := []OutputTarget{{Path: "ctx.md"}}
+	manifestFlag, logFileFlag, pathMapFlag = "manifest.json", "run.log", "paths.json"
+	assert.Equal(t, []string{
+		filepath.Join(cwd, "manifest.json"), filepath.Join(cwd, "run.log"), filepath.Join(cwd, "ctx.md"),
+	}, runOutputPaths(cwd, targets), "the path map is only written with --anonymize-paths")
+
+	anonymizePathsFlag = true
+	assert.Contains(t, runOutputPaths(cwd, targets), filepath.Join(cwd, "paths.json"))
+}
+
+func TestGenerate_SkipsOwnOutput(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":    "package main\n",
+		"context.md": "--- main.go\npackage main\n---\n",
+		"notes.md":   "# notes\n",
+	})
+	opts := GenerateOptions{
+		CWD:           tempDir,
+		ScanDirs:      []string{tempDir},
+		Extensions:    processExtensions([]string{"go", "md"}),
+		Marker:        "---",
+		ReportSkipped: true,
+		OutputPaths:   outputFilePaths(tempDir, []OutputTarget{{Format: "markdown", Path: "context.md"}}),
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "notes.md"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.Equal(t, 1, result.ExcludedBy["output"])
+	assert.Equal(t, []string{"context.md"}, result.Skipped["output"])
+}
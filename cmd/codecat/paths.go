@@ -0,0 +1,207 @@
+// cmd/codecat/paths.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// normalizeVolumePath makes Windows paths from the walker, the CWD and the
+// command line comparable: extended-length prefixes (\\?\C:\..., \\?\UNC\...)
+// are removed and drive letters upper-cased. Paths without a volume, which
+// includes every Unix path, are returned unchanged.
+func normalizeVolumePath(p string) string {
+	switch {
+	case strings.HasPrefix(p, `\\?\UNC\`):
+		p = `\\` + p[len(`\\?\UNC\`):]
+	case strings.HasPrefix(p, `\\?\`) && len(p) > 5 && p[5] == ':':
+		p = p[len(`\\?\`):]
+	}
+	if len(p) >= 3 && p[1] == ':' && (p[2] == '\\' || p[2] == '/') && 'a' <= p[0] && p[0] <= 'z' {
+		p = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return p
+}
+
+// cwdRelPath returns absPath relative to cwd with forward slashes, the form
+// used for RelPathCwd, output headers and exclude patterns on every platform.
+func cwdRelPath(cwd, absPath string) (string, error) {
+	rel, err := filepath.Rel(normalizeVolumePath(cwd), normalizeVolumePath(absPath))
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// isDirectoryLink reports whether absPath is a symlink, or on Windows an NTFS
+// junction or other reparse point, that resolves to a directory. Such links
+// are never descended, the same policy as for symlinked directories.
+func isDirectoryLink(absPath string) bool {
+	if !isLink(absPath) {
+		return false
+	}
+	target, err := os.Stat(absPath)
+	return err == nil && target.IsDir()
+}
+
+// isLink reports whether absPath is a symlink or, on Windows, a junction or
+// other reparse point.
+func isLink(absPath string) bool {
+	info, err := os.Lstat(absPath)
+	return err == nil && info.Mode()&(fs.ModeSymlink|fs.ModeIrregular) != 0
+}
+
+// errOutsideJail marks paths refused by --jail.
+var errOutsideJail = errors.New("outside the jail")
+
+// defaultJail is the --jail directory when none is given: the git root of
+// cwd, or cwd outside a repository.
+func defaultJail(cwd string) string {
+	if top, err := gitTopLevel(cwd); err == nil {
+		return top
+	}
+	return cwd
+}
+
+// resolveJail returns the jail directory with symlinks resolved, since the
+// paths checked against it are compared after resolving theirs.
+func resolveJail(dir string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("--jail: %w", err)
+	}
+	if info, err := os.Stat(resolved); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("--jail: %s is not a directory", dir)
+	}
+	return normalizeVolumePath(resolved), nil
+}
+
+// checkJail returns an error when absPath, with symlinks resolved, lies
+// outside jail, a directory from resolveJail. An empty jail allows anything,
+// and a path that cannot be resolved is left to fail when it is read.
+func checkJail(jail, absPath string) error {
+	if jail == "" {
+		return nil
+	}
+	resolved, err := filepath.EvalSymlinks(absPath)
+	if err != nil || isWithinDir(normalizeVolumePath(resolved), jail) {
+		return nil
+	}
+	return fmt.Errorf("resolves to %s, %w %s (--allow-outside includes it)", resolved, errOutsideJail, jail)
+}
+
+// relativeToModes are the accepted --relative-to values.
+var relativeToModes = []string{"cwd", "scan-root", "git-root", "abs"}
+
+// newPathRebaser returns the function mapping CWD-relative paths to the
+// --relative-to base, and the summary's description of that base. The
+// function is nil for "cwd", where paths are already in the right form.
+// With several scan roots, scan-root paths keep the root's name as prefix.
+func newPathRebaser(mode, cwd string, scanDirs []string) (func(string) string, string, error) {
+	toAbs := func(rel string) string { return filepath.Join(cwd, filepath.FromSlash(rel)) }
+	relTo := func(base, rel string) string {
+		if r, err := cwdRelPath(base, toAbs(rel)); err == nil && r != ".." && !strings.HasPrefix(r, "../") {
+			return r
+		}
+		return rel // outside the base (e.g. a manual file): keep it CWD-relative
+	}
+	switch mode {
+	case "", "cwd":
+		return nil, describePathBase("CWD", cwd), nil
+	case "abs":
+		return func(rel string) string { return filepath.ToSlash(toAbs(rel)) }, "with absolute paths", nil
+	case "git-root":
+		top, err := gitTopLevel(cwd)
+		if err != nil {
+			return nil, "", err
+		}
+		return func(rel string) string { return relTo(top, rel) }, describePathBase("git root", top), nil
+	case "scan-root":
+		if len(scanDirs) == 0 {
+			return nil, describePathBase("CWD", cwd), nil
+		}
+		if len(scanDirs) == 1 {
+			return func(rel string) string { return relTo(scanDirs[0], rel) }, describePathBase("scan root", scanDirs[0]), nil
+		}
+		return func(rel string) string {
+			abs := toAbs(rel)
+			best := ""
+			for _, dir := range scanDirs {
+				if (abs == dir || strings.HasPrefix(abs, dir+string(filepath.Separator))) && len(dir) > len(best) {
+					best = dir
+				}
+			}
+			if best == "" {
+				return rel
+			}
+			return relTo(filepath.Dir(best), rel)
+		}, "relative to their scan roots", nil
+	}
+	return nil, "", fmt.Errorf("unknown --relative-to value %q (supported: %s)", mode, strings.Join(relativeToModes, ", "))
+}
+
+// withPathPrefixes wraps rebase (nil = identity) so that the first matching
+// strip prefix is removed and prefix is prepended, for --strip-prefix and
+// --path-prefix. Both are directory prefixes; a missing trailing slash is
+// added. It returns rebase unchanged when neither is set.
+func withPathPrefixes(rebase func(string) string, strip []string, prefix string) func(string) string {
+	if len(strip) == 0 && prefix == "" {
+		return rebase
+	}
+	dirPrefix := func(p string) string {
+		p = filepath.ToSlash(p)
+		return tern(p == "" || strings.HasSuffix(p, "/"), p, p+"/")
+	}
+	strips := make([]string, 0, len(strip))
+	for _, s := range strip {
+		if s = dirPrefix(strings.TrimPrefix(filepath.ToSlash(s), "./")); s != "" {
+			strips = append(strips, s)
+		}
+	}
+	prefix = dirPrefix(prefix)
+	return func(p string) string {
+		if rebase != nil {
+			p = rebase(p)
+		}
+		for _, s := range strips {
+			if strings.HasPrefix(p, s) {
+				p = p[len(s):]
+				break
+			}
+		}
+		return prefix + p
+	}
+}
+
+// rebaseResultPaths rewrites every path recorded in result with rebase.
+func rebaseResultPaths(result *GenerateResult, rebase func(string) string) {
+	for i := range result.Documents {
+		result.Documents[i].Path = rebase(result.Documents[i].Path)
+	}
+	for i := range result.IncludedFiles {
+		result.IncludedFiles[i].Path = rebase(result.IncludedFiles[i].Path)
+	}
+	for i := range result.EmptyFiles {
+		result.EmptyFiles[i] = rebase(result.EmptyFiles[i])
+	}
+	errorFiles := make(map[string]error, len(result.ErrorFiles))
+	for p, err := range result.ErrorFiles {
+		errorFiles[rebase(p)] = err
+	}
+	result.ErrorFiles = errorFiles
+	specialFiles := make(map[string]string, len(result.SpecialFiles))
+	for p, kind := range result.SpecialFiles {
+		specialFiles[rebase(p)] = kind
+	}
+	result.SpecialFiles = specialFiles
+	for reason, paths := range result.Skipped {
+		for i := range paths {
+			paths[i] = rebase(paths[i])
+		}
+		result.Skipped[reason] = paths
+	}
+}
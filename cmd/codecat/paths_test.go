@@ -0,0 +1,219 @@
+// cmd/codecat/paths_test.go
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeVolumePath(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`/home/user/project`, `/home/user/project`},
+		{`c:\src\app`, `C:\src\app`},
+		{`C:\src\app`, `C:\src\app`},
+		{`\\?\c:\src\app`, `C:\src\app`},
+		{`\\?\UNC\server\share\app`, `\\server\share\app`},
+		{`\\server\share\app`, `\\server\share\app`},
+		{`c:relative`, `c:relative`},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, normalizeVolumePath(tc.input), "input %q", tc.input)
+	}
+}
+
+func TestCwdRelPath(t *testing.T) {
+	rel, err := cwdRelPath("/project", "/project/src/main.go")
+	require.NoError(t, err)
+	assert.Equal(t, "src/main.go", rel)
+
+	rel, err = cwdRelPath("/project", "/project")
+	require.NoError(t, err)
+	assert.Equal(t, ".", rel)
+}
+
+func TestIsDirectoryLink(t *testing.T) {
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "target")
+	require.NoError(t, os.Mkdir(target, 0755))
+	file := filepath.Join(tempDir, "file.txt")
+	require.NoError(t, os.WriteFile(file, []byte("x"), 0644))
+
+	dirLink := filepath.Join(tempDir, "dirlink")
+	fileLink := filepath.Join(tempDir, "filelink")
+	if err := os.Symlink(target, dirLink); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	require.NoError(t, os.Symlink(file, fileLink))
+
+	assert.True(t, isDirectoryLink(dirLink))
+	assert.False(t, isDirectoryLink(fileLink))
+	assert.False(t, isDirectoryLink(target))
+	assert.False(t, isDirectoryLink(filepath.Join(tempDir, "missing")))
+}
+
+func TestExcluderForwardSlashPatterns(t *testing.T) {
+	excluder := NewDefaultExcluder(nil, []string{"docs/*", "build/"})
+
+	excluded, _, pattern := excluder.IsExcluded(PathInfo{RelPathCwd: "docs/guide/intro.md", BaseName: "intro.md"})
+	assert.True(t, excluded)
+	assert.Equal(t, "docs/*", pattern)
+
+	excluded, _, _ = excluder.IsExcluded(PathInfo{RelPathCwd: "build", BaseName: "build", IsDir: true})
+	assert.True(t, excluded)
+
+	excluded, _, pattern = excluder.IsExcluded(PathInfo{RelPathCwd: "build/out/app.js", BaseName: "app.js"})
+	assert.True(t, excluded)
+	assert.Equal(t, "build/", pattern)
+
+	excluded, _, _ = excluder.IsExcluded(PathInfo{RelPathCwd: "src/docs.go", BaseName: "docs.go"})
+	assert.False(t, excluded)
+}
+
+func TestNewPathRebaser(t *testing.T) {
+	cwd := filepath.FromSlash("/work/project")
+	backend := filepath.Join(cwd, "services", "backend")
+	frontend := filepath.Join(cwd, "web")
+
+	rebase, base, err := newPathRebaser("cwd", cwd, []string{backend})
+	require.NoError(t, err)
+	assert.Nil(t, rebase)
+	assert.Equal(t, "relative to CWD 'project'", base)
+
+	rebase, base, err = newPathRebaser("scan-root", cwd, []string{backend})
+	require.NoError(t, err)
+	assert.Equal(t, "relative to scan root 'backend'", base)
+	assert.Equal(t, "main.go", rebase("services/backend/main.go"))
+	assert.Equal(t, "Makefile", rebase("Makefile"), "files outside the root stay CWD-relative")
+
+	rebase, _, err = newPathRebaser("scan-root", cwd, []string{backend, frontend})
+	require.NoError(t, err)
+	assert.Equal(t, "backend/main.go", rebase("services/backend/main.go"))
+	assert.Equal(t, "web/app.ts", rebase("web/app.ts"))
+
+	rebase, base, err = newPathRebaser("abs", cwd, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "with absolute paths", base)
+	assert.Equal(t, filepath.ToSlash(filepath.Join(cwd, "a", "b.go")), rebase("a/b.go"))
+
+	_, _, err = newPathRebaser("home", cwd, nil)
+	assert.ErrorContains(t, err, "unknown --relative-to")
+}
+
+func TestNewPathRebaser_GitRoot(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	out, err := exec.Command("git", "init", "-q", repo).CombinedOutput()
+	require.NoError(t, err, string(out))
+	sub := filepath.Join(repo, "tools", "cli")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	rebase, base, err := newPathRebaser("git-root", sub, nil)
+	require.NoError(t, err)
+	assert.Contains(t, base, "relative to git root")
+	assert.Equal(t, "tools/cli/main.go", rebase("main.go"))
+
+	_, _, err = newPathRebaser("git-root", t.TempDir(), nil)
+	assert.Error(t, err, "outside a repository")
+}
+
+func TestRebaseResultPaths(t *testing.T) {
+	result := GenerateResult{
+		Documents:     []Document{{Path: "a.go"}},
+		IncludedFiles: []FileInfo{{Path: "a.go"}},
+		EmptyFiles:    []string{"e.go"},
+		ErrorFiles:    map[string]error{"x.go": os.ErrPermission},
+		SpecialFiles:  map[string]string{"p.go": "named pipe"},
+		Skipped:       map[string][]string{"flag": {"s.go"}},
+	}
+	rebaseResultPaths(&result, func(p string) string { return "src/" + p })
+	assert.Equal(t, "src/a.go", result.Documents[0].Path)
+	assert.Equal(t, "src/a.go", result.IncludedFiles[0].Path)
+	assert.Equal(t, []string{"src/e.go"}, result.EmptyFiles)
+	assert.Contains(t, result.ErrorFiles, "src/x.go")
+	assert.Contains(t, result.SpecialFiles, "src/p.go")
+	assert.Equal(t, []string{"src/s.go"}, result.Skipped["flag"])
+}
+
+func TestWithPathPrefixes(t *testing.T) {
+	assert.Nil(t, withPathPrefixes(nil, nil, ""))
+
+	rebase := withPathPrefixes(nil, []string{"services/payments", "./libs/"}, "repo1")
+	assert.Equal(t, "repo1/api/handler.go", rebase("services/payments/api/handler.go"))
+	assert.Equal(t, "repo1/money/round.go", rebase("libs/money/round.go"))
+	assert.Equal(t, "repo1/services/paymentsx/a.go", rebase("services/paymentsx/a.go"), "only whole directories are stripped")
+
+	upper := func(p string) string { return "base/" + p }
+	rebase = withPathPrefixes(upper, []string{"base/"}, "")
+	assert.Equal(t, "x.go", rebase("x.go"), "prefixes apply after --relative-to")
+}
+
+func TestCheckJail(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "id_rsa"), []byte("secret\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644))
+	if err := os.Symlink(filepath.Join(outside, "id_rsa"), filepath.Join(root, "key")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	require.NoError(t, os.Symlink(filepath.Join(root, "main.go"), filepath.Join(root, "alias.go")))
+
+	jail, err := resolveJail(root)
+	require.NoError(t, err)
+	assert.NoError(t, checkJail(jail, filepath.Join(root, "main.go")))
+	assert.NoError(t, checkJail(jail, filepath.Join(root, "alias.go")), "link inside the jail")
+	assert.NoError(t, checkJail(jail, filepath.Join(root, "missing.go")), "left to fail on read")
+	assert.ErrorIs(t, checkJail(jail, filepath.Join(root, "key")), errOutsideJail)
+	assert.ErrorIs(t, checkJail(jail, filepath.Join(root, "..", filepath.Base(outside), "id_rsa")), errOutsideJail)
+	assert.NoError(t, checkJail("", filepath.Join(root, "key")), "no jail")
+
+	_, err = resolveJail(filepath.Join(root, "main.go"))
+	assert.ErrorContains(t, err, "not a directory")
+}
+
+func TestGenerate_Jail(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"src/main.go": "package main\n"})
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.go")
+	require.NoError(t, os.WriteFile(secret, []byte("package secret // token\n"), 0644))
+	if err := os.Symlink(secret, filepath.Join(tempDir, "src", "linked.go")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	opts := GenerateOptions{
+		CWD:         tempDir,
+		ScanDirs:    []string{tempDir},
+		Extensions:  processExtensions([]string{"go"}),
+		ManualFiles: []string{secret},
+		Marker:      "---",
+		Jail:        tempDir,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.NotContains(t, result.Output, "token")
+	assert.Equal(t, []string{"src/main.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	require.Len(t, result.ErrorFiles, 2)
+	assert.ErrorIs(t, result.ErrorFiles["src/linked.go"], errOutsideJail)
+	for _, err := range result.ErrorFiles {
+		assert.ErrorContains(t, err, "--allow-outside")
+	}
+
+	opts.ScanDirs = []string{outside}
+	opts.ManualFiles = nil
+	_, err = generate(opts)
+	assert.ErrorIs(t, err, errOutsideJail, "scan root outside")
+
+	opts.ScanDirs, opts.ManualFiles, opts.Jail = []string{tempDir}, []string{secret}, ""
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "token", "no jail")
+}
@@ -0,0 +1,101 @@
+// cmd/codecat/plugins.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"plugin"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gagin/codecat/pluginapi"
+)
+
+// Registered plugin transforms and excluders, in load order. Formatters go
+// into outputFormatters next to the built-in ones.
+var (
+	pluginTransforms []pluginapi.Transform
+	pluginExcluders  []pluginapi.Excluder
+)
+
+// defaultPluginsDir returns ~/.config/codecat/plugins, next to the config.
+func defaultPluginsDir() (string, error) {
+	configPath, err := defaultConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "plugins"), nil
+}
+
+// loadPlugins opens every *.so file in dir, in name order, and registers
+// what it exports as Plugin. A missing dir loads nothing.
+func loadPlugins(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".so")
+		p, err := plugin.Open(path)
+		if err != nil {
+			return fmt.Errorf("plugin %s: %w", name, err)
+		}
+		sym, err := p.Lookup("Plugin")
+		if err != nil {
+			return fmt.Errorf("plugin %s: %w", name, err)
+		}
+		exported, ok := sym.(*pluginapi.Plugin)
+		if !ok {
+			return fmt.Errorf("plugin %s: Plugin is a %T, not a pluginapi.Plugin", name, sym)
+		}
+		if err := registerPlugin(name, exported); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerPlugin adds a plugin's formatters, transforms and excluders.
+func registerPlugin(name string, p *pluginapi.Plugin) error {
+	if p.APIVersion != pluginapi.Version {
+		return fmt.Errorf("plugin %s: built for API version %d, codecat implements %d", name, p.APIVersion, pluginapi.Version)
+	}
+	for _, f := range p.Formatters {
+		if _, taken := outputFormatters[f.Name()]; taken {
+			return fmt.Errorf("plugin %s: format %q is already defined", name, f.Name())
+		}
+	}
+	for _, f := range p.Formatters {
+		outputFormatters[f.Name()] = pluginFormatter(f)
+	}
+	pluginTransforms = append(pluginTransforms, p.Transforms...)
+	pluginExcluders = append(pluginExcluders, p.Excluders...)
+	slog.Info("Loaded plugin.", "plugin", name, "formatters", len(p.Formatters),
+		"transforms", len(p.Transforms), "excluders", len(p.Excluders))
+	return nil
+}
+
+// pluginFormatter adapts a plugin formatter to the OutputFormatter registry.
+func pluginFormatter(f pluginapi.Formatter) OutputFormatter {
+	return func(w io.Writer, result GenerateResult, opts GenerateOptions) error {
+		docs := make([]pluginapi.Document, 0, len(result.Documents))
+		for _, doc := range result.Documents {
+			docs = append(docs, pluginapi.Document{Path: doc.Path, Content: doc.text(), Meta: slices.Clone(doc.Meta)})
+		}
+		return f.Format(w, docs)
+	}
+}
+
+// pluginExcluded reports which plugin excluder, if any, leaves out path.
+func pluginExcluded(info PathInfo) (bool, string) {
+	for _, e := range pluginExcluders {
+		if e.Exclude(info.RelPathCwd, info.IsDir) {
+			return true, e.Name()
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,115 @@
+// cmd/codecat/plugins_test.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gagin/codecat/pluginapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testFormatter struct{ name string }
+
+func (f testFormatter) Name() string { return f.name }
+func (f testFormatter) Format(w io.Writer, docs []pluginapi.Document) error {
+	for _, d := range docs {
+		fmt.Fprintf(w, "%s=%d\n", d.Path, len(d.Content))
+	}
+	return nil
+}
+
+type upperTransform struct{}
+
+func (upperTransform) Name() string { return "upper" }
+func (upperTransform) Transform(_ string, content []byte) ([]byte, error) {
+	return bytes.ToUpper(content), nil
+}
+
+type vendorExcluder struct{}
+
+func (vendorExcluder) Name() string { return "no-generated" }
+func (vendorExcluder) Exclude(path string, isDir bool) bool {
+	return !isDir && strings.HasSuffix(path, ".gen.go")
+}
+
+// resetPlugins undoes what registerPlugin adds once the test ends.
+func resetPlugins(t *testing.T, formats ...string) {
+	t.Cleanup(func() {
+		pluginTransforms, pluginExcluders = nil, nil
+		for _, name := range formats {
+			delete(outputFormatters, name)
+		}
+	})
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	resetPlugins(t, "sizes")
+	err := registerPlugin("demo", &pluginapi.Plugin{
+		APIVersion: pluginapi.Version,
+		Formatters: []pluginapi.Formatter{testFormatter{"sizes"}},
+		Transforms: []pluginapi.Transform{upperTransform{}},
+		Excluders:  []pluginapi.Excluder{vendorExcluder{}},
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	result := GenerateResult{Documents: []Document{{Path: "a.go", Content: "package a\n"}}}
+	require.NoError(t, outputFormatters["sizes"](&buf, result, GenerateOptions{}))
+	assert.Equal(t, "a.go=10\n", buf.String())
+
+	out, err := newContentPipeline(GenerateOptions{NoAdapters: true}).process("a.go", []byte("package a\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "PACKAGE A\n", string(out))
+
+	excluded, name := pluginExcluded(PathInfo{RelPathCwd: "api/types.gen.go"})
+	assert.True(t, excluded)
+	assert.Equal(t, "no-generated", name)
+	excluded, _ = pluginExcluded(PathInfo{RelPathCwd: "api/types.go"})
+	assert.False(t, excluded)
+}
+
+func TestRegisterPlugin_Refused(t *testing.T) {
+	resetPlugins(t)
+	err := registerPlugin("old", &pluginapi.Plugin{APIVersion: pluginapi.Version + 1})
+	assert.ErrorContains(t, err, "plugin old: built for API version 2, codecat implements 1")
+
+	err = registerPlugin("clash", &pluginapi.Plugin{
+		APIVersion: pluginapi.Version,
+		Formatters: []pluginapi.Formatter{testFormatter{"markdown"}},
+		Transforms: []pluginapi.Transform{upperTransform{}},
+	})
+	assert.ErrorContains(t, err, `plugin clash: format "markdown" is already defined`)
+	assert.Empty(t, pluginTransforms, "a refused plugin registers nothing")
+}
+
+func TestLoadPlugins_MissingDir(t *testing.T) {
+	assert.NoError(t, loadPlugins(t.TempDir()+"/none"))
+}
+
+func TestGenerate_PluginExcluder(t *testing.T) {
+	resetPlugins(t)
+	require.NoError(t, registerPlugin("demo", &pluginapi.Plugin{
+		APIVersion: pluginapi.Version,
+		Excluders:  []pluginapi.Excluder{vendorExcluder{}},
+	}))
+	tempDir := setupTestDir(t, map[string]string{"types.gen.go": "package a\n", "types.go": "package a\n"})
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	var paths []string
+	for _, d := range result.Documents {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"types.go"}, paths)
+	assert.Equal(t, 1, result.ExcludedBy["plugin"])
+}
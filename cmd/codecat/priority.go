@@ -0,0 +1,152 @@
+// cmd/codecat/priority.go
+package main
+
+import (
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+)
+
+// priorityOf returns the highest weight among the [priority] globs matching
+// the slash-separated relPath (see matchesTreeGlob), or 0.
+func priorityOf(relPath string, priorities map[string]int) int {
+	best, found := 0, false
+	for pattern, weight := range priorities {
+		if matchesTreeGlob(pattern, relPath) && (!found || weight > best) {
+			best, found = weight, true
+		}
+	}
+	return best
+}
+
+// matchesTreeGlob reports whether the slash-separated relPath matches
+// pattern. A glob without "/" matches the file or any directory name, like
+// exclude_basenames; otherwise it matches the path or a directory above it,
+// and "**" matches any number of directories.
+func matchesTreeGlob(pattern, relPath string) bool {
+	segments := strings.Split(relPath, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	if !strings.Contains(trimmed, "/") {
+		for _, segment := range segments {
+			if ok, _ := path.Match(trimmed, segment); ok {
+				return true
+			}
+		}
+		return false
+	}
+	parts := strings.Split(trimmed, "/")
+	return matchPathSegments(parts, segments) || matchPathSegments(append(parts, "**"), segments)
+}
+
+// documentRank orders documents for output and trimming: documentation
+// first under --docs-first, then by [priority] weight.
+type documentRank struct {
+	doc      bool
+	priority int
+}
+
+func rankDocument(relPath string, opts GenerateOptions) documentRank {
+	return documentRank{doc: opts.DocsFirst && isDocPath(relPath), priority: priorityOf(relPath, opts.Priorities)}
+}
+
+// less reports whether r comes before (and is dropped after) other.
+func (r documentRank) less(other documentRank) bool {
+	if r.doc != other.doc {
+		return r.doc
+	}
+	return r.priority > other.priority
+}
+
+// orderDocuments stably sorts each root and nested-repository section by
+// rank, so the sections stay intact and equal ranks keep the scan order.
+func orderDocuments(docs []Document, opts GenerateOptions) {
+	for start := 0; start < len(docs); {
+		end := start + 1
+		for end < len(docs) && docs[end].Root == docs[start].Root && docs[end].NestedRepo == docs[start].NestedRepo {
+			end++
+		}
+		section := docs[start:end]
+		sort.SliceStable(section, func(i, j int) bool {
+			return rankDocument(section[i].Path, opts).less(rankDocument(section[j].Path, opts))
+		})
+		start = end
+	}
+}
+
+// dropOrder returns the indexes of docs in the order --fit removes them:
+// lowest rank first and, within a rank, the largest first.
+func dropOrder(docs []Document, opts GenerateOptions) []int {
+	order := make([]int, len(docs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := rankDocument(docs[order[a]].Path, opts), rankDocument(docs[order[b]].Path, opts)
+		if ra != rb {
+			return rb.less(ra)
+		}
+		return docs[order[a]].size() > docs[order[b]].size()
+	})
+	return order
+}
+
+// fitToBudget drops documents in dropOrder until the rendered output is
+// within opts.MaxTokens, removing them from result's documents and included
+// files. render produces the output to measure. The dropped paths are
+// returned in the order they were dropped.
+func fitToBudget(result *GenerateResult, opts GenerateOptions, render func() (string, error)) ([]string, error) {
+	var dropped []string
+	for {
+		out, err := render()
+		if err != nil {
+			return dropped, err
+		}
+		over := estimateTokens(int64(len(out))) - opts.MaxTokens
+		if over <= 0 || len(result.Documents) == 0 {
+			break
+		}
+		// Drop by estimate until the overrun is covered, then measure again.
+		remove := make(map[int]bool)
+		var saved int64
+		for _, i := range dropOrder(result.Documents, opts) {
+			if saved >= over {
+				break
+			}
+			remove[i] = true
+			dropped = append(dropped, result.Documents[i].Path)
+			saved += estimateTokens(int64(result.Documents[i].size() + len(result.Documents[i].Path)))
+		}
+		kept := result.Documents[:0]
+		for i, doc := range result.Documents {
+			if !remove[i] {
+				kept = append(kept, doc)
+			}
+		}
+		result.Documents = kept
+	}
+	if len(dropped) == 0 {
+		return nil, nil
+	}
+	gone := make(map[string]bool, len(dropped))
+	for _, p := range dropped {
+		gone[p] = true
+	}
+	dropIncludedFiles(result, gone)
+	slog.Warn("Dropped files to fit the token budget.", "files", len(dropped), "max_tokens", opts.MaxTokens)
+	return dropped, nil
+}
+
+// dropIncludedFiles removes the gone paths from result's included files and
+// total size, once their documents are gone.
+func dropIncludedFiles(result *GenerateResult, gone map[string]bool) {
+	kept := result.IncludedFiles[:0]
+	result.TotalSize = 0
+	for _, f := range result.IncludedFiles {
+		if !gone[f.Path] {
+			kept = append(kept, f)
+			result.TotalSize += f.Size
+		}
+	}
+	result.IncludedFiles = kept
+}
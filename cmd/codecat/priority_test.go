@@ -0,0 +1,75 @@
+// cmd/codecat/priority_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityOf(t *testing.T) {
+	priorities := map[string]int{"cmd/**": 10, "*.md": -5, "internal/core": 7, "*_gen.go": -20}
+	assert.Equal(t, 10, priorityOf("cmd/codecat/main.go", priorities))
+	assert.Equal(t, -5, priorityOf("docs/notes.md", priorities))
+	assert.Equal(t, 10, priorityOf("cmd/README.md", priorities), "highest matching weight wins")
+	assert.Equal(t, 7, priorityOf("internal/core/engine.go", priorities))
+	assert.Equal(t, 0, priorityOf("internal/other/x.go", priorities))
+	assert.Equal(t, -20, priorityOf("api/types_gen.go", priorities))
+	assert.Equal(t, 0, priorityOf("main.go", nil))
+}
+
+func TestOrderDocuments_Priority(t *testing.T) {
+	docs := []Document{{Path: "a.go"}, {Path: "gen/x.go"}, {Path: "core/b.go"}, {Path: "README.md"}, {Path: "core/c.go"}}
+	opts := GenerateOptions{DocsFirst: true, Priorities: map[string]int{"core": 5, "gen": -1}}
+	orderDocuments(docs, opts)
+	var paths []string
+	for _, d := range docs {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"README.md", "core/b.go", "core/c.go", "a.go", "gen/x.go"}, paths)
+}
+
+func TestFitToBudget(t *testing.T) {
+	big := strings.Repeat("x", 4000)
+	result := GenerateResult{
+		Documents: []Document{
+			{Path: "core/main.go", Content: big},
+			{Path: "util.go", Content: big},
+			{Path: "gen/big.go", Content: big + big},
+			{Path: "small.go", Content: "x"},
+		},
+		IncludedFiles: []FileInfo{
+			{Path: "core/main.go", Size: 4000},
+			{Path: "util.go", Size: 4000},
+			{Path: "gen/big.go", Size: 8000},
+			{Path: "small.go", Size: 1},
+		},
+		TotalSize: 16001,
+	}
+	opts := GenerateOptions{MaxTokens: 1100, Fit: true, Priorities: map[string]int{"core": 1, "gen": -1}}
+	render := func() (string, error) {
+		var b strings.Builder
+		for _, d := range result.Documents {
+			b.WriteString(d.Path + "\n" + d.Content + "\n")
+		}
+		return b.String(), nil
+	}
+
+	dropped, err := fitToBudget(&result, opts, render)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gen/big.go", "util.go"}, dropped, "lowest priority first, then largest")
+	assert.Len(t, result.Documents, 2)
+	assert.Equal(t, int64(4001), result.TotalSize)
+	out, _ := render()
+	assert.LessOrEqual(t, estimateTokens(int64(len(out))), opts.MaxTokens)
+}
+
+func TestFitToBudget_AlreadyFits(t *testing.T) {
+	result := GenerateResult{Documents: []Document{{Path: "a.go", Content: "package a\n"}}, IncludedFiles: []FileInfo{{Path: "a.go", Size: 10}}}
+	dropped, err := fitToBudget(&result, GenerateOptions{MaxTokens: 100}, func() (string, error) { return "package a\n", nil })
+	require.NoError(t, err)
+	assert.Nil(t, dropped)
+	assert.Len(t, result.Documents, 1)
+}
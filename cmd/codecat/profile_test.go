@@ -0,0 +1,31 @@
+// cmd/codecat/profile_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartProfiles(t *testing.T) {
+	dir := t.TempDir()
+	cpu, mem := filepath.Join(dir, "cpu.out"), filepath.Join(dir, "mem.out")
+	stop, err := startProfiles(cpu, mem)
+	require.NoError(t, err)
+	stop()
+	for _, path := range []string{cpu, mem} {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Positive(t, info.Size(), path)
+	}
+
+	stop, err = startProfiles("", "")
+	require.NoError(t, err)
+	stop() // nothing to write
+
+	_, err = startProfiles(filepath.Join(dir, "missing", "cpu.out"), "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+// cmd/codecat/progress.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gocodewalker "github.com/boyter/gocodewalker"
+)
+
+const progressInterval = 200 * time.Millisecond
+
+// scanProgress renders a one-line live status of a walk for --progress.
+// A second walker counts the files in the background, so the total (and an
+// ETA) becomes available once it finishes. A nil *scanProgress reports nothing.
+type scanProgress struct {
+	w        io.Writer
+	start    time.Time
+	seen     atomic.Int64
+	included atomic.Int64
+	bytes    atomic.Int64
+	total    atomic.Int64 // -1 until the counting walk is done
+	stopOnce sync.Once
+	stopped  chan struct{}
+	rendered chan struct{}
+}
+
+// startScanProgress starts the counting walk over the directories the scan
+// walks and the render loop.
+func startScanProgress(w io.Writer, roots []string, useGitignore bool) *scanProgress {
+	p := &scanProgress{w: w, start: time.Now(), stopped: make(chan struct{}), rendered: make(chan struct{})}
+	p.total.Store(-1)
+
+	go func() {
+		queue := make(chan *gocodewalker.File, 100)
+		walker := gocodewalker.NewParallelFileWalker(roots, queue)
+		if len(roots) == 1 {
+			walker = gocodewalker.NewFileWalker(roots[0], queue)
+		}
+		walker.IgnoreGitIgnore = !useGitignore
+		walker.IgnoreIgnoreFile = !useGitignore
+		walker.IgnoreGitModules = true
+		go func() { _ = walker.Start() }()
+		var n int64
+		for range queue {
+			n++
+			select {
+			case <-p.stopped:
+				walker.Terminate()
+			default:
+			}
+		}
+		p.total.Store(n)
+	}()
+
+	go func() {
+		defer close(p.rendered)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprintf(p.w, "\r%s\x1b[K", p.line())
+			case <-p.stopped:
+				fmt.Fprintf(p.w, "\r%s\x1b[K\n", p.line())
+				return
+			}
+		}
+	}()
+	return p
+}
+
+func (p *scanProgress) fileSeen() {
+	if p != nil {
+		p.seen.Add(1)
+	}
+}
+
+func (p *scanProgress) fileIncluded(size int64) {
+	if p != nil {
+		p.included.Add(1)
+		p.bytes.Add(size)
+	}
+}
+
+// stop prints the final status line and ends the render loop.
+func (p *scanProgress) stop() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() { close(p.stopped) })
+	<-p.rendered
+}
+
+// line formats the current counters, e.g.
+// "scanned 1200/5000 files, included 300, 2.5 MiB read, ETA 12s".
+func (p *scanProgress) line() string {
+	seen, total := p.seen.Load(), p.total.Load()
+	scanned := fmt.Sprintf("%d files", seen)
+	eta := "counting..."
+	if total >= 0 {
+		scanned = fmt.Sprintf("%d/%d files", seen, max(seen, total))
+		eta = "ETA -"
+		if seen > 0 && total > seen {
+			remaining := time.Duration(float64(time.Since(p.start)) * float64(total-seen) / float64(seen))
+			eta = "ETA " + remaining.Round(time.Second).String()
+		}
+	}
+	return fmt.Sprintf("scanned %s, included %d, %s read, %s", scanned, p.included.Load(), formatBytes(p.bytes.Load()), eta)
+}
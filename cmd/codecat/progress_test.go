@@ -0,0 +1,51 @@
+// cmd/codecat/progress_test.go
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanProgressLine(t *testing.T) {
+	p := &scanProgress{start: time.Now().Add(-10 * time.Second)}
+	p.total.Store(-1)
+	p.seen.Store(50)
+	p.fileIncluded(2048)
+	assert.Equal(t, "scanned 50 files, included 1, 2 KiB read, counting...", p.line())
+
+	p.total.Store(100)
+	assert.Equal(t, "scanned 50/100 files, included 1, 2 KiB read, ETA 10s", p.line())
+
+	var nilProgress *scanProgress
+	nilProgress.fileSeen()
+	nilProgress.stop()
+}
+
+func TestStartScanProgress_CountsRootsOnly(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"small/a.go": "", "small/b.go": "", "other/c.go": "", "other/d.go": "", "e.go": ""})
+	var status bytes.Buffer
+	p := startScanProgress(&status, []string{filepath.Join(tempDir, "small")}, false)
+	require.Eventually(t, func() bool { return p.total.Load() >= 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(2), p.total.Load(), "only the scanned directory is counted")
+	p.stop()
+}
+
+func TestGenerate_Progress(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"a.go": "package a\n", "b.txt": "skip\n"})
+	var status bytes.Buffer
+	result, err := generate(GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+		Progress:   &status,
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.IncludedFiles, 1)
+	assert.Regexp(t, `scanned 2(/2)? files, included 1, 10 B read, .*\n$`, status.String())
+}
@@ -0,0 +1,194 @@
+// cmd/codecat/recipe.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	pflag "github.com/spf13/pflag"
+)
+
+// Recipe is a committed run definition for 'codecat run', so the same
+// context can be generated by everyone on the team and in CI. Each field
+// stands for the flag named in its comment; paths are CWD-relative as on
+// the command line.
+type Recipe struct {
+	Description string         `toml:"description"`
+	Roots       []string       `toml:"roots"`        // -d, entries as for -d (dir[:ext=...])
+	Include     []string       `toml:"include"`      // -f
+	IncludeFrom []string       `toml:"include_from"` // -F
+	Exclude     []string       `toml:"exclude"`      // -x
+	Extensions  []string       `toml:"extensions"`   // -e
+	Transforms  []string       `toml:"transforms"`   // boolean flags to turn on, e.g. "minify", "scrub-pii"
+	Format      string         `toml:"format"`       // --format
+	Output      []string       `toml:"output"`       // -o
+	Template    RecipeTemplate `toml:"template"`     // overrides the config templates
+	Flags       map[string]any `toml:"flags"`        // any other flag by its long name
+}
+
+// RecipeTemplate overrides the header and file templates of the config.
+type RecipeTemplate struct {
+	HeaderText *string `toml:"header_text"`
+	FileHeader *string `toml:"file_header"`
+	FileFooter *string `toml:"file_footer"`
+}
+
+// activeRecipe is the recipe given to 'codecat run', applied to the config
+// once it is loaded.
+var activeRecipe *Recipe
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "run",
+		Summary: "Generate the context defined by a recipe file; flags override it.",
+		Prepare: prepareRecipeRun,
+	})
+}
+
+// prepareRecipeRun loads the recipe named by the first argument and sets the
+// flags it defines that the command line did not. The rest of the arguments
+// are left for the run.
+func prepareRecipeRun(fs *pflag.FlagSet, args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, errors.New("usage: codecat run <recipe.toml> [flags] [target_directory]")
+	}
+	recipe, err := loadRecipe(args[0])
+	if err != nil {
+		return nil, err
+	}
+	rest := args[1:]
+	if err := recipe.applyFlags(fs, len(rest) > 0); err != nil {
+		return nil, fmt.Errorf("recipe %s: %w", args[0], err)
+	}
+	activeRecipe = recipe
+	return rest, nil
+}
+
+// loadRecipe decodes a recipe file. Unknown keys are errors: a misspelt key
+// would otherwise change the context without anyone noticing.
+func loadRecipe(path string) (*Recipe, error) {
+	var recipe Recipe
+	meta, err := toml.DecodeFile(path, &recipe)
+	if err != nil {
+		return nil, fmt.Errorf("recipe %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, k := range undecoded {
+			keys[i] = k.String()
+		}
+		return nil, fmt.Errorf("recipe %s: unknown keys %s", path, strings.Join(keys, ", "))
+	}
+	return &recipe, nil
+}
+
+// applyFlags sets each flag the recipe defines unless it was given on the
+// command line. A target directory argument replaces roots.
+func (r *Recipe) applyFlags(fs *pflag.FlagSet, hasTargetDir bool) error {
+	set := func(name string, values ...string) error {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown flag --%s", name)
+		}
+		if fs.Changed(name) || len(values) == 0 {
+			return nil // the command line wins
+		}
+		for _, v := range values {
+			if err := fs.Set(name, v); err != nil {
+				return fmt.Errorf("--%s: %w", name, err)
+			}
+		}
+		return nil
+	}
+	if !hasTargetDir {
+		if err := set("directory", r.Roots...); err != nil {
+			return err
+		}
+	}
+	for _, f := range []struct {
+		name   string
+		values []string
+	}{
+		{"files", r.Include},
+		{"files-from", r.IncludeFrom},
+		{"exclude", r.Exclude},
+		{"extensions", r.Extensions},
+		{"output", r.Output},
+	} {
+		if err := set(f.name, f.values...); err != nil {
+			return err
+		}
+	}
+	if r.Format != "" {
+		if err := set("format", r.Format); err != nil {
+			return err
+		}
+	}
+	for _, name := range r.Transforms {
+		if f := fs.Lookup(name); f != nil && f.Value.Type() != "bool" {
+			return fmt.Errorf("transform %q is not an on/off flag", name)
+		}
+		if err := set(name, "true"); err != nil {
+			return err
+		}
+	}
+	names := mapsKeys(r.Flags)
+	sort.Strings(names)
+	for _, name := range names {
+		values, err := recipeFlagValues(r.Flags[name])
+		if err != nil {
+			return fmt.Errorf("flags.%s: %w", name, err)
+		}
+		if err := set(name, values...); err != nil {
+			return err
+		}
+	}
+	slog.Debug("Applied recipe flags.", "description", r.Description)
+	return nil
+}
+
+// recipeFlagValues renders a [flags] value as flag arguments; an array sets
+// a repeatable flag once per element.
+func recipeFlagValues(v any) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case int64:
+		return []string{strconv.FormatInt(v, 10)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []any:
+		var values []string
+		for _, elem := range v {
+			vs, err := recipeFlagValues(elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, vs...)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", v)
+}
+
+// applyConfig overrides the config's templates with the recipe's.
+func (r *Recipe) applyConfig(cfg *Config) {
+	if r == nil {
+		return
+	}
+	if r.Template.HeaderText != nil {
+		cfg.HeaderText = r.Template.HeaderText
+	}
+	if r.Template.FileHeader != nil {
+		cfg.FileHeaderTemplate = *r.Template.FileHeader
+	}
+	if r.Template.FileFooter != nil {
+		cfg.FileFooterTemplate = *r.Template.FileFooter
+	}
+}
@@ -0,0 +1,107 @@
+// cmd/codecat/recipe_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	pflag "github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recipeTestFlags mirrors the kinds of flags recipes set.
+func recipeTestFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringArrayP("directory", "d", nil, "")
+	fs.StringSliceP("files", "f", nil, "")
+	fs.StringArrayP("files-from", "F", nil, "")
+	fs.StringSliceP("exclude", "x", nil, "")
+	fs.StringSliceP("extensions", "e", nil, "")
+	fs.StringArrayP("output", "o", nil, "")
+	fs.String("format", "", "")
+	fs.Bool("minify", false, "")
+	fs.Bool("scrub-pii", false, "")
+	fs.Int64("max-tokens", 0, "")
+	require.NoError(t, fs.Parse(args))
+	return fs
+}
+
+func writeRecipe(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "recipe.toml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+const testRecipe = `
+description = "Auth service"
+roots = ["internal/auth", "cmd/login:ext=go"]
+include = ["docs/auth.md"]
+exclude = ["internal/auth/testdata"]
+extensions = ["go", "sql"]
+transforms = ["minify", "scrub-pii"]
+format = "markdown"
+output = ["context/auth.md"]
+
+[template]
+file_header = "## {{.Path}}"
+
+[flags]
+max-tokens = 50000
+`
+
+func TestPrepareRecipeRun(t *testing.T) {
+	path := writeRecipe(t, testRecipe)
+	t.Cleanup(func() { activeRecipe = nil })
+	fs := recipeTestFlags(t, "-e", "py")
+
+	rest, err := prepareRecipeRun(fs, []string{path})
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	get := func(name string) string { return fs.Lookup(name).Value.String() }
+	assert.Equal(t, "[internal/auth,cmd/login:ext=go]", get("directory"))
+	assert.Equal(t, "[docs/auth.md]", get("files"))
+	assert.Equal(t, "[internal/auth/testdata]", get("exclude"))
+	assert.Equal(t, "[py]", get("extensions"), "flags on the command line win")
+	assert.Equal(t, "true", get("minify"))
+	assert.Equal(t, "true", get("scrub-pii"))
+	assert.Equal(t, "markdown", get("format"))
+	assert.Equal(t, "[context/auth.md]", get("output"))
+	assert.Equal(t, "50000", get("max-tokens"))
+	assert.True(t, fs.Changed("directory"), "recipe values count as given")
+
+	cfg := cloneDefaultConfig()
+	activeRecipe.applyConfig(&cfg)
+	assert.Equal(t, "## {{.Path}}", cfg.FileHeaderTemplate)
+	assert.Equal(t, *defaultConfig.HeaderText, *cfg.HeaderText, "unset template keys keep the config")
+}
+
+func TestPrepareRecipeRun_TargetDirReplacesRoots(t *testing.T) {
+	path := writeRecipe(t, testRecipe)
+	t.Cleanup(func() { activeRecipe = nil })
+	fs := recipeTestFlags(t)
+	rest, err := prepareRecipeRun(fs, []string{path, "services/api"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"services/api"}, rest)
+	assert.False(t, fs.Changed("directory"))
+}
+
+func TestPrepareRecipeRun_Errors(t *testing.T) {
+	t.Cleanup(func() { activeRecipe = nil })
+	_, err := prepareRecipeRun(recipeTestFlags(t), nil)
+	assert.ErrorContains(t, err, "usage: codecat run")
+
+	_, err = prepareRecipeRun(recipeTestFlags(t), []string{writeRecipe(t, "extension = [\"go\"]\n")})
+	assert.ErrorContains(t, err, "unknown keys extension")
+
+	_, err = prepareRecipeRun(recipeTestFlags(t), []string{writeRecipe(t, "transforms = [\"format\"]\n")})
+	assert.ErrorContains(t, err, `transform "format" is not an on/off flag`)
+
+	_, err = prepareRecipeRun(recipeTestFlags(t), []string{writeRecipe(t, "[flags]\nno-such-flag = true\n")})
+	assert.ErrorContains(t, err, "unknown flag --no-such-flag")
+
+	_, err = prepareRecipeRun(recipeTestFlags(t), []string{writeRecipe(t, "[flags]\nmax-tokens = \"lots\"\n")})
+	assert.ErrorContains(t, err, "--max-tokens")
+	assert.Nil(t, activeRecipe, "a failed recipe is not applied")
+}
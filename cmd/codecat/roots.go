@@ -0,0 +1,279 @@
+// cmd/codecat/roots.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ScanRoot is a -d directory that carries its own filters, written as
+// "dir:ext=go,mod:x=gen,testdata". Unset filters fall back to the run's.
+type ScanRoot struct {
+	Dir        string              // absolute path
+	Extensions map[string]struct{} // replaces the run's extensions and --lang when non-nil
+	Excludes   []string            // CWD-relative patterns added to -x for this root
+}
+
+// rootOptionKeys are the per-root options accepted after "dir:".
+var rootOptionKeys = []string{"ext", "x"}
+
+// rootOptionsStart returns the index of the ':' that begins the options of a
+// -d value, or -1. Only ":key=" with a known key counts, so drive letters and
+// directory names containing ':' are left alone.
+func rootOptionsStart(value string) int {
+	for i := strings.IndexByte(value, ':'); i >= 0; {
+		for _, key := range rootOptionKeys {
+			if strings.HasPrefix(value[i+1:], key+"=") {
+				return i
+			}
+		}
+		next := strings.IndexByte(value[i+1:], ':')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return -1
+}
+
+// parseScanDirValues splits the -d values into directories and, for values
+// with options, their per-root filters (absolute paths are resolved later).
+// Values without options keep the comma-separated list form; in values with
+// options, commas separate list items within an option instead.
+func parseScanDirValues(values []string) ([]string, []ScanRoot, error) {
+	var dirs []string
+	var roots []ScanRoot
+	for _, value := range values {
+		start := rootOptionsStart(value)
+		if start < 0 {
+			for _, dir := range parseCommaSeparatedSlice([]string{value}) {
+				dirs = append(dirs, dir)
+				roots = append(roots, ScanRoot{Dir: dir})
+			}
+			continue
+		}
+		root := ScanRoot{Dir: value[:start]}
+		for _, option := range strings.Split(value[start+1:], ":") {
+			key, val, _ := strings.Cut(option, "=")
+			items := parseCommaSeparatedSlice([]string{val})
+			switch key {
+			case "ext":
+				root.Extensions = processExtensions(items)
+			case "x":
+				root.Excludes = append(root.Excludes, items...)
+			default:
+				return nil, nil, fmt.Errorf("%w: unknown option %q in -d %q (supported: %s)",
+					errUsage, key, value, strings.Join(rootOptionKeys, ", "))
+			}
+		}
+		dirs = append(dirs, root.Dir)
+		roots = append(roots, root)
+	}
+	for _, root := range roots {
+		if root.Extensions != nil || root.Excludes != nil {
+			return dirs, roots, nil
+		}
+	}
+	return dirs, nil, nil // no per-root options: a plain single scan
+}
+
+// dedupeScanRoots drops -d directories named more than once, compared after
+// they are made absolute, keeping the first. A repeat with different per-root
+// filters is ignored with a warning. roots may be nil.
+func dedupeScanRoots(dirs []string, roots []ScanRoot) ([]string, []ScanRoot) {
+	var keptDirs []string
+	var keptRoots []ScanRoot
+	first := make(map[string]int)
+	for i, dir := range dirs {
+		if j, dup := first[dir]; dup {
+			if roots != nil && (!maps.Equal(roots[i].Extensions, roots[j].Extensions) || !slices.Equal(roots[i].Excludes, roots[j].Excludes)) {
+				slog.Warn("Ignoring repeated scan directory with different filters; the first one wins.", "dir", dir)
+			}
+			continue
+		}
+		first[dir] = i
+		keptDirs = append(keptDirs, dir)
+		if roots != nil {
+			keptRoots = append(keptRoots, roots[i])
+		}
+	}
+	return keptDirs, keptRoots
+}
+
+// nestedScanRoots returns the roots strictly inside dir.
+func nestedScanRoots(dir string, roots []ScanRoot) []string {
+	var nested []string
+	for _, root := range roots {
+		if isWithinDir(root.Dir, dir) && root.Dir != dir {
+			nested = append(nested, root.Dir)
+		}
+	}
+	return nested
+}
+
+// isWithinDir reports whether absPath is dir or lies below it.
+func isWithinDir(absPath, dir string) bool {
+	return absPath == dir || strings.HasPrefix(absPath, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// generateRoots scans each root separately with its own filters and merges
+// the results, labelling documents with their root so the formatters write
+// one section per root. Manual files are processed once, ahead of the roots,
+// and a file under nested roots belongs to the innermost one.
+func generateRoots(ctx context.Context, opts GenerateOptions) (GenerateResult, error) {
+	base := partOptions(opts)
+	base.Roots = nil
+	var parts []GenerateResult
+	var errs []error
+
+	if len(opts.ManualFiles) > 0 {
+		manual := base
+		manual.NoScan, manual.ScanDirs = true, nil
+		res, err := generateContext(ctx, manual)
+		parts, errs = append(parts, res), append(errs, err)
+		base.ManualFiles = nil
+	}
+	remaining := opts.MaxFiles // shared by the roots
+	capped := false            // roots left unscanned by --max-files
+	for _, root := range opts.Roots {
+		if ctx.Err() != nil {
+			break
+		}
+		if opts.MaxFiles > 0 && remaining <= 0 {
+			capped = true
+			break
+		}
+		run := base
+		run.MaxFiles = remaining
+		run.ScanDirs = []string{root.Dir}
+		run.NestedRoots = nestedScanRoots(root.Dir, opts.Roots)
+		if root.Extensions != nil {
+			run.Extensions, run.Languages = root.Extensions, nil
+		}
+		run.FlagExcludes = append(append([]string{}, opts.FlagExcludes...), root.Excludes...)
+		label, errRel := cwdRelPath(opts.CWD, root.Dir)
+		if errRel != nil {
+			label = root.Dir
+		}
+		slog.Info("Scanning root with its own filters.", "root", label,
+			"extensions", mapsKeys(run.Extensions), "excludes", root.Excludes)
+		res, err := generateContext(ctx, run)
+		res.setRoot(label)
+		remaining -= len(res.IncludedFiles)
+		parts, errs = append(parts, res), append(errs, err)
+	}
+
+	result := mergeResults(parts)
+	result.Partial = result.Partial || ctx.Err() != nil
+	result.MaxFilesHit = result.MaxFilesHit || capped
+	_, result.PathBase, _ = newPathRebaser(opts.RelativeTo, opts.CWD, opts.ScanDirs)
+	if err := renderResult(&result, opts); err != nil {
+		errs = append(errs, err)
+	}
+	return result, errors.Join(errs...)
+}
+
+// mergeExclusionRules adds the hits of rules already in merged (same pattern
+// and source) and appends the rest, keeping the most effective rules first.
+func mergeExclusionRules(merged, rules []ExclusionRule) []ExclusionRule {
+	for _, rule := range rules {
+		i := slices.IndexFunc(merged, func(r ExclusionRule) bool {
+			return r.Pattern == rule.Pattern && r.Source == rule.Source
+		})
+		if i < 0 {
+			merged = append(merged, rule)
+		} else {
+			merged[i].Hits += rule.Hits
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Hits > merged[j].Hits })
+	return merged
+}
+
+// setRoot labels the documents and unreadable files of one run with its
+// root or repository before the runs are merged.
+func (r *GenerateResult) setRoot(label string) {
+	for i := range r.Documents {
+		r.Documents[i].Root = label
+	}
+	r.errorRoots = make(map[string]string, len(r.ErrorFiles))
+	for p := range r.ErrorFiles {
+		r.errorRoots[p] = label
+	}
+}
+
+// mergeResults concatenates the documents and bookkeeping of several runs.
+// A file included by more than one run is kept from the first.
+func mergeResults(parts []GenerateResult) GenerateResult {
+	merged := GenerateResult{
+		ErrorFiles:   make(map[string]error),
+		SpecialFiles: make(map[string]string),
+		ExcludedBy:   make(map[string]int),
+	}
+	included := make(map[string]bool)
+	for _, part := range parts {
+		duplicates := make(map[string]bool)
+		for _, f := range part.IncludedFiles {
+			if included[f.Path] {
+				duplicates[f.Path] = true
+				part.TotalSize -= f.Size
+				continue
+			}
+			included[f.Path] = true
+			merged.IncludedFiles = append(merged.IncludedFiles, f)
+		}
+		for _, doc := range part.Documents {
+			if !duplicates[doc.Path] {
+				merged.Documents = append(merged.Documents, doc)
+			}
+		}
+		merged.EmptyFiles = append(merged.EmptyFiles, part.EmptyFiles...)
+		for path, err := range part.ErrorFiles {
+			merged.ErrorFiles[path] = err
+			if root, ok := part.errorRoots[path]; ok {
+				if merged.errorRoots == nil {
+					merged.errorRoots = make(map[string]string)
+				}
+				merged.errorRoots[path] = root
+			}
+		}
+		for path, kind := range part.SpecialFiles {
+			merged.SpecialFiles[path] = kind
+		}
+		merged.TotalSize += part.TotalSize
+		merged.spools = append(merged.spools, part.spools...)
+		merged.FilesSeen += part.FilesSeen
+		for source, n := range part.ExcludedBy {
+			merged.ExcludedBy[source] += n
+		}
+		merged.ExcludeRules = mergeExclusionRules(merged.ExcludeRules, part.ExcludeRules)
+		if part.Skipped != nil {
+			if merged.Skipped == nil {
+				merged.Skipped = make(map[string][]string)
+			}
+			for reason, paths := range part.Skipped {
+				merged.Skipped[reason] = append(merged.Skipped[reason], paths...)
+			}
+		}
+		for _, repo := range part.Repos {
+			if !slices.ContainsFunc(merged.Repos, func(r RepoInfo) bool { return r.Path == repo.Path }) {
+				merged.Repos = append(merged.Repos, repo)
+			}
+		}
+		for _, repo := range part.NestedRepos {
+			if !contains(merged.NestedRepos, repo) {
+				merged.NestedRepos = append(merged.NestedRepos, repo)
+			}
+		}
+		merged.Partial = merged.Partial || part.Partial
+		merged.MaxFilesHit = merged.MaxFilesHit || part.MaxFilesHit
+	}
+	return merged
+}
@@ -0,0 +1,183 @@
+// cmd/codecat/roots_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScanDirValues(t *testing.T) {
+	dirs, roots, err := parseScanDirValues([]string{"a,b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, dirs)
+	assert.Nil(t, roots, "plain directories need no per-root scans")
+
+	dirs, roots, err = parseScanDirValues([]string{"backend:ext=go,mod:x=backend/gen", "frontend:ext=ts,tsx", "docs"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backend", "frontend", "docs"}, dirs)
+	assert.Equal(t, []ScanRoot{
+		{Dir: "backend", Extensions: map[string]struct{}{".go": {}, ".mod": {}}, Excludes: []string{"backend/gen"}},
+		{Dir: "frontend", Extensions: map[string]struct{}{".ts": {}, ".tsx": {}}},
+		{Dir: "docs"},
+	}, roots)
+
+	dirs, _, err = parseScanDirValues([]string{`C:\src\app:ext=go`})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`C:\src\app`}, dirs, "a drive letter is not an option")
+
+	assert.Equal(t, -1, rootOptionsStart("backend:depth=2"), "unknown keys do not start options")
+
+	_, _, err = parseScanDirValues([]string{"backend:ext=go:depth=2"})
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestGenerateRoots(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"backend/main.go":     "package main\n",
+		"backend/gen/api.go":  "package gen\n",
+		"backend/notes.ts":    "// not a backend ext\n",
+		"frontend/app.ts":     "export {}\n",
+		"frontend/server.go":  "package x\n",
+		"frontend/readme.txt": "hi\n",
+		"Makefile":            "all:\n",
+	})
+	opts := GenerateOptions{
+		CWD:         tempDir,
+		ScanDirs:    []string{filepath.Join(tempDir, "backend"), filepath.Join(tempDir, "frontend")},
+		Extensions:  processExtensions([]string{"txt"}),
+		ManualFiles: []string{"Makefile"},
+		Marker:      "---",
+		Roots: []ScanRoot{
+			{Dir: filepath.Join(tempDir, "backend"), Extensions: processExtensions([]string{"go"}), Excludes: []string{"backend/gen"}},
+			{Dir: filepath.Join(tempDir, "frontend"), Extensions: processExtensions([]string{"ts"})},
+		},
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Makefile", "backend/main.go", "frontend/app.ts"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.Equal(t, "", result.Documents[0].Root)
+	assert.Equal(t, "backend", result.Documents[1].Root)
+	assert.Equal(t, "frontend", result.Documents[2].Root)
+	assert.Contains(t, result.Output, "--- === root: backend ===\n--- backend/main.go\n")
+	assert.Contains(t, result.Output, "--- === root: frontend ===\n--- frontend/app.ts\n")
+	assert.Equal(t, 1, result.ExcludedBy["flag"])
+}
+
+func TestGenerateRoots_MaxFiles(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"a/1.go": "package a\n",
+		"a/2.go": "package a\n",
+		"b/1.go": "package b\n",
+		"b/2.go": "package b\n",
+	})
+	goExt := processExtensions([]string{"go"})
+	opts := GenerateOptions{
+		CWD:      tempDir,
+		ScanDirs: []string{filepath.Join(tempDir, "a"), filepath.Join(tempDir, "b")},
+		Marker:   "---",
+		MaxFiles: 3,
+		Roots:    []ScanRoot{{Dir: filepath.Join(tempDir, "a"), Extensions: goExt}, {Dir: filepath.Join(tempDir, "b"), Extensions: goExt}},
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Len(t, result.IncludedFiles, 3, "the cap is shared by the roots")
+	assert.True(t, result.MaxFilesHit)
+
+	opts.MaxFiles = 2
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/1.go", "a/2.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.True(t, result.MaxFilesHit, "the second root was not scanned")
+}
+
+func TestDedupeScanRoots(t *testing.T) {
+	dirs, roots := dedupeScanRoots([]string{"/a", "/b", "/a"}, nil)
+	assert.Equal(t, []string{"/a", "/b"}, dirs)
+	assert.Nil(t, roots)
+
+	dirs, roots = dedupeScanRoots([]string{"/a", "/a/b", "/a"}, []ScanRoot{
+		{Dir: "/a", Extensions: processExtensions([]string{"go"})},
+		{Dir: "/a/b"},
+		{Dir: "/a", Extensions: processExtensions([]string{"ts"})},
+	})
+	assert.Equal(t, []string{"/a", "/a/b"}, dirs)
+	require.Len(t, roots, 2)
+	assert.Contains(t, roots[0].Extensions, ".go", "the first repeat wins")
+}
+
+func TestGenerate_NestedRoots(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":                  "package main\n",
+		"vendor/special/lib.go":    "package special\n",
+		"vendor/special/schema.ts": "export {}\n",
+		"vendor/other/x.go":        "package other\n",
+	})
+	vendorSpecial := filepath.Join(tempDir, "vendor", "special")
+
+	// One walk: every file appears once, however the roots nest.
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir, vendorSpecial},
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "vendor/other/x.go", "vendor/special/lib.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+
+	// Per-root filters: the innermost root owns its files.
+	opts.ManualFiles = []string{"main.go"}
+	opts.Roots = []ScanRoot{
+		{Dir: tempDir, Extensions: processExtensions([]string{"go"})},
+		{Dir: vendorSpecial, Extensions: processExtensions([]string{"ts"})},
+	}
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "vendor/other/x.go", "vendor/special/schema.ts"}, getPathsFromIncludedFiles(result.IncludedFiles),
+		"the nested root's filters apply to its files, and -f files are not repeated")
+	assert.Equal(t, 1, strings.Count(result.Output, "--- main.go\n"))
+	assert.Equal(t, int64(len("package main\n")+len("package other\n")+len("export {}\n")), result.TotalSize)
+}
+
+func TestMergeExclusionRules(t *testing.T) {
+	merged := mergeExclusionRules(nil, []ExclusionRule{{Pattern: "*.log", Source: "basename", Hits: 1}, {Pattern: "gen", Source: "project"}})
+	merged = mergeExclusionRules(merged, []ExclusionRule{{Pattern: "gen", Source: "project", Hits: 3}, {Pattern: "*.log", Source: "basename"}})
+	assert.Equal(t, []ExclusionRule{
+		{Pattern: "gen", Source: "project", Hits: 3},
+		{Pattern: "*.log", Source: "basename", Hits: 1},
+	}, merged)
+}
+
+func TestGenerateRoots_IncludeErrors(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"a/main.go": "package a\n",
+		"b/main.go": "package b\n",
+	})
+	secret := filepath.Join(t.TempDir(), "secret.go")
+	require.NoError(t, os.WriteFile(secret, []byte("package secret\n"), 0644))
+	if err := os.Symlink(secret, filepath.Join(tempDir, "a", "linked.go")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	goExt := processExtensions([]string{"go"})
+	opts := GenerateOptions{
+		CWD:           tempDir,
+		ScanDirs:      []string{filepath.Join(tempDir, "a"), filepath.Join(tempDir, "b")},
+		Marker:        "---",
+		Jail:          tempDir,
+		IncludeErrors: true,
+		Roots:         []ScanRoot{{Dir: filepath.Join(tempDir, "a"), Extensions: goExt}, {Dir: filepath.Join(tempDir, "b"), Extensions: goExt}},
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	var paths []string
+	for _, d := range result.Documents {
+		paths = append(paths, d.Root+":"+d.Path)
+	}
+	assert.Equal(t, []string{"a:a/linked.go", "a:a/main.go", "b:b/main.go"}, paths, "one placeholder, in its root's section")
+	assert.Equal(t, 1, strings.Count(result.Output, "could not be read"))
+}
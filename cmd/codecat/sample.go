@@ -0,0 +1,90 @@
+// cmd/codecat/sample.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+)
+
+// sampleDirectories keeps at most n files of each extension directly in a
+// directory, for --sample-dirs: hundreds of near-identical migrations or
+// fixtures are represented by a few of them, spread evenly from the first to
+// the last, and a note listing the rest, placed after the sample. Files given
+// with -f are always kept. listNames is false under --anonymize-paths, when
+// the note only counts the files left out. The omitted paths are returned.
+func sampleDirectories(result *GenerateResult, n int, listNames bool) []string {
+	type group struct{ dir, ext string }
+	members := make(map[group][]string)
+	var order []group
+	for _, doc := range result.Documents {
+		ext := strings.ToLower(path.Ext(doc.Path))
+		if doc.IsManual || ext == "" || strings.HasPrefix(path.Base(doc.Path), "*") {
+			continue // -f files, and notes of an earlier pass
+		}
+		g := group{path.Dir(doc.Path), ext}
+		if members[g] == nil {
+			order = append(order, g)
+		}
+		members[g] = append(members[g], doc.Path)
+	}
+
+	gone := make(map[string]bool)
+	notes := make(map[string]Document) // last sampled path -> note following it
+	var omitted []string
+	for _, g := range order {
+		paths := members[g]
+		if len(paths) <= n {
+			continue
+		}
+		slices.SortFunc(paths, compareWalkOrder)
+		keep := make(map[int]bool, n)
+		for i := 0; i < n; i++ {
+			at := 0
+			if n > 1 {
+				at = i * (len(paths) - 1) / (n - 1)
+			}
+			keep[at] = true
+		}
+		var rest []string
+		last := ""
+		for i, p := range paths {
+			if keep[i] {
+				last = p
+				continue
+			}
+			gone[p] = true
+			rest = append(rest, p)
+		}
+		omitted = append(omitted, rest...)
+		var content strings.Builder
+		fmt.Fprintf(&content, "[codecat: %d more %s files in this directory are left out; the %d above are a sample]\n", len(rest), g.ext, n)
+		if listNames {
+			for _, p := range rest {
+				content.WriteString(path.Base(p) + "\n")
+			}
+		}
+		notePath := path.Join(g.dir, "*"+g.ext)
+		notes[last] = Document{Path: notePath, Content: content.String(),
+			Meta: []string{fmt.Sprintf("sampled: %d of %d files", n, len(paths))}}
+		slog.Info("Sampled a directory.", "dir", g.dir, "extension", g.ext, "kept", n, "files", len(paths))
+	}
+	if len(omitted) == 0 {
+		return nil
+	}
+	kept := make([]Document, 0, len(result.Documents)-len(omitted)+len(notes))
+	for _, doc := range result.Documents {
+		if gone[doc.Path] {
+			continue
+		}
+		kept = append(kept, doc)
+		if note, ok := notes[doc.Path]; ok {
+			kept = append(kept, note)
+		}
+	}
+	result.Documents = kept
+	dropIncludedFiles(result, gone)
+	return omitted
+}
@@ -0,0 +1,47 @@
+// cmd/codecat/sample_test.go
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleDirectories(t *testing.T) {
+	var result GenerateResult
+	for i := 1; i <= 7; i++ {
+		p := fmt.Sprintf("migrations/%03d.sql", i)
+		result.Documents = append(result.Documents, Document{Path: p, Content: "select 1;\n"})
+		result.IncludedFiles = append(result.IncludedFiles, FileInfo{Path: p, Size: 10})
+	}
+	result.Documents = append(result.Documents,
+		Document{Path: "migrations/README.md", Content: "How to migrate.\n"},
+		Document{Path: "migrations/seed.sql", Content: "insert;\n", IsManual: true},
+		Document{Path: "main.go", Content: "package main\n"})
+
+	omitted := sampleDirectories(&result, 3, true)
+	assert.Equal(t, []string{"migrations/002.sql", "migrations/003.sql", "migrations/005.sql", "migrations/006.sql"}, omitted)
+	var paths []string
+	for _, d := range result.Documents {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"migrations/001.sql", "migrations/004.sql", "migrations/007.sql", "migrations/*.sql",
+		"migrations/README.md", "migrations/seed.sql", "main.go"}, paths,
+		"first, middle and last kept, the note after them; other extensions and -f files untouched")
+	note := result.Documents[3]
+	assert.Equal(t, "[codecat: 4 more .sql files in this directory are left out; the 3 above are a sample]\n002.sql\n003.sql\n005.sql\n006.sql\n", note.Content)
+	assert.Equal(t, []string{"sampled: 3 of 7 files"}, note.Meta)
+	assert.Len(t, result.IncludedFiles, 3)
+
+	assert.Nil(t, sampleDirectories(&result, 3, true), "within the limit")
+}
+
+func TestSampleDirectories_Anonymized(t *testing.T) {
+	result := GenerateResult{Documents: []Document{{Path: "fx/a.json"}, {Path: "fx/b.json"}, {Path: "fx/c.json"}}}
+	sampleDirectories(&result, 1, false)
+	require.Len(t, result.Documents, 2)
+	assert.Equal(t, "[codecat: 2 more .json files in this directory are left out; the 1 above are a sample]\n", result.Documents[1].Content,
+		"names stay out when paths are anonymized")
+}
@@ -0,0 +1,91 @@
+// cmd/codecat/scrub.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// piiKinds are the values --scrub-pii masks, in the order they are searched.
+// Emails go first so their domains are not taken for anything else, and IP
+// addresses before phone numbers, which they would otherwise resemble.
+var piiKinds = []struct {
+	name    string
+	mask    string
+	pattern *regexp.Regexp
+}{
+	{"email", "[EMAIL]", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)},
+	{"ip", "[IP]", regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|\b(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}\b`)},
+	{"phone", "[PHONE]", regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[ .-]\d{3,4}[ .-]\d{3,4}\b`)},
+}
+
+// defaultScrubAllow are documentation values that are never personal data.
+var defaultScrubAllow = []string{"example.com", "example.org", "example.net", "127.0.0.1", "0.0.0.0"}
+
+// piiScrubber masks emails, phone numbers and IP addresses except those on
+// the allowlist.
+type piiScrubber struct {
+	domains  []string         // email domains kept, with their subdomains
+	patterns []*regexp.Regexp // values kept when matched in full
+}
+
+// newPIIScrubber compiles the allowlist. Entries that look like domains keep
+// the addresses at that domain; every entry is also a regular expression
+// matched against the whole value, so "10\.0\.\d+\.\d+" keeps a private range.
+func newPIIScrubber(allow []string) (*piiScrubber, error) {
+	s := &piiScrubber{}
+	for _, entry := range append(append([]string{}, defaultScrubAllow...), allow...) {
+		re, err := regexp.Compile(`^(?:` + entry + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --scrub-allow pattern %q: %w", entry, err)
+		}
+		s.patterns = append(s.patterns, re)
+		if strings.Contains(entry, ".") && !strings.ContainsAny(entry, `\*+?[](){}|^$@`) {
+			s.domains = append(s.domains, strings.ToLower(entry))
+		}
+	}
+	return s, nil
+}
+
+// allowed reports whether value is on the allowlist.
+func (s *piiScrubber) allowed(kind, value string) bool {
+	for _, re := range s.patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	if kind == "email" {
+		domain := strings.ToLower(value[strings.LastIndexByte(value, '@')+1:])
+		for _, d := range s.domains {
+			if domain == d || strings.HasSuffix(domain, "."+d) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scrub masks every PII value in content that is not allowed.
+func (s *piiScrubber) scrub(relPath string, content []byte) []byte {
+	text := string(content)
+	counts := make(map[string]int)
+	for _, kind := range piiKinds {
+		text = kind.pattern.ReplaceAllStringFunc(text, func(value string) string {
+			if kind.name == "ip" && strings.Contains(value, ".") && net.ParseIP(value) == nil {
+				return value // e.g. 999.1.1.1 or a four-part version
+			}
+			if s.allowed(kind.name, value) {
+				return value
+			}
+			counts[kind.name]++
+			return kind.mask
+		})
+	}
+	if len(counts) > 0 {
+		slog.Info("Masked personal data.", "path", relPath, "emails", counts["email"], "phones", counts["phone"], "ips", counts["ip"])
+	}
+	return []byte(text)
+}
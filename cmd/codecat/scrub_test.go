@@ -0,0 +1,46 @@
+// cmd/codecat/scrub_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPIIScrubber(t *testing.T) {
+	s, err := newPIIScrubber([]string{"acme.io", `10\.0\.\d+\.\d+`})
+	require.NoError(t, err)
+
+	in := `owner = "jane.doe@gmail.com"
+support = "help@support.acme.io"
+docs = "user@example.com"
+call = "+1 415-555-0132" or (020) 7946 0958
+server = 203.0.113.42, internal = 10.0.3.7, local = 127.0.0.1
+v6 = 2001:0db8:85a3:0000:0000:8a2e:0370:7334
+version = "1.2.300.4", timeout = 30000, date = 2024-10-16
+`
+	want := `owner = "[EMAIL]"
+support = "help@support.acme.io"
+docs = "user@example.com"
+call = "[PHONE]" or [PHONE]
+server = [IP], internal = 10.0.3.7, local = 127.0.0.1
+v6 = [IP]
+version = "1.2.300.4", timeout = 30000, date = 2024-10-16
+`
+	assert.Equal(t, want, string(s.scrub("config.toml", []byte(in))))
+
+	_, err = newPIIScrubber([]string{"(unclosed"})
+	assert.ErrorContains(t, err, "invalid --scrub-allow pattern")
+}
+
+func TestContentPipeline_ScrubPII(t *testing.T) {
+	p := newContentPipeline(GenerateOptions{NoAdapters: true, ScrubPII: true})
+	out, err := p.process("a.txt", []byte("mail me: a@corp.dev\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "mail me: [EMAIL]\n", string(out))
+
+	p = newContentPipeline(GenerateOptions{NoAdapters: true, ScrubPII: true, ScrubAllow: []string{"("}})
+	_, err = p.process("a.txt", []byte("a@corp.dev\n"))
+	assert.Error(t, err, "an invalid allowlist never lets content through")
+}
@@ -0,0 +1,392 @@
+// cmd/codecat/select.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	pflag "github.com/spf13/pflag"
+)
+
+// embeddingsCacheFile caches file embeddings between runs, relative to the CWD.
+const embeddingsCacheFile = stateDir + "/embeddings.json"
+
+// embedInputBytes caps the text embedded per file; the start of a file
+// (with its path) is usually enough to place it.
+const embedInputBytes = 8000
+
+// embedBatchSize is the number of texts sent per embeddings request.
+const embedBatchSize = 64
+
+const defaultSelectTop = 20
+
+var (
+	selectQuery string
+	selectTop   int
+)
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "select",
+		Summary: "Include only the files most relevant to --query, ranked by [embeddings] similarity.",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&selectQuery, "query", "",
+				"[select] Question or topic to rank files by.")
+			fs.IntVar(&selectTop, "top", defaultSelectTop,
+				"[select] Maximum number of files to include.")
+		},
+		Run: runSelect,
+	})
+}
+
+// EmbeddingsConfig describes an OpenAI- or Ollama-compatible embeddings endpoint.
+type EmbeddingsConfig struct {
+	Provider  string `toml:"provider"`    // openai or ollama
+	Endpoint  string `toml:"endpoint"`    // base URL; provider default if empty
+	Model     string `toml:"model"`       // required
+	APIKeyEnv string `toml:"api_key_env"` // environment variable holding the API key
+}
+
+// rankedFile is a scanned file and its similarity to the query.
+type rankedFile struct {
+	Path  string
+	Score float64
+}
+
+func runSelect(cwd string, appConfig Config, args []string) int {
+	query := strings.TrimSpace(tern(selectQuery != "", selectQuery, strings.Join(args, " ")))
+	if query == "" {
+		fmt.Fprintln(os.Stderr, "Error: usage: codecat select [flags] --query \"question\"")
+		return 1
+	}
+	if selectTop <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --top must be positive")
+		return 1
+	}
+	if appConfig.Embeddings.Model == "" {
+		fmt.Fprintln(os.Stderr, "Error: no embedding model configured; set [embeddings] provider/model in config.toml")
+		return 1
+	}
+
+	opts, err := resolveGenerateOptions(cwd, appConfig, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	targets, err := resolveOutputTargets(appConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	logOutput := logWriterFor(targets)
+	opts.OutputPaths = runOutputPaths(cwd, targets)
+	// Files are written in order of relevance, trimmed to the budget once.
+	opts.DocsFirst, opts.Priorities = false, nil
+
+	scan := partOptions(opts)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	result, genErr := generateContext(ctx, scan)
+	stop()
+	if result.Partial {
+		fmt.Fprintln(os.Stderr, "Interrupted: scan cancelled, no output written.")
+		return 1
+	}
+	exitCode := 0
+	if genErr != nil || len(result.ErrorFiles) > 0 {
+		slog.Error("Error(s) reported during file processing.", "error", genErr)
+		exitCode = 1
+	}
+
+	cachePath := filepath.Join(cwd, filepath.FromSlash(embeddingsCacheFile))
+	ranked, err := rankDocuments(http.DefaultClient, appConfig.Embeddings, cachePath, query, result.Documents)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	selected := selectRanked(ranked, result.Documents, selectTop, opts.MaxTokens)
+	keepSelected(&result, selected)
+
+	opts.Fit = opts.MaxTokens > 0
+	if err := renderResult(&result, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, target := range targets {
+		slog.Info("Writing output.", "target", target.String())
+		if errWrite := writeOutput(target, result, opts); errWrite != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output %s: %v\n", target, errWrite)
+			exitCode = 1
+		}
+	}
+	if showSummary(summaryFlag, targets, isTerminal(os.Stdout)) {
+		printSummaryTree(result.IncludedFiles, result.EmptyFiles, result.ErrorFiles, result.SpecialFiles,
+			result.ExcludeRules, result.TotalSize, result.PathBase, logOutput)
+		printSelection(logOutput, ranked, selected)
+	}
+	return exitCode
+}
+
+// rankDocuments embeds the query and every document (reusing cached
+// embeddings of unchanged content) and returns the documents' paths ordered
+// by cosine similarity to the query, most similar first.
+func rankDocuments(client *http.Client, cfg EmbeddingsConfig, cachePath, query string, docs []Document) ([]rankedFile, error) {
+	cache := loadEmbeddingCache(cachePath, cfg)
+	used := make(map[string][]float32, len(docs))
+	var missing []string
+	keys := make([]string, len(docs))
+	inputs := make(map[string]string)
+	for i, doc := range docs {
+		text := embeddingInput(doc)
+		sum := sha256.Sum256([]byte(text))
+		keys[i] = hex.EncodeToString(sum[:])
+		if vec, ok := cache.Vectors[keys[i]]; ok {
+			used[keys[i]] = vec
+		} else if _, queued := inputs[keys[i]]; !queued {
+			inputs[keys[i]] = text
+			missing = append(missing, keys[i])
+		}
+	}
+	slog.Info("Embedding files.", "files", len(docs), "cached", len(docs)-len(missing), "model", cfg.Model)
+
+	for start := 0; start < len(missing); start += embedBatchSize {
+		batch := missing[start:min(start+embedBatchSize, len(missing))]
+		texts := make([]string, len(batch))
+		for i, key := range batch {
+			texts[i] = inputs[key]
+		}
+		vectors, err := embedTexts(client, cfg, texts)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range batch {
+			used[key] = vectors[i]
+		}
+	}
+	cache.Vectors = used
+	if len(missing) > 0 {
+		if err := saveEmbeddingCache(cachePath, cache); err != nil {
+			slog.Warn("Could not save the embeddings cache.", "path", cachePath, "error", err)
+		}
+	}
+
+	queryVectors, err := embedTexts(client, cfg, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	ranked := make([]rankedFile, len(docs))
+	for i, doc := range docs {
+		ranked[i] = rankedFile{Path: doc.Path, Score: cosineSimilarity(queryVectors[0], used[keys[i]])}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked, nil
+}
+
+// embeddingInput is the text embedded for a document: its path and the
+// start of its content.
+func embeddingInput(doc Document) string {
+	text := doc.Path + "\n" + doc.text()
+	if len(text) > embedInputBytes {
+		text = strings.ToValidUTF8(text[:embedInputBytes], "")
+	}
+	return text
+}
+
+// selectRanked picks up to top paths in ranking order. With a budget, files
+// that no longer fit are passed over in favour of smaller, less similar ones.
+func selectRanked(ranked []rankedFile, docs []Document, top int, maxTokens int64) []string {
+	sizes := make(map[string]int, len(docs))
+	for _, doc := range docs {
+		sizes[doc.Path] = len(doc.Path) + doc.size()
+	}
+	var selected []string
+	var tokens int64
+	for _, r := range ranked {
+		if len(selected) == top {
+			break
+		}
+		cost := estimateTokens(int64(sizes[r.Path]))
+		if maxTokens > 0 && tokens+cost > maxTokens {
+			continue
+		}
+		selected = append(selected, r.Path)
+		tokens += cost
+	}
+	return selected
+}
+
+// keepSelected reduces result to the selected files, in selection order.
+func keepSelected(result *GenerateResult, selected []string) {
+	docs := make(map[string]Document, len(result.Documents))
+	for _, doc := range result.Documents {
+		docs[doc.Path] = doc
+	}
+	files := make(map[string]FileInfo, len(result.IncludedFiles))
+	for _, f := range result.IncludedFiles {
+		files[f.Path] = f
+	}
+	result.Documents, result.IncludedFiles, result.TotalSize = nil, nil, 0
+	for _, p := range selected {
+		result.Documents = append(result.Documents, docs[p])
+		if f, ok := files[p]; ok {
+			result.IncludedFiles = append(result.IncludedFiles, f)
+			result.TotalSize += f.Size
+		}
+	}
+}
+
+// printSelection lists the ranking with each file's similarity, marking the
+// selected files.
+func printSelection(w io.Writer, ranked []rankedFile, selected []string) {
+	chosen := make(map[string]bool, len(selected))
+	for _, p := range selected {
+		chosen[p] = true
+	}
+	fmt.Fprintf(w, "\n--- Relevance (%d of %d files selected) ---\n", len(selected), len(ranked))
+	for _, r := range ranked {
+		fmt.Fprintf(w, "%s %.3f %s\n", tern(chosen[r.Path], "*", " "), r.Score, r.Path)
+	}
+	fmt.Fprintln(w, "---------------")
+}
+
+// cosineSimilarity of two vectors; 0 if either is empty or they differ in length.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// embeddingCache maps the SHA-256 of embedded text to its vector, for one
+// provider and model.
+type embeddingCache struct {
+	Provider string               `json:"provider"`
+	Model    string               `json:"model"`
+	Vectors  map[string][]float32 `json:"vectors"`
+}
+
+// loadEmbeddingCache reads the cache at path, starting empty if it is
+// missing, unreadable or was built with another provider or model.
+func loadEmbeddingCache(path string, cfg EmbeddingsConfig) embeddingCache {
+	fresh := embeddingCache{Provider: embeddingsProvider(cfg), Model: cfg.Model, Vectors: map[string][]float32{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Could not read the embeddings cache.", "path", path, "error", err)
+		}
+		return fresh
+	}
+	var cache embeddingCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		slog.Warn("Ignoring a corrupt embeddings cache.", "path", path, "error", err)
+		return fresh
+	}
+	if cache.Provider != fresh.Provider || cache.Model != fresh.Model || cache.Vectors == nil {
+		slog.Info("Embeddings cache was built with another model, re-embedding.", "path", path, "model", cache.Model)
+		return fresh
+	}
+	return cache
+}
+
+// saveEmbeddingCache writes the cache, creating its directory.
+func saveEmbeddingCache(path string, cache embeddingCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func embeddingsProvider(cfg EmbeddingsConfig) string {
+	return strings.ToLower(tern(cfg.Provider == "", "openai", cfg.Provider))
+}
+
+// embedTexts requests one embedding per text from the configured endpoint.
+func embedTexts(client *http.Client, cfg EmbeddingsConfig, texts []string) ([][]float32, error) {
+	var url string
+	headers := map[string]string{"Content-Type": "application/json"}
+	switch embeddingsProvider(cfg) {
+	case "openai":
+		url = strings.TrimRight(tern(cfg.Endpoint == "", "https://api.openai.com/v1", cfg.Endpoint), "/") + "/embeddings"
+		if apiKey := apiKeyFromEnv(cfg.APIKeyEnv); apiKey != "" {
+			headers["Authorization"] = "Bearer " + apiKey
+		}
+	case "ollama":
+		url = strings.TrimRight(tern(cfg.Endpoint == "", "http://localhost:11434", cfg.Endpoint), "/") + "/api/embed"
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q (want openai or ollama)", cfg.Provider)
+	}
+
+	payload, err := json.Marshal(map[string]any{"model": cfg.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	// OpenAI answers {"data":[{"index":0,"embedding":[...]}]}, Ollama {"embeddings":[[...]]}.
+	var body struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding %s response: %w", url, err)
+	}
+	vectors := body.Embeddings
+	if len(body.Data) > 0 {
+		vectors = make([][]float32, len(body.Data))
+		for _, d := range body.Data {
+			if d.Index < 0 || d.Index >= len(vectors) {
+				return nil, fmt.Errorf("%s returned an embedding for unknown input %d", url, d.Index)
+			}
+			vectors[d.Index] = d.Embedding
+		}
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d embeddings for %d inputs", url, len(vectors), len(texts))
+	}
+	return vectors, nil
+}
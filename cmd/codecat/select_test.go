@@ -0,0 +1,124 @@
+// cmd/codecat/select_test.go
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbeddings serves embeddings where a text's vector marks whether it
+// mentions "auth" or "billing", counting the inputs it embedded.
+func fakeEmbeddings(t *testing.T, provider string, embedded *int) *httptest.Server {
+	t.Helper()
+	vector := func(text string) []float32 {
+		return []float32{
+			tern[float32](strings.Contains(text, "auth"), 1, 0),
+			tern[float32](strings.Contains(text, "billing"), 1, 0),
+			0.1,
+		}
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "embed-m", req.Model)
+		*embedded += len(req.Input)
+		switch provider {
+		case "openai":
+			assert.Equal(t, "/embeddings", r.URL.Path)
+			var data []map[string]any
+			for i := len(req.Input) - 1; i >= 0; i-- { // out of order on purpose
+				data = append(data, map[string]any{"index": i, "embedding": vector(req.Input[i])})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"data": data})
+		case "ollama":
+			assert.Equal(t, "/api/embed", r.URL.Path)
+			var vectors [][]float32
+			for _, text := range req.Input {
+				vectors = append(vectors, vector(text))
+			}
+			json.NewEncoder(w).Encode(map[string]any{"embeddings": vectors})
+		}
+	}))
+}
+
+func TestRankDocuments(t *testing.T) {
+	docs := []Document{
+		{Path: "billing/invoice.go", Content: "package billing\n"},
+		{Path: "auth/refresh.go", Content: "package auth\n"},
+		{Path: "main.go", Content: "package main\n"},
+	}
+	for _, provider := range []string{"openai", "ollama"} {
+		t.Run(provider, func(t *testing.T) {
+			embedded := 0
+			server := fakeEmbeddings(t, provider, &embedded)
+			defer server.Close()
+			cfg := EmbeddingsConfig{Provider: provider, Endpoint: server.URL, Model: "embed-m"}
+			cachePath := filepath.Join(t.TempDir(), ".codecat", "embeddings.json")
+
+			ranked, err := rankDocuments(server.Client(), cfg, cachePath, "how does auth refresh work", docs)
+			require.NoError(t, err)
+			require.Len(t, ranked, 3)
+			assert.Equal(t, "auth/refresh.go", ranked[0].Path)
+			assert.Equal(t, 4, embedded, "three files and the query")
+
+			embedded = 0
+			_, err = rankDocuments(server.Client(), cfg, cachePath, "billing", docs)
+			require.NoError(t, err)
+			assert.Equal(t, 1, embedded, "unchanged files come from the cache")
+
+			cfg.Model = "other"
+			assert.Empty(t, loadEmbeddingCache(cachePath, cfg).Vectors, "cache is per model")
+		})
+	}
+}
+
+func TestEmbedTexts_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	_, err := embedTexts(server.Client(), EmbeddingsConfig{Endpoint: server.URL, Model: "m"}, []string{"x"})
+	assert.ErrorContains(t, err, "bad key")
+
+	_, err = embedTexts(server.Client(), EmbeddingsConfig{Provider: "anthropic", Model: "m"}, []string{"x"})
+	assert.ErrorContains(t, err, "unknown embeddings provider")
+}
+
+func TestSelectRanked(t *testing.T) {
+	docs := []Document{
+		{Path: "a.go", Content: strings.Repeat("a", 396)},
+		{Path: "b.go", Content: strings.Repeat("b", 3996)},
+		{Path: "c.go", Content: strings.Repeat("c", 396)},
+		{Path: "d.go", Content: strings.Repeat("d", 396)},
+	}
+	ranked := []rankedFile{{"b.go", 0.9}, {"a.go", 0.8}, {"c.go", 0.7}, {"d.go", 0.6}}
+	assert.Equal(t, []string{"b.go", "a.go"}, selectRanked(ranked, docs, 2, 0))
+	assert.Equal(t, []string{"a.go", "c.go"}, selectRanked(ranked, docs, 3, 200), "files over the budget are passed over")
+}
+
+func TestKeepSelected(t *testing.T) {
+	result := GenerateResult{
+		Documents:     []Document{{Path: "a.go"}, {Path: "b.go"}, {Path: "c.go"}},
+		IncludedFiles: []FileInfo{{Path: "a.go", Size: 1}, {Path: "b.go", Size: 2}, {Path: "c.go", Size: 4}},
+	}
+	keepSelected(&result, []string{"c.go", "a.go"})
+	assert.Equal(t, []Document{{Path: "c.go"}, {Path: "a.go"}}, result.Documents)
+	assert.Equal(t, int64(5), result.TotalSize)
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	assert.InDelta(t, 1.0, cosineSimilarity([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	assert.InDelta(t, 0.0, cosineSimilarity([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.Equal(t, 0.0, cosineSimilarity([]float32{1}, []float32{1, 2}))
+	assert.Equal(t, 0.0, cosineSimilarity(nil, nil))
+}
@@ -0,0 +1,218 @@
+// cmd/codecat/serve.go
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pflag "github.com/spf13/pflag"
+)
+
+var (
+	serveAddr  string
+	serveToken string
+)
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "serve",
+		Summary: "Serve concatenated context over HTTP (GET /context).",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&serveAddr, "http", "127.0.0.1:8080",
+				"[serve] Address to listen on; a non-loopback address requires --token.")
+			fs.StringVar(&serveToken, "token", "",
+				"[serve] Require this bearer token (Authorization header or ?token=).")
+		},
+		Run: runServe,
+	})
+}
+
+// contextResponse is the JSON body returned by GET /context?format=json.
+type contextResponse struct {
+	Output          string            `json:"output"`
+	Files           []FileInfo        `json:"files"`
+	EmptyFiles      []string          `json:"empty_files"`
+	Errors          map[string]string `json:"errors"`
+	TotalSize       int64             `json:"total_size"`
+	EstimatedTokens int64             `json:"estimated_tokens"`
+}
+
+func runServe(cwd string, appConfig Config, args []string) int {
+	if len(args) > 0 {
+		slog.Error("The serve command takes no positional arguments.", "args", args)
+		return 1
+	}
+	if serveToken == "" && !isLoopbackAddr(serveAddr) {
+		slog.Error("Refusing to serve on a non-loopback address without --token.", "addr", serveAddr)
+		return 1
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/context", newContextHandler(cwd, appConfig, serveToken))
+
+	slog.Warn("Serving codecat context over HTTP.", "addr", serveAddr, "cwd", cwd, "auth", serveToken != "")
+	if err := http.ListenAndServe(serveAddr, mux); err != nil {
+		slog.Error("HTTP server stopped.", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// newContextHandler returns the handler for GET /context. Every request runs a
+// fresh generation rooted at cwd, using appConfig for anything not in the query.
+func newContextHandler(cwd string, appConfig Config, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" && !requestHasToken(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		opts, maxTokens, err := contextOptionsFromQuery(cwd, appConfig, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		slog.Info("Serving context request.", "remote", r.RemoteAddr, "dirs", opts.ScanDirs, "files", opts.ManualFiles)
+		result, genErr := generateContext(r.Context(), opts)
+		if errors.Is(genErr, errOutsideJail) {
+			http.Error(w, genErr.Error(), http.StatusForbidden)
+			return
+		}
+		if genErr != nil {
+			slog.Warn("Context generation reported errors.", "error", genErr)
+		}
+
+		tokens := estimateTokens(int64(len(result.Output)))
+		if maxTokens > 0 && tokens > maxTokens {
+			var report strings.Builder
+			printDropList(&report, result.IncludedFiles, tokens, maxTokens)
+			http.Error(w, report.String(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if r.URL.Query().Get("format") == "json" {
+			resp := contextResponse{
+				Output:          result.Output,
+				Files:           result.IncludedFiles,
+				EmptyFiles:      result.EmptyFiles,
+				Errors:          make(map[string]string, len(result.ErrorFiles)),
+				TotalSize:       result.TotalSize,
+				EstimatedTokens: tokens,
+			}
+			for path, e := range result.ErrorFiles {
+				resp.Errors[path] = e.Error()
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(resp); err != nil {
+				slog.Warn("Failed to write JSON response.", "error", err)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := w.Write([]byte(result.Output)); err != nil {
+			slog.Warn("Failed to write response.", "error", err)
+		}
+	})
+}
+
+// isLoopbackAddr reports whether a listen address only accepts local
+// connections. An empty host listens on every interface.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// requestHasToken checks the bearer token from the Authorization header or the token query parameter.
+func requestHasToken(r *http.Request, token string) bool {
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if got == "" {
+		got = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// contextOptionsFromQuery maps query parameters (dir, ext, files, exclude,
+// no_gitignore, max_tokens) onto GenerateOptions. Paths may not leave cwd,
+// and files may not resolve outside the default jail.
+func contextOptionsFromQuery(cwd string, appConfig Config, r *http.Request) (GenerateOptions, int64, error) {
+	q := r.URL.Query()
+
+	dirs := parseCommaSeparatedSlice(q["dir"])
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	scanDirs := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		absDir := filepath.Clean(filepath.Join(cwd, dir))
+		if rel, err := filepath.Rel(cwd, absDir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return GenerateOptions{}, 0, fmt.Errorf("dir %q is outside the served directory", dir)
+		}
+		scanDirs = append(scanDirs, absDir)
+	}
+
+	manual := parseCommaSeparatedSlice(q["files"])
+	for _, f := range manual {
+		if !filepath.IsLocal(f) {
+			return GenerateOptions{}, 0, fmt.Errorf("file %q is outside the served directory", f)
+		}
+	}
+
+	extList := appConfig.IncludeExtensions
+	if q.Has("ext") {
+		extList = parseCommaSeparatedSlice(q["ext"])
+	}
+
+	useGitignore := *appConfig.UseGitignore
+	if v := q.Get("no_gitignore"); v != "" {
+		noGit, err := strconv.ParseBool(v)
+		if err != nil {
+			return GenerateOptions{}, 0, fmt.Errorf("invalid no_gitignore value %q", v)
+		}
+		useGitignore = !noGit
+	}
+
+	var maxTokens int64
+	if v := q.Get("max_tokens"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return GenerateOptions{}, 0, fmt.Errorf("invalid max_tokens value %q", v)
+		}
+		maxTokens = n
+	}
+
+	return GenerateOptions{
+		CWD:              cwd,
+		ScanDirs:         scanDirs,
+		Extensions:       processExtensions(extList),
+		ManualFiles:      manual,
+		ExcludeBasenames: appConfig.ExcludeBasenames,
+		IncludeBasenames: appConfig.IncludeBasenames,
+		ProjectExcludes:  loadProjectExcludes(cwd),
+		ProjectIncludes:  loadProjectIncludes(cwd),
+		FlagExcludes:     parseCommaSeparatedSlice(q["exclude"]),
+		UseGitignore:     useGitignore,
+		Header:           *appConfig.HeaderText,
+		Marker:           *appConfig.CommentMarker,
+		Jail:             defaultJail(cwd),
+	}, maxTokens, nil
+}
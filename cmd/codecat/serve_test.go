@@ -0,0 +1,121 @@
+// cmd/codecat/serve_test.go
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextHandler(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":       "package main",
+		"README.md":     "# Readme",
+		"sub/helper.go": "package sub",
+		"..notes.go":    "package notes",
+	})
+	testLogger, logBuf := setupTestLogger(t)
+	slog.SetDefault(testLogger)
+	handler := newContextHandler(tempDir, defaultConfig, "secret")
+
+	t.Run("Missing token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/context", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Text with ext filter", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/context?ext=go", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "--- main.go\n")
+		assert.Contains(t, rec.Body.String(), "--- sub/helper.go\n")
+		assert.NotContains(t, rec.Body.String(), "README.md")
+	})
+
+	t.Run("JSON with dir", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/context?token=secret&dir=sub&format=json", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp contextResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Files, 1)
+		assert.Equal(t, "sub/helper.go", resp.Files[0].Path)
+		assert.Greater(t, resp.EstimatedTokens, int64(0))
+		assert.NotContains(t, logBuf.String(), "secret", "token logged")
+	})
+
+	t.Run("Dir outside root", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/context?token=secret&dir=../", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Files outside root", func(t *testing.T) {
+		for _, f := range []string{"../x.go", "sub/../../x.go", "/etc/passwd"} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/context?token=secret&ext=md&files="+f, nil))
+			assert.Equal(t, http.StatusBadRequest, rec.Code, f)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/context?token=secret&ext=md&files=..notes.go", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "--- ..notes.go\n")
+	})
+
+	t.Run("Over max tokens", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/context?token=secret&max_tokens=1", nil))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Largest contributors")
+	})
+}
+
+func TestContextHandler_Jail(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"src/main.go": "package main\n"})
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "passwd")
+	require.NoError(t, os.WriteFile(secret, []byte("root:x:0:0\n"), 0644))
+	if err := os.Symlink(secret, filepath.Join(tempDir, "src", "pw.go")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	require.NoError(t, os.Symlink(outside, filepath.Join(tempDir, "etc")))
+	testLogger, _ := setupTestLogger(t)
+	slog.SetDefault(testLogger)
+	handler := newContextHandler(tempDir, defaultConfig, "")
+
+	for _, query := range []string{"ext=go&dir=src", "files=src/pw.go"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/context?"+query, nil))
+		require.Equal(t, http.StatusOK, rec.Code, query)
+		assert.NotContains(t, rec.Body.String(), "root:x", query)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/context?dir=etc", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8080": true,
+		"localhost:8080": true,
+		"[::1]:8080":     true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"10.0.0.5:8080":  false,
+		"example.com:80": false,
+		"8080":           false,
+	} {
+		assert.Equal(t, want, isLoopbackAddr(addr), addr)
+	}
+}
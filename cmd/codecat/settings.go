@@ -0,0 +1,92 @@
+// cmd/codecat/settings.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	pflag "github.com/spf13/pflag"
+)
+
+// settingSource names the layer that supplied a setting: a flag when it was
+// given on the command line, otherwise the config file or built-in default.
+func settingSource(flagName, configKey string, appConfig Config) string {
+	if flagName != "" && pflag.CommandLine.Changed(flagName) {
+		return "flag"
+	}
+	return appConfig.source(configKey)
+}
+
+// printSettings writes the fully resolved settings and where each came from,
+// for --show-settings.
+func printSettings(w io.Writer, opts GenerateOptions, appConfig Config, targets []OutputTarget) {
+	fmt.Fprintln(w, "--- Effective Settings ---")
+	if appConfig.sourcePath != "" {
+		fmt.Fprintf(w, "Config file: %s\n", appConfig.sourcePath)
+	} else {
+		fmt.Fprintln(w, "Config file: none (built-in defaults)")
+	}
+	fmt.Fprintf(w, "CWD: %s\n", opts.CWD)
+
+	if opts.NoScan {
+		fmt.Fprintln(w, "Scan directories: none (--no-scan)")
+	} else {
+		dirs := make([]string, 0, len(opts.ScanDirs))
+		for _, dir := range opts.ScanDirs {
+			if rel, err := filepath.Rel(opts.CWD, dir); err == nil {
+				dir = filepath.ToSlash(rel)
+			}
+			dirs = append(dirs, dir)
+		}
+		fmt.Fprintf(w, "Scan directories: %s\n", strings.Join(dirs, ", "))
+	}
+
+	fmt.Fprintf(w, "Extensions [%s]: %s\n",
+		tern(len(extPresetFlag) > 0, "flag", settingSource("extensions", "include_extensions", appConfig)), strings.Join(mapsKeys(opts.Extensions), " "))
+	if len(opts.Languages) > 0 {
+		fmt.Fprintf(w, "Languages [flag]: %s\n", strings.Join(mapsKeys(opts.Languages), " "))
+	}
+	if len(opts.ManualFiles) > 0 {
+		fmt.Fprintf(w, "Manual files [flag]: %s\n", strings.Join(opts.ManualFiles, ", "))
+	}
+
+	fmt.Fprintln(w, "Excludes:")
+	printSettingPatterns(w, "basename", appConfig.source("exclude_basenames"), opts.ExcludeBasenames)
+	printSettingPatterns(w, "cwd-relative", "project", opts.ProjectExcludes)
+	printSettingPatterns(w, "cwd-relative", "flag", opts.FlagExcludes)
+	if len(opts.ProjectIncludes) > 0 {
+		fmt.Fprintln(w, "Forced includes:")
+		printSettingPatterns(w, "cwd-relative", "project", opts.ProjectIncludes)
+	}
+
+	fmt.Fprintf(w, "Gitignore [%s]: %s\n",
+		settingSource("no-gitignore", "use_gitignore", appConfig), tern(opts.UseGitignore, "enabled", "disabled"))
+	fmt.Fprintf(w, "Pattern case [%s]: %s\n",
+		settingSource("ignore-case", "ignore_case", appConfig), tern(foldPathCase, "ignored", "matched"))
+	fmt.Fprintf(w, "Jail [%s]: %s\n",
+		tern(pflag.CommandLine.Changed("jail") || pflag.CommandLine.Changed("allow-outside"), "flag", "default"),
+		tern(opts.Jail == "", "off (--allow-outside)", opts.Jail))
+	fmt.Fprintf(w, "Comment marker [%s]: %q\n", settingSource("", "comment_marker", appConfig), opts.Marker)
+	fmt.Fprintf(w, "Header text [%s]: %q\n", settingSource("", "header_text", appConfig), opts.Header)
+	outputs := make([]string, 0, len(targets))
+	for _, t := range targets {
+		outputs = append(outputs, t.String())
+	}
+	if modelFlag != "" {
+		fmt.Fprintf(w, "Model [flag]: %s (~%.1f bytes/token)\n", modelFlag, bytesPerToken)
+	}
+	fmt.Fprintf(w, "Output [%s]: %s\n", settingSource("output", "outputs", appConfig), strings.Join(outputs, ", "))
+	fmt.Fprintln(w, "--------------------------")
+}
+
+func printSettingPatterns(w io.Writer, kind, source string, patterns []string) {
+	if len(patterns) == 0 {
+		fmt.Fprintf(w, "  (%s, %s): none\n", kind, source)
+		return
+	}
+	for _, p := range patterns {
+		fmt.Fprintf(w, "  %-24s (%s, %s)\n", p, kind, source)
+	}
+}
@@ -0,0 +1,32 @@
+// cmd/codecat/settings_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintSettings(t *testing.T) {
+	opts := GenerateOptions{
+		CWD:              "/work",
+		ScanDirs:         []string{"/work/src"},
+		Extensions:       processExtensions([]string{"go"}),
+		ExcludeBasenames: []string{"*.log"},
+		ProjectExcludes:  []string{"data"},
+		UseGitignore:     true,
+		Marker:           "---",
+	}
+	var out strings.Builder
+	printSettings(&out, opts, defaultConfig, []OutputTarget{{Format: "text", Path: "ctx.txt"}, {Format: "json", Path: stdoutTarget}})
+
+	text := out.String()
+	assert.Contains(t, text, "Scan directories: src\n")
+	assert.Contains(t, text, "Extensions [default]: .go\n")
+	assert.Contains(t, text, "(basename, default)")
+	assert.Contains(t, text, "(cwd-relative, project)")
+	assert.Contains(t, text, "(cwd-relative, flag): none")
+	assert.Contains(t, text, "Gitignore [default]: enabled\n")
+	assert.Contains(t, text, "Output [default]: ctx.txt (text), stdout (json)\n")
+}
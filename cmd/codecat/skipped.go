@@ -0,0 +1,78 @@
+// cmd/codecat/skipped.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// skippedReasons orders the --report-skipped groups; gitignore comes first
+// because ignore files are applied before any other rule.
+var skippedReasons = []string{"gitignore", "basename", "project", "flag", "plugin", "untracked", "nested-repo", "third-party", "tests", "gitattributes", "depth", "minified", "size", "binary", "grep", "seed", "output", "max-files", "outlier", "sample", "limit", "budget"}
+
+// gitignoredFiles returns the files a walk without ignore files (unignored)
+// reached that the real walk (result) never saw: those hidden by .gitignore
+// or .ignore. Both walks must use the same filters.
+func gitignoredFiles(result, unignored GenerateResult) []string {
+	known := make(map[string]bool)
+	for _, f := range result.IncludedFiles {
+		known[f.Path] = true
+	}
+	for _, paths := range [][]string{result.EmptyFiles, mapsKeys(result.ErrorFiles), mapsKeys(result.SpecialFiles)} {
+		for _, p := range paths {
+			known[p] = true
+		}
+	}
+	for _, paths := range result.Skipped {
+		for _, p := range paths {
+			known[p] = true
+		}
+	}
+
+	var ignored []string
+	add := func(p string) {
+		if !known[p] {
+			known[p] = true
+			ignored = append(ignored, p)
+		}
+	}
+	for _, f := range unignored.IncludedFiles {
+		add(f.Path)
+	}
+	for _, p := range unignored.EmptyFiles {
+		add(p)
+	}
+	for _, paths := range unignored.Skipped {
+		for _, p := range paths {
+			add(p)
+		}
+	}
+	sort.Strings(ignored)
+	return ignored
+}
+
+// printSkippedReport lists, grouped by reason, every excluded file that
+// matched the extension filters, for --report-skipped.
+func printSkippedReport(w io.Writer, skipped map[string][]string) {
+	total := 0
+	for _, paths := range skipped {
+		total += len(paths)
+	}
+	fmt.Fprintf(w, "\n--- Skipped files (%s) ---\n", formatCount(total))
+	for _, reason := range skippedReasons {
+		if len(skipped[reason]) == 0 {
+			continue
+		}
+		paths := make([]string, len(skipped[reason]))
+		for i, p := range skipped[reason] {
+			paths[i] = displayPath(p)
+		}
+		sort.Strings(paths)
+		fmt.Fprintf(w, "%s (%s):\n", reason, formatCount(len(paths)))
+		for _, p := range paths {
+			fmt.Fprintf(w, "- %s\n", p)
+		}
+	}
+	fmt.Fprintln(w, "---------------")
+}
@@ -0,0 +1,73 @@
+// cmd/codecat/skipped_test.go
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_ReportSkipped(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		".gitignore":      "*.tmp.go\n",
+		"main.go":         "package main\n",
+		"scratch.tmp.go":  "package main\n",
+		"gen/types.go":    "package gen\n",
+		"docs/guide.md":   "# guide\n",
+		"testdata/in.go":  "package testdata\n",
+		"testdata/in.txt": "not a go file\n",
+		"big.go":          "package main\n\n// " + strings.Repeat("x", 100) + "\n",
+		"blob.go":         "package main\x00\n",
+	})
+	opts := GenerateOptions{
+		CWD:              tempDir,
+		ScanDirs:         []string{tempDir},
+		Extensions:       processExtensions([]string{"go"}),
+		ExcludeBasenames: []string{"testdata"},
+		FlagExcludes:     []string{"gen", "docs"},
+		UseGitignore:     true,
+		MaxFileSize:      64,
+		SkipBinary:       true,
+		SkipContent:      true,
+		ReportSkipped:    true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"testdata/in.go"}, result.Skipped["basename"], "files failing the extension filter are not reported")
+	assert.Equal(t, []string{"gen/types.go"}, result.Skipped["flag"])
+	assert.Equal(t, []string{"big.go"}, result.Skipped["size"])
+	assert.Equal(t, []string{"blob.go"}, result.Skipped["binary"])
+
+	opts.UseGitignore = false
+	unignored, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"scratch.tmp.go"}, gitignoredFiles(result, unignored))
+}
+
+func TestPrintSkippedReport(t *testing.T) {
+	var buf bytes.Buffer
+	printSkippedReport(&buf, map[string][]string{
+		"flag":      {"gen/b.go", "gen/a.go"},
+		"gitignore": {"scratch.go"},
+	})
+	assert.Equal(t, `
+--- Skipped files (3) ---
+gitignore (1):
+- scratch.go
+flag (2):
+- gen/a.go
+- gen/b.go
+---------------
+`, buf.String())
+
+	buf.Reset()
+	printSkippedReport(&buf, map[string][]string{"binary": {"img.go"}, "size": {"dump.sql"}})
+	assert.Equal(t, "\n--- Skipped files (2) ---\nsize (1):\n- dump.sql\nbinary (1):\n- img.go\n---------------\n", buf.String())
+
+	buf.Reset()
+	printSkippedReport(&buf, map[string][]string{"plugin": {"a.go"}, "outlier": {"b.js"}, "sample": {"m/2.sql"}, "limit": {"v/c.go"}})
+	assert.Equal(t, "\n--- Skipped files (4) ---\nplugin (1):\n- a.go\noutlier (1):\n- b.js\nsample (1):\n- m/2.sql\nlimit (1):\n- v/c.go\n---------------\n", buf.String())
+}
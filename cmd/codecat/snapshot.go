@@ -0,0 +1,262 @@
+// cmd/codecat/snapshot.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pflag "github.com/spf13/pflag"
+)
+
+// stateDir holds codecat's per-project state (snapshots, the embeddings
+// cache), relative to the CWD. Scans skip it.
+const stateDir = ".codecat"
+
+// snapshotsDir holds saved snapshots, relative to the CWD.
+const snapshotsDir = stateDir + "/snapshots"
+
+// Files of one snapshot directory.
+const (
+	snapshotContextFile  = "context.json"
+	snapshotManifestFile = "manifest.json"
+)
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "snapshot",
+		Summary: "Save the context under .codecat/snapshots; list, diff or restore saved snapshots.",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.BoolVar(&diffUnified, "unified", false,
+				"[snapshot diff] Print a unified diff of each changed file.")
+		},
+		Run: runSnapshot,
+	})
+}
+
+func runSnapshot(cwd string, appConfig Config, args []string) int {
+	action := "save"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+	dir := filepath.Join(cwd, filepath.FromSlash(snapshotsDir))
+	var err error
+	switch action {
+	case "save":
+		err = saveSnapshot(cwd, dir, appConfig, args)
+	case "list":
+		err = listSnapshots(os.Stdout, dir)
+	case "diff":
+		err = diffSnapshots(os.Stdout, dir, args, diffUnified)
+	case "restore":
+		if len(args) != 1 {
+			err = errors.New("usage: codecat snapshot restore [-o target] <id>")
+		} else {
+			err = restoreSnapshot(dir, args[0], appConfig)
+		}
+	default:
+		err = fmt.Errorf("unknown snapshot action %q (want save, list, diff or restore)", action)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// saveSnapshot generates the context with the given flags and stores it.
+func saveSnapshot(cwd, dir string, appConfig Config, args []string) error {
+	opts, err := resolveGenerateOptions(cwd, appConfig, args)
+	if err != nil {
+		return err
+	}
+	opts.Checksums, opts.Stamp = true, true
+	result, err := generate(opts)
+	if err != nil {
+		return fmt.Errorf("generating context, no snapshot saved: %w", err)
+	}
+	id, err := writeSnapshot(dir, result, opts, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved snapshot %s (%d files, %s, ~%d tokens)\n",
+		id, len(result.IncludedFiles), formatBytes(result.TotalSize), estimateTokens(int64(len(result.Output))))
+	return nil
+}
+
+// writeSnapshot stores result (as JSON) and its manifest in a new directory
+// named after now, returning the snapshot ID.
+func writeSnapshot(dir string, result GenerateResult, opts GenerateOptions, now time.Time) (string, error) {
+	base := now.UTC().Format("20060102-150405")
+	id := base
+	for n := 2; ; n++ {
+		err := os.MkdirAll(dir, 0755)
+		if err == nil {
+			err = os.Mkdir(filepath.Join(dir, id), 0755)
+		}
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	path := filepath.Join(dir, id)
+	slog.Info("Writing snapshot.", "id", id, "path", path)
+	if err := writeOutput(OutputTarget{Format: "json", Path: filepath.Join(path, snapshotContextFile)}, result, opts); err != nil {
+		return "", err
+	}
+	if err := writeManifest(filepath.Join(path, snapshotManifestFile), result, now); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// snapshotIDs lists the saved snapshots, oldest first.
+func snapshotIDs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(dir, e.Name(), snapshotContextFile)); e.IsDir() && err == nil {
+			ids = append(ids, e.Name())
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// resolveSnapshot finds a snapshot by ID, unique ID prefix or "latest".
+func resolveSnapshot(dir, ref string) (string, error) {
+	ids, err := snapshotIDs(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no snapshots in %s", snapshotsDir)
+	}
+	if ref == "latest" {
+		return ids[len(ids)-1], nil
+	}
+	var matches []string
+	for _, id := range ids {
+		if id == ref {
+			return id, nil
+		}
+		if strings.HasPrefix(id, ref) {
+			matches = append(matches, id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no snapshot %q (see 'codecat snapshot list')", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("snapshot %q is ambiguous: %s", ref, strings.Join(matches, ", "))
+	}
+}
+
+// listSnapshots prints each snapshot with its file count and output size.
+func listSnapshots(w io.Writer, dir string) error {
+	ids, err := snapshotIDs(dir)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fmt.Fprintf(w, "No snapshots in %s.\n", snapshotsDir)
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tFiles\tSize\tGenerated")
+	for _, id := range ids {
+		var manifest Manifest
+		files, generated := "?", "?"
+		if data, err := os.ReadFile(filepath.Join(dir, id, snapshotManifestFile)); err == nil && json.Unmarshal(data, &manifest) == nil {
+			files, generated = fmt.Sprint(len(manifest.Files)), manifest.Generated
+		}
+		size := "?"
+		if info, err := os.Stat(filepath.Join(dir, id, snapshotContextFile)); err == nil {
+			size = formatBytes(info.Size())
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", id, files, size, generated)
+	}
+	return tw.Flush()
+}
+
+// diffSnapshots compares two snapshots: the given two, the given one against
+// the latest, or without arguments the previous against the latest.
+func diffSnapshots(w io.Writer, dir string, refs []string, unified bool) error {
+	if len(refs) > 2 {
+		return errors.New("usage: codecat snapshot diff [--unified] [<old> [<new>]]")
+	}
+	ids, err := snapshotIDs(dir)
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		if len(ids) < 2 {
+			return fmt.Errorf("need two snapshots to diff, have %d", len(ids))
+		}
+		refs = ids[len(ids)-2:]
+	}
+	if len(refs) == 1 {
+		refs = append(refs, "latest")
+	}
+	var names [2]string
+	var contexts [2]map[string]string
+	for i, ref := range refs {
+		if names[i], err = resolveSnapshot(dir, ref); err != nil {
+			return err
+		}
+		if contexts[i], err = readContextFile(filepath.Join(dir, names[i], snapshotContextFile), "", ""); err != nil {
+			return fmt.Errorf("snapshot %s: %w", names[i], err)
+		}
+	}
+	return printContextDiff(w, names[0], names[1], contexts[0], contexts[1], unified)
+}
+
+// restoreSnapshot writes a saved context to the -o targets (stdout by
+// default) in their formats, as it was when the snapshot was taken.
+func restoreSnapshot(dir, ref string, appConfig Config) error {
+	id, err := resolveSnapshot(dir, ref)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id, snapshotContextFile))
+	if err != nil {
+		return err
+	}
+	var saved jsonOutput
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("snapshot %s: %w", id, err)
+	}
+	result := GenerateResult{Documents: saved.Files, Stamp: saved.Stamp, EmptyFiles: saved.EmptyFiles, TotalSize: saved.TotalSize}
+	opts := GenerateOptions{Header: saved.Header, Marker: *appConfig.CommentMarker}
+	targets, err := resolveOutputTargets(appConfig)
+	if err != nil {
+		return err
+	}
+	for _, target := range targets {
+		slog.Info("Restoring snapshot.", "id", id, "target", target.String())
+		if err := writeOutput(target, result, opts); err != nil {
+			return fmt.Errorf("writing %s: %w", target, err)
+		}
+	}
+	return nil
+}
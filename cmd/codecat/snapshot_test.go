@@ -0,0 +1,63 @@
+// cmd/codecat/snapshot_test.go
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshots(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), filepath.FromSlash(snapshotsDir))
+	opts := GenerateOptions{Marker: "---"}
+	now := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	first := GenerateResult{
+		Documents:     []Document{{Path: "a.go", Content: "package a\n"}},
+		IncludedFiles: []FileInfo{{Path: "a.go", Size: 10, Source: &FileSource{Size: 10, SHA256: "aa"}}},
+	}
+	id1, err := writeSnapshot(dir, first, opts, now)
+	require.NoError(t, err)
+	assert.Equal(t, "20240301-093000", id1)
+
+	second := GenerateResult{Documents: []Document{{Path: "a.go", Content: "package a\n\nfunc A() {}\n"}, {Path: "b.go", Content: "package b\n"}}}
+	id2, err := writeSnapshot(dir, second, opts, now)
+	require.NoError(t, err)
+	assert.Equal(t, "20240301-093000-2", id2, "same-second snapshots get a suffix")
+	assert.FileExists(t, filepath.Join(dir, id2, snapshotManifestFile))
+
+	latest, err := resolveSnapshot(dir, "latest")
+	require.NoError(t, err)
+	assert.Equal(t, id2, latest)
+	_, err = resolveSnapshot(dir, "2024")
+	assert.ErrorContains(t, err, "ambiguous")
+	_, err = resolveSnapshot(dir, "1999")
+	assert.ErrorContains(t, err, "no snapshot")
+
+	var list bytes.Buffer
+	require.NoError(t, listSnapshots(&list, dir))
+	assert.Contains(t, list.String(), "20240301-093000    1")
+
+	var diff bytes.Buffer
+	require.NoError(t, diffSnapshots(&diff, dir, nil, false))
+	assert.Contains(t, diff.String(), "--- Diff: 20240301-093000 -> 20240301-093000-2 ---")
+	assert.Contains(t, diff.String(), "  + b.go (1 lines)")
+	assert.Contains(t, diff.String(), "  ~ a.go (+2 -0)")
+}
+
+func TestSnapshotIDs_Missing(t *testing.T) {
+	ids, err := snapshotIDs(filepath.Join(t.TempDir(), "none"))
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "broken"), 0755))
+	ids, err = snapshotIDs(dir)
+	require.NoError(t, err)
+	assert.Empty(t, ids, "directories without a context are ignored")
+}
@@ -0,0 +1,120 @@
+// cmd/codecat/spool.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// contentSpool keeps document content in memory up to a limit and writes
+// the rest to a temporary file, created on first use. A nil *contentSpool
+// keeps everything in memory.
+type contentSpool struct {
+	limit    int64
+	inMemory int64
+	file     *os.File
+	size     int64
+}
+
+// spooledContent locates a document's content in the spool file.
+type spooledContent struct {
+	spool  *contentSpool
+	offset int64
+	length int64
+}
+
+// newContentSpool returns nil when limit is 0 (unlimited).
+func newContentSpool(limit int64) *contentSpool {
+	if limit <= 0 {
+		return nil
+	}
+	return &contentSpool{limit: limit}
+}
+
+// keep returns doc with its content moved to the spool file once the
+// in-memory total would pass the limit. A spool that cannot be written
+// logs the error and keeps the content in memory.
+func (s *contentSpool) keep(doc Document) Document {
+	if s == nil || doc.spooled != nil {
+		return doc
+	}
+	length := int64(len(doc.Content))
+	if s.inMemory+length <= s.limit {
+		s.inMemory += length
+		return doc
+	}
+	if s.file == nil {
+		f, err := os.CreateTemp("", "codecat-spool-*")
+		if err != nil {
+			slog.Error("Cannot create the content spool file; keeping content in memory.", "error", err)
+			s.limit = 1<<63 - 1
+			return doc
+		}
+		slog.Info("Memory limit reached, spooling file content to disk.", "limit", formatBytes(s.limit), "path", f.Name())
+		s.file = f
+	}
+	if _, err := s.file.WriteAt([]byte(doc.Content), s.size); err != nil {
+		slog.Error("Cannot write to the content spool file; keeping content in memory.", "path", doc.Path, "error", err)
+		return doc
+	}
+	doc.spooled = &spooledContent{spool: s, offset: s.size, length: length}
+	doc.Content = ""
+	s.size += length
+	return doc
+}
+
+// spilled reports whether any content went to disk.
+func (s *contentSpool) spilled() bool {
+	return s != nil && s.file != nil
+}
+
+// Close removes the spool file.
+func (s *contentSpool) Close() error {
+	if !s.spilled() {
+		return nil
+	}
+	name := s.file.Name()
+	s.file.Close()
+	return os.Remove(name)
+}
+
+func (c *spooledContent) read() (string, error) {
+	buf := make([]byte, c.length)
+	if _, err := c.spool.file.ReadAt(buf, c.offset); err != nil && err != io.EOF {
+		return "", fmt.Errorf("reading spooled content: %w", err)
+	}
+	return string(buf), nil
+}
+
+// text returns the document's content, reading it back from the spool file
+// when it was spooled.
+func (d Document) text() string {
+	if d.spooled == nil {
+		return d.Content
+	}
+	content, err := d.spooled.read()
+	if err != nil {
+		slog.Error("Lost spooled file content.", "path", d.Path, "error", err)
+	}
+	return content
+}
+
+// size is the length of the document's content, without reading it back.
+func (d Document) size() int {
+	if d.spooled != nil {
+		return int(d.spooled.length)
+	}
+	return len(d.Content)
+}
+
+// countingWriter discards what is written to it, keeping the byte count.
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,76 @@
+// cmd/codecat/spool_test.go
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentSpool(t *testing.T) {
+	assert.Nil(t, newContentSpool(0), "0 is unlimited")
+	var unlimited *contentSpool
+	assert.Equal(t, "abc", unlimited.keep(Document{Content: "abc"}).Content)
+
+	s := newContentSpool(4)
+	first := s.keep(Document{Path: "a", Content: "abc"})
+	assert.Equal(t, "abc", first.Content, "within the limit stays in memory")
+	assert.False(t, s.spilled())
+
+	second := s.keep(Document{Path: "b", Content: "defgh"})
+	third := s.keep(Document{Path: "c", Content: "ij"})
+	require.True(t, s.spilled())
+	assert.Empty(t, second.Content)
+	assert.Equal(t, "defgh", second.text())
+	assert.Equal(t, 5, second.size())
+	assert.Equal(t, "ij", third.text())
+
+	data, err := json.Marshal(second)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"content":"defgh"`, "JSON reads spooled content back")
+
+	name := s.file.Name()
+	require.NoError(t, s.Close())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err), "Close removes the spool file")
+}
+
+func TestGenerate_MemoryLimit(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"a.go":     "package a\n",
+		"b.go":     "package b\n" + strings.Repeat("// filler\n", 20),
+		"sub/c.go": "package c\n",
+	})
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+	}
+	inMemory, err := generate(opts)
+	require.NoError(t, err)
+	defer inMemory.Close()
+	assert.False(t, inMemory.spilled())
+	assert.Equal(t, int64(len(inMemory.Output)), inMemory.OutputSize)
+
+	opts.MemoryLimit = 16
+	spooled, err := generate(opts)
+	require.NoError(t, err)
+	defer spooled.Close()
+	require.True(t, spooled.spilled())
+	assert.Empty(t, spooled.Output, "Output is not built once content is spooled")
+	assert.Equal(t, inMemory.OutputSize, spooled.OutputSize)
+
+	path := filepath.Join(t.TempDir(), "ctx.txt")
+	target, err := parseOutputTarget(path, "")
+	require.NoError(t, err)
+	require.NoError(t, writeOutput(target, spooled, opts))
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, inMemory.Output, string(written), "streamed output matches the in-memory one")
+}
@@ -0,0 +1,98 @@
+// cmd/codecat/stamp.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Stamp identifies the snapshot an output was generated from, for --stamp.
+// SHA256 covers the path and content of every document, so two outputs of the
+// same snapshot carry the same hash regardless of format or timestamp.
+type Stamp struct {
+	Version     string   `json:"version"`
+	Generated   string   `json:"generated"`
+	ScanRoots   []string `json:"scan_roots"`
+	ManualFiles []string `json:"manual_files,omitempty"`
+	Extensions  []string `json:"extensions"`
+	Excludes    []string `json:"excludes"`
+	Gitignore   bool     `json:"gitignore"`
+	Files       int      `json:"files"`
+	Partial     bool     `json:"partial,omitempty"`
+	MaxFiles    int      `json:"max_files,omitempty"` // set when the scan stopped at --max-files
+	SHA256      string   `json:"sha256"`
+}
+
+// newStamp describes the run in opts that produced docs.
+func newStamp(opts GenerateOptions, docs []Document, now time.Time) *Stamp {
+	roots := []string{}
+	if !opts.NoScan {
+		for _, dir := range opts.ScanDirs {
+			if rel, err := filepath.Rel(opts.CWD, dir); err == nil {
+				dir = filepath.ToSlash(rel)
+			}
+			roots = append(roots, dir)
+		}
+	}
+	excludes := append([]string{}, opts.ExcludeBasenames...)
+	excludes = append(excludes, opts.ProjectExcludes...)
+	excludes = append(excludes, opts.FlagExcludes...)
+	return &Stamp{
+		Version:     Version,
+		Generated:   now.UTC().Format(time.RFC3339),
+		ScanRoots:   roots,
+		ManualFiles: opts.ManualFiles,
+		Extensions:  mapsKeys(opts.Extensions),
+		Excludes:    excludes,
+		Gitignore:   opts.UseGitignore,
+		Files:       len(docs),
+		SHA256:      documentsDigest(docs),
+	}
+}
+
+// documentsDigest hashes each document's path and content, NUL-separated.
+func documentsDigest(docs []Document) string {
+	h := sha256.New()
+	for _, doc := range docs {
+		h.Write([]byte(doc.Path))
+		h.Write([]byte{0})
+		h.Write([]byte(doc.text()))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Lines renders the stamp as "key: value" lines for the text formats.
+func (s *Stamp) Lines() []string {
+	lines := []string{
+		fmt.Sprintf("codecat %s, generated %s", s.Version, s.Generated),
+		"scan roots: " + joinOrNone(s.ScanRoots, ", "),
+	}
+	if len(s.ManualFiles) > 0 {
+		lines = append(lines, "manual files: "+strings.Join(s.ManualFiles, ", "))
+	}
+	files := fmt.Sprintf("files: %d", s.Files)
+	if s.Partial {
+		files += " (partial: scan stopped early)"
+	} else if s.MaxFiles > 0 {
+		files += fmt.Sprintf(" (scan stopped at --max-files %d)", s.MaxFiles)
+	}
+	return append(lines,
+		"extensions: "+joinOrNone(s.Extensions, " "),
+		"excludes: "+joinOrNone(s.Excludes, ", "),
+		"gitignore: "+tern(s.Gitignore, "enabled", "disabled"),
+		files,
+		"sha256: "+s.SHA256,
+	)
+}
+
+func joinOrNone(items []string, sep string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, sep)
+}
@@ -0,0 +1,63 @@
+// cmd/codecat/stamp_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentsDigest(t *testing.T) {
+	a := []Document{{Path: "a.go", Content: "x"}, {Path: "b.go", Content: "y"}}
+	assert.Equal(t, documentsDigest(a), documentsDigest([]Document{{Path: "a.go", Content: "x", Meta: []string{"git: abc"}}, {Path: "b.go", Content: "y"}}),
+		"metadata lines are not part of the snapshot")
+	assert.NotEqual(t, documentsDigest(a), documentsDigest([]Document{{Path: "a.gox", Content: ""}, {Path: "b.go", Content: "y"}}))
+	assert.Len(t, documentsDigest(nil), 64)
+}
+
+func TestNewStamp(t *testing.T) {
+	opts := GenerateOptions{
+		CWD:              "/work",
+		ScanDirs:         []string{"/work/src"},
+		Extensions:       processExtensions([]string{"go,py"}),
+		ExcludeBasenames: []string{"*.log"},
+		FlagExcludes:     []string{"data"},
+		UseGitignore:     true,
+	}
+	docs := []Document{{Path: "src/a.go", Content: "package a\n"}}
+	stamp := newStamp(opts, docs, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, []string{
+		"codecat " + Version + ", generated 2025-03-01T12:00:00Z",
+		"scan roots: src",
+		"extensions: .go .py",
+		"excludes: *.log, data",
+		"gitignore: enabled",
+		"files: 1",
+		"sha256: " + documentsDigest(docs),
+	}, stamp.Lines())
+}
+
+func TestGenerate_Stamp(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"a.go": "package a\n"})
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Header:     "HEAD\n",
+		Marker:     "---",
+		Stamp:      true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	require.NotNil(t, result.Stamp)
+	assert.True(t, strings.HasPrefix(result.Output, "HEAD\n# codecat "), result.Output)
+	assert.Contains(t, result.Output, "# sha256: "+result.Stamp.SHA256+"\n--- a.go\n")
+
+	again, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, result.Stamp.SHA256, again.Stamp.SHA256)
+}
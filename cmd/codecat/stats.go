@@ -0,0 +1,121 @@
+// cmd/codecat/stats.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+const statsLargestFiles = 10
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "stats",
+		Summary: "Report per-language counts, sizes, token estimates and exclusions without output.",
+		Run:     runStats,
+	})
+}
+
+func runStats(cwd string, appConfig Config, args []string) int {
+	opts, err := resolveGenerateOptions(cwd, appConfig, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	opts.SkipContent = true
+
+	result, genErr := generate(opts)
+	exitCode := 0
+	if genErr != nil {
+		slog.Error("Error(s) reported during stats scan.", "error", genErr)
+		exitCode = 1
+	}
+
+	// Gitignored files never reach the exclusion checks, so count them as the
+	// difference to a walk without ignore files.
+	gitignored := -1
+	if opts.UseGitignore && !opts.NoScan {
+		unignored := opts
+		unignored.UseGitignore = false
+		unignored.ManualFiles = nil
+		if res, err := generate(unignored); err == nil {
+			gitignored = res.FilesSeen - result.FilesSeen
+		}
+	}
+
+	printStats(os.Stdout, result, gitignored)
+	return exitCode
+}
+
+type languageStats struct {
+	Language string
+	Files    int
+	Size     int64
+}
+
+// printStats writes the stats report. gitignored < 0 means it was not measured.
+func printStats(w io.Writer, result GenerateResult, gitignored int) {
+	fmt.Fprintln(w, "--- Stats ---")
+	fmt.Fprintf(w, "Included %d files, %s, ~%d tokens\n",
+		len(result.IncludedFiles), formatBytes(result.TotalSize), estimateTokens(result.TotalSize))
+	fmt.Fprintf(w, "Empty files: %d, errors: %d\n", len(result.EmptyFiles), len(result.ErrorFiles))
+
+	byLang := map[string]*languageStats{}
+	for _, f := range result.IncludedFiles {
+		lang := f.Language
+		if lang == "" {
+			lang = languageForPath(f.Path)
+		}
+		if byLang[lang] == nil {
+			byLang[lang] = &languageStats{Language: lang}
+		}
+		byLang[lang].Files++
+		byLang[lang].Size += f.Size
+	}
+	langs := make([]*languageStats, 0, len(byLang))
+	for _, ls := range byLang {
+		langs = append(langs, ls)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if langs[i].Size != langs[j].Size {
+			return langs[i].Size > langs[j].Size
+		}
+		return langs[i].Language < langs[j].Language
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nBy language:")
+	fmt.Fprintln(tw, "  Language\tFiles\tSize\t~Tokens")
+	for _, ls := range langs {
+		fmt.Fprintf(tw, "  %s\t%d\t%s\t%d\n", ls.Language, ls.Files, formatBytes(ls.Size), estimateTokens(ls.Size))
+	}
+
+	largest := append([]FileInfo(nil), result.IncludedFiles...)
+	sort.Slice(largest, func(i, j int) bool {
+		if largest[i].Size != largest[j].Size {
+			return largest[i].Size > largest[j].Size
+		}
+		return largest[i].Path < largest[j].Path
+	})
+	if len(largest) > statsLargestFiles {
+		largest = largest[:statsLargestFiles]
+	}
+	fmt.Fprintln(tw, "\nLargest files:")
+	for _, f := range largest {
+		fmt.Fprintf(tw, "  %s\t%s\t~%d tokens\n", f.Path, formatBytes(f.Size), estimateTokens(f.Size))
+	}
+
+	fmt.Fprintln(tw, "\nExcluded files by source:")
+	if gitignored >= 0 {
+		fmt.Fprintf(tw, "  gitignore\t%d\n", gitignored)
+	}
+	for _, source := range []string{"basename", "project", "flag", "extension", "untracked", "nested-repo", "third-party", "tests", "gitattributes", "depth", "minified", "size", "binary", "grep", "seed", "plugin", "output"} {
+		fmt.Fprintf(tw, "  %s\t%d\n", source, result.ExcludedBy[source])
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "-------------")
+}
@@ -0,0 +1,58 @@
+// cmd/codecat/stats_test.go
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_SkipContentCountsExclusions(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":        "package main",
+		"util.go":        "package main // util",
+		"notes.txt":      "skip by extension",
+		"debug.log":      "skip by basename",
+		"data/big.go":    "package data",
+		"secret/key.go":  "package secret",
+		"empty/empty.go": "",
+	})
+	testLogger, _ := setupTestLogger(t)
+	slog.SetDefault(testLogger)
+
+	result, err := generate(GenerateOptions{
+		CWD:              tempDir,
+		ScanDirs:         []string{tempDir},
+		Extensions:       processExtensions([]string{"go"}),
+		ExcludeBasenames: []string{"*.log"},
+		ProjectExcludes:  []string{"data"},
+		FlagExcludes:     []string{"secret"},
+		SkipContent:      true,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, result.Output)
+	assert.Equal(t, []string{"main.go", "util.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.Equal(t, []string{"empty/empty.go"}, result.EmptyFiles)
+	assert.Equal(t, 7, result.FilesSeen)
+	assert.Equal(t, map[string]int{"basename": 1, "project": 1, "flag": 1, "extension": 1}, result.ExcludedBy)
+}
+
+func TestPrintStats(t *testing.T) {
+	result := GenerateResult{
+		IncludedFiles: []FileInfo{{Path: "a.go", Size: 400}, {Path: "b.go", Size: 100}, {Path: "README.md", Size: 40}},
+		TotalSize:     540,
+		ExcludedBy:    map[string]int{"flag": 2},
+	}
+	var out strings.Builder
+	printStats(&out, result, 3)
+	text := out.String()
+
+	assert.Contains(t, text, "Included 3 files, 540 B, ~135 tokens")
+	assert.Regexp(t, `Go\s+2\s+500 B\s+125`, text)
+	assert.Less(t, strings.Index(text, "a.go"), strings.Index(text, "b.go"), "largest files come first")
+	assert.Regexp(t, `gitignore\s+3`, text)
+	assert.Regexp(t, `flag\s+2`, text)
+}
@@ -13,9 +13,11 @@ import (
 
 // FileInfo - IsManual field is used
 type FileInfo struct {
-	Path     string
-	Size     int64
-	IsManual bool // Field is relevant again
+	Path     string      `json:"path"`
+	Size     int64       `json:"size"`
+	IsManual bool        `json:"is_manual"` // Field is relevant again
+	Language string      `json:"language,omitempty"`
+	Source   *FileSource `json:"source,omitempty"` // on-disk state, recorded for --manifest
 }
 
 // TreeNode remains the same
@@ -78,16 +80,19 @@ func printTreeRecursive(writer io.Writer, node *TreeNode, indent string, isLast
 	fileInfoStr := ""
 	manualMarker := "" // Initialize as empty
 
+	name := node.Name
 	if node.FileInfo != nil {
-		fileInfoStr = fmt.Sprintf(" (%s)", formatBytes(node.FileInfo.Size))
+		fileInfoStr = fmt.Sprintf(" (%s)", summaryPalette.size(formatBytes(node.FileInfo.Size)))
 		// Check IsManual AND if the default logger is enabled for DEBUG level
 		if node.FileInfo.IsManual && slog.Default().Enabled(context.Background(), slog.LevelDebug) {
-			manualMarker = " [M]" // Add marker only if DEBUG is active
+			manualMarker = " " + summaryPalette.manual("[M]") // Add marker only if DEBUG is active
 		}
+	} else {
+		name = summaryPalette.dir(name)
 	}
 
 	// Use the potentially updated manualMarker
-	fmt.Fprintf(writer, "%s%s%s%s%s\n", indent, connector, node.Name, manualMarker, fileInfoStr)
+	fmt.Fprintf(writer, "%s%s%s%s%s\n", indent, connector, name, manualMarker, fileInfoStr)
 
 	childIndent := indent + tern(isLast, "    ", "│   ")
 	childNames := make([]string, 0, len(node.Children))
@@ -107,24 +112,28 @@ func printSummaryListSection[K comparable, V any](
 	items map[K]V,
 	getPath func(K) string,
 	getDetails func(K, V) string,
+	style func(string) string, // colors the entries, or nil
 ) {
-	fmt.Fprintf(writer, titleFormat, len(items))
+	if style == nil {
+		style = func(s string) string { return s }
+	}
+	fmt.Fprint(writer, summaryPalette.title(fmt.Sprintf(titleFormat, formatCount(len(items)))))
 	if len(items) > 0 {
 		keys := make([]K, 0, len(items))
 		for k := range items {
 			keys = append(keys, k)
 		}
-		sort.Slice(keys, func(i, j int) bool { return getPath(keys[i]) < getPath(keys[j]) })
+		sort.Slice(keys, func(i, j int) bool { return displayPath(getPath(keys[i])) < displayPath(getPath(keys[j])) })
 		for _, k := range keys {
-			pathStr := getPath(k)
+			pathStr := displayPath(getPath(k))
 			detailsStr := ""
 			if getDetails != nil {
 				detailsStr = getDetails(k, items[k])
 			}
 			if detailsStr != "" {
-				fmt.Fprintf(writer, "- %s: %s\n", pathStr, detailsStr)
+				fmt.Fprintf(writer, "- %s: %s\n", style(pathStr), style(detailsStr))
 			} else {
-				fmt.Fprintf(writer, "- %s\n", pathStr)
+				fmt.Fprintf(writer, "- %s\n", style(pathStr))
 			}
 		}
 	}
@@ -135,19 +144,23 @@ func printSummaryTree(
 	includedFiles []FileInfo,
 	emptyFiles []string,
 	errorFiles map[string]error,
+	specialFiles map[string]string,
+	excludeRules []ExclusionRule,
 	totalSize int64,
-	cwd string,
+	pathBase string, // how paths are shown, e.g. "relative to CWD 'project'"
 	outputWriter io.Writer,
 ) {
-	fmt.Fprintln(outputWriter, "\n--- Summary ---")
+	fmt.Fprintln(outputWriter, "\n"+summaryPalette.title("--- Summary ---"))
 
 	if len(includedFiles) > 0 {
-		base := filepath.Base(cwd)
-		cwdDisplay := tern(base != "." && base != string(filepath.Separator),
-			fmt.Sprintf("'%s'", base), fmt.Sprintf("'%s'", cwd))
-		fmt.Fprintf(outputWriter, "Included %d files (%s total) relative to CWD %s:\n",
-			len(includedFiles), formatBytes(totalSize), cwdDisplay)
-		fileTree := buildTree(includedFiles)
+		fmt.Fprintf(outputWriter, "Included %s files (%s total) %s:\n",
+			formatCount(len(includedFiles)), summaryPalette.size(formatBytes(totalSize)), pathBase)
+		shown := make([]FileInfo, len(includedFiles))
+		for i, f := range includedFiles {
+			shown[i] = f
+			shown[i].Path = displayPath(f.Path)
+		}
+		fileTree := buildTree(shown)
 		printTreeRecursive(outputWriter, fileTree, "", true) // Calls modified func
 	} else {
 		fmt.Fprintln(outputWriter, "No files included in the output.")
@@ -157,12 +170,51 @@ func printSummaryTree(
 	for _, path := range emptyFiles {
 		emptyFilesMap[path] = struct{}{}
 	}
-	printSummaryListSection(outputWriter, "\nEmpty files found (%d):\n",
-		emptyFilesMap, func(path string) string { return path }, nil)
+	printSummaryListSection(outputWriter, "\nEmpty files found (%s):\n",
+		emptyFilesMap, func(path string) string { return path }, nil, summaryPalette.warn)
+
+	if len(specialFiles) > 0 {
+		printSummaryListSection(outputWriter, "\nSpecial files skipped (%s):\n",
+			specialFiles, func(path string) string { return path },
+			func(path string, kind string) string { return kind }, summaryPalette.warn)
+	}
 
-	printSummaryListSection(outputWriter, "\nErrors encountered (%d):\n",
+	printSummaryListSection(outputWriter, "\nErrors encountered (%s):\n",
 		errorFiles, func(path string) string { return path },
-		func(path string, err error) string { return err.Error() })
+		func(path string, err error) string { return err.Error() }, summaryPalette.err)
+
+	printRuleEffectiveness(outputWriter, excludeRules)
 
 	fmt.Fprintln(outputWriter, "---------------")
 }
+
+// printRuleEffectiveness writes the exclude rules with the number of files each
+// removed, so dead rules (0 hits) and overly broad ones stand out. Unused
+// exclude_basenames rules are left out: the defaults are generic and most
+// never match, which would bury the project's own rules.
+func printRuleEffectiveness(w io.Writer, rules []ExclusionRule) {
+	var shown []ExclusionRule
+	for _, r := range rules {
+		if r.Hits > 0 || r.Source != "basename" {
+			shown = append(shown, r)
+		}
+	}
+	if len(shown) == 0 {
+		return
+	}
+	fmt.Fprint(w, "\n"+summaryPalette.title(fmt.Sprintf("Exclude rule effectiveness (%s rules):", formatCount(len(shown))))+"\n")
+	rows := [][]string{{"  Pattern", "Source", "Hits"}}
+	for _, r := range shown {
+		rows = append(rows, []string{"  " + displayPath(r.Pattern), r.Source, formatCount(r.Hits) + tern(r.Hits == 0, " "+summaryPalette.warn("(unused)"), "")})
+	}
+	writeColumns(w, rows)
+}
+
+// describePathBase phrases the directory paths are shown relative to for the
+// summary header: "relative to CWD 'project'".
+func describePathBase(kind, dir string) string {
+	base := filepath.Base(dir)
+	display := tern(base != "." && base != string(filepath.Separator),
+		fmt.Sprintf("'%s'", base), fmt.Sprintf("'%s'", dir))
+	return fmt.Sprintf("relative to %s %s", kind, display)
+}
@@ -1,10 +1,13 @@
 // cmd/codecat/summary_test.go
 package main
 
-// Add other necessary imports for testing summary later, e.g.,
-// "bytes"
-// "github.com/stretchr/testify/assert"
-// "github.com/stretchr/testify/require"
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 // TODO: Add tests for buildTree function
 // func TestBuildTree_Simple(t *testing.T) { ... }
@@ -17,3 +20,39 @@ package main
 // func TestPrintSummaryTree_Basic(t *testing.T) { ... }
 // func TestPrintSummaryTree_WithErrors(t *testing.T) { ... }
 // func TestPrintSummaryTree_NoFiles(t *testing.T) { ... }
+
+func TestPrintRuleEffectiveness(t *testing.T) {
+	var buf bytes.Buffer
+	printRuleEffectiveness(&buf, nil)
+	assert.Empty(t, buf.String())
+
+	printRuleEffectiveness(&buf, []ExclusionRule{
+		{Pattern: "build", Source: "project", Hits: 12},
+		{Pattern: "node_modules", Source: "basename", Hits: 3},
+		{Pattern: "*.bak", Source: "flag", Hits: 0},
+		{Pattern: "*.tmp", Source: "basename", Hits: 0},
+	})
+	out := buf.String()
+	assert.Contains(t, out, "Exclude rule effectiveness (3 rules):")
+	assert.Regexp(t, `build\s+project\s+12\n`, out)
+	assert.Regexp(t, `node_modules\s+basename\s+3\n`, out)
+	assert.Regexp(t, `\*\.bak\s+flag\s+0 \(unused\)\n`, out)
+	assert.NotContains(t, out, "*.tmp", "unused basename rules are left out")
+
+	buf.Reset()
+	printRuleEffectiveness(&buf, []ExclusionRule{{Pattern: "*.tmp", Source: "basename"}})
+	assert.Empty(t, buf.String(), "no table when only unused basename rules remain")
+}
+
+func TestPrintSummaryTree_NormalizesNames(t *testing.T) {
+	normalizeDisplayNames = true
+	defer func() { normalizeDisplayNames = runtime.GOOS == "darwin" }()
+	var buf bytes.Buffer
+	files := []FileInfo{{Path: "docs/cafe\u0301s.md", Size: 2}, {Path: "docs/caf\u00e9.md", Size: 1500}}
+	printSummaryTree(files, []string{"re\u0301sume\u0301.txt"}, nil, nil, nil, 1502, "relative to CWD 'x'", &buf)
+	out := buf.String()
+	assert.Contains(t, out, "├── caf\u00e9.md (1.5 KiB)\n", "decomposed and composed names sort together")
+	assert.Contains(t, out, "└── caf\u00e9s.md (2 B)\n")
+	assert.Contains(t, out, "- r\u00e9sum\u00e9.txt\n")
+	assert.Equal(t, "docs/cafe\u0301s.md", files[0].Path, "the caller's paths are left alone")
+}
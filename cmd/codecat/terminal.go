@@ -0,0 +1,168 @@
+// cmd/codecat/terminal.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// terminalWarnBytes is the output size above which codecat asks before
+// printing to a terminal.
+const terminalWarnBytes = 1 << 20
+
+// summaryModes are the accepted --summary values.
+var summaryModes = []string{"auto", "always", "never"}
+
+// errOutputDeclined reports that the user chose not to print a large output.
+var errOutputDeclined = errors.New("output not printed")
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// showSummary decides whether the summary is printed. In "auto" mode it is
+// left out when the output goes to stdout and stdout is piped, so the
+// consumer's terminal only shows what it asked for.
+func showSummary(mode string, targets []OutputTarget, stdoutIsTerminal bool) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	for _, t := range targets {
+		if t.Path == stdoutTarget {
+			return stdoutIsTerminal
+		}
+	}
+	return true
+}
+
+// openSummaryOutput opens where --summary-output sends the summary and the
+// reports after it: "stdout", "stderr" or a file, created afresh. An empty
+// dest keeps the summary with the logs. stdout is refused while it carries
+// the output, which the summary would otherwise be mixed into.
+func openSummaryOutput(dest string, targets []OutputTarget, logOutput *os.File) (*os.File, error) {
+	switch dest {
+	case "":
+		return logOutput, nil
+	case "stderr":
+		return os.Stderr, nil
+	case "stdout":
+		for _, t := range targets {
+			if t.Path == stdoutTarget {
+				return nil, errors.New("--summary-output stdout: stdout already carries the output")
+			}
+		}
+		return os.Stdout, nil
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return nil, fmt.Errorf("--summary-output: %w", err)
+	}
+	return f, nil
+}
+
+// summaryFilePath is the file --summary-output writes, or "" for a stream.
+func summaryFilePath(dest string) string {
+	if dest == "stdout" || dest == "stderr" {
+		return ""
+	}
+	return dest
+}
+
+// writeToStdout prints data, paging it through $PAGER when stdout is a
+// terminal and --pager is set, and asking first when it is larger than
+// terminalWarnBytes.
+func writeToStdout(data []byte) error {
+	if !isTerminal(os.Stdout) {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	page := pagerFlag
+	if !page && !sizeConfirmed && len(data) > terminalWarnBytes {
+		choice, err := askTerminalDump(os.Stdin, os.Stderr, len(data), isTerminal(os.Stdin))
+		if err != nil {
+			return err
+		}
+		page = choice == "p"
+	}
+	if page {
+		return runPager(data)
+	}
+	_, err := os.Stdout.Write(data)
+	return err
+}
+
+// askTerminalDump asks whether to print size bytes into the terminal and
+// returns "y" or "p" (page), or errOutputDeclined. Without an interactive
+// stdin it only warns and returns "y".
+func askTerminalDump(in io.Reader, w io.Writer, size int, interactive bool) (string, error) {
+	what := fmt.Sprintf("%s (~%d tokens)", formatBytes(int64(size)), estimateTokens(int64(size)))
+	if !interactive {
+		fmt.Fprintf(w, "Warning: printing %s to the terminal; use -o or a pipe to avoid this.\n", what)
+		return "y", nil
+	}
+	fmt.Fprintf(w, "About to print %s to the terminal. Print [y], page [p] or cancel [N]? ", what)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return "y", nil
+	case "p", "page":
+		return "p", nil
+	}
+	return "", fmt.Errorf("%w: cancelled at the size prompt", errOutputDeclined)
+}
+
+// runPager pipes data through $PAGER, falling back to less and then to
+// printing directly when no pager is available.
+func runPager(data []byte) error {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+	}
+	bin, err := exec.LookPath(pager[0])
+	if err != nil {
+		slog.Warn("Pager not found, printing directly.", "pager", pager[0])
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	cmd := exec.Command(bin, pager[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(data), os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// sizeConfirmed is set once --confirm-over was answered, so printing to a
+// terminal does not ask a second time.
+var sizeConfirmed bool
+
+// confirmOutputSize asks before writing an output of size bytes, larger than
+// limit, to targets. Without an interactive stdin it fails instead, so
+// scripts never produce a huge context by accident.
+func confirmOutputSize(in io.Reader, w io.Writer, size, limit int64, targets []OutputTarget, interactive bool) error {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.String()
+	}
+	fmt.Fprintf(w, "Output is %s (~%d tokens), over the --confirm-over limit of %s.\n",
+		formatBytes(size), estimateTokens(size), formatBytes(limit))
+	if !interactive {
+		return fmt.Errorf("%w: over the --confirm-over limit and stdin is not interactive", errOutputDeclined)
+	}
+	fmt.Fprintf(w, "Write it to %s? [y/N] ", strings.Join(names, ", "))
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		return fmt.Errorf("%w: cancelled at the --confirm-over prompt", errOutputDeclined)
+	}
+	sizeConfirmed = true
+	return nil
+}
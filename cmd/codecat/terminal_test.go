@@ -0,0 +1,90 @@
+// cmd/codecat/terminal_test.go
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowSummary(t *testing.T) {
+	stdout := []OutputTarget{{Format: "text", Path: stdoutTarget}}
+	file := []OutputTarget{{Format: "text", Path: "ctx.txt"}}
+
+	assert.False(t, showSummary("auto", stdout, false), "piped output stays clean")
+	assert.True(t, showSummary("auto", stdout, true))
+	assert.True(t, showSummary("auto", file, false))
+	assert.True(t, showSummary("always", stdout, false))
+	assert.False(t, showSummary("never", file, true))
+}
+
+func TestOpenSummaryOutput(t *testing.T) {
+	stdout := []OutputTarget{{Format: "text", Path: stdoutTarget}}
+	file := []OutputTarget{{Format: "text", Path: "ctx.txt"}}
+
+	f, err := openSummaryOutput("", file, os.Stdout)
+	require.NoError(t, err)
+	assert.Equal(t, os.Stdout, f, "with the logs by default")
+	f, err = openSummaryOutput("stderr", file, os.Stdout)
+	require.NoError(t, err)
+	assert.Equal(t, os.Stderr, f)
+	f, err = openSummaryOutput("stdout", file, os.Stderr)
+	require.NoError(t, err)
+	assert.Equal(t, os.Stdout, f)
+	_, err = openSummaryOutput("stdout", stdout, os.Stderr)
+	assert.ErrorContains(t, err, "stdout already carries the output")
+
+	path := filepath.Join(t.TempDir(), "summary.txt")
+	f, err = openSummaryOutput(path, stdout, os.Stderr)
+	require.NoError(t, err)
+	_, err = f.WriteString("--- Summary ---\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.FileExists(t, path)
+	assert.Equal(t, path, summaryFilePath(path))
+	assert.Empty(t, summaryFilePath("stderr"))
+}
+
+func TestAskTerminalDump(t *testing.T) {
+	var w bytes.Buffer
+	choice, err := askTerminalDump(strings.NewReader("p\n"), &w, 5<<20, true)
+	require.NoError(t, err)
+	assert.Equal(t, "p", choice)
+	assert.Contains(t, w.String(), "About to print 5 MiB (~1310720 tokens)")
+
+	choice, err = askTerminalDump(strings.NewReader("yes\n"), &w, 5<<20, true)
+	require.NoError(t, err)
+	assert.Equal(t, "y", choice)
+
+	_, err = askTerminalDump(strings.NewReader("\n"), &w, 5<<20, true)
+	assert.ErrorIs(t, err, errOutputDeclined, "the default answer cancels")
+
+	w.Reset()
+	choice, err = askTerminalDump(strings.NewReader(""), &w, 5<<20, false)
+	require.NoError(t, err)
+	assert.Equal(t, "y", choice)
+	assert.Contains(t, w.String(), "Warning: printing")
+}
+
+func TestConfirmOutputSize(t *testing.T) {
+	t.Cleanup(func() { sizeConfirmed = false })
+	targets := []OutputTarget{{Format: "text", Path: "ctx.txt"}}
+	var w bytes.Buffer
+
+	err := confirmOutputSize(strings.NewReader(""), &w, 60<<20, 50<<20, targets, false)
+	assert.ErrorIs(t, err, errOutputDeclined, "non-interactive runs fail")
+	assert.Contains(t, w.String(), "Output is 60 MiB (~15728640 tokens), over the --confirm-over limit of 50 MiB.")
+
+	err = confirmOutputSize(strings.NewReader("n\n"), &w, 60<<20, 50<<20, targets, true)
+	assert.ErrorIs(t, err, errOutputDeclined)
+	assert.False(t, sizeConfirmed)
+
+	require.NoError(t, confirmOutputSize(strings.NewReader("y\n"), &w, 60<<20, 50<<20, targets, true))
+	assert.Contains(t, w.String(), "Write it to ctx.txt (text)? [y/N] ")
+	assert.True(t, sizeConfirmed, "the terminal prompt is not repeated")
+}
@@ -0,0 +1,38 @@
+// cmd/codecat/testfiles.go
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// testFilePatterns are basename globs for test files by language convention.
+var testFilePatterns = []string{
+	"*_test.go",
+	"test_*.py", "*_test.py",
+	"*.test.js", "*.test.jsx", "*.test.ts", "*.test.tsx", "*.test.mjs",
+	"*.spec.js", "*.spec.jsx", "*.spec.ts", "*.spec.tsx", "*.spec.mjs",
+	"*_spec.rb", "*_test.rb",
+	"*Test.java", "*Tests.java", "*Test.kt", "*Tests.cs",
+	"*_test.exs", "*_test.dart",
+}
+
+// testDirNames are directories whose whole contents count as tests or fixtures.
+var testDirNames = []string{"__tests__", "__mocks__", "__snapshots__", "__fixtures__", "test", "tests", "spec", "testdata", "fixtures"}
+
+// isTestPath reports whether the CWD-relative, slash-separated relPath is a
+// test file or lies below a test or fixture directory.
+func isTestPath(relPath string) bool {
+	parts := strings.Split(relPath, "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if contains(testDirNames, dir) {
+			return true
+		}
+	}
+	for _, pattern := range testFilePatterns {
+		if match, _ := path.Match(pattern, parts[len(parts)-1]); match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+// cmd/codecat/testfiles_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTestPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"walk_test.go", true},
+		{"walk.go", false},
+		{"pkg/test_utils.py", true},
+		{"pkg/utils_test.py", true},
+		{"pkg/testing.py", false},
+		{"src/app.spec.ts", true},
+		{"src/app.test.jsx", true},
+		{"src/__tests__/app.js", true},
+		{"tests/integration/run.sh", true},
+		{"internal/testdata/input.json", true},
+		{"src/main/java/FooTest.java", true},
+		{"src/contest/entry.go", false},
+		{"latest.md", false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, isTestPath(tc.path), tc.path)
+	}
+}
+
+func TestGenerate_TestsPolicy(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":             "package main\n",
+		"main_test.go":        "package main\n",
+		"testdata/golden.txt": "golden\n",
+	})
+	opts := GenerateOptions{
+		CWD:         tempDir,
+		ScanDirs:    []string{tempDir},
+		Extensions:  processExtensions([]string{"go", "txt"}),
+		SkipContent: true,
+	}
+
+	opts.Tests = "exclude"
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.Equal(t, 2, result.ExcludedBy["tests"])
+
+	opts.Tests = "only"
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"main_test.go", "testdata/golden.txt"}, getPathsFromIncludedFiles(result.IncludedFiles))
+}
@@ -0,0 +1,199 @@
+// cmd/codecat/testrules.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+func init() {
+	registerSubcommand(&Subcommand{
+		Name:    "test-rules",
+		Summary: "Check sample paths (file or stdin) against the include/exclude rules.",
+		Run:     runTestRules,
+	})
+}
+
+func runTestRules(cwd string, appConfig Config, args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Error: usage: codecat test-rules [flags] [paths-file|-]")
+		return 1
+	}
+	in := io.Reader(os.Stdin)
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	opts, err := resolveGenerateOptions(cwd, appConfig, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	samples, err := readRuleSamples(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: reading paths: %v\n", err)
+		return 1
+	}
+	checker := newRuleChecker(opts)
+	failed := printRuleVerdicts(os.Stdout, checker, samples)
+	return tern(failed > 0, 1, 0)
+}
+
+// ruleSample is one line of test-rules input: a CWD-relative path, with an
+// optional "+ " (must be included) or "- " (must be excluded) prefix.
+type ruleSample struct {
+	Path   string
+	Expect string // "include", "exclude" or "" for none
+}
+
+// readRuleSamples reads one sample per line, skipping blank lines and
+// "#" comments.
+func readRuleSamples(r io.Reader) ([]ruleSample, error) {
+	var samples []ruleSample
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sample := ruleSample{Path: line}
+		if rest, ok := strings.CutPrefix(line, "+ "); ok {
+			sample = ruleSample{Path: strings.TrimSpace(rest), Expect: "include"}
+		} else if rest, ok := strings.CutPrefix(line, "- "); ok {
+			sample = ruleSample{Path: strings.TrimSpace(rest), Expect: "exclude"}
+		}
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+// ruleVerdict is what the rules decide for one path.
+type ruleVerdict struct {
+	Path     string
+	Included bool
+	Source   string // the deciding rule's source, as in --report-skipped, or "forced" for .codecat_include
+	Rule     string // the deciding pattern, plugin or attribute; "" when no rule matched
+}
+
+// ruleChecker applies a run's path rules to paths that need not exist, so
+// rule files can be tested against sample paths. What it cannot know
+// without scanning is left out: .gitignore, --git-tracked, --max-depth,
+// nested repositories, third-party directories and --grep.
+type ruleChecker struct {
+	opts       GenerateOptions
+	excluder   *DefaultExcluder
+	attributes *gitattributes
+}
+
+func newRuleChecker(opts GenerateOptions) *ruleChecker {
+	return &ruleChecker{
+		opts:       opts,
+		excluder:   NewDefaultExcluder(opts.ExcludeBasenames, append(append([]string(nil), opts.ProjectExcludes...), opts.FlagExcludes...)),
+		attributes: newGitattributes(opts.CWD, opts.Gitattributes),
+	}
+}
+
+// check decides relPath in the order the walk does. A trailing "/" marks a
+// directory, which only the exclude rules apply to.
+func (c *ruleChecker) check(relPath string) ruleVerdict {
+	isDir := strings.HasSuffix(relPath, "/")
+	if filepath.IsAbs(relPath) {
+		if rel, err := filepath.Rel(c.opts.CWD, relPath); err == nil {
+			relPath = rel
+		}
+	}
+	relPath = path.Clean(filepath.ToSlash(relPath))
+	verdict := ruleVerdict{Path: relPath + tern(isDir, "/", "")}
+	info := PathInfo{AbsPath: filepath.Join(c.opts.CWD, filepath.FromSlash(relPath)), RelPathCwd: relPath, BaseName: path.Base(relPath), IsDir: isDir}
+
+	flagPatterns := c.opts.FlagExcludes
+	forced := ""
+	if !isDir {
+		forced = forcingPattern(c.opts.ProjectIncludes, info)
+	}
+	excluded, reason, pattern := c.excluder.IsExcluded(info)
+	if !excluded {
+		if byPlugin, name := pluginExcluded(info); byPlugin {
+			excluded, reason, pattern = true, "plugin", name
+		}
+	}
+	if pattern != "" {
+		verdict.Source, verdict.Rule = exclusionSource(reason, pattern, flagPatterns), pattern
+	}
+	if excluded && forced != "" && verdict.Source != "flag" {
+		excluded = false
+	}
+	if excluded || isDir {
+		verdict.Included = !excluded
+		return verdict
+	}
+	if forced != "" {
+		verdict.Source, verdict.Rule = "forced", forced
+	}
+
+	if (c.opts.Tests == "exclude" || c.opts.Tests == "only") && isTestPath(relPath) != (c.opts.Tests == "only") {
+		verdict.Source, verdict.Rule = "tests", "--tests="+c.opts.Tests
+		return verdict
+	}
+	if attr, ok := c.attributes.excludedBy(relPath); ok && forced == "" {
+		verdict.Source, verdict.Rule = "gitattributes", attr
+		return verdict
+	}
+	if _, ok := passesFilters(&c.opts, relPath, info.AbsPath); !ok && forced == "" {
+		verdict.Source, verdict.Rule = "extension", ""
+		return verdict
+	}
+	if c.opts.NoMinifiedAssets && forced == "" && isMinifiedName(relPath) {
+		verdict.Source, verdict.Rule = "minified", "--no-minified-assets"
+		return verdict
+	}
+	verdict.Included = true
+	return verdict
+}
+
+// printRuleVerdicts writes one line per sample and returns how many missed
+// their expectation; those lines start with FAIL.
+func printRuleVerdicts(w io.Writer, checker *ruleChecker, samples []ruleSample) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	failed, expected := 0, 0
+	for _, s := range samples {
+		if s.Expect != "" {
+			expected++
+		}
+	}
+	for _, s := range samples {
+		v := checker.check(s.Path)
+		got := tern(v.Included, "include", "exclude")
+		if expected > 0 {
+			status := "ok"
+			if s.Expect == "" {
+				status = "-"
+			} else if s.Expect != got {
+				failed++
+				status = "FAIL"
+			}
+			fmt.Fprintf(tw, "%s\t", status)
+		}
+		rule := v.Source
+		if v.Rule != "" {
+			rule += ": " + v.Rule
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", got, v.Path, rule)
+	}
+	tw.Flush()
+	if expected > 0 {
+		fmt.Fprintf(w, "%d of %d expectations failed\n", failed, expected)
+	}
+	return failed
+}
@@ -0,0 +1,76 @@
+// cmd/codecat/testrules_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRuleSamples(t *testing.T) {
+	samples, err := readRuleSamples(strings.NewReader("# rules\n\nmain.go\n+ src/app.go\n-  vendor/x.go\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []ruleSample{
+		{Path: "main.go"},
+		{Path: "src/app.go", Expect: "include"},
+		{Path: "vendor/x.go", Expect: "exclude"},
+	}, samples)
+}
+
+func TestRuleChecker(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{".gitattributes": "gen/** linguist-generated\n"})
+	checker := newRuleChecker(GenerateOptions{
+		CWD:              tempDir,
+		Extensions:       processExtensions([]string{"go", "js"}),
+		ExcludeBasenames: []string{"*.log", "node_modules"},
+		ProjectExcludes:  []string{"vendor", "build/*.go", "!build/keep.go"},
+		FlagExcludes:     []string{"secret"},
+		ProjectIncludes:  []string{"vendor/schema.go", "secret/a.go"},
+		Gitattributes:    []string{"linguist-generated"},
+		Tests:            "exclude",
+		NoMinifiedAssets: true,
+	})
+	for _, tc := range []struct {
+		path   string
+		want   ruleVerdict
+		reason string
+	}{
+		{"main.go", ruleVerdict{Path: "main.go", Included: true}, "no rule"},
+		{"./src/../main.go", ruleVerdict{Path: "main.go", Included: true}, "paths are cleaned"},
+		{"notes.txt", ruleVerdict{Path: "notes.txt", Source: "extension"}, "extension filter"},
+		{"web/node_modules/x/y.go", ruleVerdict{Path: "web/node_modules/x/y.go", Source: "basename", Rule: "node_modules"}, "ancestor basename"},
+		{"vendor/lib.go", ruleVerdict{Path: "vendor/lib.go", Source: "project", Rule: "vendor"}, ".codecat_exclude"},
+		{"vendor/schema.go", ruleVerdict{Path: "vendor/schema.go", Included: true, Source: "forced", Rule: "vendor/schema.go"}, ".codecat_include wins over .codecat_exclude"},
+		{"secret/a.go", ruleVerdict{Path: "secret/a.go", Source: "flag", Rule: "secret"}, "-x wins over .codecat_include"},
+		{"build/keep.go", ruleVerdict{Path: "build/keep.go", Included: true, Source: "project", Rule: "!build/keep.go"}, "negation"},
+		{"pkg/a_test.go", ruleVerdict{Path: "pkg/a_test.go", Source: "tests", Rule: "--tests=exclude"}, "test filter"},
+		{"gen/api.go", ruleVerdict{Path: "gen/api.go", Source: "gitattributes", Rule: "linguist-generated"}, "gitattributes"},
+		{"static/jq.min.js", ruleVerdict{Path: "static/jq.min.js", Source: "minified", Rule: "--no-minified-assets"}, "minified asset name"},
+		{"vendor/", ruleVerdict{Path: "vendor/", Source: "project", Rule: "vendor"}, "directory"},
+		{"src/", ruleVerdict{Path: "src/", Included: true}, "directories skip the file filters"},
+	} {
+		assert.Equal(t, tc.want, checker.check(tc.path), tc.reason)
+	}
+}
+
+func TestPrintRuleVerdicts(t *testing.T) {
+	checker := newRuleChecker(GenerateOptions{CWD: t.TempDir(), Extensions: processExtensions([]string{"go"}), ProjectExcludes: []string{"vendor"}})
+	var out strings.Builder
+	failed := printRuleVerdicts(&out, checker, []ruleSample{{Path: "main.go"}})
+	assert.Zero(t, failed)
+	assert.Equal(t, "include  main.go  \n", out.String(), "no status column without expectations")
+
+	out.Reset()
+	failed = printRuleVerdicts(&out, checker, []ruleSample{
+		{Path: "main.go", Expect: "include"},
+		{Path: "vendor/x.go", Expect: "include"},
+		{Path: "README.md"},
+	})
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, "ok    include  main.go      \n"+
+		"FAIL  exclude  vendor/x.go  project: vendor\n"+
+		"-     exclude  README.md    extension\n"+
+		"1 of 2 expectations failed\n", out.String())
+}
@@ -0,0 +1,81 @@
+// cmd/codecat/textwidth.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// normalizeDisplayNames is set on macOS, whose file systems hand back names
+// decomposed (NFD) while names typed or copied elsewhere are usually
+// composed (NFC): the summary shows and sorts them all composed. Elsewhere
+// the two forms are different files and are shown as they are.
+var normalizeDisplayNames = runtime.GOOS == "darwin"
+
+// displayPath is p as the summary shows it.
+func displayPath(p string) string {
+	if normalizeDisplayNames {
+		return toNFC(p)
+	}
+	return p
+}
+
+// toNFC composes the decomposed characters in s, reordering combining
+// marks first as Unicode normalization requires.
+func toNFC(s string) string {
+	return norm.NFC.String(s)
+}
+
+// displayWidth is how many terminal columns s takes: marks take none and
+// East Asian wide characters two. tabwriter counts runes instead, which
+// misaligns such names.
+func displayWidth(s string) int {
+	cols := 0
+	for _, r := range s {
+		switch {
+		case r < 0x20 || (r >= 0x7F && r < 0xA0) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		case isWide(r):
+			cols += 2
+		default:
+			cols++
+		}
+	}
+	return cols
+}
+
+// isWide reports whether r is East Asian wide or fullwidth.
+func isWide(r rune) bool {
+	kind := width.LookupRune(r).Kind()
+	return kind == width.EastAsianWide || kind == width.EastAsianFullwidth
+}
+
+// writeColumns writes rows as a table, padding each cell but the last to
+// the widest in its column plus two spaces, like a tabwriter with a padding
+// of 2, but measured with displayWidth.
+func writeColumns(w io.Writer, rows [][]string) {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row[:max(len(row)-1, 0)] {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+	for _, row := range rows {
+		var b strings.Builder
+		for i, cell := range row {
+			b.WriteString(cell)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)+2))
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
+}
@@ -0,0 +1,34 @@
+// cmd/codecat/textwidth_test.go
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToNFC(t *testing.T) {
+	assert.Equal(t, "plain/ascii.go", toNFC("plain/ascii.go"))
+	assert.Equal(t, "caf\u00e9/r\u00e9sum\u00e9.md", toNFC("cafe\u0301/re\u0301sume\u0301.md"), "macOS decomposed names")
+	assert.Equal(t, "caf\u00e9", toNFC("caf\u00e9"), "already composed")
+	assert.Equal(t, "\u1ec7", toNFC("e\u0323\u0302"), "two marks in canonical order")
+	assert.Equal(t, "\u1ec7", toNFC("e\u0302\u0323"), "marks out of canonical order are reordered")
+	assert.Equal(t, "\u1eb9\u0301", toNFC("e\u0301\u0323"), "the lower-class mark is reordered and composed first")
+	assert.Equal(t, "\u00e1\u0301", toNFC("a\u0301\u0301"), "a second acute has no composite")
+	assert.Equal(t, "\ud55c\uae00", toNFC("\u1112\u1161\u11ab\u1100\u1173\u11af"), "Hangul jamo")
+}
+
+func TestDisplayWidth(t *testing.T) {
+	assert.Equal(t, 6, displayWidth("app.go"))
+	assert.Equal(t, 4, displayWidth("cafe\u0301"), "combining marks take no column")
+	assert.Equal(t, 9, displayWidth("日本語.go"), "wide characters take two")
+	assert.Equal(t, 2, displayWidth("\u200bok"), "format characters take none")
+	assert.Equal(t, 4, displayWidth("ＡＢ"), "fullwidth forms take two")
+}
+
+func TestWriteColumns(t *testing.T) {
+	var buf bytes.Buffer
+	writeColumns(&buf, [][]string{{"  Pattern", "Source", "Hits"}, {"  日本", "project", "3"}, {"  cafe\u0301", "flag", "0"}})
+	assert.Equal(t, "  Pattern  Source   Hits\n  日本     project  3\n  cafe\u0301     flag     0\n", buf.String())
+}
@@ -0,0 +1,136 @@
+// cmd/codecat/thirdparty.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// thirdPartyPolicies are the accepted --third-party values.
+var thirdPartyPolicies = []string{"include", "exclude", "summarize"}
+
+// thirdPartyDirs are directory names holding vendored or installed dependencies.
+var thirdPartyDirs = []string{"vendor", "node_modules", "third_party", "third-party", "bower_components"}
+
+// thirdPartyRoot returns the CWD-relative root of the outermost third-party
+// tree containing relPath, relPath itself for a go.sum file, or "" otherwise.
+func thirdPartyRoot(relPath string) string {
+	parts := strings.Split(relPath, "/")
+	for i, part := range parts[:len(parts)-1] {
+		if contains(thirdPartyDirs, part) {
+			return strings.Join(parts[:i+1], "/")
+		}
+	}
+	if parts[len(parts)-1] == "go.sum" {
+		return relPath
+	}
+	return ""
+}
+
+// thirdPartyPackage counts the files of one package in a third-party tree.
+type thirdPartyPackage struct {
+	files int
+	size  int64
+}
+
+// thirdPartyCollector gathers third-party files for --third-party=summarize
+// and turns them into one summary document per root.
+type thirdPartyCollector struct {
+	trees map[string]map[string]*thirdPartyPackage // root -> package -> counts
+	sums  map[string]string                        // go.sum path -> dependency list
+}
+
+func newThirdPartyCollector() *thirdPartyCollector {
+	return &thirdPartyCollector{
+		trees: make(map[string]map[string]*thirdPartyPackage),
+		sums:  make(map[string]string),
+	}
+}
+
+// add records a file below root; go.sum files are read for their module list.
+func (c *thirdPartyCollector) add(root, relPath, absPath string, size int64) error {
+	if root == relPath {
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			return err
+		}
+		c.sums[relPath] = goSumDependencies(string(content))
+		return nil
+	}
+	if c.trees[root] == nil {
+		c.trees[root] = make(map[string]*thirdPartyPackage)
+	}
+	name := thirdPartyPackageName(strings.TrimPrefix(relPath, root+"/"))
+	if c.trees[root][name] == nil {
+		c.trees[root][name] = &thirdPartyPackage{}
+	}
+	c.trees[root][name].files++
+	c.trees[root][name].size += size
+	return nil
+}
+
+// thirdPartyPackageName groups a path below a third-party root by package:
+// "@scope/name" for npm scopes, host/owner/repo for Go-style import paths and
+// the first directory otherwise.
+func thirdPartyPackageName(rest string) string {
+	parts := strings.Split(rest, "/")
+	if len(parts) == 1 {
+		return "(top level)"
+	}
+	n := 1
+	if strings.HasPrefix(parts[0], "@") {
+		n = 2
+	} else if strings.Contains(parts[0], ".") {
+		n = 3
+	}
+	return strings.Join(parts[:min(n, len(parts)-1)], "/")
+}
+
+// goSumDependencies lists the "module version" pairs of a go.sum, once each.
+func goSumDependencies(content string) string {
+	seen := map[string]bool{}
+	var deps []string
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		dep := fields[0] + " " + strings.TrimSuffix(fields[1], "/go.mod")
+		if !seen[dep] {
+			seen[dep] = true
+			deps = append(deps, dep)
+		}
+	}
+	sort.Strings(deps)
+	return strings.Join(deps, "\n") + tern(len(deps) > 0, "\n", "")
+}
+
+// documents renders the summaries, ordered by path.
+func (c *thirdPartyCollector) documents() []Document {
+	var docs []Document
+	for _, path := range mapsKeys(c.sums) {
+		deps := c.sums[path]
+		docs = append(docs, Document{
+			Path:    path,
+			Content: deps,
+			Meta:    []string{fmt.Sprintf("third-party summary: %d modules", strings.Count(deps, "\n"))},
+		})
+	}
+	for _, root := range mapsKeys(c.trees) {
+		var listing strings.Builder
+		files := 0
+		for _, name := range mapsKeys(c.trees[root]) {
+			pkg := c.trees[root][name]
+			files += pkg.files
+			listing.WriteString(fmt.Sprintf("%s (%d files, %s)\n", name, pkg.files, formatBytes(pkg.size)))
+		}
+		docs = append(docs, Document{
+			Path:    root + "/",
+			Content: listing.String(),
+			Meta:    []string{fmt.Sprintf("third-party summary: %d packages, %d files", len(c.trees[root]), files)},
+		})
+	}
+	return docs
+}
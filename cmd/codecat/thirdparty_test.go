@@ -0,0 +1,64 @@
+// cmd/codecat/thirdparty_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThirdPartyRoot(t *testing.T) {
+	assert.Equal(t, "vendor", thirdPartyRoot("vendor/github.com/a/b/x.go"))
+	assert.Equal(t, "web/node_modules", thirdPartyRoot("web/node_modules/left-pad/index.js"))
+	assert.Equal(t, "go.sum", thirdPartyRoot("go.sum"))
+	assert.Equal(t, "tools/go.sum", thirdPartyRoot("tools/go.sum"))
+	assert.Empty(t, thirdPartyRoot("cmd/vendor.go"))
+	assert.Empty(t, thirdPartyRoot("vendor"), "a file named like the directory is not third-party")
+}
+
+func TestThirdPartyPackageName(t *testing.T) {
+	assert.Equal(t, "github.com/a/b", thirdPartyPackageName("github.com/a/b/sub/x.go"))
+	assert.Equal(t, "@types/node", thirdPartyPackageName("@types/node/index.d.ts"))
+	assert.Equal(t, "left-pad", thirdPartyPackageName("left-pad/lib/index.js"))
+	assert.Equal(t, "(top level)", thirdPartyPackageName("modules.txt"))
+}
+
+func TestGoSumDependencies(t *testing.T) {
+	sum := "github.com/a/b v1.2.0 h1:abc=\ngithub.com/a/b v1.2.0/go.mod h1:def=\ngolang.org/x/y v0.1.0/go.mod h1:x=\n"
+	assert.Equal(t, "github.com/a/b v1.2.0\ngolang.org/x/y v0.1.0\n", goSumDependencies(sum))
+}
+
+func TestGenerate_ThirdParty(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":                        "package main\n",
+		"go.sum":                         "github.com/a/b v1.2.0 h1:abc=\n",
+		"vendor/github.com/a/b/b.go":     "package b\n",
+		"vendor/github.com/a/b/c/c.go":   "package c\n",
+		"vendor/modules.txt":             "# github.com/a/b v1.2.0\n",
+		"third_party/proto/any.proto":    "syntax = \"proto3\";\n",
+		"internal/third_party_helper.go": "package internal\n",
+	})
+	opts := GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go", "sum"}),
+		Marker:     "---",
+	}
+
+	opts.ThirdParty = "exclude"
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"internal/third_party_helper.go", "main.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.Equal(t, 5, result.ExcludedBy["third-party"])
+
+	opts.ThirdParty = "summarize"
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go.sum", "internal/third_party_helper.go", "main.go", "third_party/", "vendor/"},
+		getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.Contains(t, result.Output, "--- go.sum\n--- third-party summary: 1 modules\ngithub.com/a/b v1.2.0\n---\n")
+	assert.Contains(t, result.Output, "--- vendor/\n--- third-party summary: 2 packages, 3 files\n"+
+		"(top level) (1 files, 24 B)\ngithub.com/a/b (2 files, 20 B)\n---\n")
+	assert.NotContains(t, result.Output, "package b")
+}
@@ -0,0 +1,170 @@
+// cmd/codecat/transform.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// contentTransform rewrites a file's content before it is concatenated.
+type contentTransform struct {
+	name  string
+	apply func(relPath string, content []byte) ([]byte, error)
+}
+
+// contentPipeline runs the enabled transforms in order for every included file.
+// A nil pipeline passes content through unchanged.
+type contentPipeline struct {
+	transforms []contentTransform
+}
+
+// newContentPipeline assembles the transforms enabled in opts.
+func newContentPipeline(opts GenerateOptions) *contentPipeline {
+	p := &contentPipeline{}
+	if !opts.NoAdapters {
+		p.transforms = append(p.transforms, contentTransform{
+			name:  "adapter",
+			apply: func(relPath string, content []byte) ([]byte, error) { return adaptContent(relPath, content, opts) },
+		})
+	}
+	if opts.Hooks != nil && len(opts.Hooks.hooks.Transform) > 0 {
+		p.transforms = append(p.transforms, contentTransform{name: "hook", apply: opts.Hooks.transform})
+	}
+	for _, t := range pluginTransforms {
+		p.transforms = append(p.transforms, contentTransform{name: "plugin " + t.Name(), apply: t.Transform})
+	}
+	if len(opts.ContentPolicies) > 0 {
+		p.transforms = append(p.transforms, contentTransform{
+			name: "content-policy",
+			apply: func(relPath string, content []byte) ([]byte, error) {
+				return applyContentPolicy(opts.ContentPolicies, relPath, content), nil
+			},
+		})
+	}
+	if opts.Obfuscator != nil {
+		p.transforms = append(p.transforms, contentTransform{
+			name: "obfuscate-identifiers",
+			apply: func(relPath string, content []byte) ([]byte, error) {
+				return opts.Obfuscator.obfuscate(relPath, content), nil
+			},
+		})
+	}
+	if opts.Minify {
+		p.transforms = append(p.transforms, contentTransform{
+			name:  "minify",
+			apply: func(relPath string, content []byte) ([]byte, error) { return minifyContent(content, opts.Dedent), nil },
+		})
+	}
+	if opts.TruncateLines > 0 {
+		p.transforms = append(p.transforms, contentTransform{
+			name: "truncate",
+			apply: func(relPath string, content []byte) ([]byte, error) {
+				return truncateMiddle(content, opts.TruncateLines), nil
+			},
+		})
+	}
+	if opts.ScrubPII {
+		scrubber, err := newPIIScrubber(opts.ScrubAllow)
+		p.transforms = append(p.transforms, contentTransform{
+			name: "scrub-pii",
+			apply: func(relPath string, content []byte) ([]byte, error) {
+				if err != nil {
+					return nil, err // never pass content through unscrubbed
+				}
+				return scrubber.scrub(relPath, content), nil
+			},
+		})
+	}
+	return p
+}
+
+// process applies each transform in turn; the first error aborts the file.
+func (p *contentPipeline) process(relPath string, content []byte) ([]byte, error) {
+	if p == nil {
+		return content, nil
+	}
+	for _, t := range p.transforms {
+		out, err := t.apply(relPath, content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.name, err)
+		}
+		if len(out) != len(content) {
+			slog.Debug("Content transformed.", "path", relPath, "transform", t.name, "before", len(content), "after", len(out))
+		}
+		content = out
+	}
+	return content, nil
+}
+
+// minifyContent trims trailing whitespace, collapses runs of blank lines into
+// one and drops leading/trailing blank lines. With dedent, the indentation
+// common to all non-blank lines is removed as well.
+func minifyContent(content []byte, dedent bool) []byte {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+
+	if dedent {
+		common := ""
+		first := true
+		for _, line := range lines {
+			if line == "" {
+				continue
+			}
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			if first {
+				common, first = indent, false
+				continue
+			}
+			for !strings.HasPrefix(indent, common) {
+				common = common[:len(common)-1]
+			}
+		}
+		if common != "" {
+			for i, line := range lines {
+				lines[i] = strings.TrimPrefix(line, common)
+			}
+		}
+	}
+
+	var out strings.Builder
+	blank := true // suppresses leading blank lines
+	for _, line := range lines {
+		if line == "" {
+			blank = true
+			continue
+		}
+		if blank && out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		blank = false
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+	return []byte(out.String())
+}
+
+// truncateMiddle keeps the first and last lines of content, maxLines in
+// total, and replaces the rest with one line saying how many were omitted.
+// Content with at most maxLines lines is returned unchanged.
+func truncateMiddle(content []byte, maxLines int) []byte {
+	text := string(content)
+	trailingNewline := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) <= maxLines {
+		return content
+	}
+	head := maxLines - maxLines/2
+	tail := maxLines / 2
+	omitted := len(lines) - head - tail
+	kept := append([]string{}, lines[:head]...)
+	kept = append(kept, fmt.Sprintf("... (%d lines omitted) ...", omitted))
+	kept = append(kept, lines[len(lines)-tail:]...)
+	out := strings.Join(kept, "\n")
+	if trailingNewline {
+		out += "\n"
+	}
+	return []byte(out)
+}
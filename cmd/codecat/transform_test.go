@@ -0,0 +1,79 @@
+// cmd/codecat/transform_test.go
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinifyContent(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		dedent   bool
+		expected string
+	}{
+		{
+			name:     "Trailing whitespace and blank runs",
+			input:    "\n\nfunc a() {  \n\treturn\t\n}\n\n\n\nfunc b() {}\r\n\n",
+			expected: "func a() {\n\treturn\n}\n\nfunc b() {}\n",
+		},
+		{
+			name:     "No trailing newline",
+			input:    "a\n\n\nb",
+			expected: "a\n\nb\n",
+		},
+		{
+			name:     "Dedent common indentation",
+			input:    "    def f():\n        return 1\n\n    x = 2\n",
+			dedent:   true,
+			expected: "def f():\n    return 1\n\nx = 2\n",
+		},
+		{
+			name:     "Dedent mixed indentation keeps shared prefix",
+			input:    "\t  a\n\tb\n",
+			dedent:   true,
+			expected: "  a\nb\n",
+		},
+		{
+			name:     "Without dedent indentation stays",
+			input:    "    x\n",
+			expected: "    x\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, string(minifyContent([]byte(tc.input), tc.dedent)))
+		})
+	}
+}
+
+func TestContentPipeline(t *testing.T) {
+	var nilPipeline *contentPipeline
+	out, err := nilPipeline.process("a.txt", []byte("x  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, "x  \n", string(out))
+
+	p := newContentPipeline(GenerateOptions{Minify: true, CSVRows: 1})
+	out, err = p.process("data.csv", []byte("h  \n1\n\n\n2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "h\n1\n... (3 more rows)\n", string(out), "adapters run before minify")
+
+	p.transforms = append(p.transforms, contentTransform{name: "fail", apply: func(string, []byte) ([]byte, error) {
+		return nil, errors.New("boom")
+	}})
+	_, err = p.process("a.txt", []byte("x"))
+	assert.EqualError(t, err, "fail: boom")
+}
+
+func TestTruncateMiddle(t *testing.T) {
+	content := []byte("1\n2\n3\n4\n5\n6\n7\n")
+	assert.Equal(t, "1\n2\n... (3 lines omitted) ...\n6\n7\n", string(truncateMiddle(content, 4)))
+	assert.Equal(t, "1\n2\n3\n... (2 lines omitted) ...\n6\n7\n", string(truncateMiddle(content, 5)), "odd budgets favour the head")
+	assert.Equal(t, string(content), string(truncateMiddle(content, 7)), "short files are unchanged")
+	assert.Equal(t, "a\n... (2 lines omitted) ...\nd", string(truncateMiddle([]byte("a\nb\nc\nd"), 2)))
+}
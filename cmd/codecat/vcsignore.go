@@ -0,0 +1,300 @@
+// cmd/codecat/vcsignore.go
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	gitignore "github.com/boyter/gocodewalker/go-gitignore"
+)
+
+// vcsIgnore holds the ignore rules the walker does not read itself: the
+// user's global excludes file in a git repository, or the rules of a
+// Mercurial or Subversion working copy. A nil *vcsIgnore ignores nothing.
+type vcsIgnore struct {
+	root string // working copy root; rules are relative to it
+
+	// Git (core.excludesFile). Unlike git, a "!" in a .gitignore cannot
+	// bring back a file the global file ignores.
+	gitExcludes gitignore.GitIgnore
+
+	// Mercurial (.hgignore)
+	globs    [][]string // glob/relglob: match at any depth
+	rootGlob [][]string // rootglob/path: anchored at the root
+	regexps  []*regexp.Regexp
+
+	// Subversion
+	svnIgnore map[string][]string // directory -> svn:ignore globs for its direct children
+	svnGlobal map[string][]string // directory -> svn:global-ignores globs for everything below
+
+	dirs dirDecisions // root-relative directory -> ignored, itself or through a parent
+}
+
+// dirDecisions caches a yes/no answer per directory for ignore matchers,
+// where a file's answer starts with its directory's, so the files of one
+// directory share a single check of the directories above them. The zero
+// value is ready to use and safe for concurrent use.
+type dirDecisions struct {
+	mu sync.RWMutex
+	m  map[string]bool
+}
+
+// get returns the cached answer for dir, calling decide the first time.
+func (c *dirDecisions) get(dir string, decide func() bool) bool {
+	c.mu.RLock()
+	decision, ok := c.m[dir]
+	c.mu.RUnlock()
+	if ok {
+		return decision
+	}
+	decision = decide()
+	c.mu.Lock()
+	if c.m == nil {
+		c.m = make(map[string]bool)
+	}
+	c.m[dir] = decision
+	c.mu.Unlock()
+	return decision
+}
+
+// loadVCSIgnore finds the working copy containing cwd and loads its extra
+// ignore rules. Problems are logged, not fatal: the scan goes on without the
+// rules.
+func loadVCSIgnore(cwd string) *vcsIgnore {
+	for dir := filepath.Clean(cwd); ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return loadGitExcludes(dir)
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".hg")); err == nil {
+			ignore, err := loadHgIgnore(dir)
+			if err != nil {
+				slog.Warn("Cannot read .hgignore; continuing without it.", "root", dir, "error", err)
+				return nil
+			}
+			return ignore
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".svn")); err == nil {
+			ignore, err := loadSVNIgnore(dir)
+			if err != nil {
+				slog.Warn("Cannot read svn:ignore properties; continuing without them.", "root", dir, "error", err)
+				return nil
+			}
+			return ignore
+		}
+		if filepath.Dir(dir) == dir {
+			return nil
+		}
+	}
+}
+
+// loadGitExcludes reads the global excludes file of the repository at root:
+// core.excludesFile, or $XDG_CONFIG_HOME/git/ignore (~/.config/git/ignore)
+// when that is unset. It returns nil when there is no such file.
+func loadGitExcludes(root string) *vcsIgnore {
+	file := ""
+	if out, err := exec.Command("git", "-C", root, "config", "--path", "--get", "core.excludesFile").Output(); err == nil {
+		file = strings.TrimSpace(string(out))
+	}
+	if file == "" {
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil
+			}
+			configHome = filepath.Join(home, ".config")
+		}
+		file = filepath.Join(configHome, "git", "ignore")
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Cannot read the global git excludes file; continuing without it.", "path", file, "error", err)
+		}
+		return nil
+	}
+	slog.Debug("Using the global git excludes file.", "path", file)
+	return &vcsIgnore{root: root, gitExcludes: gitignore.New(strings.NewReader(string(content)), root, nil)}
+}
+
+// hgPatternKinds are the Mercurial pattern prefixes; only some are supported.
+var hgPatternKinds = []string{"glob", "relglob", "rootglob", "path", "relpath", "re", "regexp", "relre",
+	"rootfilesin", "include", "subinclude", "listfile", "listfile0"}
+
+// loadHgIgnore parses root/.hgignore. As in Mercurial, patterns are regular
+// expressions until a "syntax: glob" line, and a "glob:", "re:", "path:" or
+// similar prefix overrides the syntax for one line.
+func loadHgIgnore(root string) (*vcsIgnore, error) {
+	ignore := &vcsIgnore{root: root}
+	f, err := os.Open(filepath.Join(root, ".hgignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ignore, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	syntax := "regexp"
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "syntax:"); ok {
+			syntax = strings.TrimSpace(rest)
+			continue
+		}
+		kind, pattern := syntax, line
+		if prefix, rest, ok := strings.Cut(line, ":"); ok && contains(hgPatternKinds, prefix) {
+			kind, pattern = prefix, rest
+		}
+		switch kind {
+		case "glob", "relglob":
+			ignore.globs = append(ignore.globs, strings.Split(strings.Trim(pattern, "/"), "/"))
+		case "rootglob", "path", "relpath":
+			ignore.rootGlob = append(ignore.rootGlob, strings.Split(strings.Trim(pattern, "/"), "/"))
+		case "regexp", "re", "relre":
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				slog.Warn("Skipping .hgignore pattern Go cannot compile.", "pattern", pattern, "error", err)
+				continue
+			}
+			ignore.regexps = append(ignore.regexps, re)
+		default:
+			slog.Warn("Skipping unsupported .hgignore pattern.", "line", line)
+		}
+	}
+	return ignore, scanner.Err()
+}
+
+// loadSVNIgnore reads the svn:ignore and svn:global-ignores properties of the
+// working copy at root with 'svn proplist'.
+func loadSVNIgnore(root string) (*vcsIgnore, error) {
+	cmd := exec.Command("svn", "proplist", "--recursive", "--verbose", "--xml", ".")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("svn proplist in %s: %s", root, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("svn proplist in %s: %w", root, err)
+	}
+	return parseSVNProperties(root, out)
+}
+
+// parseSVNProperties builds the rules from 'svn proplist --xml' output.
+func parseSVNProperties(root string, data []byte) (*vcsIgnore, error) {
+	var doc struct {
+		Targets []struct {
+			Path       string `xml:"path,attr"`
+			Properties []struct {
+				Name  string `xml:"name,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"property"`
+		} `xml:"target"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing svn proplist output: %w", err)
+	}
+	ignore := &vcsIgnore{root: root, svnIgnore: make(map[string][]string), svnGlobal: make(map[string][]string)}
+	for _, target := range doc.Targets {
+		dir := path.Clean(filepath.ToSlash(target.Path))
+		for _, prop := range target.Properties {
+			var patterns []string
+			for _, line := range strings.Split(prop.Value, "\n") {
+				patterns = append(patterns, strings.Fields(line)...)
+			}
+			switch prop.Name {
+			case "svn:ignore":
+				ignore.svnIgnore[dir] = append(ignore.svnIgnore[dir], patterns...)
+			case "svn:global-ignores":
+				ignore.svnGlobal[dir] = append(ignore.svnGlobal[dir], patterns...)
+			}
+		}
+	}
+	return ignore, nil
+}
+
+// ignored reports whether absPath, or a directory above it inside the
+// working copy, matches an ignore rule.
+func (v *vcsIgnore) ignored(absPath string) bool {
+	if v == nil {
+		return false
+	}
+	rel, err := filepath.Rel(v.root, absPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	return v.dirIgnored(segments[:len(segments)-1]) || v.matches(segments, false)
+}
+
+// dirIgnored reports whether the root-relative directory segments, or one
+// above it, is ignored, deciding each directory once.
+func (v *vcsIgnore) dirIgnored(segments []string) bool {
+	if len(segments) == 0 {
+		return false
+	}
+	return v.dirs.get(strings.Join(segments, "/"), func() bool {
+		return v.dirIgnored(segments[:len(segments)-1]) || v.matches(segments, true)
+	})
+}
+
+// matches checks one root-relative path against the rules, without its parents.
+func (v *vcsIgnore) matches(segments []string, isDir bool) bool {
+	if v.gitExcludes != nil {
+		if m := v.gitExcludes.Relative(strings.Join(segments, "/"), isDir); m != nil && m.Ignore() {
+			return true
+		}
+	}
+	for _, glob := range v.globs {
+		for start := range segments {
+			if matchPathSegments(glob, segments[start:]) {
+				return true
+			}
+		}
+	}
+	for _, glob := range v.rootGlob {
+		if matchPathSegments(glob, segments) {
+			return true
+		}
+	}
+	if len(v.regexps) > 0 {
+		rel := strings.Join(segments, "/")
+		for _, re := range v.regexps {
+			if re.MatchString(rel) {
+				return true
+			}
+		}
+	}
+
+	name := segments[len(segments)-1]
+	matchAny := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if match, _ := path.Match(pattern, name); match {
+				return true
+			}
+		}
+		return false
+	}
+	if matchAny(v.svnIgnore[path.Join(append([]string{"."}, segments[:len(segments)-1]...)...)]) {
+		return true
+	}
+	for i := 0; i < len(segments); i++ {
+		if matchAny(v.svnGlobal[path.Join(append([]string{"."}, segments[:i]...)...)]) {
+			return true
+		}
+	}
+	return false
+}
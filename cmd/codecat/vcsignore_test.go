@@ -0,0 +1,154 @@
+// cmd/codecat/vcsignore_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadHgIgnore(t *testing.T) {
+	root := setupTestDir(t, map[string]string{
+		".hg/requires": "store\n",
+		".hgignore": "# default syntax is regexp\n" +
+			`\.orig$` + "\n" +
+			"^out/\n" +
+			"glob:*.tmp\n" +
+			"syntax: glob\n" +
+			"build\n" +
+			"gen/*.go\n" +
+			"rootglob:docs/_site\n" +
+			"re:^cache$\n",
+	})
+	ignore := loadVCSIgnore(filepath.Join(root, "src"))
+	require.NotNil(t, ignore)
+
+	testCases := map[string]bool{
+		"main.go":             false,
+		"main.go.orig":        true,
+		"out/app":             true,
+		"src/out/app":         false,
+		"src/x.tmp":           true,
+		"build/lib.go":        true,
+		"src/build/lib.go":    true,
+		"src/gen/types.go":    true,
+		"docs/_site/index.md": true,
+		"src/docs/_site/a.md": false,
+		"cache/blob":          true,
+		"src/cache/blob":      false,
+	}
+	for pass := 0; pass < 2; pass++ { // the second pass is answered from the directory cache
+		for rel, want := range testCases {
+			assert.Equal(t, want, ignore.ignored(filepath.Join(root, rel)), rel)
+		}
+	}
+	assert.False(t, ignore.ignored(filepath.Dir(root)), "paths outside the working copy")
+	assert.True(t, ignore.dirs.m["build"], "ignored directories are cached")
+	assert.False(t, ignore.dirs.m["src"])
+}
+
+// isolateGitConfig points git and XDG lookups at an empty home.
+func isolateGitConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	return home
+}
+
+func TestLoadVCSIgnore_GitWins(t *testing.T) {
+	isolateGitConfig(t)
+	root := setupTestDir(t, map[string]string{
+		".hg/requires": "store\n",
+		".hgignore":    "syntax: glob\n*.go\n",
+		"sub/.git":     "gitdir: elsewhere\n",
+	})
+	assert.Nil(t, loadVCSIgnore(filepath.Join(root, "sub")), "the nearest working copy is git")
+	assert.NotNil(t, loadVCSIgnore(root))
+}
+
+func TestLoadGitExcludes(t *testing.T) {
+	home := isolateGitConfig(t)
+	root := setupTestDir(t, map[string]string{".git/HEAD": "ref: refs/heads/main\n"})
+	assert.Nil(t, loadVCSIgnore(root), "no global excludes file")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "git", "ignore"), []byte("*.scratch\n.idea/\n"), 0o644))
+	ignore := loadVCSIgnore(filepath.Join(root, "pkg"))
+	require.NotNil(t, ignore)
+	assert.True(t, ignore.ignored(filepath.Join(root, "pkg", "notes.scratch")))
+	assert.True(t, ignore.ignored(filepath.Join(root, ".idea", "workspace.xml")))
+	assert.False(t, ignore.ignored(filepath.Join(root, "pkg", "main.go")))
+
+	custom := filepath.Join(home, "excludes")
+	require.NoError(t, os.WriteFile(custom, []byte("*.go\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[core]\n\texcludesFile = "+custom+"\n"), 0o644))
+	ignore = loadVCSIgnore(root)
+	require.NotNil(t, ignore)
+	assert.True(t, ignore.ignored(filepath.Join(root, "pkg", "main.go")), "core.excludesFile wins over the XDG file")
+	assert.False(t, ignore.ignored(filepath.Join(root, "pkg", "notes.scratch")))
+}
+
+func TestParseSVNProperties(t *testing.T) {
+	out := `<?xml version="1.0" encoding="UTF-8"?>
+<properties>
+<target path=".">
+<property name="svn:ignore">*.log
+bin
+</property>
+<property name="svn:global-ignores">*.bak</property>
+</target>
+<target path="lib">
+<property name="svn:ignore">generated</property>
+<property name="svn:eol-style">native</property>
+</target>
+</properties>`
+	ignore, err := parseSVNProperties("/wc", []byte(out))
+	require.NoError(t, err)
+
+	testCases := map[string]bool{
+		"/wc/app.log":            true,
+		"/wc/lib/app.log":        false, // svn:ignore covers direct children only
+		"/wc/bin/tool":           true,
+		"/wc/lib/generated/a.go": true,
+		"/wc/generated/a.go":     false,
+		"/wc/lib/deep/x.bak":     true,
+		"/wc/lib/main.go":        false,
+	}
+	for p, want := range testCases {
+		assert.Equal(t, want, ignore.ignored(filepath.FromSlash(p)), p)
+	}
+
+	_, err = parseSVNProperties("/wc", []byte("<properties"))
+	assert.Error(t, err)
+}
+
+func TestGenerate_HgIgnore(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		".hg/requires": "store\n",
+		".hgignore":    "syntax: glob\ngen\n",
+		"main.go":      "package main\n",
+		"gen/types.go": "package gen\n",
+	})
+	opts := GenerateOptions{
+		CWD:          tempDir,
+		ScanDirs:     []string{tempDir},
+		Extensions:   processExtensions([]string{"go"}),
+		Marker:       "---",
+		UseGitignore: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "--- main.go\n")
+	assert.NotContains(t, result.Output, "gen/types.go")
+
+	opts.UseGitignore = false
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "--- gen/types.go\n")
+}
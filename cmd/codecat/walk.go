@@ -2,16 +2,193 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	gocodewalker "github.com/boyter/gocodewalker"
 )
 
+// GenerateOptions holds everything needed to produce one concatenated output.
+type GenerateOptions struct {
+	CWD                string
+	ScanDirs           []string
+	Extensions         map[string]struct{}
+	Languages          map[string]struct{} // lower-case detected languages to include (--lang), alongside Extensions
+	Shebangs           map[string]struct{} // lower-case interpreters or languages whose scripts without a known extension are included (--shebang)
+	ManualFiles        []string
+	ExcludeBasenames   []string
+	IncludeBasenames   []string // file name globs included alongside Extensions (Makefile, Dockerfile, ...)
+	ProjectExcludes    []string
+	ProjectIncludes    []string // .codecat_include globs: force inclusion past extension filters and non-flag excludes
+	FlagExcludes       []string
+	UseGitignore       bool
+	Header             string
+	Marker             string
+	NoScan             bool
+	SkipContent        bool                     // classify scanned files by stat only, without reading them into Output
+	NoAdapters         bool                     // include notebooks, PDFs, docx and CSV files verbatim
+	CSVRows            int                      // keep only the first CSVRows data rows of CSV files (0 = all)
+	NotebookCellIndex  bool                     // number the cells of converted notebooks
+	Minify             bool                     // trim trailing whitespace and collapse blank lines
+	Dedent             bool                     // with Minify, strip indentation common to all lines
+	Annotate           string                   // per-file metadata under each header: "" or "git"
+	GitTracked         bool                     // only scan files listed by git ls-files
+	NestedRepos        string                   // git repositories below CWD: "include" (default), "skip" or "separate"
+	Stamp              bool                     // add a header with version, time, filters and a content hash
+	FileMap            bool                     // add a <file_map> block listing the included paths and sizes
+	QuotePaths         bool                     // quote the paths in text and Markdown headers (Go syntax)
+	RepoInfo           bool                     // add the remote, branch and HEAD commit of each scan root's git checkout
+	ThirdParty         string                   // vendored dependency trees and go.sum: "include" (default), "exclude" or "summarize"
+	Gitattributes      []string                 // .gitattributes attributes that exclude a file; none disables
+	Tests              string                   // test files and fixture dirs: "include" (default), "exclude" or "only"
+	NoMinifiedAssets   bool                     // skip minified and bundled .js/.css files and source maps
+	MaxFileSize        int64                    // skip scanned files larger than this many bytes (0 = no limit)
+	SkipBinary         bool                     // skip scanned files with a NUL byte near the start, except adapter formats
+	ManualExcludes     bool                     // apply exclude rules, MaxFileSize, SkipBinary and Limits to ManualFiles too (--manual-respects-excludes)
+	TruncateLines      int                      // keep only the first and last lines of longer files, this many in total (0 = off)
+	ContentPolicies    map[string]ContentPolicy // per-extension sampling from [content.<ext>], keyed by ".ext"
+	Roots              []ScanRoot               // -d roots with their own filters, scanned separately into per-root sections
+	NestedRoots        []string                 // absolute roots inside ScanDirs whose files another run owns
+	RelativeTo         string                   // base for displayed paths: "cwd" (default), "scan-root", "git-root" or "abs"
+	StripPrefixes      []string                 // directory prefixes removed from displayed paths (first match)
+	PathPrefix         string                   // directory prefix prepended to displayed paths
+	Checksums          bool                     // record each included file's FileSource (for --manifest)
+	OutputPaths        []string                 // absolute paths this run writes to; scans skip them
+	ScrubPII           bool                     // mask emails, phone numbers and IP addresses
+	ScrubAllow         []string                 // domains and patterns --scrub-pii keeps
+	Hooks              *hookRunner              // [hooks]; its transform hooks filter matching files
+	Obfuscator         *identifierObfuscator    // renames project-specific identifiers (--obfuscate-identifiers); nil disables it
+	Preamble           string                   // generated section before the files: "" or "imports"
+	RecentCommits      int                      // add a generated section with the last N commits of each scan root; 0 is none
+	RecentCommitsStat  bool                     // with RecentCommits, add each commit's --shortstat summary
+	GitHubIssues       []string                 // issues or pull requests (owner/repo#123 or URL) fetched into documents before the files
+	DiffRange          string                   // add the git diff of this range as a document before the files; the changed files come in through ManualFiles
+	Jail               string                   // refuse scan roots, symlinked files and manual files resolving outside this directory; "" allows any
+	DocsFirst          bool                     // READMEs, docs/ and ADRs before source files
+	IncludeErrors      bool                     // a placeholder block for each unreadable file, with the error
+	DirReadmes         bool                     // each directory's README.md right before its files, included whatever the filters
+	Seeds              []string                 // CWD-relative files to start from; only files they reach through imports are kept
+	ExpandDepth        int                      // import hops followed from Seeds, in both directions
+	MaxDepth           int                      // deepest file level below its scan root, 1 being the root's own files; 0 is unlimited
+	MinDepth           int                      // shallowest file level below its scan root; 0 is unlimited
+	MaxFiles           int                      // stop the scan after this many included files; 0 is unlimited (files given with -f not counted)
+	Grep               string                   // keep only scanned files whose path or content matches this RE2 expression
+	GrepContext        int                      // with Grep, keep only matching lines and this many around them (0 = whole files)
+	IgnoreCase         bool                     // match Grep whatever the case, as asked for with --ignore-case (paths fold with foldPathCase)
+	Priorities         map[string]int           // [priority] globs -> weight; higher comes first and is dropped last
+	Limits             []dirLimit               // [limits] caps on what the files matching a glob contribute
+	SampleDirs         int                      // --sample-dirs: files of one extension kept per directory; 0 keeps all
+	OutlierPercentile  float64                  // --auto-trim-outliers: percentile of file sizes the limit is based on; 0 disables trimming
+	OutlierMultiplier  float64                  // --auto-trim-outliers: the limit is this many times the percentile
+	MaxTokens          int64                    // token budget; only enforced here with Fit
+	Fit                bool                     // drop the lowest-ranked files until the output fits MaxTokens
+	Progress           io.Writer                // live scan status (--progress); nil disables it
+	Cache              *fileCache               // file contents kept warm by 'codecat daemon'; nil reads from disk
+	ReportSkipped      bool                     // record excluded files that pass the extension/language filters in Skipped
+	FileHeaderTemplate string                   // text/template written before each file in the text format ("" = marker + path)
+	FileFooterTemplate string                   // text/template written after each file in the text format ("" = marker)
+	HeaderFields       []string                 // metadata appended to each file's header: size, mtime, mode, lang, tokens
+	AnonymizePaths     bool                     // replace paths in the output with pseudonyms, see anonymizeResult
+	MemoryLimit        int64                    // file content kept in memory before spooling to a temporary file (0 = unlimited)
+}
+
+// GenerateResult collects the output and bookkeeping of a generation run.
+type GenerateResult struct {
+	Output        string     // Documents rendered in the text format
+	Documents     []Document // included files in output order, for the formatters
+	Stamp         *Stamp     // set when opts.Stamp is enabled
+	IncludedFiles []FileInfo
+	EmptyFiles    []string
+	ErrorFiles    map[string]error
+	SpecialFiles  map[string]string // skipped FIFOs, sockets and devices -> kind
+	TotalSize     int64
+	FilesSeen     int                 // scanned (non-directory) candidates reaching the exclusion checks
+	ExcludedBy    map[string]int      // excluded file counts by source: basename, project, flag, extension, untracked, nested-repo, third-party, tests, minified, size, binary, grep, seed, plugin
+	ExcludeRules  []ExclusionRule     // per-pattern hit counts of the scan's exclude rules
+	Skipped       map[string][]string // with ReportSkipped: exclusion source -> CWD-relative paths
+	NestedRepos   []string            // CWD-relative roots of nested git repositories met during the scan
+	Repos         []RepoInfo          // with RepoInfo: the git checkouts of the scan roots
+	Partial       bool                // the run was cancelled or timed out before all files were processed
+	MaxFilesHit   bool                // the scan stopped at MaxFiles included files; more may have matched
+	PathBase      string              // what displayed paths are relative to, for the summary
+	Dropped       []string            // paths --fit removed to meet the token budget, in drop order
+	Outliers      []FileInfo          // files --auto-trim-outliers removed, largest first
+	OutlierLimit  int64               // the size over which --auto-trim-outliers removed files
+	OutputSize    int64               // bytes of the text rendering, set even when Output is left empty
+	PathMap       map[string]string   // with AnonymizePaths: pseudonym -> real CWD-relative path
+
+	spools     []*contentSpool   // spool files holding Documents content past MemoryLimit
+	history    []commitLog       // with RecentCommits, when gathered before renderResult (codecat multi)
+	patch      string            // with DiffRange, when gathered before renderResult (codecat multi)
+	errorRoots map[string]string // ErrorFiles path -> Root, for the placeholders of merged results
+}
+
+// spilled reports whether any document content was spooled to disk, in
+// which case Output is not built and the formatters stream instead.
+func (r GenerateResult) spilled() bool {
+	for _, s := range r.spools {
+		if s.spilled() {
+			return true
+		}
+	}
+	return false
+}
+
+// Close removes the result's spool files; Documents cannot be read after.
+func (r GenerateResult) Close() error {
+	var errs []error
+	for _, s := range r.spools {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// passesFilters reports whether a file passes the extension, basename and
+// language filters (true when none is set), along with its detected
+// language.
+func passesFilters(opts *GenerateOptions, relPathCwd, absPath string) (language string, ok bool) {
+	_, ok = opts.Extensions[strings.ToLower(filepath.Ext(relPathCwd))]
+	for _, pattern := range opts.IncludeBasenames {
+		if compileGlob(pattern, false).match(filepath.Base(relPathCwd)) {
+			ok = true
+			break
+		}
+	}
+	if _, known := extensionLanguages[strings.ToLower(filepath.Ext(relPathCwd))]; !ok && !known && len(opts.Shebangs) > 0 {
+		ok = matchShebang(readHead(absPath), opts.Shebangs)
+	}
+	if ok || len(opts.Languages) > 0 {
+		language = fileLanguage(relPathCwd, absPath)
+	}
+	if _, langAllowed := opts.Languages[strings.ToLower(language)]; langAllowed {
+		ok = true
+	}
+	return language, ok || (len(opts.Extensions) == 0 && len(opts.Languages) == 0)
+}
+
+// forcingPattern returns the .codecat_include pattern matching the file or
+// one of its directories, or "".
+func forcingPattern(includes []string, info PathInfo) string {
+	for _, p := range includes {
+		if match, _ := matchesCwdRelative(p, info); match {
+			return p
+		}
+	}
+	return ""
+}
+
 // generateConcatenatedCode walks directories, processes files, and generates the output.
+// It is a positional wrapper around generate kept for existing callers and tests.
 func generateConcatenatedCode(
 	cwd string,
 	scanDirs []string,
@@ -31,12 +208,79 @@ func generateConcatenatedCode(
 	totalSize int64,
 	returnedErr error,
 ) {
-	slog.Debug("generateConcatenatedCode received extensions map", "exts_keys", mapsKeys(exts))
+	res, err := generate(GenerateOptions{
+		CWD:              cwd,
+		ScanDirs:         scanDirs,
+		Extensions:       exts,
+		ManualFiles:      manualFilePaths,
+		ExcludeBasenames: excludeBasenames,
+		ProjectExcludes:  projectExcludePatterns,
+		FlagExcludes:     flagExcludePatterns,
+		UseGitignore:     useGitignore,
+		Header:           header,
+		Marker:           marker,
+		NoScan:           noScan,
+	})
+	return res.Output, res.IncludedFiles, res.EmptyFiles, res.ErrorFiles, res.TotalSize, err
+}
 
-	var outputBuilder strings.Builder
-	if header != "" {
-		outputBuilder.WriteString(header)
+// generate walks directories, processes files, and generates the output.
+func generate(opts GenerateOptions) (GenerateResult, error) {
+	return generateContext(context.Background(), opts)
+}
+
+// generateContext is generate with cancellation: once ctx is done the walk is
+// terminated, no further files are read, and the result gathered so far is
+// returned marked Partial together with an error wrapping ctx.Err().
+func generateContext(ctx context.Context, opts GenerateOptions) (GenerateResult, error) {
+	if len(opts.Roots) > 0 {
+		return generateRoots(ctx, opts)
+	}
+	cwd := normalizeVolumePath(opts.CWD)
+	scanDirs := make([]string, len(opts.ScanDirs))
+	for i, dir := range opts.ScanDirs {
+		scanDirs[i] = normalizeVolumePath(dir)
+	}
+	jail := ""
+	if opts.Jail != "" {
+		var err error
+		if jail, err = resolveJail(opts.Jail); err != nil {
+			return GenerateResult{}, err
+		}
+		for _, dir := range scanDirs {
+			if err := checkJail(jail, dir); err != nil {
+				return GenerateResult{}, fmt.Errorf("scan root %s %w", dir, err)
+			}
+		}
 	}
+	exts := opts.Extensions
+	manualFilePaths := opts.ManualFiles
+	excludeBasenames := opts.ExcludeBasenames
+	projectExcludePatterns := opts.ProjectExcludes
+	flagExcludePatterns := opts.FlagExcludes
+	useGitignore := opts.UseGitignore
+	noScan := opts.NoScan
+
+	var (
+		includedFiles []FileInfo
+		emptyFiles    []string
+		errorFiles    map[string]error
+		totalSize     int64
+		returnedErr   error
+		filesSeen     int
+		nestedRepos   []string
+		documents     []Document
+		excludeRules  []ExclusionRule
+		maxFilesHit   bool
+	)
+	excludedBy := make(map[string]int)
+	var skipped map[string][]string
+	if opts.ReportSkipped {
+		skipped = make(map[string][]string)
+	}
+	specialFiles := make(map[string]string)
+
+	slog.Debug("generateConcatenatedCode received extensions map", "exts_keys", mapsKeys(exts))
 
 	includedFiles = make([]FileInfo, 0)
 	emptyFiles = make([]string, 0)
@@ -70,24 +314,83 @@ func generateConcatenatedCode(
 	}
 	slog.Debug("Using combined CWD-relative exclude patterns", "patterns", cwdRelativeExcludePatterns)
 
+	matchFilters := func(relPathCwd, absPath string) (language string, ok bool) {
+		return passesFilters(&opts, relPathCwd, absPath)
+	}
+	forceIncluded := func(info PathInfo) bool {
+		return forcingPattern(opts.ProjectIncludes, info) != ""
+	}
+	// recordSkipped notes an excluded file for --report-skipped, but only if
+	// the extension filters would otherwise have let it through.
+	recordSkipped := func(source, relPathCwd, absPath string) {
+		if skipped == nil {
+			return
+		}
+		if _, ok := matchFilters(relPathCwd, absPath); ok {
+			skipped[source] = append(skipped[source], relPathCwd)
+		}
+	}
+
+	pipeline := newContentPipeline(opts)
+	spool := newContentSpool(opts.MemoryLimit)
+	annotate := newFileAnnotator(opts.Annotate)
+	grep, errGrep := newGrepFilter(opts.Grep, opts.GrepContext, opts.IgnoreCase)
+	if errGrep != nil {
+		return GenerateResult{}, errGrep
+	}
+
+	excluder := NewDefaultExcluder(validBasenameExcludes, cwdRelativeExcludePatterns)
+	var manualSkip manualSkipCheck
+	if opts.ManualExcludes {
+		manualSkip = func(relPathCwd, absPath string, info os.FileInfo) string {
+			reason := ""
+			pathInfo := PathInfo{AbsPath: absPath, RelPathCwd: relPathCwd, BaseName: filepath.Base(absPath)}
+			if excluded, why, pattern := excluder.IsExcluded(pathInfo); excluded {
+				reason = exclusionSource(why, pattern, flagExcludePatterns)
+			} else if byPlugin, _ := pluginExcluded(pathInfo); byPlugin {
+				reason = "plugin"
+			} else if opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize {
+				reason = "size"
+			} else if binaryChecked(relPathCwd, opts) {
+				if binary, errSniff := sniffBinary(absPath); errSniff == nil && binary {
+					reason = "binary"
+				}
+			}
+			if reason != "" {
+				slog.Info("Skipping manual file under --manual-respects-excludes.", "path", relPathCwd, "reason", reason)
+				excludedBy[reason]++
+				if skipped != nil {
+					skipped[reason] = append(skipped[reason], relPathCwd)
+				}
+			}
+			return reason
+		}
+	}
+
 	// --- Process Manually Specified Files (-f) ---
 	processManualFiles(
+		ctx,
 		cwd,
 		manualFilePaths,
-		marker,
-		&outputBuilder,
+		pipeline,
+		annotate,
+		&documents,
 		processedAbsPaths,
 		&includedFiles,
 		&emptyFiles,
 		errorFiles,
 		&totalSize,
+		opts.Checksums,
+		jail,
+		manualSkip,
 	)
+	for i := range documents {
+		documents[i] = spool.keep(documents[i])
+	}
 
 	// --- Perform Directory Scan ---
 	shouldScan := !noScan && len(scanDirs) > 0
 	if shouldScan {
-		excluder := NewDefaultExcluder(validBasenameExcludes, cwdRelativeExcludePatterns)
-
 		if len(exts) == 0 && len(manualFilePaths) == 0 {
 			slog.Warn("Scanning requested, but no extensions/manual files provided. Scan will find nothing.")
 		}
@@ -118,105 +421,430 @@ func generateConcatenatedCode(
 			}
 		}
 
+		var trackedFiles map[string]bool
+		if opts.GitTracked && returnedErr == nil {
+			var errTracked error
+			trackedFiles, errTracked = gitTrackedFiles(cwd)
+			if errTracked != nil {
+				slog.Error("Cannot list git-tracked files.", "error", errTracked)
+				returnedErr = fmt.Errorf("--git-tracked: %w", errTracked)
+			} else {
+				slog.Debug("Restricting scan to git-tracked files.", "count", len(trackedFiles))
+			}
+		}
+
 		// If a fatal validation error occurred, stop before walking.
 		if returnedErr != nil {
 			slog.Error("Aborting scan due to errors with specified scan directories.")
 		} else {
-			// **BUG FIX #1**: Always start the walker from CWD to respect its .gitignore.
-			// We will filter for scanDirs down below.
+			// Scan directories below CWD are walked from their own roots,
+			// concurrently when there are several, with the ignore files between
+			// CWD and each root loaded here (**BUG FIX #1**: the CWD .gitignore
+			// must apply). Otherwise walk CWD and filter for scanDirs below.
 			fileListQueue := make(chan *gocodewalker.File, 100)
-			fileWalker := gocodewalker.NewFileWalker(cwd, fileListQueue)
+			var fileWalker *gocodewalker.FileWalker
+			var aboveRoots *ancestorIgnores
+			roots := walkRoots(cwd, scanDirs)
+			switch len(roots) {
+			case 0:
+				fileWalker = gocodewalker.NewFileWalker(cwd, fileListQueue)
+			case 1:
+				slog.Debug("Walking the scan directory only.", "root", roots[0])
+				fileWalker = gocodewalker.NewFileWalker(roots[0], fileListQueue)
+			default:
+				slog.Debug("Walking scan directories concurrently.", "roots", roots)
+				fileWalker = gocodewalker.NewParallelFileWalker(roots, fileListQueue)
+			}
+			if len(roots) > 0 && useGitignore {
+				aboveRoots = loadAncestorIgnores(cwd, roots)
+			}
 			fileWalker.IgnoreGitIgnore = !useGitignore
 			fileWalker.IgnoreIgnoreFile = !useGitignore
+			// Nested repositories, submodules included, are handled by the
+			// --nested-repos policy below rather than dropped by the walker.
+			fileWalker.IgnoreGitModules = true
+			nestedFinder := newNestedRepoFinder(cwd)
+			collected := newFileCollector(errorFiles, spool, opts.MaxFiles)
+			thirdParty := newThirdPartyCollector()
+			attributes := newGitattributes(cwd, opts.Gitattributes)
+			var vcsIgnores *vcsIgnore
+			if useGitignore {
+				vcsIgnores = loadVCSIgnore(cwd)
+			}
 
 			var walkErr error
 			var firstWalkError error
+			var walkErrorMu sync.Mutex // the walker reports errors from several goroutines
 			processingDone := make(chan struct{})
+			var progress *scanProgress
+			if opts.Progress != nil {
+				progress = startScanProgress(opts.Progress, tern(len(roots) > 0, roots, []string{cwd}), useGitignore)
+			}
 
 			go func() {
 				defer close(processingDone)
 				walkerErrorHandler := func(e error) bool {
 					slog.Warn("Error reported by file walker.", "scanDir", cwd, "error", e)
+					walkErrorMu.Lock()
 					if firstWalkError == nil {
 						firstWalkError = e
 					}
+					walkErrorMu.Unlock()
 					return true
 				}
 				fileWalker.SetErrorHandler(walkerErrorHandler)
 				walkErr = fileWalker.Start()
 			}()
+			go func() {
+				select {
+				case <-ctx.Done():
+					fileWalker.Terminate()
+				case <-processingDone:
+				}
+			}()
 
 			for f := range fileListQueue {
-				absPath := f.Location
+				if ctx.Err() != nil {
+					continue // drain the queue until the terminated walker closes it
+				}
+				absPath := normalizeVolumePath(f.Location)
+				progress.fileSeen()
 
 				// **BUG FIX #1 (cont.)**: Filter results to only include files within the target scanDirs.
 				isInScanDir := false
+				scanRoot := ""
 				for _, dir := range scanDirs {
 					// Check if the file's absolute path is the scan dir itself or is inside it.
 					if absPath == dir || strings.HasPrefix(absPath, dir+string(filepath.Separator)) {
 						isInScanDir = true
-						break
+						if len(dir) > len(scanRoot) {
+							scanRoot = dir // depth counts from the nearest root
+						}
 					}
 				}
 				if !isInScanDir {
 					continue // Not in a directory we're supposed to scan.
 				}
+				if slices.ContainsFunc(opts.NestedRoots, func(root string) bool { return isWithinDir(absPath, root) }) {
+					continue // the run for the nested root scans it
+				}
+				if aboveRoots.ignored(absPath) {
+					continue // what the CWD walk would not have reached: reported as gitignore
+				}
 
 				if processedAbsPaths[absPath] {
 					continue
 				}
 
 				baseName := filepath.Base(absPath)
-				relPathCwd, _ := filepath.Rel(cwd, absPath)
-				relPathCwd = filepath.ToSlash(relPathCwd)
+				relPathCwd, _ := cwdRelPath(cwd, absPath)
 
 				fileInfo, statErr := os.Stat(absPath)
 				if statErr != nil {
-					errorFiles[relPathCwd] = statErr
+					collected.addError(relPathCwd, statErr)
 					processedAbsPaths[absPath] = true
 					continue
 				}
 
 				isDir := fileInfo.IsDir()
+				if !isDir {
+					filesSeen++
+				}
+				if !isDir && isOutputFile(absPath, opts.OutputPaths) {
+					slog.Info("Skipping this run's own output file.", "path", relPathCwd)
+					excludedBy["output"]++
+					recordSkipped("output", relPathCwd, absPath)
+					processedAbsPaths[absPath] = true
+					continue
+				}
 				pathInfo := PathInfo{AbsPath: absPath, RelPathCwd: relPathCwd, BaseName: baseName, IsDir: isDir}
+				forced := !isDir && forceIncluded(pathInfo)
 				excluded, reason, pattern := excluder.IsExcluded(pathInfo)
+				if !excluded {
+					if byPlugin, name := pluginExcluded(pathInfo); byPlugin {
+						excluded, reason, pattern = true, "plugin", name
+					}
+				}
+				if excluded && forced && exclusionSource(reason, pattern, flagExcludePatterns) != "flag" {
+					slog.Debug("Including file forced by .codecat_include despite exclude.", "path", relPathCwd, "pattern", pattern)
+					excluded = false
+				}
+				if !excluded && vcsIgnores.ignored(absPath) {
+					// Reported like .gitignore: missing from the walk, not skipped.
+					slog.Debug("Skipping path ignored by the working copy.", "path", relPathCwd)
+					processedAbsPaths[absPath] = true
+					continue
+				}
 				if excluded {
 					logMsg := tern(isDir, "Excluding directory and its contents.", "Excluding file.")
 					slog.Log(nil, slog.LevelDebug, logMsg, "path", relPathCwd, "reason", reason, "pattern", pattern)
+					if !isDir {
+						source := exclusionSource(reason, pattern, flagExcludePatterns)
+						excludedBy[source]++
+						recordSkipped(source, relPathCwd, absPath)
+					}
 					processedAbsPaths[absPath] = true
 					continue
 				}
 
 				if isDir {
+					// Symlinked directories and Windows junctions are listed like
+					// files by the walker; neither is descended.
+					if isDirectoryLink(absPath) {
+						slog.Debug("Not following directory link.", "path", relPathCwd)
+					}
+					processedAbsPaths[absPath] = true
+					continue
+				}
+
+				// Reading a FIFO blocks forever and devices never end; skip them.
+				if kind := specialFileKind(fileInfo.Mode()); kind != "" {
+					slog.Warn("Skipping special file.", "path", relPathCwd, "kind", kind)
+					specialFiles[relPathCwd] = kind
+					processedAbsPaths[absPath] = true
+					continue
+				}
+
+				nestedSection := ""
+				if repoRoot := nestedFinder.repoRoot(absPath); repoRoot != "" {
+					relRoot, _ := filepath.Rel(cwd, repoRoot)
+					relRoot = filepath.ToSlash(relRoot)
+					if !contains(nestedRepos, relRoot) {
+						slog.Info("Nested git repository detected.", "path", relRoot, "policy", tern(opts.NestedRepos == "", "include", opts.NestedRepos))
+						nestedRepos = append(nestedRepos, relRoot)
+					}
+					switch opts.NestedRepos {
+					case "skip":
+						excludedBy["nested-repo"]++
+						recordSkipped("nested-repo", relPathCwd, absPath)
+						processedAbsPaths[absPath] = true
+						continue
+					case "separate":
+						nestedSection = relRoot
+					}
+				}
+
+				if opts.ThirdParty == "exclude" || opts.ThirdParty == "summarize" {
+					if root := thirdPartyRoot(relPathCwd); root != "" {
+						processedAbsPaths[absPath] = true
+						if opts.ThirdParty == "exclude" {
+							slog.Debug("Excluding third-party file.", "path", relPathCwd, "root", root)
+							excludedBy["third-party"]++
+							recordSkipped("third-party", relPathCwd, absPath)
+						} else if errAdd := thirdParty.add(root, relPathCwd, absPath, fileInfo.Size()); errAdd != nil {
+							collected.addError(relPathCwd, errAdd)
+						}
+						continue
+					}
+				}
+
+				if (opts.Tests == "exclude" || opts.Tests == "only") && isTestPath(relPathCwd) != (opts.Tests == "only") {
+					slog.Debug("Skipping file by test filter.", "path", relPathCwd, "policy", opts.Tests)
+					excludedBy["tests"]++
+					recordSkipped("tests", relPathCwd, absPath)
+					processedAbsPaths[absPath] = true
+					continue
+				}
+
+				if attr, ok := attributes.excludedBy(relPathCwd); ok && !forced {
+					slog.Debug("Skipping file marked in .gitattributes.", "path", relPathCwd, "attribute", attr)
+					excludedBy["gitattributes"]++
+					recordSkipped("gitattributes", relPathCwd, absPath)
+					processedAbsPaths[absPath] = true
+					continue
+				}
+
+				if opts.MaxDepth > 0 || opts.MinDepth > 0 {
+					rel, _ := filepath.Rel(scanRoot, absPath)
+					depth := strings.Count(rel, string(filepath.Separator)) + 1
+					if (opts.MaxDepth > 0 && depth > opts.MaxDepth) || depth < opts.MinDepth {
+						slog.Debug("Skipping file outside the depth range.", "path", relPathCwd, "depth", depth)
+						excludedBy["depth"]++
+						recordSkipped("depth", relPathCwd, absPath)
+						processedAbsPaths[absPath] = true
+						continue
+					}
+				}
+
+				language, extAllowed := matchFilters(relPathCwd, absPath)
+				extAllowed = extAllowed || forced
+				if trackedFiles != nil && !trackedFiles[absPath] {
+					slog.Debug("Skipping file not tracked by git.", "path", relPathCwd)
+					excludedBy["untracked"]++
+					recordSkipped("untracked", relPathCwd, absPath)
 					processedAbsPaths[absPath] = true
 					continue
 				}
 
-				currentExt := strings.ToLower(filepath.Ext(baseName))
-				_, extAllowed := exts[currentExt]
-				if len(exts) > 0 && !extAllowed {
+				if !extAllowed {
+					excludedBy["extension"]++
+					processedAbsPaths[absPath] = true
+					continue
+				}
+				if opts.NoMinifiedAssets && !forced && isMinifiedName(relPathCwd) {
+					slog.Debug("Skipping minified asset.", "path", relPathCwd)
+					excludedBy["minified"]++
+					recordSkipped("minified", relPathCwd, absPath)
+					processedAbsPaths[absPath] = true
+					continue
+				}
+				if opts.MaxFileSize > 0 && !forced && fileInfo.Size() > opts.MaxFileSize {
+					slog.Debug("Skipping file over --max-file-size.", "path", relPathCwd, "size", fileInfo.Size())
+					excludedBy["size"]++
+					recordSkipped("size", relPathCwd, absPath)
+					processedAbsPaths[absPath] = true
+					continue
+				}
+
+				// Only a symlinked file can lead out: linked directories are
+				// not descended.
+				if jail != "" && isLink(absPath) {
+					if errJail := checkJail(jail, absPath); errJail != nil {
+						slog.Warn("Refusing file outside the jail.", "path", relPathCwd, "error", errJail)
+						collected.addError(relPathCwd, errJail)
+						processedAbsPaths[absPath] = true
+						continue
+					}
+				}
+
+				// Under --max-files, a file after the first N included ones in
+				// walk order cannot make the cut; it is not read at all.
+				if fileInfo.Size() > 0 && collected.pastLimit(relPathCwd) {
+					excludedBy["max-files"]++
+					recordSkipped("max-files", relPathCwd, absPath)
+					processedAbsPaths[absPath] = true
+					continue
+				}
+
+				if opts.SkipContent {
+					if opts.NoMinifiedAssets && !forced && minifiedExts[strings.ToLower(filepath.Ext(relPathCwd))] {
+						if content, errRead := opts.Cache.read(absPath, fileInfo); errRead == nil && hasMinifiedLines(relPathCwd, content) {
+							excludedBy["minified"]++
+							recordSkipped("minified", relPathCwd, absPath)
+							processedAbsPaths[absPath] = true
+							continue
+						}
+					}
+					if !forced && binaryChecked(relPathCwd, opts) {
+						if binary, errSniff := sniffBinary(absPath); errSniff == nil && binary {
+							excludedBy["binary"]++
+							recordSkipped("binary", relPathCwd, absPath)
+							processedAbsPaths[absPath] = true
+							continue
+						}
+					}
+					if grep != nil && !grep.matchesPath(relPathCwd) {
+						if content, errRead := opts.Cache.read(absPath, fileInfo); errRead == nil && !grep.matches(relPathCwd, content) {
+							excludedBy["grep"]++
+							recordSkipped("grep", relPathCwd, absPath)
+							processedAbsPaths[absPath] = true
+							continue
+						}
+					}
+					if fileInfo.Size() == 0 {
+						collected.addEmpty(relPathCwd)
+					} else {
+						collected.include(FileInfo{Path: relPathCwd, Size: fileInfo.Size(), Language: language}, nil)
+						progress.fileIncluded(fileInfo.Size())
+					}
 					processedAbsPaths[absPath] = true
 					continue
 				}
 
-				content, errRead := os.ReadFile(absPath)
+				content, errRead := opts.Cache.read(absPath, fileInfo)
 				if errRead != nil {
-					errorFiles[relPathCwd] = errRead
+					collected.addError(relPathCwd, errRead)
+					processedAbsPaths[absPath] = true
+					continue
+				}
+				if opts.NoMinifiedAssets && !forced && hasMinifiedLines(relPathCwd, content) {
+					slog.Debug("Skipping file with minified lines.", "path", relPathCwd)
+					excludedBy["minified"]++
+					recordSkipped("minified", relPathCwd, absPath)
+					processedAbsPaths[absPath] = true
+					continue
+				}
+				if !forced && binaryChecked(relPathCwd, opts) && looksBinary(content) {
+					slog.Debug("Skipping binary file.", "path", relPathCwd)
+					excludedBy["binary"]++
+					recordSkipped("binary", relPathCwd, absPath)
+					processedAbsPaths[absPath] = true
+					continue
+				}
+				if !grep.matches(relPathCwd, content) {
+					slog.Debug("Skipping file not matching --grep.", "path", relPathCwd)
+					excludedBy["grep"]++
+					recordSkipped("grep", relPathCwd, absPath)
 					processedAbsPaths[absPath] = true
 					continue
 				}
 				if len(content) == 0 {
-					emptyFiles = append(emptyFiles, relPathCwd)
+					collected.addEmpty(relPathCwd)
 					processedAbsPaths[absPath] = true
 					continue
 				}
-				fileSize := fileInfo.Size()
-				appendFileContent(&outputBuilder, marker, relPathCwd, content)
-				includedFiles = append(includedFiles, FileInfo{Path: relPathCwd, Size: fileSize, IsManual: false})
-				totalSize += fileSize
+				var source *FileSource
+				if opts.Checksums {
+					source = newFileSource(fileInfo, content)
+				}
+				content, errRead = pipeline.process(relPathCwd, content)
+				if errRead != nil {
+					slog.Warn("Error transforming file content.", "path", relPathCwd, "error", errRead)
+					collected.addError(relPathCwd, errRead)
+					processedAbsPaths[absPath] = true
+					continue
+				}
+				content = grep.regions(content)
+				fileSize := int64(len(content))
+				var meta []string
+				if annotate != nil {
+					meta = annotate(absPath)
+				}
+				collected.include(FileInfo{Path: relPathCwd, Size: fileSize, IsManual: false, Language: language, Source: source},
+					&Document{Path: relPathCwd, Content: string(content), Meta: meta, NestedRepo: nestedSection, modTime: fileInfo.ModTime(), mode: fileInfo.Mode()})
+				progress.fileIncluded(fileSize)
 				processedAbsPaths[absPath] = true
 			}
 			<-processingDone
+			progress.stop()
+
+			// Files arrive in whatever order the walker's goroutines find them;
+			// the collector puts them back in walk order, and --max-files keeps
+			// the first ones in that order.
+			scannedFiles, scannedDocs, scannedEmpty, cut, scannedSize := collected.results()
+			if len(cut) > 0 {
+				excludedBy["max-files"] += len(cut)
+				if skipped != nil {
+					skipped["max-files"] = append(skipped["max-files"], cut...)
+				}
+			}
+			if maxFilesHit = excludedBy["max-files"] > 0; maxFilesHit {
+				slog.Info("Reached --max-files, kept the first files in walk order.", "files", opts.MaxFiles, "dropped", excludedBy["max-files"])
+			}
+			includedFiles = append(includedFiles, scannedFiles...)
+			emptyFiles = append(emptyFiles, scannedEmpty...)
+			totalSize += scannedSize
+			nestedSections := make(map[string][]Document)
+			for _, doc := range scannedDocs {
+				if doc.NestedRepo != "" {
+					nestedSections[doc.NestedRepo] = append(nestedSections[doc.NestedRepo], doc)
+				} else {
+					documents = append(documents, doc)
+				}
+			}
+			for _, paths := range skipped {
+				slices.SortFunc(paths, compareWalkOrder)
+			}
+			sort.Strings(nestedRepos)
+			for _, relRoot := range nestedRepos {
+				documents = append(documents, nestedSections[relRoot]...)
+			}
+			for _, doc := range thirdParty.documents() {
+				slog.Info("Summarizing third-party code.", "path", doc.Path)
+				documents = append(documents, doc)
+				includedFiles = append(includedFiles, FileInfo{Path: doc.Path, Size: int64(doc.size())})
+				totalSize += int64(doc.size())
+			}
 
 			finalWalkError := walkErr
 			if finalWalkError == nil && firstWalkError != nil {
@@ -227,6 +855,8 @@ func generateConcatenatedCode(
 			}
 		}
 
+		excludeRules = excluder.Rules(flagExcludePatterns)
+
 		if returnedErr == nil {
 			slog.Info("File scan completed.")
 		} else {
@@ -238,6 +868,190 @@ func generateConcatenatedCode(
 		slog.Info("Skipping directory scan as no scan directories were provided or determined.")
 	}
 
-	output = outputBuilder.String()
-	return
+	if ctx.Err() != nil {
+		slog.Warn("Generation stopped early, results are partial.", "error", ctx.Err())
+		returnedErr = fmt.Errorf("scan stopped before completion: %w", ctx.Err())
+	}
+
+	result := GenerateResult{
+		Documents:     documents,
+		IncludedFiles: includedFiles,
+		EmptyFiles:    emptyFiles,
+		ErrorFiles:    errorFiles,
+		SpecialFiles:  specialFiles,
+		TotalSize:     totalSize,
+		FilesSeen:     filesSeen,
+		ExcludedBy:    excludedBy,
+		ExcludeRules:  excludeRules,
+		Skipped:       skipped,
+		NestedRepos:   nestedRepos,
+		Partial:       ctx.Err() != nil,
+		MaxFilesHit:   maxFilesHit,
+	}
+	if opts.RepoInfo {
+		result.Repos = repoInfos(cwd, tern(noScan || len(scanDirs) == 0, []string{cwd}, scanDirs))
+	}
+	if spool != nil {
+		result.spools = []*contentSpool{spool}
+	}
+	if len(opts.Seeds) > 0 && !opts.SkipContent {
+		left, errSeeds := applySeeds(&result, opts)
+		if errSeeds != nil && returnedErr == nil {
+			returnedErr = errSeeds
+		}
+		excludedBy["seed"] += len(left)
+		if skipped != nil && len(left) > 0 {
+			skipped["seed"] = append(skipped["seed"], left...)
+		}
+	}
+	rebase, pathBase, errRebase := newPathRebaser(opts.RelativeTo, cwd, scanDirs)
+	if errRebase != nil && returnedErr == nil {
+		returnedErr = errRebase
+	}
+	if rebase = withPathPrefixes(rebase, opts.StripPrefixes, opts.PathPrefix); rebase != nil {
+		rebaseResultPaths(&result, rebase)
+	}
+	result.PathBase = pathBase
+	if errRender := renderResult(&result, opts); errRender != nil && returnedErr == nil {
+		returnedErr = errRender
+	}
+	return result, returnedErr
+}
+
+// partOptions returns opts for one of several runs whose results are merged
+// (-d roots, codecat multi, --select): renderResult applies these steps
+// once, to the merged result.
+func partOptions(opts GenerateOptions) GenerateOptions {
+	opts.Stamp, opts.Preamble, opts.Fit, opts.AnonymizePaths = false, "", false, false
+	opts.DirReadmes, opts.IncludeErrors = false, false
+	opts.SampleDirs, opts.OutlierPercentile, opts.Limits = 0, 0, nil
+	opts.RecentCommits, opts.GitHubIssues, opts.DiffRange = 0, nil, ""
+	return opts
+}
+
+// renderResult orders the documents, trims them to the budget under --fit,
+// adds the generated sections and stamp (if enabled) and the text rendering
+// to result.
+func renderResult(result *GenerateResult, opts GenerateOptions) error {
+	if opts.IncludeErrors {
+		result.Documents = withErrorPlaceholders(result.Documents, result.ErrorFiles, result.errorRoots)
+	}
+	if opts.DocsFirst || len(opts.Priorities) > 0 {
+		orderDocuments(result.Documents, opts)
+	}
+	if opts.DirReadmes {
+		placeDirReadmes(result.Documents)
+	}
+	if opts.OutlierPercentile > 0 {
+		result.Outliers, result.OutlierLimit = trimOutliers(result, opts.OutlierPercentile, opts.OutlierMultiplier)
+		if result.Skipped != nil {
+			for _, f := range result.Outliers {
+				result.Skipped["outlier"] = append(result.Skipped["outlier"], f.Path)
+			}
+		}
+	}
+	if opts.SampleDirs > 0 {
+		if omitted := sampleDirectories(result, opts.SampleDirs, !opts.AnonymizePaths); result.Skipped != nil && len(omitted) > 0 {
+			result.Skipped["sample"] = append(result.Skipped["sample"], omitted...)
+		}
+	}
+	if len(opts.Limits) > 0 {
+		if capped := applyLimits(result, opts.Limits, opts.ManualExcludes); result.Skipped != nil && len(capped) > 0 {
+			result.Skipped["limit"] = append(result.Skipped["limit"], capped...)
+		}
+	}
+	if opts.RecentCommits > 0 && result.history == nil && !opts.SkipContent {
+		result.history = recentCommits(opts.CWD, historyDirs(opts), opts.RecentCommits, opts.RecentCommitsStat)
+	}
+	history, withHistory := recentCommitsDocument(result.history, opts.RecentCommits)
+	if opts.DiffRange != "" && result.patch == "" && !opts.SkipContent {
+		patch, err := gitDiffPatch(opts.CWD, opts.DiffRange, "")
+		if err != nil {
+			return err
+		}
+		result.patch = patch
+	}
+	diff, withDiff := diffDocument(result.patch, opts.DiffRange)
+	var issues []Document
+	for _, s := range tern(opts.SkipContent, nil, opts.GitHubIssues) {
+		ref, err := parseIssueRef(s)
+		if err != nil {
+			return err
+		}
+		slog.Info("Fetching GitHub issue.", "issue", ref.String())
+		doc, err := fetchIssueDocument(githubClient, ref)
+		if err != nil {
+			return err
+		}
+		issues = append(issues, doc)
+	}
+	withSections := func(docs []Document) []Document {
+		if opts.Preamble == "imports" {
+			if doc, ok := importMapDocument(docs); ok {
+				docs = append([]Document{doc}, docs...)
+			}
+		}
+		if withDiff {
+			docs = append([]Document{diff}, docs...)
+		}
+		if len(issues) > 0 {
+			docs = append(slices.Clip(issues), docs...)
+		}
+		if withHistory {
+			docs = append(slices.Clip(docs), history)
+		}
+		return docs
+	}
+	// Before --fit, so the budget counts the longer headers.
+	setHeaderFields := func(docs []Document) {
+		for i := range docs {
+			if len(opts.HeaderFields) > 0 && docs[i].Fields == nil {
+				docs[i].Fields = headerFields(docs[i], opts.HeaderFields)
+			}
+		}
+	}
+	setHeaderFields(result.Documents)
+	if opts.Fit && opts.MaxTokens > 0 {
+		dropped, err := fitToBudget(result, opts, func() (string, error) {
+			var b strings.Builder
+			err := formatText(&b, GenerateResult{Documents: withSections(result.Documents)}, opts)
+			return b.String(), err
+		})
+		if err != nil {
+			return fmt.Errorf("rendering output: %w", err)
+		}
+		result.Dropped = dropped
+		if result.Skipped != nil && len(dropped) > 0 {
+			result.Skipped["budget"] = append(result.Skipped["budget"], dropped...)
+		}
+	}
+	result.Documents = withSections(result.Documents)
+	setHeaderFields(result.Documents) // the generated sections
+	if opts.AnonymizePaths {
+		result.PathMap = anonymizeResult(result)
+	}
+	if opts.Stamp {
+		result.Stamp = newStamp(opts, result.Documents, time.Now())
+		result.Stamp.Partial = result.Partial
+		if result.MaxFilesHit {
+			result.Stamp.MaxFiles = opts.MaxFiles
+		}
+	}
+	if result.spilled() {
+		// Keep the spooled content out of memory: the formatters stream it
+		// when writing, and only the size is needed here.
+		var counter countingWriter
+		if errFormat := formatText(&counter, *result, opts); errFormat != nil {
+			return fmt.Errorf("rendering output: %w", errFormat)
+		}
+		result.OutputSize = counter.n
+		return nil
+	}
+	var textOutput strings.Builder
+	if errFormat := formatText(&textOutput, *result, opts); errFormat != nil {
+		return fmt.Errorf("rendering output: %w", errFormat)
+	}
+	result.Output = textOutput.String()
+	result.OutputSize = int64(len(result.Output))
+	return nil
 }
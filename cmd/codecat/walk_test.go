@@ -3,15 +3,19 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -567,3 +571,211 @@ func TestGenerateConcatenatedCode_NoScan(t *testing.T) {
 	t.Logf("Log output:\n%s", logOutput)
 	assertions.Contains(logOutput, "Skipping directory scan due to --no-scan flag.")
 }
+
+func TestGenerateContext_Cancelled(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"a.go": "package a\n", "b.go": "package b\n"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := generateContext(ctx, GenerateOptions{
+		CWD:         tempDir,
+		ScanDirs:    []string{tempDir},
+		Extensions:  processExtensions([]string{"go"}),
+		ManualFiles: []string{"a.go"},
+		Marker:      "---",
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.True(t, result.Partial)
+	assert.Empty(t, result.IncludedFiles, "no files are read once cancelled")
+}
+
+func TestGenerateContext_DeadlineMarksStampPartial(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{"a.go": "package a\n"})
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	result, err := generateContext(ctx, GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+		Stamp:      true,
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, result.Partial)
+	require.NotNil(t, result.Stamp)
+	assert.True(t, result.Stamp.Partial)
+	assert.Contains(t, result.Output, "# files: 0 (partial: scan stopped early)\n")
+}
+
+func TestGenerate_SkipsSpecialFiles(t *testing.T) {
+	if _, err := exec.LookPath("mkfifo"); err != nil {
+		t.Skip("mkfifo not available")
+	}
+	tempDir := setupTestDir(t, map[string]string{"a.go": "package a\n"})
+	out, err := exec.Command("mkfifo", filepath.Join(tempDir, "pipe.go")).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	done := make(chan struct{})
+	var result GenerateResult
+	go func() {
+		defer close(done)
+		result, err = generate(GenerateOptions{
+			CWD:         tempDir,
+			ScanDirs:    []string{tempDir},
+			Extensions:  processExtensions([]string{"go"}),
+			ManualFiles: []string{"pipe.go"},
+			Marker:      "---",
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("generate blocked on a named pipe")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.ErrorContains(t, result.ErrorFiles["pipe.go"], "named pipe", "manual FIFOs are reported as errors")
+
+	result, err = generate(GenerateOptions{
+		CWD:        tempDir,
+		ScanDirs:   []string{tempDir},
+		Extensions: processExtensions([]string{"go"}),
+		Marker:     "---",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pipe.go": "named pipe"}, result.SpecialFiles)
+}
+
+func TestGenerate_ProjectIncludes(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		".codecat_include": "schema/*.sql\ndocs/adr/\nbuild/keep.go\n",
+		".codecat_exclude": "docs\nbuild\n",
+		"main.go":          "package main\n",
+		"schema/users.sql": "CREATE TABLE users();\n",
+		"schema/notes.txt": "not forced\n",
+		"docs/adr/0001.md": "# ADR 1\n",
+		"docs/guide.md":    "# guide\n",
+		"build/keep.go":    "package build\n",
+	})
+	opts := GenerateOptions{
+		CWD:             tempDir,
+		ScanDirs:        []string{tempDir},
+		Extensions:      processExtensions([]string{"go"}),
+		ProjectExcludes: loadProjectExcludes(tempDir),
+		ProjectIncludes: loadProjectIncludes(tempDir),
+		FlagExcludes:    []string{"build/keep.go"},
+		Marker:          "---",
+	}
+	require.Equal(t, []string{"schema/*.sql", "docs/adr/", "build/keep.go"}, opts.ProjectIncludes)
+
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"main.go", "schema/users.sql", "docs/adr/0001.md"},
+		getPathsFromIncludedFiles(result.IncludedFiles), "includes beat extension filters and project excludes, not -x")
+}
+
+func TestGenerate_IncludeBasenames(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":           "package main\n",
+		"Makefile":          "all:\n",
+		"deploy/Dockerfile": "FROM scratch\n",
+		"Dockerfile.dev":    "FROM golang\n",
+		"NOTICE":            "notice\n",
+	})
+	opts := GenerateOptions{
+		CWD:              tempDir,
+		ScanDirs:         []string{tempDir},
+		Extensions:       processExtensions([]string{"go"}),
+		IncludeBasenames: []string{"Makefile", "Dockerfile*"},
+		Marker:           "---",
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"main.go", "Makefile", "deploy/Dockerfile", "Dockerfile.dev"},
+		getPathsFromIncludedFiles(result.IncludedFiles))
+}
+
+func TestGenerate_DepthRange(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		"main.go":             "package main\n",
+		"pkg/lib.go":          "package pkg\n",
+		"pkg/inner/deep.go":   "package inner\n",
+		"svc/api/handler.go":  "package api\n",
+		"svc/api/v1/types.go": "package v1\n",
+	})
+	opts := GenerateOptions{
+		CWD:           tempDir,
+		ScanDirs:      []string{tempDir},
+		Extensions:    processExtensions([]string{"go"}),
+		Marker:        "---",
+		MaxDepth:      2,
+		ReportSkipped: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "pkg/lib.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+	assert.ElementsMatch(t, []string{"pkg/inner/deep.go", "svc/api/handler.go", "svc/api/v1/types.go"}, result.Skipped["depth"])
+
+	opts.MaxDepth, opts.MinDepth = 0, 3
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg/inner/deep.go", "svc/api/handler.go", "svc/api/v1/types.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+
+	// Depth counts from the scan root, not the CWD.
+	opts.ScanDirs = []string{filepath.Join(tempDir, "svc")}
+	opts.MaxDepth, opts.MinDepth = 2, 0
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc/api/handler.go"}, getPathsFromIncludedFiles(result.IncludedFiles))
+}
+
+func TestGenerate_MaxFiles(t *testing.T) {
+	structure := map[string]string{"notes.txt": "hi\n"}
+	for i := 0; i < 10; i++ {
+		structure[fmt.Sprintf("pkg%d/f.go", i)] = "package p\n"
+	}
+	tempDir := setupTestDir(t, structure)
+	opts := GenerateOptions{
+		CWD:           tempDir,
+		ScanDirs:      []string{tempDir},
+		ManualFiles:   []string{"notes.txt"},
+		Extensions:    processExtensions([]string{"go"}),
+		Marker:        "---",
+		MaxFiles:      3,
+		Stamp:         true,
+		ReportSkipped: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err, "stopping at the cap is not an error")
+	assert.True(t, result.MaxFilesHit)
+	assert.Equal(t, 7, result.ExcludedBy["max-files"])
+	assert.Equal(t, []string{"pkg3/f.go", "pkg4/f.go", "pkg5/f.go", "pkg6/f.go", "pkg7/f.go", "pkg8/f.go", "pkg9/f.go"},
+		result.Skipped["max-files"], "dropped files are listed in walk order")
+	assert.False(t, result.Partial)
+	assert.Equal(t, []string{"notes.txt", "pkg0/f.go", "pkg1/f.go", "pkg2/f.go"}, getPathsFromIncludedFiles(result.IncludedFiles),
+		"the -f file plus the first three scanned files in walk order")
+	assert.Contains(t, result.Output, "# files: 4 (scan stopped at --max-files 3)\n")
+	for i := 0; i < 3; i++ {
+		again, err := generate(opts)
+		require.NoError(t, err)
+		assert.Equal(t, result.Output, again.Output, "the same files make the cut on every run")
+	}
+
+	opts.MaxFiles = 10
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.False(t, result.MaxFilesHit, "exactly N matching files drop nothing")
+	assert.Len(t, result.IncludedFiles, 11)
+	assert.Empty(t, result.Skipped["max-files"])
+}
+
+func TestPartOptions(t *testing.T) {
+	opts := GenerateOptions{
+		CWD: "/repo", Stamp: true, Preamble: "imports", Fit: true, AnonymizePaths: true,
+		DirReadmes: true, IncludeErrors: true, SampleDirs: 2, OutlierPercentile: 95,
+		Limits: []dirLimit{{Glob: "vendor", Bytes: 10}}, RecentCommits: 5,
+		GitHubIssues: []string{"o/r#1"}, DiffRange: "main", MaxTokens: 100,
+	}
+	assert.Equal(t, GenerateOptions{CWD: "/repo", MaxTokens: 100}, partOptions(opts))
+}
@@ -0,0 +1,130 @@
+// cmd/codecat/walkroots.go
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitignore "github.com/boyter/gocodewalker/go-gitignore"
+)
+
+// walkRoots returns the directories to walk instead of the CWD: the scan
+// directories not inside another one. It returns nil, meaning walk the CWD,
+// when a scan directory is the CWD itself or lies outside it.
+func walkRoots(cwd string, scanDirs []string) []string {
+	var roots []string
+	for _, dir := range scanDirs {
+		if dir == cwd || !isWithinDir(dir, cwd) {
+			return nil
+		}
+		nested := false
+		for _, other := range scanDirs {
+			if other != dir && isWithinDir(dir, other) {
+				nested = true
+				break
+			}
+		}
+		if !nested && !contains(roots, dir) {
+			roots = append(roots, dir)
+		}
+	}
+	return roots
+}
+
+// ancestorIgnores holds the .gitignore and .ignore files between the CWD and
+// the walk roots: a walker started at a root never reads them itself.
+type ancestorIgnores struct {
+	chains map[string][]gitignore.GitIgnore // walk root -> ignore files from the CWD down to its parent
+	dirs   dirDecisions                     // absolute directory -> ignored, itself or through a parent
+}
+
+// loadAncestorIgnores reads the ignore files of every directory from cwd
+// down to each root's parent, shallowest first.
+func loadAncestorIgnores(cwd string, roots []string) *ancestorIgnores {
+	loaded := make(map[string][]gitignore.GitIgnore) // directory -> its ignore files
+	a := &ancestorIgnores{chains: make(map[string][]gitignore.GitIgnore)}
+	for _, root := range roots {
+		var dirs []string
+		for dir := filepath.Dir(root); isWithinDir(dir, cwd); dir = filepath.Dir(dir) {
+			dirs = append([]string{dir}, dirs...)
+			if dir == cwd {
+				break
+			}
+		}
+		var chain []gitignore.GitIgnore
+		for _, dir := range dirs {
+			files, ok := loaded[dir]
+			if !ok {
+				for _, name := range []string{".gitignore", ".ignore"} {
+					content, err := os.ReadFile(filepath.Join(dir, name))
+					if err != nil {
+						if !os.IsNotExist(err) {
+							slog.Warn("Cannot read ignore file above the scan directory.", "path", filepath.Join(dir, name), "error", err)
+						}
+						continue
+					}
+					files = append(files, gitignore.New(strings.NewReader(string(content)), dir, nil))
+				}
+				loaded[dir] = files
+			}
+			chain = append(chain, files...)
+		}
+		if len(chain) > 0 {
+			a.chains[root] = chain
+		}
+	}
+	return a
+}
+
+// ignored reports whether absPath, or a directory above it, is ignored by
+// the ignore files above its walk root. As in git, the last matching
+// pattern of the deepest file decides, and nothing below an ignored
+// directory comes back.
+func (a *ancestorIgnores) ignored(absPath string) bool {
+	if a == nil {
+		return false
+	}
+	var chain []gitignore.GitIgnore
+	for root, c := range a.chains {
+		if isWithinDir(absPath, root) {
+			chain = c
+			break
+		}
+	}
+	if chain == nil {
+		return false
+	}
+	if absPath == chain[0].Base() || !isWithinDir(absPath, chain[0].Base()) {
+		return false
+	}
+	return a.dirIgnored(chain, filepath.Dir(absPath)) || ignoredByChain(chain, absPath, false)
+}
+
+// dirIgnored reports whether dir, or a directory above it up to the CWD,
+// is ignored, deciding each directory once. Every chain covering dir holds
+// the same ignore files above it, so the answer is shared between roots.
+func (a *ancestorIgnores) dirIgnored(chain []gitignore.GitIgnore, dir string) bool {
+	if dir == chain[0].Base() || !isWithinDir(dir, chain[0].Base()) {
+		return false
+	}
+	return a.dirs.get(dir, func() bool {
+		return a.dirIgnored(chain, filepath.Dir(dir)) || ignoredByChain(chain, dir, true)
+	})
+}
+
+// ignoredByChain checks p alone, without its parents: the last matching
+// pattern of the deepest ignore file above p decides.
+func ignoredByChain(chain []gitignore.GitIgnore, p string, isDir bool) bool {
+	ignored := false
+	for _, ignore := range chain {
+		if p == ignore.Base() || !isWithinDir(p, ignore.Base()) {
+			continue
+		}
+		if m := ignore.Absolute(p, isDir); m != nil {
+			ignored = m.Ignore()
+		}
+	}
+	return ignored
+}
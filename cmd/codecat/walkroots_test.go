@@ -0,0 +1,106 @@
+// cmd/codecat/walkroots_test.go
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalkRoots(t *testing.T) {
+	cwd := filepath.FromSlash("/repo")
+	a, b, ab := filepath.Join(cwd, "a"), filepath.Join(cwd, "b"), filepath.Join(cwd, "a", "b")
+	assert.Equal(t, []string{a, b}, walkRoots(cwd, []string{a, ab, b, a}), "nested and repeated roots are walked once")
+	assert.Nil(t, walkRoots(cwd, []string{cwd, a}), "the CWD itself means one CWD walk")
+	assert.Nil(t, walkRoots(cwd, []string{a, filepath.FromSlash("/elsewhere")}))
+}
+
+func TestGenerate_ConcurrentRoots(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		".gitignore":          "*.tmp.go\nsvcA/gen/\n",
+		"svcA/.gitignore":     "local.go\n",
+		"svcA/main.go":        "package a\n",
+		"svcA/x.tmp.go":       "package a\n",
+		"svcA/local.go":       "package a\n",
+		"svcA/gen/types.go":   "package gen\n",
+		"svcB/.ignore":        "skip/\n",
+		"svcB/main.go":        "package b\n",
+		"svcB/skip/old.go":    "package skip\n",
+		"libs/.gitignore":     "vendor/\n",
+		"libs/c/c.go":         "package c\n",
+		"libs/c/vendor/v.go":  "package v\n",
+		"unscanned/ignore.go": "package u\n",
+	})
+	opts := GenerateOptions{
+		CWD:          tempDir,
+		ScanDirs:     []string{filepath.Join(tempDir, "svcA"), filepath.Join(tempDir, "svcB"), filepath.Join(tempDir, "libs", "c")},
+		Extensions:   processExtensions([]string{"go"}),
+		Marker:       "---",
+		UseGitignore: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"libs/c/c.go", "svcA/main.go", "svcB/main.go"}, getPathsFromIncludedFiles(result.IncludedFiles),
+		"ignore files above and inside the roots both apply")
+
+	opts.UseGitignore = false
+	result, err = generate(opts)
+	require.NoError(t, err)
+	assert.Len(t, result.IncludedFiles, 8)
+}
+
+func TestGenerate_NarrowRoot(t *testing.T) {
+	tempDir := setupTestDir(t, map[string]string{
+		".gitignore":                  "generated/\n",
+		"services/api/main.go":        "package api\n",
+		"services/api/generated/x.go": "package generated\n",
+		"services/web/web.go":         "package web\n",
+		"other/big.go":                "package other\n",
+	})
+	opts := GenerateOptions{
+		CWD:          tempDir,
+		ScanDirs:     []string{filepath.Join(tempDir, "services", "api")},
+		Extensions:   processExtensions([]string{"go"}),
+		Marker:       "---",
+		UseGitignore: true,
+	}
+	result, err := generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"services/api/main.go"}, getPathsFromIncludedFiles(result.IncludedFiles),
+		"the CWD .gitignore applies to a walk started at the scan directory")
+	assert.Equal(t, 1, result.FilesSeen, "neither ignored files nor files outside the scan directory are seen")
+}
+
+func TestAncestorIgnores_DirectoryCache(t *testing.T) {
+	cwd := setupTestDir(t, map[string]string{
+		".gitignore":      "a/b/gen/\n*.log\n",
+		"a/.gitignore":    "!keep.log\n",
+		"a/b/main.go":     "package b\n",
+		"a/b/gen/x.go":    "package gen\n",
+		"a/b/gen/y/z.go":  "package y\n",
+		"a/b/debug.log":   "log\n",
+		"a/b/keep.log":    "log\n",
+		"a/b/sub/main.go": "package sub\n",
+	})
+	root := filepath.Join(cwd, "a", "b")
+	ignores := loadAncestorIgnores(cwd, []string{root})
+
+	testCases := map[string]bool{
+		"a/b/main.go":     false,
+		"a/b/gen/x.go":    true,
+		"a/b/gen/y/z.go":  true,
+		"a/b/debug.log":   true,
+		"a/b/keep.log":    false, // the deeper ignore file wins
+		"a/b/sub/main.go": false,
+	}
+	for pass := 0; pass < 2; pass++ { // the second pass is answered from the directory cache
+		for rel, want := range testCases {
+			assert.Equal(t, want, ignores.ignored(filepath.Join(cwd, filepath.FromSlash(rel))), rel)
+		}
+	}
+	assert.True(t, ignores.dirs.m[filepath.Join(root, "gen")])
+	assert.False(t, ignores.dirs.m[filepath.Join(root, "sub")])
+	assert.False(t, ignores.ignored(cwd))
+}
@@ -13,14 +13,16 @@ go 1.21 // Or your target Go version (e.g., 1.22)
 require (
 	github.com/BurntSushi/toml v1.5.0
 	github.com/boyter/gocodewalker v1.4.0
+	github.com/klauspost/compress v1.17.11
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/spf13/pflag v1.0.6
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/text v0.14.0
 )
 
 require (
 	github.com/danwakefield/fnmatch v0.0.0-20160403171240-cbb64ac3d964 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sync v0.7.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
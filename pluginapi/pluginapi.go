@@ -0,0 +1,59 @@
+// pluginapi/pluginapi.go
+
+// Package pluginapi is the interface between codecat and its plugins:
+// output formatters, content transforms and excluders built out of tree.
+//
+// A plugin is a Go plugin (go build -buildmode=plugin) whose package main
+// exports a variable named Plugin:
+//
+//	var Plugin = pluginapi.Plugin{
+//		APIVersion: pluginapi.Version,
+//		Transforms: []pluginapi.Transform{stripBanner{}},
+//	}
+//
+// Go plugins must be built with the same Go version and the same version of
+// this package as codecat itself, and load only on Linux, macOS and FreeBSD.
+package pluginapi
+
+import "io"
+
+// Version is the API version codecat implements. A plugin built against
+// another version is refused rather than loaded.
+const Version = 1
+
+// Plugin lists what a plugin contributes; any part may be empty.
+type Plugin struct {
+	APIVersion int // must be Version
+	Formatters []Formatter
+	Transforms []Transform
+	Excluders  []Excluder
+}
+
+// Document is one included file as formatters see it.
+type Document struct {
+	Path    string   // display path, forward slashes
+	Content string   // after all transforms
+	Meta    []string // metadata lines, e.g. from --annotate
+}
+
+// Formatter renders the documents of a run. Its Name is used as a format,
+// as in -o name:path or --format name, and must not be a built-in format.
+type Formatter interface {
+	Name() string
+	Format(w io.Writer, docs []Document) error
+}
+
+// Transform rewrites the content of each included file. Transforms run after
+// codecat's format conversion and [hooks] transforms, in the order plugins
+// are loaded. An error skips the file, which is reported as unreadable.
+type Transform interface {
+	Name() string
+	Transform(path string, content []byte) ([]byte, error)
+}
+
+// Excluder leaves out files and directories the built-in rules keep. path
+// is CWD-relative with forward slashes. It is asked from several goroutines.
+type Excluder interface {
+	Name() string
+	Exclude(path string, isDir bool) bool
+}